@@ -0,0 +1,24 @@
+// keepsake-openapi writes the OpenAPI document of the daemon's HTTP/JSON
+// gateway to the path passed to it. It is run by `go generate`.
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/shared"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		console.Fatal("Usage: keepsake-openapi <output path>")
+	}
+	data, err := shared.OpenAPI()
+	if err != nil {
+		console.Fatal("Failed to generate OpenAPI document: %s", err)
+	}
+	if err := ioutil.WriteFile(os.Args[1], data, 0644); err != nil {
+		console.Fatal("Failed to write OpenAPI document: %s", err)
+	}
+}
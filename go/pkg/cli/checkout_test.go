@@ -72,13 +72,13 @@ func TestCheckout(t *testing.T) {
 	err = ioutil.WriteFile(path.Join(codeDir, "subdir", rand3), []byte(rand3), 0644)
 	require.NoError(t, err)
 
-	err = repo.PutPathTar(codeDir, "experiments/1eeeeeeeee.tar.gz", rand1)
+	_, err = repo.PutPathTar(codeDir, "experiments/1eeeeeeeee.tar.gz", rand1)
 	require.NoError(t, err)
 
-	err = repo.PutPathTar(codeDir, "checkpoints/1ccccccccc.tar.gz", rand2)
+	_, err = repo.PutPathTar(codeDir, "checkpoints/1ccccccccc.tar.gz", rand2)
 	require.NoError(t, err)
 
-	err = repo.PutPathTar(codeDir, "checkpoints/2aaaaaaaaa.tar.gz", "")
+	_, err = repo.PutPathTar(codeDir, "checkpoints/2aaaaaaaaa.tar.gz", "")
 	require.NoError(t, err)
 
 	outputDir, err := files.TempDir("test-checkout-output")
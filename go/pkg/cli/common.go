@@ -10,6 +10,7 @@ import (
 
 	"github.com/replicate/keepsake/go/pkg/config"
 	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/errors"
 	"github.com/replicate/keepsake/go/pkg/global"
 	"github.com/replicate/keepsake/go/pkg/repository"
 )
@@ -19,6 +20,12 @@ func getAurora() aurora.Aurora {
 	return aurora.NewAurora(os.Getenv("NO_COLOR") == "")
 }
 
+// colorEnabled returns whether output should be colored, from --color and
+// the NO_COLOR environment variable
+func colorEnabled() bool {
+	return global.Color && os.Getenv("NO_COLOR") == ""
+}
+
 func addRepositoryURLFlag(cmd *cobra.Command) {
 	cmd.Flags().StringP("repository", "R", "", "Repository URL (e.g. 's3://my-keepsake-bucket' (if omitted, uses repository URL from keepsake.yaml)")
 }
@@ -33,16 +40,27 @@ func addRepositoryURLFlagVar(cmd *cobra.Command, opt *string) {
 // * If an explicit directory is passed with -D, that is used
 // * Else, if repository URL isn't manually passed with -R, the directory of keepsake.yaml is used
 // * Otherwise, the current working directory is used
+// The repository in keepsake.yaml is the remote passed with --remote, if it is
+// passed, then $KEEPSAKE_REMOTE, then the default remote or the top-level
+// repository.
 // Returns (repositoryURL, projectDir, error)
 func getRepositoryURLFromStringOrConfig(repositoryURL string) (string, string, error) {
 	projectDir := global.ProjectDirectory
+	if repositoryURL != "" && global.Remote != "" {
+		return "", "", fmt.Errorf("--repository and --remote can't both be used. Pass only one of them.")
+	}
 	if repositoryURL == "" {
 		conf, confProjectDir, err := config.FindConfigInWorkingDir(global.ProjectDirectory)
 		if err != nil {
 			return "", "", err
 		}
-		if repositoryURL == "" {
-			repositoryURL = conf.Repository
+		remote := global.Remote
+		if remote == "" {
+			remote = os.Getenv("KEEPSAKE_REMOTE")
+		}
+		repositoryURL, err = conf.RepositoryURL(remote)
+		if err != nil {
+			return "", "", err
 		}
 		if global.ProjectDirectory == "" {
 			projectDir = confProjectDir
@@ -79,6 +97,29 @@ func getProjectDir() (string, error) {
 	return projectDir, nil
 }
 
+// getProjectConfig returns the project's keepsake.yaml, or nil if there
+// isn't one
+func getProjectConfig(projectDir string) (*config.Config, error) {
+	conf, _, err := config.FindConfigInWorkingDir(projectDir)
+	if err != nil {
+		if errors.IsConfigNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return conf, nil
+}
+
+// getRetentionPolicy returns the retention policy in the project's
+// keepsake.yaml, or nil if it doesn't have one or there is no keepsake.yaml
+func getRetentionPolicy(projectDir string) (*config.RetentionPolicy, error) {
+	conf, err := getProjectConfig(projectDir)
+	if conf == nil || err != nil {
+		return nil, err
+	}
+	return conf.Retention, nil
+}
+
 // getRepository returns the project's repository, with caching if needed
 // This is not in repository package so we can do user interface stuff around syncing
 func getRepository(repositoryURL, projectDir string) (repository.Repository, error) {
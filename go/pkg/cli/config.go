@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+)
+
+type configOpts struct {
+	origin        bool
+	repositoryURL string
+}
+
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the project's config",
+		Long: `Show the project's config, and where each value came from.
+
+Config is read from these places, from highest to lowest precedence:
+
+- flags, like --repository
+- environment variables: KEEPSAKE_REPOSITORY, KEEPSAKE_DEFAULT_REMOTE, KEEPSAKE_SYMLINKS, and KEEPSAKE_TRASH_RETENTION
+- keepsake.local.yaml, next to keepsake.yaml, for settings that shouldn't be committed
+- keepsake.yaml
+- ~/.config/keepsake/config.yaml, for settings that apply to every project
+- defaults`,
+	}
+	cmd.AddCommand(newConfigShowCommand(), newConfigGetCommand())
+	return cmd
+}
+
+func newConfigShowCommand() *cobra.Command {
+	var opts configOpts
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show every config value",
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			resolved, err := resolveConfig(opts)
+			if err != nil {
+				return err
+			}
+			return showConfigValues(resolved.Values(), opts.origin, os.Stdout)
+		}),
+		Args: cobra.NoArgs,
+	}
+	addConfigFlags(cmd, &opts)
+	return cmd
+}
+
+func newConfigGetCommand() *cobra.Command {
+	var opts configOpts
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Show a config value",
+		Long: `Show a config value.
+
+Keys inside other keys are separated with dots, like trash.retention or remotes.team.profile. If the key contains other keys, like trash, all of the values in it are shown.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			resolved, err := resolveConfig(opts)
+			if err != nil {
+				return err
+			}
+			values := resolved.Get(args[0])
+			if len(values) == 0 {
+				return fmt.Errorf("%s isn't set", args[0])
+			}
+			if len(values) == 1 && values[0].Key == args[0] && !opts.origin {
+				fmt.Println(values[0].Value)
+				return nil
+			}
+			return showConfigValues(values, opts.origin, os.Stdout)
+		}),
+		Args: cobra.ExactArgs(1),
+		Example: `Show which repository is used, and where it was set:
+$ keepsake config get repository --origin`,
+	}
+	addConfigFlags(cmd, &opts)
+	return cmd
+}
+
+func addConfigFlags(cmd *cobra.Command, opts *configOpts) {
+	cmd.Flags().BoolVar(&opts.origin, "origin", false, "Show where each value came from")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+}
+
+func resolveConfig(opts configOpts) (*config.Resolved, error) {
+	_, configPath, err := getConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	flags := []config.Setting{}
+	if opts.repositoryURL != "" {
+		flags = append(flags, config.Setting{Key: "repository", Value: opts.repositoryURL, Name: "--repository"})
+	}
+	return config.ResolveConfig(configPath, flags)
+}
+
+func showConfigValues(values []config.Value, origin bool, out io.Writer) error {
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	for _, value := range values {
+		if origin {
+			fmt.Fprintf(tw, "%s:\t%s\t%s\n", value.Key, value.Value, value.Origin)
+		} else {
+			fmt.Fprintf(tw, "%s:\t%s\n", value.Key, value.Value)
+		}
+	}
+	return tw.Flush()
+}
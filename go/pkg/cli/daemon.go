@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/global"
 	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
 	"github.com/replicate/keepsake/go/pkg/shared"
 )
 
@@ -17,6 +20,8 @@ func NewDaemonCommand() *cobra.Command {
 	setPersistentFlags(cmd)
 	handleEnvironmentVariables()
 	addRepositoryURLFlag(cmd)
+	cmd.Flags().String("metrics-address", os.Getenv("KEEPSAKE_METRICS_ADDRESS"), "Serve Prometheus metrics at http://<address>/metrics, e.g. 'localhost:9090'. Default: $KEEPSAKE_METRICS_ADDRESS, or don't serve metrics")
+	cmd.Flags().String("http-address", os.Getenv("KEEPSAKE_HTTP_ADDRESS"), "Also serve the daemon's API as HTTP/JSON at http://<address>/v1/, e.g. 'localhost:8080', for languages without gRPC. It must be a loopback address. Requests must have the token in $KEEPSAKE_HTTP_TOKEN, or if that isn't set, a random token written to <socket-path>.http-token. Default: $KEEPSAKE_HTTP_ADDRESS, or only serve it on the socket")
 	return cmd
 }
 
@@ -26,6 +31,9 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 	if global.Verbose {
 		console.SetLevel(console.DebugLevel)
 	}
+	if err := setUpLogging(); err != nil {
+		return err
+	}
 
 	projectGetter := func() (proj *project.Project, err error) {
 		repositoryURL, projectDir, err := getRepositoryURLFromFlagOrConfig(cmd)
@@ -36,11 +44,29 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return nil, err
 		}
-		proj = project.NewProject(repo, projectDir)
-		return proj, err
+		proj = project.NewProject(repository.NewInstrumentedRepository(repo), projectDir)
+		conf, err := getProjectConfig(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		if conf != nil {
+			proj.SetRetentionPolicy(conf.Retention)
+			proj.SetSymlinkMode(conf.Symlinks)
+			proj.SetHooks(conf.Hooks)
+			proj.SetHeartbeatConfig(conf.Heartbeat)
+		}
+		return proj, nil
 	}
 
-	if err := shared.Serve(projectGetter, socketPath); err != nil {
+	metricsAddress, err := cmd.Flags().GetString("metrics-address")
+	if err != nil {
+		return err
+	}
+	httpAddress, err := cmd.Flags().GetString("http-address")
+	if err != nil {
+		return err
+	}
+	if err := shared.Serve(projectGetter, socketPath, metricsAddress, httpAddress, os.Getenv("KEEPSAKE_HTTP_TOKEN")); err != nil {
 		return err
 	}
 	return nil
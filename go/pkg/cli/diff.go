@@ -1,13 +1,15 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"sort"
 	"strconv"
-	"text/tabwriter"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
@@ -17,32 +19,51 @@ import (
 	"github.com/replicate/keepsake/go/pkg/project"
 )
 
+type diffOpts struct {
+	json          bool
+	markdown      bool
+	repositoryURL string
+}
+
 func newDiffCommand() *cobra.Command {
+	var opts diffOpts
+
 	cmd := &cobra.Command{
-		Use:   "diff <ID> <ID>",
-		Short: "Compare two experiments or checkpoints",
-		Long: `Compare two experiments or checkpoints.
+		Use:   "diff <ID> <ID> [ID...]",
+		Short: "Compare experiments or checkpoints",
+		Long: `Compare experiments or checkpoints.
+
+Pass two or more IDs to compare them side by side, with a column for each. Only the values that differ are shown.
 
 If an experiment ID is passed, it will pick the best checkpoint from that experiment. If a primary metric is not defined in keepsake.yaml, it will use the latest checkpoint.`,
-		Run:  handleErrors(diffCheckpoints),
-		Args: cobra.ExactArgs(2),
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return diffCheckpoints(opts, args, os.Stdout)
+		}),
+		Args: cobra.MinimumNArgs(2),
+		Example: `Compare two experiments:
+$ keepsake diff 1eeeeee 2eeeeee
+
+Compare every experiment that used the "adam" optimizer, as Markdown:
+$ keepsake diff --markdown $(keepsake ls -q --filter "optimizer = adam")
+`,
 	}
 
-	// We should have a --json flag here, see https://github.com/replicate/keepsake/issues/338
-	addRepositoryURLFlag(cmd)
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Print output in JSON format")
+	cmd.Flags().BoolVar(&opts.markdown, "markdown", false, "Print output as Markdown tables")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
 
 	return cmd
 }
 
-func diffCheckpoints(cmd *cobra.Command, args []string) error {
-	// We should allow >2 experiments/checkpoints, see https://github.com/replicate/keepsake/issues/339
+func diffCheckpoints(opts diffOpts, prefixes []string, out io.Writer) error {
 	// TODO(bfirsh): it probably makes sense to refactor this to diff param.Values instead of strings at some point.
 	// that way we can do interesting stuff like diff JSON structures, using param.Value comparison methods, ShortString, etc.
 
-	prefix1 := args[0]
-	prefix2 := args[1]
+	if opts.json && opts.markdown {
+		return fmt.Errorf("Cannot use the --json flag in combination with --markdown")
+	}
 
-	repositoryURL, projectDir, err := getRepositoryURLFromFlagOrConfig(cmd)
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
 	if err != nil {
 		return err
 	}
@@ -51,95 +72,333 @@ func diffCheckpoints(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	proj := project.NewProject(repo, projectDir)
-	au := getAurora()
-	return printDiff(os.Stdout, au, proj, prefix1, prefix2)
+
+	if opts.json {
+		return printDiffJSON(out, proj, prefixes...)
+	}
+	if opts.markdown {
+		return printDiffMarkdown(out, proj, prefixes...)
+	}
+	return printDiff(out, getAurora(), proj, prefixes...)
 }
 
-// TODO: implement this as a thing in console
-func br(w *tabwriter.Writer) {
-	fmt.Fprintf(w, "\t\t\n")
+// checkpointDiff is the comparison of a number of checkpoints, grouped into
+// sections, with a value for each checkpoint in every row.
+type checkpointDiff struct {
+	Columns  []*diffColumn  `json:"columns"`
+	Sections []*diffSection `json:"sections"`
 }
 
-func heading(w *tabwriter.Writer, au aurora.Aurora, text string) {
-	fmt.Fprintf(w, "%s\t\t\n", au.Bold(text))
+type diffColumn struct {
+	ExperimentID string `json:"experiment_id"`
+	CheckpointID string `json:"checkpoint_id"`
 }
 
-func printDiff(out io.Writer, au aurora.Aurora, proj *project.Project, prefix1 string, prefix2 string) error {
-	exp1, com1, err := loadCheckpoint(proj, prefix1)
-	if err != nil {
-		return err
+type diffSection struct {
+	Name string `json:"name"`
+	// IDs are the IDs of the experiments or checkpoints in this section
+	IDs  []string   `json:"ids"`
+	Rows []*diffRow `json:"rows"`
+
+	showIDs    bool
+	sameObject bool
+}
+
+type diffRow struct {
+	Key    string    `json:"key"`
+	Values []*string `json:"values"`
+	// Best is the index of the best value, if this row is a primary metric
+	Best *int `json:"best,omitempty"`
+}
+
+func newCheckpointDiff(proj *project.Project, prefixes []string) (*checkpointDiff, error) {
+	experiments := []*project.Experiment{}
+	checkpoints := []*project.Checkpoint{}
+	for _, prefix := range prefixes {
+		exp, chk, err := loadCheckpoint(proj, prefix)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, exp)
+		checkpoints = append(checkpoints, chk)
+	}
+
+	diff := &checkpointDiff{}
+	experimentIDs := []string{}
+	checkpointIDs := []string{}
+	experimentMaps := []map[string]string{}
+	paramMaps := []map[string]string{}
+	packageMaps := []map[string]string{}
+	checkpointMaps := []map[string]string{}
+	metricMaps := []map[string]string{}
+	sameExperiment := true
+	for i, exp := range experiments {
+		chk := checkpoints[i]
+		diff.Columns = append(diff.Columns, &diffColumn{ExperimentID: exp.ID, CheckpointID: chk.ID})
+		experimentIDs = append(experimentIDs, exp.ShortID())
+		checkpointIDs = append(checkpointIDs, chk.ShortID())
+		experimentMaps = append(experimentMaps, experimentToMap(exp))
+		paramMaps = append(paramMaps, paramMapToStringMap(exp.Params))
+		packageMaps = append(packageMaps, exp.PythonPackages)
+		checkpointMaps = append(checkpointMaps, checkpointToMap(chk))
+		metricMaps = append(metricMaps, paramMapToStringMap(chk.Metrics))
+		if exp.ID != experiments[0].ID {
+			sameExperiment = false
+		}
+	}
+
+	experimentSection := &diffSection{Name: "Experiment", IDs: experimentIDs, Rows: []*diffRow{}, showIDs: true}
+	// HACK: don't show "no differences" if it's the same experiment, but still show ID because that's useful
+	if sameExperiment {
+		experimentSection.sameObject = true
+	} else {
+		experimentSection.Rows = diffRows(experimentMaps...)
 	}
-	exp2, com2, err := loadCheckpoint(proj, prefix2)
+	metricsSection := &diffSection{Name: "Metrics", IDs: checkpointIDs, Rows: diffRows(metricMaps...)}
+	// TODO(bfirsh): put primary metric first
+	for _, row := range metricsSection.Rows {
+		row.Best = bestMetricIndex(checkpoints, row.Key)
+	}
+
+	diff.Sections = []*diffSection{
+		experimentSection,
+		{Name: "Params", IDs: experimentIDs, Rows: diffRows(paramMaps...)},
+		{Name: "Python Packages", IDs: experimentIDs, Rows: diffRows(packageMaps...)},
+		{Name: "Checkpoint", IDs: checkpointIDs, Rows: diffRows(checkpointMaps...), showIDs: true},
+		metricsSection,
+	}
+	return diff, nil
+}
+
+// diffRows returns a row for each key that has different values across
+// maps, sorted by key
+func diffRows(maps ...map[string]string) []*diffRow {
+	rows := []*diffRow{}
+	for k, v := range mapString(maps...) {
+		rows = append(rows, &diffRow{Key: k, Values: v})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
+
+// bestMetricIndex returns the index of the checkpoint with the best value for
+// metricName, or nil if metricName isn't the primary metric of any of the
+// checkpoints
+func bestMetricIndex(checkpoints []*project.Checkpoint, metricName string) *int {
+	var goal project.MetricGoal
+	for _, chk := range checkpoints {
+		if chk.PrimaryMetric != nil && chk.PrimaryMetric.Name == metricName {
+			goal = chk.PrimaryMetric.Goal
+			break
+		}
+	}
+	if goal == "" {
+		return nil
+	}
+
+	var best *int
+	var bestVal param.Value
+	for i, chk := range checkpoints {
+		val, ok := chk.Metrics[metricName]
+		if !ok || val.IsNone() {
+			continue
+		}
+		if best == nil {
+			i := i
+			best = &i
+			bestVal = val
+			continue
+		}
+		var better bool
+		var err error
+		if goal == project.GoalMaximize {
+			better, err = val.GreaterThan(bestVal)
+		} else {
+			better, err = val.LessThan(bestVal)
+		}
+		if err != nil {
+			console.Warn("Got error when comparing metrics: %s", err)
+			return nil
+		}
+		if better {
+			i := i
+			best = &i
+			bestVal = val
+		}
+	}
+	return best
+}
+
+func printDiff(out io.Writer, au aurora.Aurora, proj *project.Project, prefixes ...string) error {
+	diff, err := newCheckpointDiff(proj, prefixes)
 	if err != nil {
 		return err
 	}
 
-	// min width for 3 columns in 78 char terminal
-	w := tabwriter.NewWriter(out, 78/3, 8, 2, ' ', 0)
+	// min width for each column to fit in 78 char terminal
+	t := &diffTable{minWidth: 78 / (len(diff.Columns) + 1), padding: 2}
 
-	heading(w, au, "Experiment")
-	fmt.Fprintf(w, "ID:\t%s\t%s\n", exp1.ShortID(), exp2.ShortID())
-	// HACK: don't show "no differences" if it's the same experiment, but still show ID because that's useful
-	if exp1.ID != exp2.ID {
-		printMapDiff(w, au, experimentToMap(exp1), experimentToMap(exp2))
+	for _, section := range diff.Sections {
+		t.heading(au, section.Name)
+		if section.showIDs {
+			t.row(append([]string{"ID:"}, section.IDs...), -1, au)
+		}
+		if len(section.Rows) > 0 {
+			for _, row := range section.Rows {
+				cells := []string{row.Key + ":"}
+				for _, value := range row.Values {
+					s := "(not set)"
+					if value != nil {
+						s = *value
+					}
+					// Truncate to 50, which seems ball-park sensible figure to make this fit in a wide terminal
+					// At some point when we have a clever responsive tabwriter, we can adjust this based on terminal width!
+					cells = append(cells, param.Truncate(s, 50))
+				}
+				best := -1
+				if row.Best != nil {
+					best = *row.Best + 1
+				}
+				t.row(cells, best, au)
+			}
+		} else if !section.sameObject {
+			t.faint(au, "(no difference)")
+		}
+		t.br()
 	}
 
-	br(w)
+	return t.write(out)
+}
 
-	heading(w, au, "Params")
-	printMapDiff(w, au, paramMapToStringMap(exp1.Params), paramMapToStringMap(exp2.Params))
-	br(w)
+func printDiffJSON(out io.Writer, proj *project.Project, prefixes ...string) error {
+	diff, err := newCheckpointDiff(proj, prefixes)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diff)
+}
 
-	heading(w, au, "Python Packages")
-	printMapDiff(w, au, exp1.PythonPackages, exp2.PythonPackages)
-	br(w)
+func printDiffMarkdown(out io.Writer, proj *project.Project, prefixes ...string) error {
+	diff, err := newCheckpointDiff(proj, prefixes)
+	if err != nil {
+		return err
+	}
+	for i, section := range diff.Sections {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "### %s\n\n", section.Name)
+		if section.sameObject {
+			fmt.Fprintf(out, "_(same %s: `%s`)_\n", strings.ToLower(section.Name), section.IDs[0])
+			continue
+		}
+		if len(section.Rows) == 0 {
+			fmt.Fprintln(out, "_(no difference)_")
+			continue
+		}
+		header := []interface{}{}
+		for _, id := range section.IDs {
+			header = append(header, "`"+id+"`")
+		}
+		fmt.Fprintf(out, "| |"+strings.Repeat(" %s |", len(section.IDs))+"\n", header...)
+		fmt.Fprintf(out, "|---|%s\n", strings.Repeat("---|", len(section.IDs)))
+		for _, row := range section.Rows {
+			fmt.Fprintf(out, "| %s |", markdownEscape(row.Key))
+			for j, value := range row.Values {
+				s := "_(not set)_"
+				if value != nil {
+					s = markdownEscape(*value)
+				}
+				if row.Best != nil && *row.Best == j {
+					s = "**" + s + "**"
+				}
+				fmt.Fprintf(out, " %s |", s)
+			}
+			fmt.Fprintln(out)
+		}
+	}
+	return nil
+}
 
-	heading(w, au, "Checkpoint")
-	fmt.Fprintf(w, "ID:\t%s\t%s\n", com1.ShortID(), com2.ShortID())
-	printMapDiff(w, au, checkpointToMap(com1), checkpointToMap(com2))
-	br(w)
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
 
-	heading(w, au, "Metrics")
-	// TODO(bfirsh): put primary metric first
-	printMapDiff(w, au, paramMapToStringMap(com1.Metrics), paramMapToStringMap(com2.Metrics))
-	br(w)
+// diffTable aligns columns like a tabwriter, but measures cells without their
+// color codes so highlighted values don't break the alignment.
+//
+// Like a tabwriter, the last column is not padded.
+type diffTable struct {
+	minWidth int
+	padding  int
+	rows     [][]diffCell
+}
 
-	return w.Flush()
+type diffCell struct {
+	text    string
+	display string
 }
 
-func printMapDiff(w *tabwriter.Writer, au aurora.Aurora, map1, map2 map[string]string) {
-	diffMap := mapString(map1, map2)
+func (t *diffTable) heading(au aurora.Aurora, text string) {
+	t.rows = append(t.rows, []diffCell{{text: text, display: au.Bold(text).String()}})
+}
 
-	// sort the keys
-	type keyVal struct {
-		key   string
-		value []*string
-	}
-	keyVals := []keyVal{}
-	for k, v := range diffMap {
-		keyVals = append(keyVals, keyVal{k, v})
+func (t *diffTable) faint(au aurora.Aurora, text string) {
+	t.rows = append(t.rows, []diffCell{{text: text, display: au.Faint(text).String()}})
+}
+
+func (t *diffTable) br() {
+	t.rows = append(t.rows, []diffCell{})
+}
+
+// row adds a row of cells, highlighting the cell at index highlight (if it
+// is not -1)
+func (t *diffTable) row(cells []string, highlight int, au aurora.Aurora) {
+	row := []diffCell{}
+	for i, text := range cells {
+		display := text
+		if i == highlight {
+			display = au.Bold(au.Green(text)).String()
+		}
+		row = append(row, diffCell{text: text, display: display})
 	}
-	sort.Slice(keyVals, func(i, j int) bool {
-		return keyVals[i].key < keyVals[j].key
-	})
+	t.rows = append(t.rows, row)
+}
 
-	if len(keyVals) > 0 {
-		for _, kv := range keyVals {
-			left := "(not set)"
-			right := "(not set)"
-			if kv.value[0] != nil {
-				left = *(kv.value[0])
+func (t *diffTable) write(out io.Writer) error {
+	widths := []int{}
+	for _, row := range t.rows {
+		// the last column in each row isn't aligned
+		for i := 0; i < len(row)-1; i++ {
+			w := utf8.RuneCountInString(row[i].text) + t.padding
+			if w < t.minWidth {
+				w = t.minWidth
 			}
-			if kv.value[1] != nil {
-				right = *(kv.value[1])
+			if i >= len(widths) {
+				widths = append(widths, w)
+			} else if w > widths[i] {
+				widths[i] = w
 			}
-			// Truncate to 50, which seems ball-park sensible figure to make this fit in a wide terminal
-			// At some point when we have a clever responsive tabwriter, we can adjust this based on terminal width!
-			fmt.Fprintf(w, "%s:\t%s\t%s\n", kv.key, param.Truncate(left, 50), param.Truncate(right, 50))
 		}
-	} else {
-		fmt.Fprintf(w, "%s\t\t\n", au.Faint("(no difference)"))
 	}
+	for _, row := range t.rows {
+		line := ""
+		for i, cell := range row {
+			line += cell.display
+			if i < len(row)-1 {
+				line += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell.text))
+			}
+		}
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Returns a map of checkpoint things we want to show in diff
@@ -201,9 +460,9 @@ func loadCheckpoint(proj *project.Project, prefix string) (*project.Experiment,
 	return exp, checkpoint, nil
 }
 
-// mapString takes two maps of strings and returns a single map with two values
-// where the values are different. If only one map has a key, then the map
-// without the value will be marked as nil
+// mapString takes any number of maps of strings and returns a single map with
+// a value for each map where the values are different. If only some of the
+// maps have a key, then the maps without the value will be marked as nil
 //
 // e.g.
 // >>> mapString({"layers": "2", "foo": "bar"}, {"layers": "4"})
@@ -212,29 +471,38 @@ func loadCheckpoint(proj *project.Project, prefix string) (*project.Experiment,
 //	  "layers": ["2", "4"]
 // }
 //
-func mapString(left, right map[string]string) map[string][]*string {
+func mapString(maps ...map[string]string) map[string][]*string {
+	keys := map[string]bool{}
+	for _, m := range maps {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+
 	result := make(map[string][]*string)
-	for k, v := range left {
-		if _, ok := right[k]; ok {
-			// Key in both left and right
-			if v != right[k] {
+	for k := range keys {
+		values := make([]*string, len(maps))
+		different := false
+		for i, m := range maps {
+			if v, ok := m[k]; ok {
 				// copy so pointers are unique
 				v2 := v
-				s := right[k]
-				result[k] = []*string{&v2, &s}
+				values[i] = &v2
+			}
+			if i > 0 && !stringPointersEqual(values[0], values[i]) {
+				different = true
 			}
-		} else {
-			// Key just in left
-			v2 := v
-			result[k] = []*string{&v2, nil}
 		}
-	}
-	for k, v := range right {
-		// Key just in right
-		if _, ok := left[k]; !ok {
-			v2 := v
-			result[k] = []*string{nil, &v2}
+		if different {
+			result[k] = values
 		}
 	}
 	return result
 }
+
+func stringPointersEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
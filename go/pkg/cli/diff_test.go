@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -103,6 +104,143 @@ metric-3:                 (not set)                      0.5
 	require.Equal(t, expected, actual)
 }
 
+func TestDiffMultiple(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	conf := &config.Config{}
+	repo := createShowTestData(t, workingDir, conf)
+	proj := project.NewProject(repo, workingDir)
+
+	au := aurora.NewAurora(false)
+	out := new(bytes.Buffer)
+	err = printDiff(out, au, proj, "1c", "2c", "4c")
+	require.NoError(t, err)
+	actual := out.String()
+
+	expected := `
+Experiment
+ID:                1eeeeee                        1eeeeee                        2eeeeee
+Command:           train.py --gamma=1.2 -x        train.py --gamma=1.2 -x
+Created:           Mon, 02 Jan 2006 22:54:05 +08  Mon, 02 Jan 2006 22:54:05 +08  Mon, 02 Jan 2006 23:03:05 +08
+Host:              10.1.1.1                       10.1.1.1                       10.1.1.2
+Python version:    3.4.5                          3.4.5                          3.4.6
+
+Params
+param-1:           100                            100                            200
+param-3:           (not set)                      (not set)                      hi
+
+Python Packages
+foo:               1.2.3                          1.2.3                          (not set)
+foo2:              1.2.3                          1.2.3                          (not set)
+foo3:              1.2.3                          1.2.3                          (not set)
+foo4:              1.2.3                          1.2.3                          (not set)
+foo5:              1.2.3                          1.2.3                          (not set)
+tensorflow:        2.0.0                          2.0.0                          (not set)
+
+Checkpoint
+ID:                1cccccc                        2cccccc                        4cccccc
+Created:           Mon, 02 Jan 2006 22:59:05 +08  Mon, 02 Jan 2006 23:00:05 +08  Mon, 02 Jan 2006 23:02:05 +08
+Step:              10                             20                             5
+
+Metrics
+metric-1:          0.1                            0.01                           (not set)
+metric-2:          2                              2                              (not set)
+metric-3:          (not set)                      (not set)                      0.5
+
+`
+	actual = testutil.TrimRightLines(actual)
+	expected = expected[1:]
+	require.Equal(t, expected, actual)
+
+	// best value of the primary metric is highlighted, without breaking alignment
+	out = new(bytes.Buffer)
+	err = printDiff(out, aurora.NewAurora(true), proj, "1c", "2c", "4c")
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "metric-1:          0.1                            \x1b[1;32m0.01\x1b[0m                           (not set)\n")
+}
+
+func TestDiffMarkdown(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	conf := &config.Config{}
+	repo := createShowTestData(t, workingDir, conf)
+	proj := project.NewProject(repo, workingDir)
+
+	out := new(bytes.Buffer)
+	err = printDiffMarkdown(out, proj, "1c", "2c")
+	require.NoError(t, err)
+	actual := out.String()
+
+	expected := `
+### Experiment
+
+_(same experiment: ` + "`1eeeeee`" + `)_
+
+### Params
+
+_(no difference)_
+
+### Python Packages
+
+_(no difference)_
+
+### Checkpoint
+
+| | ` + "`1cccccc`" + ` | ` + "`2cccccc`" + ` |
+|---|---|---|
+| Created | Mon, 02 Jan 2006 22:59:05 +08 | Mon, 02 Jan 2006 23:00:05 +08 |
+| Step | 10 | 20 |
+
+### Metrics
+
+| | ` + "`1cccccc`" + ` | ` + "`2cccccc`" + ` |
+|---|---|---|
+| metric-1 | 0.1 | **0.01** |
+`
+	require.Equal(t, expected[1:], actual)
+}
+
+func TestDiffJSON(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	conf := &config.Config{}
+	repo := createShowTestData(t, workingDir, conf)
+	proj := project.NewProject(repo, workingDir)
+
+	out := new(bytes.Buffer)
+	err = printDiffJSON(out, proj, "2c", "1c", "4c")
+	require.NoError(t, err)
+
+	diff := new(checkpointDiff)
+	require.NoError(t, json.Unmarshal(out.Bytes(), diff))
+
+	require.Equal(t, []*diffColumn{
+		{ExperimentID: "1eeeeeeeee", CheckpointID: "2ccccccccc"},
+		{ExperimentID: "1eeeeeeeee", CheckpointID: "1ccccccccc"},
+		{ExperimentID: "2eeeeeeeee", CheckpointID: "4ccccccccc"},
+	}, diff.Columns)
+
+	names := []string{}
+	for _, section := range diff.Sections {
+		names = append(names, section.Name)
+	}
+	require.Equal(t, []string{"Experiment", "Params", "Python Packages", "Checkpoint", "Metrics"}, names)
+
+	metrics := diff.Sections[4]
+	require.Equal(t, []string{"2cccccc", "1cccccc", "4cccccc"}, metrics.IDs)
+	require.Equal(t, "metric-1", metrics.Rows[0].Key)
+	require.Equal(t, []*string{testutil.SP("0.01"), testutil.SP("0.1"), nil}, metrics.Rows[0].Values)
+	require.Equal(t, 0, *metrics.Rows[0].Best)
+	require.Equal(t, "metric-3", metrics.Rows[2].Key)
+	require.Nil(t, metrics.Rows[2].Best)
+}
+
 func TestMapString(t *testing.T) {
 	// string pointer helpers
 	baz := "baz"
@@ -145,4 +283,19 @@ func TestMapString(t *testing.T) {
 		"same":      "in both",
 		"different": "bop",
 	}))
+
+	// more than two
+	require.Equal(t, map[string][]*string{
+		"different": {&baz, nil, &bop},
+		"last":      {nil, nil, &baz},
+	}, mapString(map[string]string{
+		"same":      "in both",
+		"different": "baz",
+	}, map[string]string{
+		"same": "in both",
+	}, map[string]string{
+		"same":      "in both",
+		"different": "bop",
+		"last":      "baz",
+	}))
 }
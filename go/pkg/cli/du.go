@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+const (
+	duByExperiment = "experiment"
+	duByCheckpoint = "checkpoint"
+	duByUser       = "user"
+	duByAge        = "age"
+)
+
+type duOpts struct {
+	by            string
+	json          bool
+	repositoryURL string
+}
+
+func newDiskUsageCommand() *cobra.Command {
+	var opts duOpts
+
+	cmd := &cobra.Command{
+		Use:   "du [experiment ID...]",
+		Short: "Show how much storage experiments and checkpoints use",
+		Long: `Show how much storage experiments and checkpoints use in the repository, largest first.
+
+Usage can be grouped by experiment, checkpoint, the user who ran the experiment, or how long ago the experiment was created. Experiments can be selected by passing ID prefixes, with --filter, or both. If neither are passed, every experiment in the project is included.
+
+Sizes are the compressed sizes of the files stored in the repository. They are recorded in the experiment's metadata when the files are uploaded, and files uploaded by older versions of Keepsake are looked up in the repository.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			filters, err := parseListFilterFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return showDiskUsage(opts, args, filters, os.Stdout)
+		}),
+		Example: `Show the experiments that use the most storage:
+$ keepsake du
+
+Show how much storage each user's experiments use:
+$ keepsake du --by user
+
+Show the checkpoints of stopped experiments that use the most storage:
+$ keepsake du --by checkpoint --filter "status = stopped"
+`,
+	}
+
+	cmd.Flags().StringVar(&opts.by, "by", duByExperiment, "What to group usage by: \"experiment\", \"checkpoint\", \"user\", or \"age\"")
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Print output in JSON format")
+	addListFilterFlag(cmd)
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+
+	return cmd
+}
+
+// diskUsage is the storage used by a group of experiments or checkpoints
+type diskUsage struct {
+	Experiment  string `json:"experiment,omitempty"`
+	Checkpoint  string `json:"checkpoint,omitempty"`
+	Step        *int64 `json:"step,omitempty"`
+	User        string `json:"user,omitempty"`
+	Age         string `json:"age,omitempty"`
+	Experiments int    `json:"experiments"`
+	Tarballs    int    `json:"tarballs"`
+	Size        int64  `json:"size"`
+
+	experiment    *project.Experiment
+	experimentIDs map[string]bool
+}
+
+func showDiskUsage(opts duOpts, prefixes []string, filters *param.Filters, out io.Writer) error {
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+	proj := project.NewProject(repo, projectDir)
+
+	experiments, err := selectExperiments(proj, prefixes, filters)
+	if err != nil {
+		return err
+	}
+	usage, err := diskUsageBy(opts.by, experiments, newTarballSizes(repo), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(usage)
+	}
+	return writeDiskUsage(out, opts.by, usage)
+}
+
+// diskUsageBy returns the storage used by experiments, grouped by the
+// experiment, checkpoint, user, or age bucket, largest first
+func diskUsageBy(by string, experiments []*project.Experiment, sizes *tarballSizes, now time.Time) ([]*diskUsage, error) {
+	if by != duByExperiment && by != duByCheckpoint && by != duByUser && by != duByAge {
+		return nil, fmt.Errorf("Unknown grouping %q, it must be one of %q, %q, %q, or %q", by, duByExperiment, duByCheckpoint, duByUser, duByAge)
+	}
+
+	groups := map[string]*diskUsage{}
+	add := func(key string, group *diskUsage, exp *project.Experiment, size int64) {
+		g, ok := groups[key]
+		if !ok {
+			g = group
+			g.experiment = exp
+			g.experimentIDs = map[string]bool{}
+			groups[key] = g
+		}
+		g.experimentIDs[exp.ID] = true
+		g.Experiments = len(g.experimentIDs)
+		g.Tarballs++
+		g.Size += size
+	}
+
+	for _, exp := range experiments {
+		tarballs, err := experimentTarballSizes(exp, sizes)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tarballs {
+			switch by {
+			case duByExperiment:
+				add(exp.ID, &diskUsage{Experiment: exp.ID, User: exp.User}, exp, t.size)
+			case duByCheckpoint:
+				if t.checkpoint == nil {
+					continue
+				}
+				step := t.checkpoint.Step
+				add(t.checkpoint.ID, &diskUsage{Experiment: exp.ID, Checkpoint: t.checkpoint.ID, Step: &step}, exp, t.size)
+			case duByUser:
+				add(exp.User, &diskUsage{User: exp.User}, exp, t.size)
+			case duByAge:
+				age := ageBucket(now.Sub(exp.Created))
+				add(age, &diskUsage{Age: age}, exp, t.size)
+			}
+		}
+	}
+
+	ret := []*diskUsage{}
+	for _, g := range groups {
+		ret = append(ret, g)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Size != ret[j].Size {
+			return ret[i].Size > ret[j].Size
+		}
+		return ret[i].experiment.Created.After(ret[j].experiment.Created)
+	})
+	return ret, nil
+}
+
+// ageBuckets are the upper bounds of the age buckets, in days
+var ageBuckets = []struct {
+	days  int
+	label string
+}{
+	{1, "less than a day"},
+	{7, "1-7 days"},
+	{30, "7-30 days"},
+	{90, "30-90 days"},
+	{365, "90-365 days"},
+}
+
+func ageBucket(age time.Duration) string {
+	for _, b := range ageBuckets {
+		if age < time.Duration(b.days)*24*time.Hour {
+			return b.label
+		}
+	}
+	return "more than a year"
+}
+
+type tarballSize struct {
+	// checkpoint is nil for the experiment's code tarball
+	checkpoint *project.Checkpoint
+	size       int64
+}
+
+// experimentTarballSizes returns the sizes of the tarballs stored for an
+// experiment and its checkpoints. Tarballs that don't exist are skipped.
+func experimentTarballSizes(exp *project.Experiment, sizes *tarballSizes) ([]tarballSize, error) {
+	ret := []tarballSize{}
+	if exp.Path != "" {
+		size, ok, err := sizes.get(exp.StorageTarPath(), exp.Size)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ret = append(ret, tarballSize{size: size})
+		}
+	}
+	for _, chk := range exp.Checkpoints {
+		if chk.Path == "" || chk.Pruned || chk.Trashed {
+			continue
+		}
+		size, ok, err := sizes.get(chk.StorageTarPath(), chk.Size)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ret = append(ret, tarballSize{checkpoint: chk, size: size})
+		}
+	}
+	return ret, nil
+}
+
+// tarballSizes looks up the sizes of tarballs in a repository. Sizes are
+// recorded in metadata when tarballs are uploaded, but older tarballs don't
+// have them, so the repository is listed the first time one of those is
+// looked up.
+type tarballSizes struct {
+	repo   repository.Repository
+	listed map[string]int64
+}
+
+func newTarballSizes(repo repository.Repository) *tarballSizes {
+	return &tarballSizes{repo: repo}
+}
+
+// get returns the size of the tarball at tarPath, using recorded if it is
+// set, and false if the tarball doesn't exist
+func (s *tarballSizes) get(tarPath string, recorded int64) (int64, bool, error) {
+	if recorded > 0 {
+		return recorded, true, nil
+	}
+	if s.listed == nil {
+		listed := map[string]int64{}
+		for _, folder := range []string{"experiments", "checkpoints"} {
+			results := make(chan repository.ListResult)
+			go s.repo.ListRecursive(results, folder)
+			for result := range results {
+				if result.Error != nil {
+					return 0, false, result.Error
+				}
+				listed[result.Path] = result.Size
+			}
+		}
+		s.listed = listed
+	}
+	size, ok := s.listed[tarPath]
+	return size, ok, nil
+}
+
+func writeDiskUsage(out io.Writer, by string, usage []*diskUsage) error {
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	switch by {
+	case duByExperiment:
+		fmt.Fprintln(tw, "EXPERIMENT\tSTARTED\tUSER\tTARBALLS\tSIZE")
+	case duByCheckpoint:
+		fmt.Fprintln(tw, "EXPERIMENT\tCHECKPOINT\tSTEP\tSIZE")
+	case duByUser:
+		fmt.Fprintln(tw, "USER\tEXPERIMENTS\tTARBALLS\tSIZE")
+	case duByAge:
+		fmt.Fprintln(tw, "AGE\tEXPERIMENTS\tTARBALLS\tSIZE")
+	}
+
+	total := int64(0)
+	tarballs := 0
+	for _, u := range usage {
+		total += u.Size
+		tarballs += u.Tarballs
+		size := console.FormatBytes(u.Size)
+		switch by {
+		case duByExperiment:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", u.experiment.ShortID(), console.FormatTime(u.experiment.Created), u.User, u.Tarballs, size)
+		case duByCheckpoint:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", u.experiment.ShortID(), u.Checkpoint[:7], strconv.FormatInt(*u.Step, 10), size)
+		case duByUser:
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", u.User, u.Experiments, u.Tarballs, size)
+		case duByAge:
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", u.Age, u.Experiments, u.Tarballs, size)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\nTotal: %s in %d tarballs\n", console.FormatBytes(total), tarballs)
+	return err
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+var duTestTime = time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)
+
+func createDiskUsageTestData(t *testing.T, repoDir string) repository.Repository {
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	experiments := []*project.Experiment{
+		{
+			ID:      "1eeeeeeeee",
+			Created: duTestTime.Add(-2 * time.Hour),
+			User:    "ada",
+			Config:  &config.Config{},
+			Path:    ".",
+			Size:    100,
+			Params:  param.ValueMap{"lr": param.Float(0.1)},
+			Checkpoints: []*project.Checkpoint{
+				{ID: "1ccccccccc", Created: duTestTime, Step: 1, Path: "model.pth", Size: 1000},
+				{ID: "2ccccccccc", Created: duTestTime, Step: 2, Path: "model.pth", Size: 2000},
+				{ID: "3ccccccccc", Created: duTestTime, Step: 3, Path: "model.pth", Size: 4000, Pruned: true},
+				{ID: "4ccccccccc", Created: duTestTime, Step: 4},
+			},
+		},
+		{
+			ID:      "2eeeeeeeee",
+			Created: duTestTime.Add(-10 * 24 * time.Hour),
+			User:    "grace",
+			Config:  &config.Config{},
+			Path:    ".",
+			Size:    200,
+			Params:  param.ValueMap{"lr": param.Float(0.01)},
+			Checkpoints: []*project.Checkpoint{
+				// uploaded before sizes were recorded, so it is listed
+				{ID: "5ccccccccc", Created: duTestTime, Step: 0, Path: "model.pth"},
+			},
+		},
+		{
+			ID:      "3eeeeeeeee",
+			Created: duTestTime.Add(-3 * time.Hour),
+			User:    "ada",
+			Config:  &config.Config{},
+			Path:    ".",
+			Size:    5000,
+			Params:  param.ValueMap{"lr": param.Float(0.001)},
+		},
+	}
+	for _, exp := range experiments {
+		require.NoError(t, exp.Save(repo))
+	}
+
+	codeDir, err := files.TempDir("test-du-code")
+	require.NoError(t, err)
+	defer os.RemoveAll(codeDir)
+	require.NoError(t, ioutil.WriteFile(path.Join(codeDir, "model.pth"), []byte("weights"), 0644))
+	_, err = repo.PutPathTar(codeDir, "checkpoints/5ccccccccc.tar.gz", "model.pth")
+	require.NoError(t, err)
+
+	return repo
+}
+
+func TestDiskUsageBy(t *testing.T) {
+	repoDir, err := files.TempDir("test-du")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo := createDiskUsageTestData(t, repoDir)
+
+	info, err := os.Stat(path.Join(repoDir, "checkpoints", "5ccccccccc.tar.gz"))
+	require.NoError(t, err)
+	listedSize := info.Size()
+
+	proj := project.NewProject(repo, "")
+	experiments, err := selectExperiments(proj, []string{}, new(param.Filters))
+	require.NoError(t, err)
+
+	summarize := func(usage []*diskUsage, key func(*diskUsage) string) [][]interface{} {
+		ret := [][]interface{}{}
+		for _, u := range usage {
+			ret = append(ret, []interface{}{key(u), u.Experiments, u.Tarballs, u.Size})
+		}
+		return ret
+	}
+
+	usage, err := diskUsageBy(duByExperiment, experiments, newTarballSizes(repo), duTestTime)
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{
+		{"3eeeeeeeee", 1, 1, int64(5000)},
+		{"1eeeeeeeee", 1, 3, int64(3100)},
+		{"2eeeeeeeee", 1, 2, int64(200) + listedSize},
+	}, summarize(usage, func(u *diskUsage) string { return u.Experiment }))
+
+	usage, err = diskUsageBy(duByCheckpoint, experiments, newTarballSizes(repo), duTestTime)
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{
+		{"2ccccccccc", 1, 1, int64(2000)},
+		{"1ccccccccc", 1, 1, int64(1000)},
+		{"5ccccccccc", 1, 1, listedSize},
+	}, summarize(usage, func(u *diskUsage) string { return u.Checkpoint }))
+
+	usage, err = diskUsageBy(duByUser, experiments, newTarballSizes(repo), duTestTime)
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{
+		{"ada", 2, 4, int64(8100)},
+		{"grace", 1, 2, int64(200) + listedSize},
+	}, summarize(usage, func(u *diskUsage) string { return u.User }))
+
+	usage, err = diskUsageBy(duByAge, experiments, newTarballSizes(repo), duTestTime)
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{
+		{"less than a day", 2, 4, int64(8100)},
+		{"7-30 days", 1, 2, int64(200) + listedSize},
+	}, summarize(usage, func(u *diskUsage) string { return u.Age }))
+
+	_, err = diskUsageBy("host", experiments, newTarballSizes(repo), duTestTime)
+	require.Error(t, err)
+}
+
+func TestDiskUsageJSON(t *testing.T) {
+	repoDir, err := files.TempDir("test-du-json")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	createDiskUsageTestData(t, repoDir)
+
+	filters, err := param.MakeFilters([]string{"lr < 0.05"})
+	require.NoError(t, err)
+	out := new(bytes.Buffer)
+	err = showDiskUsage(duOpts{by: duByCheckpoint, json: true, repositoryURL: "file://" + repoDir}, []string{}, filters, out)
+	require.NoError(t, err)
+
+	var usage []map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &usage))
+	require.Len(t, usage, 1)
+	require.Equal(t, "2eeeeeeeee", usage[0]["experiment"])
+	require.Equal(t, "5ccccccccc", usage[0]["checkpoint"])
+	require.Equal(t, 0.0, usage[0]["step"])
+}
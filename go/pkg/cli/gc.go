@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+type gcOpts struct {
+	dryRun        bool
+	repositoryURL string
+}
+
+func newGCCommand() *cobra.Command {
+	var opts gcOpts
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Permanently delete things that have been in the trash for longer than the trash retention",
+		Long: `Permanently delete experiments and checkpoints that have been in the trash for longer than "retention" in the "trash" section of keepsake.yaml.
+
+Nothing is deleted if the retention isn't set. Unlike "keepsake trash empty", it doesn't ask before deleting anything, so it can be run on a schedule.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			proj, projectDir, err := getTrashProject(opts.repositoryURL)
+			if err != nil {
+				return err
+			}
+			retention, err := trashRetention(projectDir)
+			if err != nil {
+				return err
+			}
+			return collectTrash(proj, retention, opts.dryRun, os.Stdout)
+		}),
+		Args: cobra.NoArgs,
+		Example: `See what would be deleted:
+$ keepsake gc --dry-run`,
+	}
+	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "n", false, "Show what would be deleted, without deleting anything")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+	return cmd
+}
+
+// collectTrash deletes things that have been in the trash for longer than
+// retention. Nothing is deleted if retention is 0.
+func collectTrash(proj *project.Project, retention time.Duration, dryRun bool, out io.Writer) error {
+	if retention == 0 {
+		console.Info("Nothing was deleted, because \"retention\" isn't set in the \"trash\" section of keepsake.yaml, so things are kept in the trash until it is emptied.")
+		return nil
+	}
+
+	if dryRun {
+		entries, err := proj.TrashEntries()
+		if err != nil {
+			return err
+		}
+		cutoff := time.Now().Add(-retention)
+		count := 0
+		for _, entry := range entries {
+			if entry.Deleted.After(cutoff) {
+				continue
+			}
+			fmt.Fprintf(out, "Would delete %s %s\n", entry.Kind(), entry.ShortID())
+			count++
+		}
+		console.Info("%d experiments and checkpoints have been in the trash for longer than %s. Run 'keepsake gc' without --dry-run to delete them.", count, retention)
+		return nil
+	}
+
+	if err := proj.CheckCanDelete(); err != nil {
+		return err
+	}
+	deleted, err := proj.EmptyTrash(retention)
+	if err != nil {
+		return err
+	}
+	for _, entry := range deleted {
+		fmt.Fprintf(out, "Deleted %s %s\n", entry.Kind(), entry.ShortID())
+	}
+	console.Info("Permanently deleted %d experiments and checkpoints that have been in the trash for longer than %s", len(deleted), retention)
+	return nil
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestCollectTrash(t *testing.T) {
+	repoDir, err := files.TempDir("test-gc")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	proj := project.NewProject(repo, "")
+
+	created := time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)
+	exp := &project.Experiment{ID: "1eeeeeeeee", Created: created, Config: &config.Config{}}
+	for _, id := range []string{"1ccccccccc", "2ccccccccc"} {
+		exp.Checkpoints = append(exp.Checkpoints, &project.Checkpoint{ID: id, Created: created, Path: "model.pth"})
+		require.NoError(t, repo.Put("checkpoints/"+id+".tar.gz", []byte("weights")))
+	}
+	require.NoError(t, exp.Save(repo))
+	for _, chk := range exp.Checkpoints {
+		require.NoError(t, proj.TrashCheckpoint(chk, exp))
+	}
+
+	// 1ccccccccc was removed two days ago
+	entryPath := "trash/1ccccccccc/trash.json"
+	data, err := repo.Get(entryPath)
+	require.NoError(t, err)
+	entry := new(project.TrashEntry)
+	require.NoError(t, json.Unmarshal(data, entry))
+	entry.Deleted = time.Now().Add(-48 * time.Hour)
+	data, err = json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, repo.Put(entryPath, data))
+
+	// nothing is deleted without a retention
+	out := new(bytes.Buffer)
+	require.NoError(t, collectTrash(proj, 0, false, out))
+	require.Empty(t, out.String())
+	require.FileExists(t, path.Join(repoDir, entryPath))
+
+	// dry run doesn't delete anything
+	require.NoError(t, collectTrash(proj, 24*time.Hour, true, out))
+	require.Equal(t, "Would delete checkpoint 1cccccc\n", out.String())
+	require.FileExists(t, path.Join(repoDir, entryPath))
+
+	out.Reset()
+	require.NoError(t, collectTrash(proj, 24*time.Hour, false, out))
+	require.Equal(t, "Deleted checkpoint 1cccccc\n", out.String())
+	require.NoDirExists(t, path.Join(repoDir, "trash/1ccccccccc"))
+	require.DirExists(t, path.Join(repoDir, "trash/2ccccccccc"))
+}
@@ -44,8 +44,6 @@ func generateDocs(rootCmd *cobra.Command) func(cmd *cobra.Command, args []string
 }
 
 func genMarkdownSingleFile(cmd *cobra.Command, path string) error {
-	// TODO: support more than two levels of commands?
-
 	f, err := os.Create(path)
 	if err != nil {
 		return err
@@ -125,8 +123,19 @@ func genMarkdown(cmd *cobra.Command, f *os.File) error {
 			return err
 		}
 	}
-	_, err := buf.WriteTo(f)
-	return err
+	if _, err := buf.WriteTo(f); err != nil {
+		return err
+	}
+
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		if err := genMarkdown(c, f); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func printOptions(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/global"
+	"github.com/replicate/keepsake/go/pkg/hooks"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+func newHooksCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage the hooks in keepsake.yaml",
+		Long: `Manage the hooks in keepsake.yaml, which are commands that are run, or URLs that are sent a POST request, when things happen to experiments.
+
+Hooks are run by Keepsake in the background while your training script runs. If a hook fails or times out, a warning is printed, and training carries on.`,
+	}
+	cmd.AddCommand(newHooksTestCommand())
+	return cmd
+}
+
+func newHooksTestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [event]",
+		Short: "Run the hooks in keepsake.yaml with a test event",
+		Long: fmt.Sprintf(`Run the hooks in keepsake.yaml with a test event, to check they work.
+
+The event is one of: %s. If an event isn't passed, the hooks for every event are run.
+
+Test events have "test": true in their JSON, and made-up experiment and checkpoint IDs.`, strings.Join(config.HookEvents, ", ")),
+		Run:  handleErrors(testHooks),
+		Args: cobra.MaximumNArgs(1),
+		Example: `Check the hook that posts to Slack when a checkpoint is the best so far:
+$ keepsake hooks test best_checkpoint`,
+	}
+	return cmd
+}
+
+func testHooks(cmd *cobra.Command, args []string) error {
+	conf, projectDir, err := config.FindConfigInWorkingDir(global.ProjectDirectory)
+	if err != nil {
+		return err
+	}
+	events := config.HookEvents
+	if len(args) == 1 {
+		if !isHookEvent(args[0]) {
+			return fmt.Errorf("%q is not an event. It must be one of: %s", args[0], strings.Join(config.HookEvents, ", "))
+		}
+		events = []string{args[0]}
+	}
+
+	ran, failed := 0, 0
+	for _, event := range events {
+		for _, hook := range conf.Hooks.ForEvent(event) {
+			ran++
+			output, err := hooks.Run(hook, testEvent(event), projectDir)
+			if err != nil {
+				failed++
+				console.Error("on_%s: %s: FAILED: %v%s", event, hook, err, formatHookOutput(output))
+			} else {
+				console.Info("on_%s: %s: OK%s", event, hook, formatHookOutput(output))
+			}
+		}
+	}
+	if ran == 0 {
+		if len(args) == 1 {
+			return fmt.Errorf("There are no on_%s hooks in keepsake.yaml", args[0])
+		}
+		return fmt.Errorf("There are no hooks in keepsake.yaml")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d hooks failed", failed, ran)
+	}
+	return nil
+}
+
+func isHookEvent(event string) bool {
+	for _, e := range config.HookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// testEvent returns an event like the ones the daemon sends, for an experiment
+// and checkpoint that don't exist
+func testEvent(event string) *hooks.Event {
+	exp := project.NewExperiment(param.ValueMap{"learning_rate": param.Float(0.01)})
+	exp.Command = "train.py"
+	var chk *project.Checkpoint
+	switch event {
+	case config.EventCheckpoint, config.EventBestCheckpoint:
+		chk = project.NewCheckpoint(param.ValueMap{"loss": param.Float(0.1)})
+		chk.Step = 1
+		chk.PrimaryMetric = &project.PrimaryMetric{Name: "loss", Goal: project.GoalMinimize}
+		exp.Checkpoints = []*project.Checkpoint{chk}
+	}
+	e := hooks.NewEvent(event, exp, chk)
+	if event == config.EventCrash {
+		e.Error = "Traceback (most recent call last):\n  File \"train.py\", line 1, in <module>\nException: This is a test"
+	}
+	e.Test = true
+	return e
+}
+
+// formatHookOutput returns a hook's output on the lines after a message
+func formatHookOutput(output []byte) string {
+	s := strings.TrimSpace(string(output))
+	if s == "" {
+		return ""
+	}
+	return "\n" + s
+}
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/global"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+const defaultKeepsakeIgnore = `# Files that aren't saved with experiments, in the same format as .gitignore
+__pycache__/
+*.pyc
+.ipynb_checkpoints/
+.venv/
+venv/
+*.egg-info/
+.DS_Store
+keepsake.local.yaml
+`
+
+// gitIgnoreLines are added to .gitignore, if the project uses git. .keepsake/
+// is where metadata is cached, even if the repository is somewhere else.
+var gitIgnoreLines = []string{".keepsake/", config.LocalConfigFilename}
+
+type initOpts struct {
+	repositoryURL string
+	createBucket  bool
+	force         bool
+}
+
+func newInitCommand() *cobra.Command {
+	var opts initOpts
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up Keepsake in a project",
+		Long: `Set up Keepsake in a project, by writing keepsake.yaml and .keepsakeignore in the project directory.
+
+The repository is checked by writing a test file to it and reading it back, so problems with credentials or permissions show up now rather than when you train a model. If the repository is on Amazon S3 or Google Cloud Storage and the bucket doesn't exist, it is created.
+
+If --repository isn't passed, you are asked for the repository URL.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			dir := global.ProjectDirectory
+			if dir == "" {
+				var err error
+				dir, err = os.Getwd()
+				if err != nil {
+					return err
+				}
+			}
+			return initProject(opts, dir)
+		}),
+		Args: cobra.NoArgs,
+		Example: `Store experiments in an S3 bucket, creating it if it doesn't exist:
+$ keepsake init -R s3://hooli-hotdog-detector --create-bucket`,
+	}
+	cmd.Flags().StringVarP(&opts.repositoryURL, "repository", "R", "", "Repository URL to write to keepsake.yaml, e.g. 's3://my-keepsake-bucket'")
+	cmd.Flags().BoolVar(&opts.createBucket, "create-bucket", false, "Create the bucket if it doesn't exist, without asking")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Overwrite keepsake.yaml if it already exists")
+	return cmd
+}
+
+func initProject(opts initOpts, dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for _, filename := range global.ConfigFilenames {
+		exists, err := files.FileExists(filepath.Join(dir, filename))
+		if err != nil {
+			return err
+		}
+		if exists && !opts.force {
+			return fmt.Errorf("%s already exists in %s. Pass -f to overwrite it.", filename, dir)
+		}
+	}
+
+	interactive := opts.repositoryURL == ""
+	repositoryURL := opts.repositoryURL
+	if interactive {
+		repositoryURL, err = console.Interactive{
+			Prompt:   "Repository URL, like s3://my-bucket, gs://my-bucket, or file://.keepsake",
+			Default:  "file://.keepsake",
+			Required: true,
+		}.Read()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ensureBucketExists(repositoryURL, opts.createBucket, interactive); err != nil {
+		return err
+	}
+	if err := checkRepository(repositoryURL, dir); err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(dir, global.ConfigFilenames[0])
+	conf := fmt.Sprintf("# The repository is where experiments and checkpoints are stored.\n# See %s/docs/reference/yaml for everything that can go in this file.\nrepository: %q\n", global.WebURL, repositoryURL)
+	if err := ioutil.WriteFile(configPath, []byte(conf), 0644); err != nil {
+		return err
+	}
+	console.Info("Wrote %s", configPath)
+
+	ignorePath := filepath.Join(dir, ".keepsakeignore")
+	exists, err := files.FileExists(ignorePath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := ioutil.WriteFile(ignorePath, []byte(defaultKeepsakeIgnore), 0644); err != nil {
+			return err
+		}
+		console.Info("Wrote %s", ignorePath)
+	}
+
+	if err := addToGitIgnore(dir); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ensureBucketExists creates the bucket in an S3 or Google Cloud Storage
+// repository URL if it doesn't exist. It asks first, unless create is true.
+func ensureBucketExists(repositoryURL string, create bool, interactive bool) error {
+	scheme, bucket, root, err := repository.SplitURL(repositoryURL)
+	if err != nil {
+		return err
+	}
+	urlOpts, err := repository.ParseURLOptions(repositoryURL)
+	if err != nil {
+		return err
+	}
+	s3Opts := repository.S3Options{Profile: urlOpts.Profile, Endpoint: urlOpts.Endpoint}
+
+	var exists bool
+	var gcs *repository.GCSRepository
+	switch scheme {
+	case repository.SchemeS3:
+		exists, err = repository.S3BucketExists(bucket, s3Opts)
+	case repository.SchemeGCS:
+		gcs, err = repository.NewGCSRepository(bucket, root)
+		if err != nil {
+			return err
+		}
+		exists, err = gcs.BucketExists()
+	default:
+		return nil
+	}
+	if err != nil || exists {
+		return err
+	}
+
+	bucketURL := fmt.Sprintf("%s://%s", scheme, bucket)
+	if !create {
+		if !interactive {
+			return fmt.Errorf("The bucket %s doesn't exist. Pass --create-bucket to create it.", bucketURL)
+		}
+		create, err = console.InteractiveBool{
+			Prompt:         fmt.Sprintf("The bucket %s doesn't exist. Do you want to create it?", bucketURL),
+			Default:        false,
+			NonDefaultFlag: "--create-bucket",
+		}.Read()
+		if err != nil {
+			return err
+		}
+		if !create {
+			return fmt.Errorf("Aborting.")
+		}
+	}
+
+	console.Info("Creating bucket %s...", bucketURL)
+	if scheme == repository.SchemeGCS {
+		return gcs.CreateBucket()
+	}
+	return repository.CreateS3BucketWithOptions(global.S3Region, bucket, s3Opts)
+}
+
+// checkRepository checks the repository can be used, and writes its spec if
+// it is new
+func checkRepository(repositoryURL string, dir string) error {
+	console.Info("Checking %s...", repositoryURL)
+	repo, err := repository.ForURL(repositoryURL, dir)
+	if err != nil {
+		return err
+	}
+	mode, err := repository.ModeOf(repo)
+	if err != nil {
+		return err
+	}
+	if mode == repository.ModeReadOnly {
+		// Nothing can be written, so just check it can be read
+		_, err := repo.List("")
+		return err
+	}
+	if err := repository.CheckWritable(repo); err != nil {
+		return err
+	}
+	_, err = repository.EnsureSpec(repo)
+	return err
+}
+
+// addToGitIgnore adds the files Keepsake writes in the project directory to
+// .gitignore, if the project uses git
+func addToGitIgnore(dir string) error {
+	gitIgnorePath := filepath.Join(dir, ".gitignore")
+	isGit, err := files.FileExists(filepath.Join(dir, ".git"))
+	if err != nil {
+		return err
+	}
+	hasGitIgnore, err := files.FileExists(gitIgnorePath)
+	if err != nil {
+		return err
+	}
+	if !isGit && !hasGitIgnore {
+		return nil
+	}
+
+	content := ""
+	if hasGitIgnore {
+		data, err := ioutil.ReadFile(gitIgnorePath)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	}
+	existing := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	missing := []string{}
+	for _, line := range gitIgnoreLines {
+		if !existing[line] {
+			missing = append(missing, line)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += strings.Join(missing, "\n") + "\n"
+	if err := ioutil.WriteFile(gitIgnorePath, []byte(content), 0644); err != nil {
+		return err
+	}
+	console.Info("Added %s to %s", strings.Join(missing, " and "), gitIgnorePath)
+	return nil
+}
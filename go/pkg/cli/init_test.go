@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestInitProject(t *testing.T) {
+	dir, err := files.TempDir("test-init")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.pyc\n.keepsake/"), 0644))
+
+	require.NoError(t, initProject(initOpts{repositoryURL: "file://.keepsake"}, dir))
+
+	conf, err := config.LoadConfig(filepath.Join(dir, "keepsake.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "file://.keepsake", conf.Repository)
+	require.FileExists(t, filepath.Join(dir, ".keepsakeignore"))
+	gitIgnore, err := ioutil.ReadFile(filepath.Join(dir, ".gitignore"))
+	require.NoError(t, err)
+	require.Equal(t, "*.pyc\n.keepsake/\nkeepsake.local.yaml\n", string(gitIgnore))
+
+	// the repository is bootstrapped, and the test file is cleaned up
+	repo, err := repository.NewDiskRepository(filepath.Join(dir, ".keepsake"))
+	require.NoError(t, err)
+	spec, err := repository.LoadSpec(repo)
+	require.NoError(t, err)
+	require.Equal(t, repository.Version, spec.Version)
+	tmp, err := repo.List(".keepsake-internal/tmp")
+	require.NoError(t, err)
+	require.Empty(t, tmp)
+
+	// doesn't overwrite keepsake.yaml without -f
+	err = initProject(initOpts{repositoryURL: "file://other"}, dir)
+	require.Error(t, err)
+	require.NoError(t, initProject(initOpts{repositoryURL: "file://other", force: true}, dir))
+	conf, err = config.LoadConfig(filepath.Join(dir, "keepsake.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "file://other", conf.Repository)
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/lineage"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+type lineageOpts struct {
+	dot           bool
+	check         bool
+	repositoryURL string
+}
+
+func newLineageCommand() *cobra.Command {
+	var opts lineageOpts
+
+	cmd := &cobra.Command{
+		Use:   "lineage [experiment or checkpoint ID]",
+		Short: "View the experiments an experiment was derived from, and derived from it",
+		Long: `View the ancestors of an experiment, which are the experiments and checkpoints it was resumed, fine-tuned, or rerun from, and its descendants, which are the experiments derived from it.
+
+Parents are recorded by passing them to keepsake.init(parents=...), and by running experiments with "keepsake rerun". If a checkpoint ID is passed, only the descendants derived from that checkpoint are shown.
+
+References to experiments or checkpoints that no longer exist, such as ones that have been deleted, are marked as missing. To check the whole project for them, pass --check.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return showLineage(opts, args, os.Stdout)
+		}),
+		Args: cobra.MaximumNArgs(1),
+		Example: `View the lineage of an experiment:
+$ keepsake lineage 1eeeeee
+
+Draw it as an image with Graphviz:
+$ keepsake lineage 1eeeeee --dot | dot -Tpng -o lineage.png
+
+Find references to deleted experiments and checkpoints:
+$ keepsake lineage --check
+`,
+	}
+
+	cmd.Flags().BoolVar(&opts.dot, "dot", false, "Print the lineage as a graph in Graphviz DOT format")
+	cmd.Flags().BoolVar(&opts.check, "check", false, "Check every experiment in the project for references to experiments or checkpoints that do not exist")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+
+	return cmd
+}
+
+func showLineage(opts lineageOpts, args []string, out io.Writer) error {
+	if !opts.check && len(args) == 0 {
+		return fmt.Errorf("An experiment or checkpoint ID must be passed, unless --check is passed")
+	}
+
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+	proj := project.NewProject(repo, projectDir)
+
+	experiments, err := proj.Experiments()
+	if err != nil {
+		return err
+	}
+	graph := lineage.NewGraph(experiments)
+
+	if opts.check {
+		return checkLineage(graph, out)
+	}
+
+	result, err := proj.CheckpointOrExperimentFromPrefix(args[0])
+	if err != nil {
+		return err
+	}
+	experimentID := result.Experiment.ID
+	checkpointID := ""
+	if result.Checkpoint != nil {
+		checkpointID = result.Checkpoint.ID
+	}
+
+	if opts.dot {
+		if err := graph.WriteDOT(out, experimentID); err != nil {
+			return err
+		}
+	} else {
+		if err := graph.WriteTree(out, experimentID, checkpointID); err != nil {
+			return err
+		}
+	}
+
+	related := graph.Related(experimentID)
+	for _, d := range graph.Dangling() {
+		if related[d.Edge.ChildID] {
+			console.Warn("%s. It may have been deleted.", d)
+		}
+	}
+	return nil
+}
+
+func checkLineage(graph *lineage.Graph, out io.Writer) error {
+	dangling := graph.Dangling()
+	if len(dangling) == 0 {
+		console.Info("No references to missing experiments or checkpoints found")
+		return nil
+	}
+	for _, d := range dangling {
+		fmt.Fprintln(out, d)
+	}
+	return fmt.Errorf("Found %d references to missing experiments or checkpoints", len(dangling))
+}
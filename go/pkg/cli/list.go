@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 
 	"github.com/replicate/keepsake/go/pkg/cli/list"
@@ -28,6 +30,9 @@ $ keepsake ls --filter "optimizer = adam" --filter "accuracy > 0.8"
 
 Sort all stopped experiments by the metric "val_loss":
 $ keepsake ls --sort "val_loss" --filter "status = stopped"
+
+Keep the list on screen, refreshing it every 10 seconds:
+$ keepsake ls --watch --interval 10s
 `,
 	}
 
@@ -35,6 +40,7 @@ $ keepsake ls --sort "val_loss" --filter "status = stopped"
 	addListFormatFlags(cmd)
 	addListFilterFlag(cmd)
 	addListSortFlag(cmd)
+	addListWatchFlags(cmd)
 
 	return cmd
 }
@@ -56,10 +62,17 @@ func listExperiments(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	watch, interval, err := parseListWatchFlags(cmd, format)
+	if err != nil {
+		return err
+	}
 	repo, err := getRepository(repositoryURL, projectDir)
 	if err != nil {
 		return err
 	}
+	if watch {
+		return list.Watch(repo, all, filters, sortKey, interval, aurora.NewAurora(colorEnabled()))
+	}
 	return list.Experiments(repo, format, all, filters, sortKey)
 }
 
@@ -123,6 +136,26 @@ func parseListFilterFlag(cmd *cobra.Command) (*param.Filters, error) {
 	return new(param.Filters), nil
 }
 
+func addListWatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("watch", "w", false, "Keep the list on screen and refresh it until interrupted, highlighting new checkpoints and status changes")
+	cmd.Flags().Duration("interval", 5*time.Second, "How often to refresh the list with --watch. On S3 and GCS, each refresh lists the heartbeats and reads the metadata of running experiments, and everything is listed once a minute, which costs a request per 1,000 files")
+}
+
+func parseListWatchFlags(cmd *cobra.Command, format list.Format) (watch bool, interval time.Duration, err error) {
+	watch, err = cmd.Flags().GetBool("watch")
+	if err != nil {
+		return false, 0, err
+	}
+	interval, err = cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return false, 0, err
+	}
+	if watch && format != list.FormatTable {
+		return false, 0, fmt.Errorf("The --watch flag can't be used in combination with --json or --quiet")
+	}
+	return watch, interval, nil
+}
+
 func addListSortFlag(cmd *cobra.Command) {
 	cmd.Flags().StringP("sort", "s", "created", "Sort key. Suffix with '-desc' for descending sort, e.g. --sort=created-desc")
 }
@@ -41,6 +41,12 @@ type ListExperiment struct {
 	User             string              `json:"user"`
 	Host             string              `json:"host"`
 	Running          bool                `json:"running"`
+	// Status is "running", "stopped", or "dead" if the experiment's process
+	// has exited without stopping it
+	Status string `json:"status"`
+	// LastHeartbeat is when the experiment was last seen running, if it
+	// has a heartbeat
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
 
 	// exclude config from json output
 	Config *config.Config `json:"-"`
@@ -68,10 +74,7 @@ func (exp *ListExperiment) GetValue(name string) param.Value {
 		return param.String(exp.Command)
 	}
 	if name == "status" {
-		if exp.Running {
-			return param.String("running")
-		}
-		return param.String("stopped")
+		return param.String(exp.Status)
 	}
 	if exp.BestCheckpoint != nil {
 		if val, ok := exp.BestCheckpoint.Metrics[name]; ok {
@@ -85,20 +88,27 @@ func (exp *ListExperiment) GetValue(name string) param.Value {
 }
 
 func Experiments(repo repository.Repository, format Format, all bool, filters *param.Filters, sorter *param.Sorter) error {
+	return experiments(repo, format, all, filters, sorter, false)
+}
+
+// Processes lists experiments like Experiments does, and also shows when
+// each experiment's heartbeat was last seen in tables
+func Processes(repo repository.Repository, format Format, all bool, filters *param.Filters, sorter *param.Sorter) error {
+	return experiments(repo, format, all, filters, sorter, true)
+}
+
+func experiments(repo repository.Repository, format Format, all bool, filters *param.Filters, sorter *param.Sorter, lastSeen bool) error {
 	proj := project.NewProject(repo, "")
-	listExperiments, err := createListExperiments(proj, filters)
+	listExperiments, err := ListExperiments(proj, filters, sorter)
 	if err != nil {
 		return err
 	}
-	sort.Slice(listExperiments, func(i, j int) bool {
-		return sorter.LessThan(listExperiments[i], listExperiments[j])
-	})
 
 	switch format {
 	case FormatJSON:
 		return outputJSON(listExperiments)
 	case FormatTable:
-		return outputTable(listExperiments, all)
+		return outputTable(listExperiments, all, lastSeen)
 	case FormatQuiet:
 		return outputQuiet(listExperiments)
 	}
@@ -118,7 +128,7 @@ func outputJSON(experiments []*ListExperiment) error {
 	return enc.Encode(experiments)
 }
 
-func outputTable(experiments []*ListExperiment, all bool) error {
+func outputTable(experiments []*ListExperiment, all bool, lastSeen bool) error {
 	if len(experiments) == 0 {
 		console.Info("No experiments found")
 		return nil
@@ -152,6 +162,9 @@ func outputTable(experiments []*ListExperiment, all bool) error {
 	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
 	headings := []string{"EXPERIMENT", "STARTED", "STATUS"}
+	if lastSeen {
+		headings = append(headings, "LAST SEEN")
+	}
 	if displayHost {
 		headings = append(headings, "HOST")
 	}
@@ -173,11 +186,9 @@ func outputTable(experiments []*ListExperiment, all bool) error {
 	fmt.Fprint(tw, "\n")
 
 	for _, exp := range experiments {
-		columns := []string{exp.ID[:7], console.FormatTime(exp.Created)}
-		if exp.Running {
-			columns = append(columns, "running")
-		} else {
-			columns = append(columns, "stopped")
+		columns := []string{exp.ID[:7], console.FormatTime(exp.Created), exp.Status}
+		if lastSeen {
+			columns = append(columns, formatLastSeen(exp.LastHeartbeat, time.Now()))
 		}
 
 		if displayHost {
@@ -221,6 +232,22 @@ func outputTable(experiments []*ListExperiment, all bool) error {
 	return nil
 }
 
+// formatLastSeen formats how long ago a heartbeat was, in seconds
+func formatLastSeen(lastHeartbeat *time.Time, now time.Time) string {
+	if lastHeartbeat == nil {
+		return ""
+	}
+	seconds := int(now.Sub(*lastHeartbeat).Seconds())
+	if seconds < 0 {
+		// the clocks of different machines don't quite agree
+		seconds = 0
+	}
+	if seconds == 1 {
+		return "1 second ago"
+	}
+	return fmt.Sprintf("%d seconds ago", seconds)
+}
+
 func displayCheckpoint(checkpoint *project.Checkpoint, metricsToDisplay []string) string {
 	out := []string{fmt.Sprintf("%s (step %s)", checkpoint.ShortID(), strconv.FormatInt(checkpoint.Step, 10))}
 
@@ -355,14 +382,24 @@ func createListExperiments(proj *project.Project, filters *param.Filters) ([]*Li
 			User:    exp.User,
 			Config:  exp.Config,
 		}
-		running, err := proj.ExperimentIsRunning(exp.ID)
+		heartbeat, err := proj.ExperimentHeartbeat(exp.ID)
 		if err != nil {
 			return nil, err
 		}
-		listExperiment.LatestCheckpoint = exp.LatestCheckpoint()
-		listExperiment.BestCheckpoint = exp.BestCheckpoint()
-		listExperiment.NumCheckpoints = len(exp.Checkpoints)
-		listExperiment.Running = running
+		listExperiment.Status = string(project.StatusStopped)
+		if heartbeat != nil {
+			listExperiment.Status = string(heartbeat.Status())
+			lastHeartbeat := heartbeat.LastHeartbeat
+			listExperiment.LastHeartbeat = &lastHeartbeat
+		}
+		// checkpoints in the trash have been removed, so they aren't
+		// counted or shown
+		withoutTrashed := *exp
+		withoutTrashed.Checkpoints = exp.CheckpointsNotTrashed()
+		listExperiment.LatestCheckpoint = withoutTrashed.LatestCheckpoint()
+		listExperiment.BestCheckpoint = withoutTrashed.BestCheckpoint()
+		listExperiment.NumCheckpoints = len(withoutTrashed.Checkpoints)
+		listExperiment.Running = listExperiment.Status == string(project.StatusRunning)
 
 		match, err := filters.Matches(listExperiment)
 		if err != nil {
@@ -381,3 +418,34 @@ func createListExperiments(proj *project.Project, filters *param.Filters) ([]*Li
 	return ret, nil
 
 }
+
+// ListExperiments returns the experiments in a project that match filters,
+// in the order given by sorter
+func ListExperiments(proj *project.Project, filters *param.Filters, sorter *param.Sorter) ([]*ListExperiment, error) {
+	listExperiments, err := createListExperiments(proj, filters)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(listExperiments, func(i, j int) bool {
+		return sorter.LessThan(listExperiments[i], listExperiments[j])
+	})
+	return listExperiments, nil
+}
+
+// FilterExperiments returns the experiments in a project that match filters,
+// oldest first
+func FilterExperiments(proj *project.Project, filters *param.Filters) ([]*project.Experiment, error) {
+	listExperiments, err := createListExperiments(proj, filters)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*project.Experiment{}
+	for _, listExp := range listExperiments {
+		exp, err := proj.ExperimentByID(listExp.ID)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, exp)
+	}
+	return ret, nil
+}
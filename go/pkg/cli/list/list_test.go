@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"testing"
 	"time"
@@ -269,6 +270,40 @@ EXPERIMENT  STARTED             STATUS   HOST      USER     PARAMS       BEST CH
 	require.Equal(t, expected, actual)
 }
 
+func TestProcessesOutputTable(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	conf := &config.Config{}
+	repo := createTestData(t, workingDir, conf)
+
+	// the process of this experiment has exited, but its heartbeat hasn't
+	// timed out yet
+	cmd := exec.Command("go", "version")
+	require.NoError(t, cmd.Run())
+	hb := project.NewHeartbeat("3eeeeeeeee", cmd.Process.Pid, nil)
+	require.NoError(t, project.NewProject(repo, "").RefreshHeartbeat(hb))
+
+	filters, err := param.MakeFilters([]string{"status != stopped"})
+	require.NoError(t, err)
+	actual := capturer.CaptureStdout(func() {
+		err = Processes(repo, FormatTable, false, filters, param.NewSorter("started"))
+	})
+	require.NoError(t, err)
+	expected := `
+EXPERIMENT  STARTED             STATUS   LAST SEEN      HOST      USER     PARAMS       BEST CHECKPOINT    LATEST CHECKPOINT
+3eeeeee     2 minutes ago       dead     0 seconds ago  10.1.1.2  ben      param-1=200
+
+1eeeeee     about a second ago  running  0 seconds ago  10.1.1.1  andreas  param-1=100  2cccccc (step 20)  3cccccc (step 20)
+                                                                                        metric-1=0.01      metric-1=0.02
+
+`
+	expected = expected[1:] // strip initial whitespace, added for readability
+	actual = testutil.TrimRightLines(actual)
+	require.Equal(t, expected, actual)
+}
+
 func TestListJSON(t *testing.T) {
 	workingDir, err := ioutil.TempDir("", "keepsake-test")
 	require.NoError(t, err)
@@ -310,6 +345,13 @@ func TestListJSON(t *testing.T) {
 			}),
 		},
 	}
+	// checkpoints in the trash aren't counted
+	trashed := project.NewCheckpoint(param.ValueMap{
+		"accuracy": param.Float(0.5),
+	})
+	trashed.Created = trashed.Created.Add(time.Minute)
+	trashed.Trashed = true
+	exp.Checkpoints = append(exp.Checkpoints, trashed)
 	require.NoError(t, exp.Save(repository))
 	require.NoError(t, err)
 	require.NoError(t, project.CreateHeartbeat(repository, exp.ID, time.Now().UTC()))
@@ -0,0 +1,539 @@
+package list
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/logrusorgru/aurora"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+// how long new checkpoints and status changes stay highlighted for
+const highlightDuration = 30 * time.Second
+
+// how often to check if the terminal has been resized
+const resizeInterval = 250 * time.Millisecond
+
+// how often all of a remote repository's metadata is listed. On S3 and GCS,
+// listing costs a request per 1,000 files, which adds up when a big
+// repository is refreshed every few seconds. Between full listings, only
+// heartbeats are listed, and the metadata of running experiments is read,
+// which costs a request plus one per running experiment. Changes to
+// experiments that aren't running, like deleting them, show up at the next
+// full listing.
+const fullSyncInterval = time.Minute
+
+// watchState is what we know about an experiment from previous refreshes
+type watchState struct {
+	checkpointID      string
+	status            string
+	displayed         bool
+	added             time.Time
+	checkpointChanged time.Time
+	statusChanged     time.Time
+}
+
+type watchRow struct {
+	*ListExperiment
+	experiment *project.Experiment
+	state      watchState
+}
+
+type watcher struct {
+	repo     repository.Repository
+	proj     *project.Project
+	all      bool
+	filters  *param.Filters
+	sorter   *param.Sorter
+	interval time.Duration
+	au       aurora.Aurora
+
+	states          map[string]*watchState
+	rows            []*watchRow
+	hasRefreshed    bool
+	refreshed       time.Time
+	refreshDuration time.Duration
+	refreshErr      error
+
+	// when all of the metadata was last synced, and the IDs of the
+	// experiments that were running then
+	fullSynced time.Time
+	runningIDs map[string]bool
+}
+
+// Watch displays a table of experiments that is refreshed every interval,
+// until the process is interrupted. The repository and project are kept
+// between refreshes, so only metadata that has changed is fetched again.
+// Remote repositories are only listed in full every fullSyncInterval.
+func Watch(repo repository.Repository, all bool, filters *param.Filters, sorter *param.Sorter, interval time.Duration, au aurora.Aurora) error {
+	if interval <= 0 {
+		return fmt.Errorf("The refresh interval must be greater than zero, got %s", interval)
+	}
+	w := newWatcher(repo, all, filters, sorter, interval, au)
+	if err := w.refresh(time.Now()); err != nil {
+		return err
+	}
+	width := terminalWidth()
+	w.draw(os.Stdout, width)
+
+	refreshTicker := time.NewTicker(interval)
+	defer refreshTicker.Stop()
+	resizeTicker := time.NewTicker(resizeInterval)
+	defer resizeTicker.Stop()
+	for {
+		select {
+		case <-refreshTicker.C:
+			// Errors are displayed rather than returned, so a flaky connection
+			// doesn't stop the dashboard
+			w.refreshErr = w.refresh(time.Now())
+			width = terminalWidth()
+			w.draw(os.Stdout, width)
+		case <-resizeTicker.C:
+			if newWidth := terminalWidth(); newWidth != width {
+				width = newWidth
+				w.draw(os.Stdout, width)
+			}
+		}
+	}
+}
+
+func newWatcher(repo repository.Repository, all bool, filters *param.Filters, sorter *param.Sorter, interval time.Duration, au aurora.Aurora) *watcher {
+	return &watcher{
+		repo:     repo,
+		proj:     project.NewProject(repo, ""),
+		all:      all,
+		filters:  filters,
+		sorter:   sorter,
+		interval: interval,
+		au:       au,
+		states:   map[string]*watchState{},
+	}
+}
+
+// refresh loads what has changed in the repository, and records which
+// experiments have new checkpoints or have changed status
+func (w *watcher) refresh(now time.Time) error {
+	start := time.Now()
+	if cachedRepo, ok := w.repo.(*repository.CachedRepository); ok {
+		if err := w.syncCache(cachedRepo, now); err != nil {
+			return err
+		}
+	}
+	if err := w.proj.Refresh(); err != nil {
+		return err
+	}
+	// Load everything, not just what matches the filters, so experiments
+	// that stop matching (e.g. they stop running in `ps`) can be shown for a
+	// bit with their new status
+	listExperiments, err := createListExperiments(w.proj, new(param.Filters))
+	if err != nil {
+		return err
+	}
+
+	rows := []*watchRow{}
+	for _, listExp := range listExperiments {
+		exp, err := w.proj.ExperimentByID(listExp.ID)
+		if err != nil {
+			return err
+		}
+		checkpointID := ""
+		if latest, _ := latestCheckpoints(exp); latest != nil {
+			checkpointID = latest.ID
+		}
+		state, ok := w.states[listExp.ID]
+		if !ok {
+			state = &watchState{checkpointID: checkpointID, status: listExp.Status}
+			if w.hasRefreshed {
+				state.added = now
+			}
+			w.states[listExp.ID] = state
+		}
+		if state.checkpointID != checkpointID {
+			state.checkpointID = checkpointID
+			state.checkpointChanged = now
+		}
+		if state.status != listExp.Status {
+			state.status = listExp.Status
+			state.statusChanged = now
+		}
+
+		match, err := w.filters.Matches(listExp)
+		if err != nil {
+			return err
+		}
+		if !match && !(state.displayed && now.Sub(state.statusChanged) < highlightDuration) {
+			state.displayed = false
+			continue
+		}
+		state.displayed = true
+		rows = append(rows, &watchRow{ListExperiment: listExp, experiment: exp, state: *state})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return w.sorter.LessThan(rows[i].ListExperiment, rows[j].ListExperiment)
+	})
+
+	w.rows = rows
+	w.hasRefreshed = true
+	w.refreshed = now
+	w.refreshDuration = time.Since(start)
+	return nil
+}
+
+// syncCache fetches the metadata that has changed in a remote repository,
+// listing all of it only every fullSyncInterval
+func (w *watcher) syncCache(repo *repository.CachedRepository, now time.Time) error {
+	fullSync := w.fullSynced.IsZero() || now.Sub(w.fullSynced) >= fullSyncInterval
+	if fullSync {
+		if err := repo.SyncCache(); err != nil {
+			return err
+		}
+		w.fullSynced = now
+	} else if err := repo.SyncCacheDir("metadata/heartbeats"); err != nil {
+		return err
+	}
+
+	heartbeatPaths, err := repo.List("metadata/heartbeats/")
+	if err != nil {
+		return err
+	}
+	runningIDs := map[string]bool{}
+	for _, p := range heartbeatPaths {
+		runningIDs[strings.TrimSuffix(path.Base(p), ".json")] = true
+	}
+	if !fullSync {
+		// Experiments that have just stopped are read too, for their
+		// final checkpoints
+		toSync := map[string]bool{}
+		for id := range w.runningIDs {
+			toSync[id] = true
+		}
+		for id := range runningIDs {
+			toSync[id] = true
+		}
+		for id := range toSync {
+			if err := repo.SyncCacheFile("metadata/experiments/" + id + ".json"); err != nil {
+				return err
+			}
+		}
+	}
+	w.runningIDs = runningIDs
+	return nil
+}
+
+// draw clears the terminal and renders the dashboard in one write, to
+// avoid flickering
+func (w *watcher) draw(out io.Writer, width int) {
+	fmt.Fprint(out, "\x1b[H\x1b[2J"+w.render(width, time.Now()))
+}
+
+func (w *watcher) render(width int, now time.Time) string {
+	var sb strings.Builder
+	header := fmt.Sprintf("Every %s, last refreshed at %s (took %s)", w.interval, w.refreshed.Format("15:04:05"), w.refreshDuration.Round(time.Millisecond))
+	sb.WriteString(truncate(header, width) + "\n\n")
+
+	if len(w.rows) == 0 {
+		sb.WriteString("No experiments found\n")
+	} else {
+		for _, line := range w.renderTable(width, now) {
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	if w.refreshErr != nil {
+		sb.WriteString("\n" + w.au.Red(truncate("Failed to refresh: "+w.refreshErr.Error(), width)).String() + "\n")
+	}
+	return sb.String()
+}
+
+// cell is a table cell that might contain color codes, so its width is
+// measured from plain
+type cell struct {
+	plain   string
+	colored string
+}
+
+func plainCell(s string) cell {
+	return cell{plain: s, colored: s}
+}
+
+func colorCell(s string, color func(interface{}) aurora.Value) cell {
+	return cell{plain: s, colored: color(s).String()}
+}
+
+func (c cell) append(other cell) cell {
+	return cell{plain: c.plain + other.plain, colored: c.colored + other.colored}
+}
+
+type watchColumn struct {
+	heading string
+	cells   []cell
+	// truncatable columns are shortened to fit the terminal, instead of
+	// being hidden. They must not contain color codes.
+	truncatable bool
+}
+
+func (w *watcher) renderTable(width int, now time.Time) []string {
+	listExperiments := make([]*ListExperiment, len(w.rows))
+	for i, row := range w.rows {
+		listExperiments[i] = row.ListExperiment
+	}
+	paramsToDisplay := getParamsToDisplay(listExperiments, w.all)
+	metricsToDisplay := getMetricsToDisplay(listExperiments, w.all)
+
+	displayHost := false
+	displayUser := false
+	for _, row := range w.rows {
+		if row.Host != w.rows[0].Host {
+			displayHost = true
+		}
+		if row.User != w.rows[0].User {
+			displayUser = true
+		}
+	}
+
+	highlight := func(t time.Time) bool {
+		return !t.IsZero() && now.Sub(t) < highlightDuration
+	}
+	bold := func(color func(interface{}) aurora.Value) func(interface{}) aurora.Value {
+		return func(s interface{}) aurora.Value { return w.au.Bold(color(s)) }
+	}
+
+	columns := []*watchColumn{
+		{heading: "EXPERIMENT"},
+		{heading: "STARTED"},
+		{heading: "STATUS"},
+	}
+	hostColumn := &watchColumn{heading: "HOST"}
+	userColumn := &watchColumn{heading: "USER"}
+	if displayHost {
+		columns = append(columns, hostColumn)
+	}
+	if displayUser {
+		columns = append(columns, userColumn)
+	}
+	stepColumn := &watchColumn{heading: "STEP"}
+	rateColumn := &watchColumn{heading: "RATE"}
+	checkpointColumn := &watchColumn{heading: "LATEST CHECKPOINT"}
+	metricsColumn := &watchColumn{heading: "METRICS"}
+	paramsColumn := &watchColumn{heading: "PARAMS", truncatable: true}
+	columns = append(columns, stepColumn, rateColumn, checkpointColumn, metricsColumn, paramsColumn)
+
+	for _, row := range w.rows {
+		id := plainCell(row.ID[:7])
+		if highlight(row.state.added) {
+			id = colorCell(row.ID[:7], bold(w.au.Green))
+		}
+		columns[0].cells = append(columns[0].cells, id)
+		columns[1].cells = append(columns[1].cells, plainCell(console.FormatTime(row.Created)))
+
+		status := row.Status
+		if highlight(row.state.statusChanged) {
+			columns[2].cells = append(columns[2].cells, colorCell(status, bold(w.au.Yellow)))
+		} else {
+			columns[2].cells = append(columns[2].cells, plainCell(status))
+		}
+		hostColumn.cells = append(hostColumn.cells, plainCell(row.Host))
+		userColumn.cells = append(userColumn.cells, plainCell(row.User))
+
+		latest, previous := latestCheckpoints(row.experiment)
+		if latest == nil {
+			stepColumn.cells = append(stepColumn.cells, plainCell(""))
+			rateColumn.cells = append(rateColumn.cells, plainCell(""))
+			checkpointColumn.cells = append(checkpointColumn.cells, plainCell(""))
+			metricsColumn.cells = append(metricsColumn.cells, plainCell(""))
+		} else {
+			step := strconv.FormatInt(latest.Step, 10)
+			if highlight(row.state.checkpointChanged) {
+				stepColumn.cells = append(stepColumn.cells, colorCell(step, bold(w.au.Green)))
+				checkpointColumn.cells = append(checkpointColumn.cells, colorCell(latest.ShortID(), bold(w.au.Green)))
+			} else {
+				stepColumn.cells = append(stepColumn.cells, plainCell(step))
+				checkpointColumn.cells = append(checkpointColumn.cells, plainCell(latest.ShortID()))
+			}
+			rateColumn.cells = append(rateColumn.cells, plainCell(formatStepRate(latest, previous)))
+			metricsColumn.cells = append(metricsColumn.cells, w.metricsCell(latest, previous, metricsToDisplay))
+		}
+
+		params := []string{}
+		for _, key := range paramsToDisplay {
+			if val, ok := row.Params[key]; ok {
+				params = append(params, key+"="+val.ShortString(valueMaxLength, valueTruncate))
+			}
+		}
+		paramsColumn.cells = append(paramsColumn.cells, plainCell(strings.Join(params, " ")))
+	}
+
+	return layoutColumns(columns, width)
+}
+
+// metricsCell displays the metrics of the latest checkpoint, with how much
+// they have changed since the previous checkpoint
+func (w *watcher) metricsCell(latest, previous *project.Checkpoint, metricsToDisplay []string) cell {
+	c := plainCell("")
+	for _, key := range metricsToDisplay {
+		val, ok := latest.Metrics[key]
+		if !ok {
+			continue
+		}
+		if c.plain != "" {
+			c = c.append(plainCell(" "))
+		}
+		c = c.append(plainCell(key + "=" + val.ShortString(valueMaxLength, valueTruncate)))
+		if previous == nil {
+			continue
+		}
+		prevVal, ok := previous.Metrics[key]
+		if !ok {
+			continue
+		}
+		delta, ok := metricDelta(val, prevVal)
+		if !ok || delta == 0 {
+			continue
+		}
+		deltaCell := plainCell(" (" + strconv.FormatFloat(delta, 'g', 3, 64) + ")")
+		if delta > 0 {
+			deltaCell = plainCell(" (+" + strconv.FormatFloat(delta, 'g', 3, 64) + ")")
+		}
+		if latest.PrimaryMetric != nil && latest.PrimaryMetric.Name == key {
+			improved := delta < 0
+			if latest.PrimaryMetric.Goal == project.GoalMaximize {
+				improved = delta > 0
+			}
+			if improved {
+				deltaCell = colorCell(deltaCell.plain, w.au.Green)
+			} else {
+				deltaCell = colorCell(deltaCell.plain, w.au.Red)
+			}
+		}
+		c = c.append(deltaCell)
+	}
+	return c
+}
+
+// latestCheckpoints returns the last two checkpoints of an experiment, or
+// nil if they don't exist
+func latestCheckpoints(exp *project.Experiment) (latest, previous *project.Checkpoint) {
+	checkpoints := make([]*project.Checkpoint, len(exp.Checkpoints))
+	copy(checkpoints, exp.Checkpoints)
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Created.Before(checkpoints[j].Created)
+	})
+	if len(checkpoints) > 0 {
+		latest = checkpoints[len(checkpoints)-1]
+	}
+	if len(checkpoints) > 1 {
+		previous = checkpoints[len(checkpoints)-2]
+	}
+	return latest, previous
+}
+
+func formatStepRate(latest, previous *project.Checkpoint) string {
+	if previous == nil {
+		return ""
+	}
+	seconds := latest.Created.Sub(previous.Created).Seconds()
+	if seconds <= 0 {
+		return ""
+	}
+	rate := float64(latest.Step-previous.Step) / seconds
+	if rate >= 1 {
+		return strconv.FormatFloat(rate, 'f', 1, 64) + " steps/s"
+	}
+	return strconv.FormatFloat(rate*60, 'f', 1, 64) + " steps/min"
+}
+
+func metricDelta(val, prevVal param.Value) (float64, bool) {
+	v, ok := val.NumericVal()
+	if !ok {
+		return 0, false
+	}
+	prev, ok := prevVal.NumericVal()
+	if !ok {
+		return 0, false
+	}
+	return v - prev, true
+}
+
+// layoutColumns pads columns to line up, hiding empty columns and columns on
+// the right that don't fit in width. A width of 0 means unlimited.
+func layoutColumns(columns []*watchColumn, width int) []string {
+	const padding = 2
+
+	widths := []int{}
+	total := 0
+	visible := []*watchColumn{}
+	for i, col := range columns {
+		colWidth := 0
+		for _, c := range col.cells {
+			if n := utf8.RuneCountInString(c.plain); n > colWidth {
+				colWidth = n
+			}
+		}
+		// Hide empty columns
+		if colWidth == 0 {
+			continue
+		}
+		if n := utf8.RuneCountInString(col.heading); n > colWidth {
+			colWidth = n
+		}
+		if width > 0 && i > 0 && total+colWidth > width {
+			remaining := width - total
+			if col.truncatable && remaining >= utf8.RuneCountInString(col.heading) {
+				visible = append(visible, col)
+				widths = append(widths, remaining)
+			}
+			break
+		}
+		visible = append(visible, col)
+		widths = append(widths, colWidth)
+		total += colWidth + padding
+	}
+
+	numRows := len(columns[0].cells)
+	lines := []string{}
+	for row := -1; row < numRows; row++ {
+		var sb strings.Builder
+		for i, col := range visible {
+			c := plainCell(col.heading)
+			if row >= 0 {
+				c = col.cells[row]
+			}
+			if col.truncatable {
+				c = plainCell(truncate(c.plain, widths[i]))
+			}
+			sb.WriteString(c.colored)
+			if i < len(visible)-1 {
+				sb.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c.plain)+padding))
+			}
+		}
+		lines = append(lines, strings.TrimRight(sb.String(), " "))
+	}
+	return lines
+}
+
+// truncate shortens s to width characters, if width is set
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+func terminalWidth() int {
+	width, err := console.GetWidth()
+	if err != nil {
+		return 0
+	}
+	return int(width)
+}
@@ -0,0 +1,142 @@
+package list
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestWatch(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	conf := &config.Config{}
+	repo := createTestData(t, workingDir, conf)
+
+	filters := new(param.Filters)
+	filters.SetExclusive("status", param.OperatorEqual, param.String("running"))
+	au := aurora.NewAurora(true)
+	w := newWatcher(repo, false, filters, param.NewSorter("created"), 5*time.Second, au)
+
+	now := time.Now()
+	require.NoError(t, w.refresh(now))
+	out := w.render(0, now)
+	require.Contains(t, out, "Every 5s, last refreshed at")
+	lines := strings.Split(out, "\n")
+	require.Regexp(t, `^EXPERIMENT +STARTED +STATUS +STEP +RATE +LATEST CHECKPOINT +METRICS$`, lines[2])
+	require.Regexp(t, `^1eeeeee +.* +running +20 +0\.0 steps/min +3cccccc +metric-1=0\.02`, lines[3])
+	require.Len(t, lines, 5)
+	// Nothing is highlighted on the first refresh, except the change in the primary metric
+	require.Contains(t, out, au.Red(" (+0.01)").String())
+	require.NotContains(t, out, au.Bold(au.Green("20")).String())
+
+	// New checkpoint
+	exp, err := w.proj.ExperimentByID("1eeeeeeeee")
+	require.NoError(t, err)
+	exp.Checkpoints = append(exp.Checkpoints, &project.Checkpoint{
+		ID:      "5ccccccccc",
+		Created: time.Now().UTC().Add(time.Minute),
+		Metrics: param.ValueMap{
+			"metric-1": param.Float(0.005),
+			"metric-2": param.Int(2),
+		},
+		PrimaryMetric: &project.PrimaryMetric{
+			Name: "metric-1",
+			Goal: project.GoalMinimize,
+		},
+		Step: 30,
+	})
+	require.NoError(t, exp.Save(repo))
+	now = now.Add(5 * time.Second)
+	require.NoError(t, w.refresh(now))
+	out = w.render(0, now)
+	require.Contains(t, out, au.Bold(au.Green("30")).String())
+	require.Contains(t, out, au.Bold(au.Green("5cccccc")).String())
+	require.Contains(t, out, au.Green(" (-0.015)").String())
+
+	// Stopped experiments stay visible for a bit, with their status highlighted
+	require.NoError(t, project.DeleteHeartbeat(repo, "1eeeeeeeee"))
+	now = now.Add(5 * time.Second)
+	require.NoError(t, w.refresh(now))
+	out = w.render(0, now)
+	require.Contains(t, out, au.Bold(au.Yellow("stopped")).String())
+
+	now = now.Add(highlightDuration)
+	require.NoError(t, w.refresh(now))
+	out = w.render(0, now)
+	require.Contains(t, out, "No experiments found")
+}
+
+func TestWatchRemote(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	conf := &config.Config{}
+	remoteRepo := createTestData(t, workingDir, conf)
+	repo, err := repository.NewCachedRepository(remoteRepo, "metadata", workingDir, filepath.Join(workingDir, "cache"))
+	require.NoError(t, err)
+
+	w := newWatcher(repo, false, new(param.Filters), param.NewSorter("created"), 5*time.Second, aurora.NewAurora(false))
+	now := time.Now()
+	require.NoError(t, w.refresh(now))
+
+	// A new checkpoint in a running experiment is fetched straight away...
+	exp, err := project.NewProject(remoteRepo, "").ExperimentByID("1eeeeeeeee")
+	require.NoError(t, err)
+	exp.Checkpoints = append(exp.Checkpoints, &project.Checkpoint{
+		ID:      "5ccccccccc",
+		Created: time.Now().UTC().Add(time.Minute),
+		Step:    30,
+	})
+	require.NoError(t, exp.Save(remoteRepo))
+	// ...but experiments that aren't running are only fetched when
+	// everything is listed
+	require.NoError(t, (&project.Experiment{ID: "9eeeeeeeee", Created: time.Now().UTC(), Config: conf}).Save(remoteRepo))
+
+	now = now.Add(5 * time.Second)
+	require.NoError(t, w.refresh(now))
+	out := w.render(0, now)
+	require.Contains(t, out, "5cccccc")
+	require.NotContains(t, out, "9eeeeee")
+
+	now = now.Add(fullSyncInterval)
+	require.NoError(t, w.refresh(now))
+	out = w.render(0, now)
+	require.Contains(t, out, "9eeeeee")
+}
+
+func TestLayoutColumns(t *testing.T) {
+	columns := []*watchColumn{
+		{heading: "ID", cells: []cell{plainCell("abc"), colorCell("def", aurora.NewAurora(true).Red)}},
+		{heading: "STEP", cells: []cell{plainCell("10"), plainCell("200")}},
+		{heading: "PARAMS", cells: []cell{plainCell("learning_rate=0.01"), plainCell("")}, truncatable: true},
+	}
+	require.Equal(t, []string{
+		"ID   STEP  PARAMS",
+		"abc  10    learning_rate=0.01",
+		"\x1b[31mdef\x1b[0m  200",
+	}, layoutColumns(columns, 0))
+	require.Equal(t, []string{
+		"ID   STEP  PARAMS",
+		"abc  10    learnin…",
+		"\x1b[31mdef\x1b[0m  200",
+	}, layoutColumns(columns, 19))
+	require.Equal(t, []string{
+		"ID   STEP",
+		"abc  10",
+		"\x1b[31mdef\x1b[0m  200",
+	}, layoutColumns(columns, 12))
+}
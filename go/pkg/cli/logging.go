@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/global"
+)
+
+// setUpLogging sets the format and destination of log messages from the
+// --log-* flags
+func setUpLogging() error {
+	format, err := console.ParseFormat(global.LogFormat)
+	if err != nil {
+		return err
+	}
+	console.SetFormat(format)
+	if global.LogFile == "" {
+		return nil
+	}
+	file, err := console.OpenRotatingFile(global.LogFile, int64(global.LogMaxSize)*1024*1024, global.LogMaxBackups)
+	if err != nil {
+		return err
+	}
+	console.SetWriter(file)
+	// colors are escape codes in files
+	console.SetColor(false)
+	return nil
+}
+
+func envOrDefault(name string, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+type migrateOpts struct {
+	dryRun        bool
+	repositoryURL string
+}
+
+func newMigrateCommand() *cobra.Command {
+	var opts migrateOpts
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the repository to the latest version of the repository format",
+		Long: `Upgrade the repository to the latest version of the repository format.
+
+Once a repository has been migrated, older versions of Keepsake can't use it, so upgrade Keepsake everywhere the repository is used first. Experiments can't be created while the repository is being migrated.
+
+If the migration is interrupted, run "keepsake migrate" again to resume it.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return migrateRepository(opts)
+		}),
+		Args: cobra.NoArgs,
+		Example: `See what would be changed, without changing anything:
+$ keepsake migrate --dry-run`,
+	}
+
+	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "n", false, "Show what would be changed, without changing anything")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+
+	return cmd
+}
+
+func migrateRepository(opts migrateOpts) error {
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+	proj := project.NewProject(repo, projectDir)
+	return proj.Migrate(opts.dryRun, os.Stdout)
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/cli/list"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/plot"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+const (
+	xAxisStep = "step"
+	xAxisTime = "time"
+)
+
+type plotOpts struct {
+	metrics       []string
+	xAxis         string
+	logScale      bool
+	smoothing     float64
+	width         int
+	height        int
+	ascii         bool
+	output        string
+	repositoryURL string
+}
+
+func newPlotCommand() *cobra.Command {
+	var opts plotOpts
+
+	cmd := &cobra.Command{
+		Use:   "plot [experiment ID...]",
+		Short: "Plot metrics from experiments",
+		Long: `Plot metrics from experiments as a line chart, with a line for each experiment and metric.
+
+Experiments can be selected by passing ID prefixes, with --filter, or both. If neither are passed, every experiment in the project is plotted. If --metric is not passed, the primary metric from keepsake.yaml is plotted.
+
+The chart is drawn in the terminal, unless --output is passed with a file name ending in .svg or .png.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			filters, err := parseListFilterFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return plotMetrics(opts, args, filters, os.Stdout)
+		}),
+		Example: `Plot the primary metric of two experiments:
+$ keepsake plot 1eeeeee 2eeeeee
+
+Plot the loss of every experiment that used the "adam" optimizer, on a log scale:
+$ keepsake plot --metric loss --log --filter "optimizer = adam"
+
+Save a chart of accuracy over time as an image:
+$ keepsake plot --metric accuracy --x-axis time --output accuracy.png
+`,
+	}
+
+	cmd.Flags().StringArrayVarP(&opts.metrics, "metric", "m", []string{}, "Metric to plot. Can be passed multiple times (default: the primary metric)")
+	cmd.Flags().StringVarP(&opts.xAxis, "x-axis", "x", xAxisStep, "Value for the x axis: \"step\", or \"time\" for seconds since the experiment started")
+	cmd.Flags().BoolVar(&opts.logScale, "log", false, "Use a log scale for the y axis")
+	cmd.Flags().Float64Var(&opts.smoothing, "smoothing", 0, "Amount of smoothing to apply to each line, between 0 (none) and 1")
+	cmd.Flags().IntVar(&opts.width, "width", 0, "Width of the chart, in characters, or pixels for image output (default: width of the terminal)")
+	cmd.Flags().IntVar(&opts.height, "height", 0, "Height of the chart, in characters, or pixels for image output")
+	cmd.Flags().BoolVar(&opts.ascii, "ascii", false, "Draw the chart with ASCII characters instead of Unicode")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "", "Save the chart to an image file instead of printing it. Must end with .svg or .png")
+	addListFilterFlag(cmd)
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+
+	return cmd
+}
+
+func plotMetrics(opts plotOpts, prefixes []string, filters *param.Filters, out io.Writer) error {
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+	proj := project.NewProject(repo, projectDir)
+
+	metrics, series, err := loadMetricSeries(proj, prefixes, filters, opts.metrics, opts.xAxis)
+	if err != nil {
+		return err
+	}
+
+	plotOptions := plot.Options{
+		Title:     strings.Join(metrics, ", "),
+		XLabel:    opts.xAxis,
+		LogScale:  opts.logScale,
+		Smoothing: opts.smoothing,
+		Width:     opts.width,
+		Height:    opts.height,
+		Color:     colorEnabled(),
+		ASCII:     opts.ascii,
+	}
+	if opts.xAxis == xAxisTime {
+		plotOptions.XLabel = "seconds since start"
+	}
+
+	if opts.output != "" {
+		if err := plot.WriteFile(opts.output, series, plotOptions); err != nil {
+			return err
+		}
+		console.Info("Saved chart to %s", opts.output)
+		return nil
+	}
+
+	if plotOptions.Width == 0 {
+		width, err := console.GetWidth()
+		if err != nil {
+			return err
+		}
+		plotOptions.Width = int(width)
+	}
+	return plot.WriteTerminal(out, series, plotOptions)
+}
+
+// loadMetricSeries returns the series to plot for the experiments selected by
+// prefixes and filters. If metrics is empty, the primary metrics are used.
+func loadMetricSeries(proj *project.Project, prefixes []string, filters *param.Filters, metrics []string, xAxis string) ([]string, []*plot.Series, error) {
+	if xAxis != xAxisStep && xAxis != xAxisTime {
+		return nil, nil, fmt.Errorf("Unknown x axis %q, it must be either %q or %q", xAxis, xAxisStep, xAxisTime)
+	}
+	experiments, err := selectExperiments(proj, prefixes, filters)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(experiments) == 0 {
+		return nil, nil, fmt.Errorf("No experiments found")
+	}
+
+	if len(metrics) == 0 {
+		metrics = primaryMetricNames(experiments)
+		if len(metrics) == 0 {
+			return nil, nil, fmt.Errorf("No primary metric is defined in keepsake.yaml, so the metric to plot must be passed")
+		}
+	}
+
+	series := metricSeries(experiments, metrics, xAxis)
+	if len(series) == 0 {
+		return nil, nil, fmt.Errorf("None of the experiments have numeric values for %s", strings.Join(metrics, ", "))
+	}
+	return metrics, series, nil
+}
+
+// selectExperiments returns the experiments that match filters, and
+// prefixes if any are passed
+func selectExperiments(proj *project.Project, prefixes []string, filters *param.Filters) ([]*project.Experiment, error) {
+	experiments, err := list.FilterExperiments(proj, filters)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixes) == 0 {
+		return experiments, nil
+	}
+	selected := map[string]bool{}
+	for _, prefix := range prefixes {
+		exp, err := proj.ExperimentFromPrefix(prefix)
+		if err != nil {
+			return nil, err
+		}
+		selected[exp.ID] = true
+	}
+	ret := []*project.Experiment{}
+	for _, exp := range experiments {
+		if selected[exp.ID] {
+			ret = append(ret, exp)
+		}
+	}
+	return ret, nil
+}
+
+func primaryMetricNames(experiments []*project.Experiment) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, exp := range experiments {
+		for _, chk := range exp.Checkpoints {
+			if chk.PrimaryMetric != nil && !seen[chk.PrimaryMetric.Name] {
+				seen[chk.PrimaryMetric.Name] = true
+				names = append(names, chk.PrimaryMetric.Name)
+			}
+		}
+	}
+	return names
+}
+
+// metricSeries returns a series for each experiment and metric that has
+// numeric values. Names only include the parts needed to tell them apart.
+func metricSeries(experiments []*project.Experiment, metrics []string, xAxis string) []*plot.Series {
+	ret := []*plot.Series{}
+	for _, exp := range experiments {
+		checkpoints := make([]*project.Checkpoint, len(exp.Checkpoints))
+		copy(checkpoints, exp.Checkpoints)
+		sort.SliceStable(checkpoints, func(i, j int) bool {
+			if xAxis == xAxisTime || checkpoints[i].Step == checkpoints[j].Step {
+				return checkpoints[i].Created.Before(checkpoints[j].Created)
+			}
+			return checkpoints[i].Step < checkpoints[j].Step
+		})
+
+		for _, metric := range metrics {
+			s := &plot.Series{}
+			switch {
+			case len(experiments) == 1:
+				s.Name = metric
+			case len(metrics) == 1:
+				s.Name = exp.ShortID()
+			default:
+				s.Name = exp.ShortID() + " " + metric
+			}
+			for _, chk := range checkpoints {
+				value, ok := chk.Metrics[metric]
+				if !ok {
+					continue
+				}
+				y, ok := value.NumericVal()
+				if !ok {
+					continue
+				}
+				x := float64(chk.Step)
+				if xAxis == xAxisTime {
+					x = chk.Created.Sub(exp.Created).Seconds()
+				}
+				s.Points = append(s.Points, plot.Point{X: x, Y: y})
+			}
+			if len(s.Points) > 0 {
+				ret = append(ret, s)
+			}
+		}
+	}
+	return ret
+}
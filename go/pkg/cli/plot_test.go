@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/plot"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+func TestMetricSeries(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	repo := createShowTestData(t, workingDir, &config.Config{})
+	proj := project.NewProject(repo, workingDir)
+	experiments, err := selectExperiments(proj, []string{"1e"}, new(param.Filters))
+	require.NoError(t, err)
+	require.Len(t, experiments, 1)
+
+	// Checkpoints with the same step are ordered by when they were created
+	series := metricSeries(experiments, []string{"metric-1", "metric-3"}, xAxisStep)
+	require.Equal(t, []*plot.Series{{
+		Name:   "metric-1",
+		Points: []plot.Point{{X: 10, Y: 0.1}, {X: 20, Y: 0.01}, {X: 20, Y: 0.02}},
+	}}, series)
+
+	series = metricSeries(experiments, []string{"metric-2"}, xAxisTime)
+	require.Equal(t, []*plot.Series{{
+		Name:   "metric-2",
+		Points: []plot.Point{{X: 300, Y: 2}, {X: 360, Y: 2}, {X: 420, Y: 2}},
+	}}, series)
+
+	experiments, err = selectExperiments(proj, []string{}, new(param.Filters))
+	require.NoError(t, err)
+	require.Len(t, experiments, 2)
+	require.Equal(t, []string{"metric-1"}, primaryMetricNames(experiments))
+
+	series = metricSeries(experiments, []string{"metric-1", "metric-3"}, xAxisStep)
+	require.Len(t, series, 2)
+	require.Equal(t, "1eeeeee metric-1", series[0].Name)
+	require.Equal(t, "2eeeeee metric-3", series[1].Name)
+}
+
+func TestPlotFilter(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	repo := createShowTestData(t, workingDir, &config.Config{})
+	proj := project.NewProject(repo, workingDir)
+	filters, err := param.MakeFilters([]string{"param-1 = 200"})
+	require.NoError(t, err)
+
+	experiments, err := selectExperiments(proj, []string{}, filters)
+	require.NoError(t, err)
+	require.Len(t, experiments, 1)
+	require.Equal(t, "2eeeeeeeee", experiments[0].ID)
+
+	experiments, err = selectExperiments(proj, []string{"1e"}, filters)
+	require.NoError(t, err)
+	require.Len(t, experiments, 0)
+}
+
+func TestPlotMetrics(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	createShowTestData(t, workingDir, &config.Config{})
+	opts := plotOpts{
+		xAxis:         xAxisStep,
+		width:         60,
+		height:        10,
+		ascii:         true,
+		repositoryURL: "file://" + path.Join(workingDir, ".keepsake"),
+	}
+
+	out := new(bytes.Buffer)
+	require.NoError(t, plotMetrics(opts, []string{"1e"}, new(param.Filters), out))
+	require.Contains(t, out.String(), "metric-1")
+
+	opts.metrics = []string{"param-1"}
+	err = plotMetrics(opts, []string{}, new(param.Filters), out)
+	require.EqualError(t, err, "None of the experiments have numeric values for param-1")
+
+	opts.metrics = []string{}
+	opts.width, opts.height = 0, 0
+	opts.output = path.Join(workingDir, "chart.svg")
+	require.NoError(t, plotMetrics(opts, []string{}, new(param.Filters), out))
+	contents, err := ioutil.ReadFile(opts.output)
+	require.NoError(t, err)
+	require.Contains(t, string(contents), "<polyline")
+}
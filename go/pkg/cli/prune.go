@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+type pruneOpts struct {
+	dryRun        bool
+	force         bool
+	keepLast      int
+	keepBest      int
+	keepEvery     int64
+	keepTagged    bool
+	repositoryURL string
+}
+
+func newPruneCommand() *cobra.Command {
+	var opts pruneOpts
+
+	cmd := &cobra.Command{
+		Use:   "prune [experiment ID...]",
+		Short: "Delete the files of checkpoints that the retention policy doesn't keep",
+		Long: `Delete the files of checkpoints that the retention policy doesn't keep. The checkpoints' metrics and other metadata are kept, so they still show up in "keepsake ls", "keepsake show", and so on.
+
+The retention policy is set with "retention" in keepsake.yaml, and is applied automatically as experiments run. This command applies it to checkpoints that already exist, for example after the policy has been added or changed. The policy can also be passed with the --keep-* flags, which replace the policy in keepsake.yaml.
+
+If experiment IDs are passed, only those experiments are pruned. Otherwise, every experiment in the project is pruned.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return pruneCheckpoints(opts, args, os.Stdout)
+		}),
+		Example: `See which checkpoints would be pruned, and how much space would be reclaimed:
+$ keepsake prune --dry-run
+
+Keep only the 3 most recent and 2 best checkpoints of an experiment:
+$ keepsake prune 1eeeeee --keep-last 3 --keep-best 2
+`,
+	}
+
+	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "n", false, "Show what would be deleted, without deleting anything")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Delete without interactive prompt")
+	cmd.Flags().IntVar(&opts.keepLast, "keep-last", 0, "Keep the files of this many of the most recent checkpoints")
+	cmd.Flags().IntVar(&opts.keepBest, "keep-best", 0, "Keep the files of this many of the best checkpoints, by primary metric")
+	cmd.Flags().Int64Var(&opts.keepEvery, "keep-every", 0, "Keep the files of checkpoints where the step is a multiple of this number")
+	cmd.Flags().BoolVar(&opts.keepTagged, "keep-tagged", false, "Keep the files of checkpoints that have tags")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+
+	return cmd
+}
+
+// experimentToPrune is an experiment and the checkpoints in it to prune
+type experimentToPrune struct {
+	experiment  *project.Experiment
+	checkpoints []*project.Checkpoint
+}
+
+func pruneCheckpoints(opts pruneOpts, prefixes []string, out io.Writer) error {
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+	proj := project.NewProject(repo, projectDir)
+
+	policy, err := prunePolicy(opts, projectDir)
+	if err != nil {
+		return err
+	}
+
+	experiments, err := selectPruneExperiments(proj, prefixes)
+	if err != nil {
+		return err
+	}
+	toPrune := []*experimentToPrune{}
+	numCheckpoints := 0
+	for _, exp := range experiments {
+		if checkpoints := exp.CheckpointsToPrune(policy); len(checkpoints) > 0 {
+			toPrune = append(toPrune, &experimentToPrune{experiment: exp, checkpoints: checkpoints})
+			numCheckpoints += len(checkpoints)
+		}
+	}
+	if numCheckpoints == 0 {
+		console.Info("No checkpoints to prune")
+		return nil
+	}
+
+	total, err := writePruneReport(out, toPrune, newTarballSizes(repo))
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		fmt.Fprintf(out, "\nPruning would delete the files of %d checkpoints, reclaiming %s.\n", numCheckpoints, console.FormatBytes(total))
+		return nil
+	}
+	if err := proj.CheckCanDelete(); err != nil {
+		return err
+	}
+
+	if !opts.force {
+		continuePrune, err := console.InteractiveBool{
+			Prompt:         fmt.Sprintf("\nDo you want to delete the files of these %d checkpoints?", numCheckpoints),
+			Default:        false,
+			NonDefaultFlag: "-f",
+		}.Read()
+		if err != nil {
+			return err
+		}
+		if !continuePrune {
+			return fmt.Errorf("Aborting.")
+		}
+	}
+
+	// Mark the checkpoints as pruned before deleting their files, so the
+	// metadata never points at files that don't exist
+	for _, p := range toPrune {
+		for _, chk := range p.checkpoints {
+			chk.Pruned = true
+		}
+		if _, err := proj.SaveExperiment(p.experiment, true); err != nil {
+			return err
+		}
+		for _, chk := range p.checkpoints {
+			if err := proj.DeleteCheckpoint(chk); err != nil {
+				return err
+			}
+		}
+	}
+	console.Info("Deleted the files of %d checkpoints, reclaiming %s", numCheckpoints, console.FormatBytes(total))
+	return nil
+}
+
+// prunePolicy returns the policy from the --keep-* flags if any are passed,
+// otherwise the policy in keepsake.yaml
+func prunePolicy(opts pruneOpts, projectDir string) (*config.RetentionPolicy, error) {
+	policy := &config.RetentionPolicy{
+		KeepLast:   opts.keepLast,
+		KeepBest:   opts.keepBest,
+		KeepEvery:  opts.keepEvery,
+		KeepTagged: opts.keepTagged,
+	}
+	if *policy != (config.RetentionPolicy{}) {
+		return policy, policy.Validate()
+	}
+	policy, err := getRetentionPolicy(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, fmt.Errorf("No retention policy found. Add \"retention\" to keepsake.yaml, or pass the policy with the --keep-* flags. Run \"keepsake prune --help\" for more information.")
+	}
+	return policy, nil
+}
+
+func selectPruneExperiments(proj *project.Project, prefixes []string) ([]*project.Experiment, error) {
+	if len(prefixes) == 0 {
+		experiments, err := proj.Experiments()
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(experiments, func(i, j int) bool {
+			return experiments[i].Created.Before(experiments[j].Created)
+		})
+		return experiments, nil
+	}
+	experiments := []*project.Experiment{}
+	for _, prefix := range prefixes {
+		exp, err := proj.ExperimentFromPrefix(prefix)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, exp)
+	}
+	return experiments, nil
+}
+
+// writePruneReport writes a table of the checkpoints to prune, and returns
+// the total size of their files
+func writePruneReport(out io.Writer, toPrune []*experimentToPrune, sizes *tarballSizes) (int64, error) {
+	total := int64(0)
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "EXPERIMENT\tCHECKPOINT\tSTEP\tSIZE")
+	for _, p := range toPrune {
+		for _, chk := range p.checkpoints {
+			size, ok, err := sizes.get(chk.StorageTarPath(), chk.Size)
+			if err != nil {
+				return 0, err
+			}
+			sizeString := "missing"
+			if ok {
+				sizeString = console.FormatBytes(size)
+				total += size
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.experiment.ShortID(), chk.ShortID(), strconv.FormatInt(chk.Step, 10), sizeString)
+		}
+	}
+	return total, tw.Flush()
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestPrune(t *testing.T) {
+	repoDir, err := files.TempDir("test-prune")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	codeDir, err := files.TempDir("test-prune-code")
+	require.NoError(t, err)
+	defer os.RemoveAll(codeDir)
+	require.NoError(t, ioutil.WriteFile(path.Join(codeDir, "model.pth"), []byte("weights"), 0644))
+
+	created := time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)
+	exp := &project.Experiment{ID: "1eeeeeeeee", Created: created, Config: &config.Config{}}
+	for i, id := range []string{"1ccccccccc", "2ccccccccc", "3ccccccccc"} {
+		exp.Checkpoints = append(exp.Checkpoints, &project.Checkpoint{
+			ID:      id,
+			Created: created.Add(time.Duration(i) * time.Minute),
+			Step:    int64(i),
+			Path:    "model.pth",
+		})
+		_, err = repo.PutPathTar(codeDir, "checkpoints/"+id+".tar.gz", "model.pth")
+		require.NoError(t, err)
+	}
+	require.NoError(t, exp.Save(repo))
+
+	opts := pruneOpts{keepLast: 1, repositoryURL: "file://" + repoDir}
+
+	// dry run doesn't delete anything
+	opts.dryRun = true
+	out := new(bytes.Buffer)
+	require.NoError(t, pruneCheckpoints(opts, nil, out))
+	require.Contains(t, out.String(), "1cccccc")
+	require.Contains(t, out.String(), "2cccccc")
+	require.NotContains(t, out.String(), "3cccccc")
+	require.Contains(t, out.String(), "Pruning would delete the files of 2 checkpoints")
+	for _, id := range []string{"1ccccccccc", "2ccccccccc", "3ccccccccc"} {
+		require.FileExists(t, path.Join(repoDir, "checkpoints", id+".tar.gz"))
+	}
+
+	opts.dryRun = false
+	opts.force = true
+	require.NoError(t, pruneCheckpoints(opts, []string{"1eee"}, new(bytes.Buffer)))
+	require.NoFileExists(t, path.Join(repoDir, "checkpoints", "1ccccccccc.tar.gz"))
+	require.NoFileExists(t, path.Join(repoDir, "checkpoints", "2ccccccccc.tar.gz"))
+	require.FileExists(t, path.Join(repoDir, "checkpoints", "3ccccccccc.tar.gz"))
+
+	proj := project.NewProject(repo, "")
+	saved, err := proj.ExperimentByID("1eeeeeeeee")
+	require.NoError(t, err)
+	require.True(t, saved.Checkpoints[0].Pruned)
+	require.True(t, saved.Checkpoints[1].Pruned)
+	require.False(t, saved.Checkpoints[2].Pruned)
+
+	// pruned checkpoints aren't pruned again
+	require.NoError(t, pruneCheckpoints(opts, nil, new(bytes.Buffer)))
+}
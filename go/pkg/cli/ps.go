@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 
 	"github.com/replicate/keepsake/go/pkg/cli/list"
@@ -20,6 +21,7 @@ func newPsCommand() *cobra.Command {
 	addListFormatFlags(cmd)
 	addListFilterFlag(cmd)
 	addListSortFlag(cmd)
+	addListWatchFlags(cmd)
 
 	return cmd
 }
@@ -41,10 +43,19 @@ func listRunningExperiments(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	filters.SetExclusive("status", param.OperatorEqual, param.String("running"))
+	watch, interval, err := parseListWatchFlags(cmd, format)
+	if err != nil {
+		return err
+	}
+	// experiments whose process has exited are shown too, as dead, until
+	// their heartbeat times out
+	filters.SetExclusive("status", param.OperatorNotEqual, param.String("stopped"))
 	repo, err := getRepository(repositoryURL, projectDir)
 	if err != nil {
 		return err
 	}
-	return list.Experiments(repo, format, allParams, filters, sortKey)
+	if watch {
+		return list.Watch(repo, allParams, filters, sortKey, interval, aurora.NewAurora(colorEnabled()))
+	}
+	return list.Processes(repo, format, allParams, filters, sortKey)
 }
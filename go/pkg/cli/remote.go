@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/global"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func newRemoteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remote",
+		Short: "Manage the named repositories in keepsake.yaml",
+		Long: `Manage the named repositories in keepsake.yaml, which are called remotes.
+
+Pass --remote <name> to any command to use a remote as the repository, or set the KEEPSAKE_REMOTE environment variable. Otherwise, the remote in "default_remote" in keepsake.yaml is used, or "repository" if there isn't a default remote.`,
+	}
+	cmd.AddCommand(newRemoteAddCommand(), newRemoteListCommand(), newRemoteRemoveCommand())
+	return cmd
+}
+
+type remoteAddOpts struct {
+	profile     string
+	endpoint    string
+	cache       string
+	readOnly    bool
+	makeDefault bool
+}
+
+func newRemoteAddCommand() *cobra.Command {
+	var opts remoteAddOpts
+	cmd := &cobra.Command{
+		Use:   "add <name> <repository URL>",
+		Short: "Add a remote to keepsake.yaml",
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return addRemote(opts, args[0], args[1])
+		}),
+		Args: cobra.ExactArgs(2),
+		Example: `Add a read-only remote for the models your team has published:
+$ keepsake remote add team s3://hooli-team-models --profile work --read-only
+
+Add a remote on a MinIO server and use it by default:
+$ keepsake remote add minio s3://models --endpoint http://localhost:9000 --default`,
+	}
+	cmd.Flags().StringVar(&opts.profile, "profile", "", "AWS profile to use for S3 remotes")
+	cmd.Flags().StringVar(&opts.endpoint, "endpoint", "", "URL of an S3-compatible service to use instead of S3")
+	cmd.Flags().StringVar(&opts.cache, "cache", "", "Whether to cache metadata locally: auto, always, or never (default: auto)")
+	cmd.Flags().BoolVar(&opts.readOnly, "read-only", false, "Don't allow anything in the remote to be changed")
+	cmd.Flags().BoolVar(&opts.makeDefault, "default", false, "Use this remote when --remote isn't passed")
+	return cmd
+}
+
+func newRemoteListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "List the remotes in keepsake.yaml",
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			conf, _, err := getConfigPath()
+			if err != nil {
+				return err
+			}
+			return listRemotes(conf, os.Stdout)
+		}),
+		Args: cobra.NoArgs,
+	}
+}
+
+func newRemoteRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Remove a remote from keepsake.yaml",
+		Long: `Remove a remote from keepsake.yaml.
+
+This doesn't change anything in the remote's repository.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			_, configPath, err := getConfigPath()
+			if err != nil {
+				return err
+			}
+			if err := config.RemoveRemote(configPath, args[0]); err != nil {
+				return err
+			}
+			console.Info("Removed remote %s from %s", args[0], configPath)
+			return nil
+		}),
+		Args: cobra.ExactArgs(1),
+	}
+}
+
+// getConfigPath returns the project's keepsake.yaml and the path to it
+func getConfigPath() (*config.Config, string, error) {
+	conf, projectDir, err := config.FindConfigInWorkingDir(global.ProjectDirectory)
+	if err != nil {
+		return nil, "", err
+	}
+	configPath, deprecatedRepositoryProjectRoot, err := config.FindConfigPath(projectDir)
+	if err != nil {
+		return nil, "", err
+	}
+	if deprecatedRepositoryProjectRoot != "" {
+		return nil, "", fmt.Errorf("There isn't a keepsake.yaml in %s. Create one before adding remotes.", deprecatedRepositoryProjectRoot)
+	}
+	return conf, configPath, nil
+}
+
+func addRemote(opts remoteAddOpts, name string, repositoryURL string) error {
+	remote := &config.Remote{
+		Repository: repositoryURL,
+		Profile:    opts.profile,
+		Endpoint:   opts.endpoint,
+		Cache:      config.CachePolicy(opts.cache),
+		ReadOnly:   opts.readOnly,
+	}
+	if err := remote.Validate(name); err != nil {
+		return err
+	}
+	// Check the URL can be used before it is written to keepsake.yaml
+	if _, _, _, err := repository.SplitURL(repositoryURL); err != nil {
+		return err
+	}
+	_, configPath, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := config.AddRemote(configPath, name, remote, opts.makeDefault); err != nil {
+		return err
+	}
+	console.Info("Added remote %s to %s", name, configPath)
+	return nil
+}
+
+func listRemotes(conf *config.Config, out io.Writer) error {
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tREPOSITORY\tOPTIONS")
+	if conf.Repository != "" {
+		fmt.Fprintf(tw, "%s\t%s\t\n", "(repository)", conf.Repository)
+	}
+	names := []string{}
+	for name := range conf.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		remote := conf.Remotes[name]
+		options := []string{}
+		if name == conf.DefaultRemote {
+			options = append(options, "default")
+		}
+		if remote.ReadOnly {
+			options = append(options, "read-only")
+		}
+		if remote.Profile != "" {
+			options = append(options, "profile="+remote.Profile)
+		}
+		if remote.Endpoint != "" {
+			options = append(options, "endpoint="+remote.Endpoint)
+		}
+		if remote.Cache != "" {
+			options = append(options, "cache="+string(remote.Cache))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, remote.Repository, strings.Join(options, ", "))
+	}
+	return tw.Flush()
+}
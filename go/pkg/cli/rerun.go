@@ -0,0 +1,389 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/global"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+type rerunOpts struct {
+	outputDirectory string
+	force           bool
+	params          []string
+	python          string
+	repositoryURL   string
+}
+
+func newRerunCommand() *cobra.Command {
+	var opts rerunOpts
+
+	cmd := &cobra.Command{
+		Use:   "rerun <experiment or checkpoint ID> [-- <extra arguments>]",
+		Short: "Run an experiment again from its recorded command and code",
+		Long: `Check out the files from an experiment or checkpoint into a new directory, then run the command the experiment was started with in that directory.
+
+The first experiment created by the command records the experiment and checkpoint it was rerun from as a parent, which is shown by keepsake lineage. If the Python version or the versions of the Python packages that will be used are different to the ones the experiment was run with, a warning is printed.
+
+Params can be changed with --param, which replaces the value of the matching argument in the command. Any arguments after -- are added to the end of the command.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			prefix, extraArgs, err := splitRerunArgs(args, cmd.ArgsLenAtDash())
+			if err != nil {
+				return err
+			}
+			return rerunExperiment(opts, prefix, extraArgs)
+		}),
+		Args: cobra.MinimumNArgs(1),
+		Example: `Run an experiment again with the files from its best checkpoint:
+$ keepsake rerun 1eeeeee
+
+Run it again with a different learning rate, replacing "--learning-rate 0.01" in the command:
+$ keepsake rerun 1eeeeee --param learning_rate=0.001
+
+Run it again in a particular directory, passing an extra argument to the script:
+$ keepsake rerun 1eeeeee -o ../finetune -- --epochs 5
+`,
+	}
+
+	cmd.Flags().StringVarP(&opts.outputDirectory, "output-directory", "o", "", "Directory to check out files and run the command in (defaults to a new temporary directory)")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Check out files without prompting, even if the output directory is not empty")
+	cmd.Flags().StringArrayVarP(&opts.params, "param", "p", []string{}, "Change a param, in the form name=value. Can be passed multiple times")
+	cmd.Flags().StringVar(&opts.python, "python", "", "Python interpreter to run Python scripts with (defaults to python, or python3 if python is not found)")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+
+	return cmd
+}
+
+// splitRerunArgs splits positional arguments into the ID prefix and any
+// arguments passed after --
+func splitRerunArgs(args []string, dashIndex int) (prefix string, extraArgs []string, err error) {
+	if dashIndex == -1 {
+		dashIndex = len(args)
+	}
+	if dashIndex != 1 {
+		return "", nil, fmt.Errorf("Exactly one experiment or checkpoint ID must be passed before --")
+	}
+	return args[0], args[dashIndex:], nil
+}
+
+func rerunExperiment(opts rerunOpts, prefix string, extraArgs []string) error {
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+	proj := project.NewProject(repo, projectDir)
+
+	experiment, checkpoint, err := getExperimentAndCheckpoint(prefix, proj, projectDir)
+	if err != nil {
+		return err
+	}
+	if experiment.Command == "" {
+		return fmt.Errorf("Experiment %s does not have a command recorded, so it can't be run again", experiment.ShortID())
+	}
+
+	python := ""
+	args, err := rerunCommand(experiment.Command, opts.params, extraArgs)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(args[0], ".py") {
+		python, err = findPython(opts.python)
+		if err != nil {
+			return err
+		}
+		args = append([]string{python}, args...)
+	}
+
+	outputDir := opts.outputDirectory
+	if outputDir == "" {
+		outputDir, err = files.TempDir("rerun-" + experiment.ShortID())
+		if err != nil {
+			return err
+		}
+	} else {
+		if err := validateOrCreateOutputDir(outputDir); err != nil {
+			return err
+		}
+		if err := overwriteDisplayPathPrompt(outputDir, opts.force); err != nil {
+			return err
+		}
+	}
+	outputDir, err = filepath.Abs(outputDir)
+	if err != nil {
+		return err
+	}
+
+	if err := proj.CheckoutCheckpoint(checkpoint, experiment, outputDir, true); err != nil {
+		return err
+	}
+	// The new run uses this repository, in case the checked out
+	// keepsake.yaml has a path relative to the old project
+	rerunRepositoryURL, err := absoluteRepositoryURL(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+	if err := writeRerunConfig(outputDir, experiment, rerunRepositoryURL); err != nil {
+		return err
+	}
+	console.Info("Checked out files to %q", outputDir)
+
+	if python != "" && experiment.PythonVersion != "" {
+		warnAboutPythonEnvironment(python, experiment)
+	}
+
+	console.Info("Running: %s", shellquote.Join(args...))
+	fmt.Fprintln(os.Stderr)
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = outputDir
+	cmd.Env = append(rerunEnviron(), project.RerunExperimentIDEnvVar+"="+experiment.ID, "KEEPSAKE_REPOSITORY="+rerunRepositoryURL)
+	if checkpoint != nil {
+		cmd.Env = append(cmd.Env, project.RerunCheckpointIDEnvVar+"="+checkpoint.ID)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// The command gets interrupts from the terminal too, so let it decide
+	// when to exit
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("The command exited with status %d", exitErr.ExitCode())
+		}
+		return fmt.Errorf("Failed to run %q: %w", args[0], err)
+	}
+	return nil
+}
+
+// rerunCommand parses an experiment's command into arguments, replaces the
+// values of any params passed as name=value, and appends extraArgs
+func rerunCommand(command string, params []string, extraArgs []string) ([]string, error) {
+	args, err := shellquote.Split(command)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the command %q: %w", command, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("The command is empty")
+	}
+	for _, p := range params {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid param %q, it must be in the form name=value", p)
+		}
+		if err := replaceArgValue(args, parts[0], parts[1]); err != nil {
+			return nil, fmt.Errorf("%w. Arguments can be added to the end of the command by passing them after --", err)
+		}
+	}
+	return append(args, extraArgs...), nil
+}
+
+// replaceArgValue replaces the value of the flag for name in args, in
+// either the "--name value" or "--name=value" form. Dashes and underscores
+// in flag names are treated the same.
+func replaceArgValue(args []string, name string, value string) error {
+	found := false
+	for i := 1; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		flag := args[i]
+		hasValue := false
+		if idx := strings.Index(flag, "="); idx != -1 {
+			flag = flag[:idx]
+			hasValue = true
+		}
+		if normalizeFlagName(flag) != normalizeFlagName(name) {
+			continue
+		}
+		if hasValue {
+			args[i] = flag + "=" + value
+		} else {
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "--") {
+				return fmt.Errorf("The argument %s in the command does not have a value to replace", flag)
+			}
+			args[i+1] = value
+			i++
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("Could not find an argument for the param %q in the command", name)
+	}
+	return nil
+}
+
+func normalizeFlagName(name string) string {
+	return strings.Replace(strings.TrimLeft(name, "-"), "-", "_", -1)
+}
+
+// findPython returns the Python interpreter to run scripts with
+func findPython(python string) (string, error) {
+	if python != "" {
+		return python, nil
+	}
+	for _, name := range []string{"python", "python3"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("Could not find python or python3 in PATH. Pass the Python interpreter to use with --python")
+}
+
+// absoluteRepositoryURL makes a disk repository URL that is relative to
+// projectDir absolute, so it can be used from another directory. Options
+// like ?mode= are kept.
+func absoluteRepositoryURL(repositoryURL string, projectDir string) (string, error) {
+	scheme, _, root, err := repository.SplitURL(repositoryURL)
+	if err != nil {
+		return "", err
+	}
+	if scheme != repository.SchemeDisk || filepath.IsAbs(root) {
+		return repositoryURL, nil
+	}
+	root, err = filepath.Abs(filepath.Join(projectDir, root))
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(repositoryURL)
+	if err != nil {
+		return "", err
+	}
+	u.Host = ""
+	u.Path = filepath.ToSlash(root)
+	return u.String(), nil
+}
+
+// rerunEnviron returns the environment to run the command in, without
+// anything that would stop KEEPSAKE_REPOSITORY from being used
+func rerunEnviron() []string {
+	env := []string{}
+	for _, v := range os.Environ() {
+		if strings.HasPrefix(v, "KEEPSAKE_REPOSITORY=") || strings.HasPrefix(v, "KEEPSAKE_DEFAULT_REMOTE=") {
+			continue
+		}
+		env = append(env, v)
+	}
+	return env
+}
+
+// writeRerunConfig writes the config the experiment was run with to
+// keepsake.yaml in dir, if keepsake.yaml wasn't saved with its files
+func writeRerunConfig(dir string, exp *project.Experiment, repositoryURL string) error {
+	for _, filename := range global.ConfigFilenames {
+		if _, err := os.Stat(filepath.Join(dir, filename)); err == nil {
+			return nil
+		}
+	}
+	configPath := filepath.Join(dir, global.ConfigFilenames[0])
+	conf := &config.Config{}
+	if exp.Config != nil {
+		c := *exp.Config
+		conf = &c
+	}
+	conf.Repository = repositoryURL
+	conf.DefaultRemote = ""
+	conf.Storage = ""
+	data, err := yaml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("Failed to write %s: %w", configPath, err)
+	}
+	return nil
+}
+
+type pythonEnvironment struct {
+	PythonVersion string            `json:"python_version"`
+	Packages      map[string]string `json:"packages"`
+}
+
+// Prints the same Python version and package versions that the Python library
+// records for experiments
+const pythonEnvironmentScript = `
+import json, sys
+packages = {}
+try:
+    import pkg_resources
+    packages = {d.key: d.version for d in pkg_resources.working_set}
+except ImportError:
+    pass
+print(json.dumps({
+    "python_version": ".".join(str(x) for x in sys.version_info[:3]),
+    "packages": packages,
+}))
+`
+
+func getPythonEnvironment(python string) (*pythonEnvironment, error) {
+	out, err := exec.Command(python, "-c", pythonEnvironmentScript).Output()
+	if err != nil {
+		return nil, err
+	}
+	env := new(pythonEnvironment)
+	if err := json.Unmarshal(out, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func warnAboutPythonEnvironment(python string, exp *project.Experiment) {
+	env, err := getPythonEnvironment(python)
+	if err != nil {
+		console.Warn("Failed to get the Python version and packages from %s, so they can't be compared to the ones the experiment was run with: %s", python, err)
+		return
+	}
+	differences := pythonEnvironmentDifferences(exp, env)
+	if len(differences) == 0 {
+		return
+	}
+	console.Warn("The Python environment is different to the one experiment %s was run with:\n\n  %s\n", exp.ShortID(), strings.Join(differences, "\n  "))
+}
+
+// pythonEnvironmentDifferences returns a description of each way env differs
+// from the Python version and packages an experiment was run with
+func pythonEnvironmentDifferences(exp *project.Experiment, env *pythonEnvironment) []string {
+	differences := []string{}
+	if exp.PythonVersion != "" && exp.PythonVersion != env.PythonVersion {
+		differences = append(differences, fmt.Sprintf("Python is version %s, but the experiment used %s", env.PythonVersion, exp.PythonVersion))
+	}
+	names := []string{}
+	for name := range exp.PythonPackages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		expected := exp.PythonPackages[name]
+		installed, ok := env.Packages[name]
+		if !ok {
+			differences = append(differences, fmt.Sprintf("%s is not installed, but the experiment used version %s", name, expected))
+		} else if installed != expected {
+			differences = append(differences, fmt.Sprintf("%s is version %s, but the experiment used %s", name, installed, expected))
+		}
+	}
+	return differences
+}
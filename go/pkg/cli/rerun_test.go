@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestRerunCommand(t *testing.T) {
+	args, err := rerunCommand("train.py --learning-rate 0.01 --epochs=10 'with space'", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"train.py", "--learning-rate", "0.01", "--epochs=10", "with space"}, args)
+
+	args, err = rerunCommand("train.py --learning-rate 0.01 --epochs=10", []string{"learning_rate=0.1", "epochs=20"}, []string{"--resume"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"train.py", "--learning-rate", "0.1", "--epochs=20", "--resume"}, args)
+
+	_, err = rerunCommand("train.py --learning-rate 0.01", []string{"batch_size=64"}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "batch_size")
+
+	_, err = rerunCommand("train.py --verbose --learning-rate 0.01", []string{"verbose=1"}, nil)
+	require.Error(t, err)
+
+	_, err = rerunCommand("train.py --learning-rate 0.01", []string{"learning_rate"}, nil)
+	require.Error(t, err)
+}
+
+func TestSplitRerunArgs(t *testing.T) {
+	prefix, extraArgs, err := splitRerunArgs([]string{"1eee"}, -1)
+	require.NoError(t, err)
+	require.Equal(t, "1eee", prefix)
+	require.Empty(t, extraArgs)
+
+	prefix, extraArgs, err = splitRerunArgs([]string{"1eee", "--epochs", "5"}, 1)
+	require.NoError(t, err)
+	require.Equal(t, "1eee", prefix)
+	require.Equal(t, []string{"--epochs", "5"}, extraArgs)
+
+	_, _, err = splitRerunArgs([]string{"1eee", "2eee"}, -1)
+	require.Error(t, err)
+}
+
+func TestPythonEnvironmentDifferences(t *testing.T) {
+	exp := &project.Experiment{
+		PythonVersion:  "3.8.5",
+		PythonPackages: map[string]string{"torch": "1.6.0", "numpy": "1.19.1", "pandas": "1.1.0"},
+	}
+
+	require.Empty(t, pythonEnvironmentDifferences(exp, &pythonEnvironment{
+		PythonVersion: "3.8.5",
+		Packages:      map[string]string{"torch": "1.6.0", "numpy": "1.19.1", "pandas": "1.1.0", "other": "1.0"},
+	}))
+
+	require.Equal(t, []string{
+		"Python is version 3.7.9, but the experiment used 3.8.5",
+		"numpy is version 1.18.0, but the experiment used 1.19.1",
+		"torch is not installed, but the experiment used version 1.6.0",
+	}, pythonEnvironmentDifferences(exp, &pythonEnvironment{
+		PythonVersion: "3.7.9",
+		Packages:      map[string]string{"numpy": "1.18.0", "pandas": "1.1.0"},
+	}))
+}
+
+func TestRerun(t *testing.T) {
+	repoDir, err := files.TempDir("test-rerun")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	fixedTime, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05Z")
+
+	experiment := &project.Experiment{
+		ID:      "1eeeeeeeee",
+		Created: fixedTime.Add(-10 * time.Minute),
+		Config:  &config.Config{},
+		Command: "sh train.sh --learning-rate 0.01",
+		Path:    "train.sh",
+		Checkpoints: []*project.Checkpoint{
+			{
+				ID:      "1ccccccccc",
+				Created: fixedTime.Add(-5 * time.Minute),
+			},
+		},
+	}
+	require.NoError(t, experiment.Save(repo))
+
+	codeDir, err := files.TempDir("test-rerun-code")
+	require.NoError(t, err)
+	defer os.RemoveAll(codeDir)
+
+	script := `echo "$KEEPSAKE_RERUN_EXPERIMENT_ID $KEEPSAKE_RERUN_CHECKPOINT_ID $KEEPSAKE_REPOSITORY $2 $3" > out.txt`
+	err = ioutil.WriteFile(path.Join(codeDir, "train.sh"), []byte(script), 0644)
+	require.NoError(t, err)
+	projectConfig := "repository: .keepsake\nsymlinks: follow\n"
+	err = ioutil.WriteFile(path.Join(codeDir, "keepsake.yaml"), []byte(projectConfig), 0644)
+	require.NoError(t, err)
+	_, err = repo.PutPathTar(codeDir, "experiments/1eeeeeeeee.tar.gz", "")
+	require.NoError(t, err)
+
+	outputDir, err := files.TempDir("test-rerun-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(outputDir)
+
+	opts := rerunOpts{
+		outputDirectory: outputDir,
+		force:           true,
+		params:          []string{"learning_rate=0.1"},
+		repositoryURL:   "file://" + repoDir,
+	}
+	require.NoError(t, rerunExperiment(opts, "1eee", []string{"--resume"}))
+
+	out, err := ioutil.ReadFile(path.Join(outputDir, "out.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "1eeeeeeeee 1ccccccccc file://"+repoDir+" 0.1 --resume\n", string(out))
+
+	// the repository is passed in the environment, so the project's config
+	// is left as it is
+	config, err := ioutil.ReadFile(path.Join(outputDir, "keepsake.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, projectConfig, string(config))
+
+	experiment.Command = ""
+	require.NoError(t, experiment.Save(repo))
+	err = rerunExperiment(opts, "1eee", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not have a command")
+}
+
+func TestAbsoluteRepositoryURL(t *testing.T) {
+	for _, tt := range []struct{ url, expected string }{
+		{"file://.keepsake", "file:///project/.keepsake"},
+		{"file://.keepsake?mode=read-only", "file:///project/.keepsake?mode=read-only"},
+		{"file:///data/keepsake", "file:///data/keepsake"},
+		{"s3://bucket/root?profile=dev&endpoint=http://localhost:9000", "s3://bucket/root?profile=dev&endpoint=http://localhost:9000"},
+	} {
+		actual, err := absoluteRepositoryURL(tt.url, "/project")
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, actual)
+	}
+}
+
+func TestWriteRerunConfig(t *testing.T) {
+	dir, err := files.TempDir("test-rerun-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// experiments that weren't saved with keepsake.yaml get the config they
+	// were run with
+	exp := &project.Experiment{Config: &config.Config{
+		DefaultRemote: "team",
+		Remotes:       map[string]*config.Remote{"team": {Repository: "s3://team-bucket"}},
+		Symlinks:      config.SymlinksFollow,
+	}}
+	require.NoError(t, writeRerunConfig(dir, exp, "file:///data/keepsake"))
+	data, err := ioutil.ReadFile(path.Join(dir, "keepsake.yaml"))
+	require.NoError(t, err)
+	conf, err := config.Parse(data, dir)
+	require.NoError(t, err)
+	require.Equal(t, "file:///data/keepsake", conf.Repository)
+	require.Equal(t, config.SymlinksFollow, conf.Symlinks)
+	require.Equal(t, "s3://team-bucket", conf.Remotes["team"].Repository)
+
+	// and it isn't replaced if it is there
+	require.NoError(t, writeRerunConfig(dir, exp, "file:///somewhere/else"))
+	data2, err := ioutil.ReadFile(path.Join(dir, "keepsake.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(data2))
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+)
+
+func newRestoreCommand() *cobra.Command {
+	var repositoryURL string
+	cmd := &cobra.Command{
+		Use:   "restore <experiment or checkpoint ID> [experiment or checkpoint ID...]",
+		Short: "Restore experiments or checkpoints from the trash",
+		Long: `Restore experiments or checkpoints that were removed with "keepsake rm" from the trash.
+
+To see what is in the trash, run "keepsake trash ls".`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return restoreFromTrash(repositoryURL, args)
+		}),
+		Args: cobra.MinimumNArgs(1),
+		Example: `Restore an experiment and its checkpoints
+(where a1b2c3d4 is an experiment ID):
+keepsake restore a1b2c3d4`,
+	}
+	addRepositoryURLFlagVar(cmd, &repositoryURL)
+	return cmd
+}
+
+func restoreFromTrash(repositoryURL string, prefixes []string) error {
+	proj, _, err := getTrashProject(repositoryURL)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		entry, err := proj.TrashEntryFromPrefix(prefix)
+		if err != nil {
+			return err
+		}
+		if err := proj.RestoreFromTrash(entry); err != nil {
+			return err
+		}
+		console.Info("Restored %s %s", entry.Kind(), entry.ShortID())
+	}
+	return nil
+}
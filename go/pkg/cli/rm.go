@@ -16,6 +16,8 @@ func newRmCommand() *cobra.Command {
 		Long: `Remove experiments or checkpoints.
 
 To remove experiments or checkpoints, pass any number of IDs (or prefixes).
+
+Experiments and checkpoints are moved to the trash, where they can be restored with "keepsake restore". Things in the trash are deleted permanently with "keepsake trash empty", or with "keepsake gc" after "retention" in the "trash" section of keepsake.yaml. Pass --permanent to delete them straight away.
 `,
 		Run:        handleErrors(removeExperimentOrCheckpoint),
 		Args:       cobra.MinimumNArgs(1),
@@ -28,11 +30,16 @@ keepsake rm a1b2c3d4
 Delete all experiments where the metric "val_accuracy" is less
 than 0.2 at the best checkpoints:
 keepsake rm $(keepsake ls -q --filter "val_accuracy < 0.2")
+
+Undo that:
+keepsake trash ls
+keepsake restore a1b2c3d4
 `,
 	}
 
 	addRepositoryURLFlag(cmd)
 	cmd.Flags().BoolP("force", "f", false, "Force delete without interactive prompt")
+	cmd.Flags().Bool("permanent", false, "Delete permanently, instead of moving to the trash")
 
 	return cmd
 }
@@ -47,10 +54,11 @@ func removeExperimentOrCheckpoint(cmd *cobra.Command, prefixes []string) error {
 		return err
 	}
 	proj := project.NewProject(repo, projectDir)
+	force, err := cmd.Flags().GetBool("force")
 	if err != nil {
 		return err
 	}
-	force, err := cmd.Flags().GetBool("force")
+	permanent, err := cmd.Flags().GetBool("permanent")
 	if err != nil {
 		return err
 	}
@@ -67,9 +75,16 @@ func removeExperimentOrCheckpoint(cmd *cobra.Command, prefixes []string) error {
 	if len(comOrExps) == 0 {
 		return nil
 	}
+	if err := proj.CheckCanDelete(); err != nil {
+		return err
+	}
 
 	if !force {
-		fmt.Println("You are about to delete the following:")
+		if permanent {
+			fmt.Println("You are about to permanently delete the following:")
+		} else {
+			fmt.Println("You are about to move the following to the trash:")
+		}
 		for _, comOrExp := range comOrExps {
 			if comOrExp.Experiment != nil {
 				fmt.Printf("* Experiment %s (%d checkpoints)\n", comOrExp.Experiment.ShortID(), len(comOrExp.Experiment.Checkpoints))
@@ -95,25 +110,43 @@ func removeExperimentOrCheckpoint(cmd *cobra.Command, prefixes []string) error {
 		if err != nil {
 			return err
 		}
-		if comOrExp.Checkpoint != nil {
-			console.Info("Removing checkpoint %s...", comOrExp.Checkpoint.ShortID())
-			if err := proj.DeleteCheckpoint(comOrExp.Checkpoint); err != nil {
-				return err
-			}
+		if permanent {
+			err = deleteExperimentOrCheckpoint(proj, comOrExp)
 		} else {
-			console.Info("Removing experiment %s and its checkpoints...", comOrExp.Experiment.ShortID())
-			experiment := comOrExp.Experiment
-			// This is slow, see https://github.com/replicate/keepsake/issues/333
-			for _, checkpoint := range experiment.Checkpoints {
-				if err := proj.DeleteCheckpoint(checkpoint); err != nil {
-					return err
-				}
-			}
-			if err := proj.DeleteExperiment(experiment); err != nil {
-				return err
-			}
+			err = trashExperimentOrCheckpoint(proj, comOrExp)
+		}
+		if err != nil {
+			return err
 		}
 	}
 
+	if !permanent {
+		console.Info("To undo this, run 'keepsake restore' with the IDs above.")
+	}
 	return nil
 }
+
+func trashExperimentOrCheckpoint(proj *project.Project, comOrExp *project.CheckpointOrExperiment) error {
+	if comOrExp.Checkpoint != nil {
+		console.Info("Moving checkpoint %s to the trash...", comOrExp.Checkpoint.ShortID())
+		return proj.TrashCheckpoint(comOrExp.Checkpoint, comOrExp.Experiment)
+	}
+	console.Info("Moving experiment %s and its checkpoints to the trash...", comOrExp.Experiment.ShortID())
+	return proj.TrashExperiment(comOrExp.Experiment)
+}
+
+func deleteExperimentOrCheckpoint(proj *project.Project, comOrExp *project.CheckpointOrExperiment) error {
+	if comOrExp.Checkpoint != nil {
+		console.Info("Removing checkpoint %s...", comOrExp.Checkpoint.ShortID())
+		return proj.DeleteCheckpoint(comOrExp.Checkpoint)
+	}
+	console.Info("Removing experiment %s and its checkpoints...", comOrExp.Experiment.ShortID())
+	experiment := comOrExp.Experiment
+	// This is slow, see https://github.com/replicate/keepsake/issues/333
+	for _, checkpoint := range experiment.Checkpoints {
+		if err := proj.DeleteCheckpoint(checkpoint); err != nil {
+			return err
+		}
+	}
+	return proj.DeleteExperiment(experiment)
+}
@@ -27,6 +27,9 @@ To learn how to get started, go to ` + global.WebURL + `/docs/tutorial`,
 				console.SetLevel(console.DebugLevel)
 			}
 			console.SetColor(global.Color)
+			if err := setUpLogging(); err != nil {
+				console.Fatal("%s", err)
+			}
 
 			if err := analytics.TrackCommand(cmd.Name()); err != nil {
 				console.Debug("analytics error: %s", err)
@@ -41,13 +44,27 @@ To learn how to get started, go to ` + global.WebURL + `/docs/tutorial`,
 	rootCmd.AddCommand(
 		newAnalyticsCommand(),
 		newCheckoutCommand(),
+		newConfigCommand(),
 		newRmCommand(),
 		newDiffCommand(),
+		newDiskUsageCommand(),
 		newFeedbackCommand(),
+		newGCCommand(),
 		newGenerateDocsCommand(&rootCmd),
+		newHooksCommand(),
+		newInitCommand(),
+		newLineageCommand(),
 		newListCommand(),
+		newMigrateCommand(),
+		newPlotCommand(),
+		newPruneCommand(),
 		newPsCommand(),
+		newRemoteCommand(),
+		newRestoreCommand(),
+		newRerunCommand(),
+		newServeCommand(),
 		newShowCommand(),
+		newTrashCommand(),
 	)
 
 	return &rootCmd, nil
@@ -58,6 +75,11 @@ func setPersistentFlags(cmd *cobra.Command) {
 	// FIXME (bfirsh): this noun needs standardizing. we use the term "working directory" in some places.
 	cmd.PersistentFlags().StringVarP(&global.ProjectDirectory, "project-directory", "D", "", "Project directory. Default: nearest parent directory with keepsake.yaml")
 	cmd.PersistentFlags().BoolVarP(&global.Verbose, "verbose", "v", false, "Verbose output")
+	cmd.PersistentFlags().StringVar(&global.Remote, "remote", "", "Name of a remote in keepsake.yaml to use as the repository. Default: default_remote in keepsake.yaml, or $KEEPSAKE_REMOTE")
+	cmd.PersistentFlags().StringVar(&global.LogFormat, "log-format", envOrDefault("KEEPSAKE_LOG_FORMAT", global.LogFormat), "Format of log messages: text, or json for a JSON object on each line. Default: $KEEPSAKE_LOG_FORMAT, or text")
+	cmd.PersistentFlags().StringVar(&global.LogFile, "log-file", os.Getenv("KEEPSAKE_LOG_FILE"), "Write log messages to this file instead of stderr. Default: $KEEPSAKE_LOG_FILE")
+	cmd.PersistentFlags().IntVar(&global.LogMaxSize, "log-max-size", global.LogMaxSize, "Size in megabytes the log file can grow to before it is rotated")
+	cmd.PersistentFlags().IntVar(&global.LogMaxBackups, "log-max-backups", global.LogMaxBackups, "Number of rotated log files to keep")
 
 }
 
@@ -0,0 +1,419 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/cli/list"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/plot"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+	"github.com/replicate/keepsake/go/pkg/webui"
+)
+
+// metadata is fetched again at most this often, so a page making several
+// requests doesn't hit the repository for each of them
+const webRefreshInterval = 2 * time.Second
+
+type serveOpts struct {
+	host          string
+	port          int
+	repositoryURL string
+}
+
+func newServeCommand() *cobra.Command {
+	var opts serveOpts
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Browse experiments in a web browser",
+		Long: `Start a web server on this computer for browsing experiments in a web browser.
+
+It serves a web interface for listing, comparing and plotting experiments, and the JSON API that it uses. Everything is served locally, so it works without an internet connection.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return serveWeb(opts)
+		}),
+		Args: cobra.NoArgs,
+		Example: `Browse experiments at http://localhost:8080:
+$ keepsake serve
+
+List running experiments with the API:
+$ curl 'http://localhost:8080/api/experiments?filter=status%20%3D%20running'
+`,
+	}
+
+	cmd.Flags().StringVar(&opts.host, "host", "localhost", "Host name or IP address to listen on. Use 0.0.0.0 to make it available to other computers")
+	cmd.Flags().IntVarP(&opts.port, "port", "p", 8080, "Port to listen on")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+
+	return cmd
+}
+
+func serveWeb(opts serveOpts) error {
+	repositoryURL, projectDir, err := getRepositoryURLFromStringOrConfig(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(opts.host, strconv.Itoa(opts.port))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("Failed to listen on %s: %w", address, err)
+	}
+	console.Info("Serving experiments from %s at http://%s", repositoryURL, address)
+	console.Info("Press Ctrl+C to stop")
+	return http.Serve(listener, newWebHandler(repo))
+}
+
+// webHandler serves the web interface and the JSON API it uses
+type webHandler struct {
+	repo repository.Repository
+	proj *project.Project
+	mux  *http.ServeMux
+
+	// Project isn't safe for concurrent use, so requests are handled one
+	// at a time
+	mu          sync.Mutex
+	lastRefresh time.Time
+}
+
+// webError is an error with an HTTP status code
+type webError struct {
+	status  int
+	message string
+}
+
+func (e *webError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &webError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func newWebHandler(repo repository.Repository) *webHandler {
+	h := &webHandler{
+		repo: repo,
+		proj: project.NewProject(repo, ""),
+		mux:  http.NewServeMux(),
+	}
+	h.mux.HandleFunc("/", h.handleAsset)
+	h.mux.HandleFunc("/api/experiments", h.api(h.handleListExperiments))
+	h.mux.HandleFunc("/api/experiments/", h.api(h.handleExperiment))
+	h.mux.HandleFunc("/api/checkpoints/", h.api(h.handleCheckpoint))
+	h.mux.HandleFunc("/api/diff", h.api(h.handleDiff))
+	h.mux.HandleFunc("/api/metrics", h.api(h.handleMetrics))
+	h.mux.HandleFunc("/api/plot.svg", h.api(h.handlePlot))
+	return h
+}
+
+func (h *webHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *webHandler) handleAsset(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" {
+		name = "index.html"
+	}
+	data, err := webui.Asset(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+	_, _ = w.Write(data)
+}
+
+// api wraps an API endpoint, loading any new metadata before it is called
+// and responding with a JSON error if it fails
+func (h *webHandler) api(handler func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		err := h.refresh()
+		if err == nil {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				err = &webError{status: http.StatusMethodNotAllowed, message: "Method not allowed: " + r.Method}
+			} else {
+				err = handler(w, r)
+			}
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if webErr, ok := err.(*webError); ok {
+				status = webErr.status
+			} else if errors.IsDoesNotExist(err) {
+				status = http.StatusNotFound
+			}
+			console.Debug("%s %s: %s", r.Method, r.URL, err)
+			writeJSON(w, status, map[string]string{"error": err.Error()})
+		}
+	}
+}
+
+func (h *webHandler) refresh() error {
+	if time.Since(h.lastRefresh) < webRefreshInterval {
+		return nil
+	}
+	if cachedRepo, ok := h.repo.(*repository.CachedRepository); ok {
+		if err := cachedRepo.SyncCache(); err != nil {
+			return err
+		}
+	}
+	if err := h.proj.Refresh(); err != nil {
+		return err
+	}
+	h.lastRefresh = time.Now()
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		console.Debug("Failed to write response: %s", err)
+	}
+}
+
+// GET /api/experiments?filter=<filter>&sort=<key>
+func (h *webHandler) handleListExperiments(w http.ResponseWriter, r *http.Request) error {
+	filters, err := webFilters(r)
+	if err != nil {
+		return err
+	}
+	sortKey := r.URL.Query().Get("sort")
+	if sortKey == "" {
+		sortKey = "created"
+	}
+	experiments, err := list.ListExperiments(h.proj, filters, param.NewSorter(sortKey))
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, experiments)
+	return nil
+}
+
+type experimentResponse struct {
+	*project.Experiment
+	Running bool `json:"running"`
+}
+
+type checkpointResponse struct {
+	*project.Checkpoint
+	ExperimentID string `json:"experiment_id"`
+}
+
+// GET /api/experiments/<id>
+// GET /api/experiments/<id>/files
+// GET /api/experiments/<id>/files/<path>
+func (h *webHandler) handleExperiment(w http.ResponseWriter, r *http.Request) error {
+	prefix, rest := splitWebPath(strings.TrimPrefix(r.URL.Path, "/api/experiments/"))
+	exp, err := h.proj.ExperimentFromPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	if rest == "" {
+		running, err := h.proj.ExperimentIsRunning(exp.ID)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, &experimentResponse{Experiment: exp, Running: running})
+		return nil
+	}
+	return h.handleFiles(w, r, exp.StorageTarPath(), exp.StorageDigest(), rest)
+}
+
+// GET /api/checkpoints/<id>
+// GET /api/checkpoints/<id>/files
+// GET /api/checkpoints/<id>/files/<path>
+func (h *webHandler) handleCheckpoint(w http.ResponseWriter, r *http.Request) error {
+	prefix, rest := splitWebPath(strings.TrimPrefix(r.URL.Path, "/api/checkpoints/"))
+	chk, exp, err := h.proj.CheckpointFromPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	if rest == "" {
+		writeJSON(w, http.StatusOK, &checkpointResponse{Checkpoint: chk, ExperimentID: exp.ID})
+		return nil
+	}
+	return h.handleFiles(w, r, chk.StorageTarPath(), chk.StorageDigest(), rest)
+}
+
+// handleFiles lists the files in a tarball if rest is "files", or
+// downloads a file from it if rest is "files/<path>"
+func (h *webHandler) handleFiles(w http.ResponseWriter, r *http.Request, tarPath string, digest *repository.Digest, rest string) error {
+	if rest == "files" {
+		paths, err := h.repo.ListTarFile(tarPath)
+		if errors.IsDoesNotExist(err) {
+			// Nothing was saved
+			paths = []string{}
+		} else if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, paths)
+		return nil
+	}
+	if !strings.HasPrefix(rest, "files/") {
+		return &webError{status: http.StatusNotFound, message: "Not found: " + r.URL.Path}
+	}
+
+	// Cleaning it as an absolute path removes any ".." that would escape
+	// the temporary directory
+	itemPath := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(rest, "files/")), "/")
+	if itemPath == "" {
+		return badRequest("No file path given")
+	}
+	tmpDir, err := files.TempDir("serve-download")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := h.repo.GetPathItemTar(tarPath, itemPath, tmpDir, digest); err != nil {
+		return err
+	}
+	localPath := filepath.Join(tmpDir, filepath.FromSlash(itemPath))
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return badRequest("%s is a directory, only files can be downloaded", itemPath)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(itemPath)}))
+	http.ServeFile(w, r, localPath)
+	return nil
+}
+
+// GET /api/diff?id=<id>&id=<id>...
+func (h *webHandler) handleDiff(w http.ResponseWriter, r *http.Request) error {
+	ids := r.URL.Query()["id"]
+	if len(ids) < 2 {
+		return badRequest("Pass at least two experiment or checkpoint IDs to compare")
+	}
+	diff, err := newCheckpointDiff(h.proj, ids)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, diff)
+	return nil
+}
+
+type metricsResponse struct {
+	Metrics []string       `json:"metrics"`
+	XAxis   string         `json:"x_axis"`
+	Series  []*plot.Series `json:"series"`
+}
+
+// GET /api/metrics?id=<id>&filter=<filter>&metric=<name>&x_axis=<step|time>
+func (h *webHandler) handleMetrics(w http.ResponseWriter, r *http.Request) error {
+	metrics, series, xAxis, err := h.metricSeries(r)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, &metricsResponse{Metrics: metrics, XAxis: xAxis, Series: series})
+	return nil
+}
+
+// GET /api/plot.svg, with the same parameters as /api/metrics, and
+// log=true, smoothing=<0-1>, width=<pixels>, height=<pixels>
+func (h *webHandler) handlePlot(w http.ResponseWriter, r *http.Request) error {
+	metrics, series, xAxis, err := h.metricSeries(r)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	opts := plot.Options{Title: strings.Join(metrics, ", "), XLabel: xAxis}
+	if xAxis == xAxisTime {
+		opts.XLabel = "seconds since start"
+	}
+	opts.LogScale = query.Get("log") == "true"
+	if s := query.Get("smoothing"); s != "" {
+		if opts.Smoothing, err = strconv.ParseFloat(s, 64); err != nil {
+			return badRequest("Invalid smoothing: %s", s)
+		}
+	}
+	if s := query.Get("width"); s != "" {
+		if opts.Width, err = strconv.Atoi(s); err != nil {
+			return badRequest("Invalid width: %s", s)
+		}
+	}
+	if s := query.Get("height"); s != "" {
+		if opts.Height, err = strconv.Atoi(s); err != nil {
+			return badRequest("Invalid height: %s", s)
+		}
+	}
+
+	// Render before writing anything, so errors can still be returned as JSON
+	var sb strings.Builder
+	if err := plot.WriteSVG(&sb, series, opts); err != nil {
+		return badRequest("%s", err)
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = w.Write([]byte(sb.String()))
+	return nil
+}
+
+func (h *webHandler) metricSeries(r *http.Request) (metrics []string, series []*plot.Series, xAxis string, err error) {
+	query := r.URL.Query()
+	filters, err := webFilters(r)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	xAxis = query.Get("x_axis")
+	if xAxis == "" {
+		xAxis = xAxisStep
+	}
+	metrics, series, err = loadMetricSeries(h.proj, query["id"], filters, query["metric"], xAxis)
+	if err != nil {
+		if errors.IsDoesNotExist(err) {
+			return nil, nil, "", err
+		}
+		return nil, nil, "", badRequest("%s", err)
+	}
+	return metrics, series, xAxis, nil
+}
+
+func webFilters(r *http.Request) (*param.Filters, error) {
+	filterStrings := r.URL.Query()["filter"]
+	if len(filterStrings) == 0 {
+		return new(param.Filters), nil
+	}
+	filters, err := param.MakeFilters(filterStrings)
+	if err != nil {
+		return nil, badRequest("%s", err)
+	}
+	return filters, nil
+}
+
+// splitWebPath splits "<id>/<rest>" into its parts
+func splitWebPath(p string) (id string, rest string) {
+	parts := strings.SplitN(p, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
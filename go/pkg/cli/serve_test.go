@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func webGet(t *testing.T, handler http.Handler, method string, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func webGetJSON(t *testing.T, handler http.Handler, target string, expectedStatus int, v interface{}) {
+	rec := webGet(t, handler, "GET", target)
+	require.Equal(t, expectedStatus, rec.Code, rec.Body.String())
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+}
+
+func createServeTestData(t *testing.T, workingDir string) repository.Repository {
+	repo := createShowTestData(t, workingDir, &config.Config{})
+
+	filesDir := path.Join(workingDir, "files")
+	require.NoError(t, os.MkdirAll(path.Join(filesDir, "data"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(filesDir, "data", "weights.txt"), []byte("hello"), 0644))
+	_, err := repo.PutPathTar(filesDir, "checkpoints/1ccccccccc.tar.gz", "data")
+	require.NoError(t, err)
+	return repo
+}
+
+func TestServeUI(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+	handler := newWebHandler(createServeTestData(t, workingDir))
+
+	rec := webGet(t, handler, "GET", "/")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "<title>Keepsake</title>")
+	require.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html"))
+
+	rec = webGet(t, handler, "GET", "/app.js")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "javascript")
+
+	rec = webGet(t, handler, "GET", "/nope.js")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServeExperiments(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+	handler := newWebHandler(createServeTestData(t, workingDir))
+
+	var experiments []map[string]interface{}
+	webGetJSON(t, handler, "/api/experiments", http.StatusOK, &experiments)
+	require.Len(t, experiments, 2)
+	require.Equal(t, "1eeeeeeeee", experiments[0]["id"])
+	require.Equal(t, true, experiments[0]["running"])
+	require.Equal(t, "2eeeeeeeee", experiments[1]["id"])
+
+	webGetJSON(t, handler, "/api/experiments?sort=created-desc", http.StatusOK, &experiments)
+	require.Equal(t, "2eeeeeeeee", experiments[0]["id"])
+
+	webGetJSON(t, handler, "/api/experiments?filter="+url.QueryEscape("param-1 = 200"), http.StatusOK, &experiments)
+	require.Len(t, experiments, 1)
+	require.Equal(t, "2eeeeeeeee", experiments[0]["id"])
+
+	var errorBody map[string]string
+	webGetJSON(t, handler, "/api/experiments?filter=nonsense", http.StatusBadRequest, &errorBody)
+	require.NotEmpty(t, errorBody["error"])
+
+	var experiment map[string]interface{}
+	webGetJSON(t, handler, "/api/experiments/1e", http.StatusOK, &experiment)
+	require.Equal(t, "1eeeeeeeee", experiment["id"])
+	require.Equal(t, true, experiment["running"])
+	require.Len(t, experiment["checkpoints"], 3)
+
+	webGetJSON(t, handler, "/api/experiments/ffff", http.StatusNotFound, &errorBody)
+	require.Equal(t, "Experiment not found: ffff", errorBody["error"])
+
+	var checkpoint map[string]interface{}
+	webGetJSON(t, handler, "/api/checkpoints/4c", http.StatusOK, &checkpoint)
+	require.Equal(t, "4ccccccccc", checkpoint["id"])
+	require.Equal(t, "2eeeeeeeee", checkpoint["experiment_id"])
+
+	rec := webGet(t, handler, "POST", "/api/experiments")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestServeFiles(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+	handler := newWebHandler(createServeTestData(t, workingDir))
+
+	var paths []string
+	webGetJSON(t, handler, "/api/checkpoints/1c/files", http.StatusOK, &paths)
+	require.Contains(t, paths, "data/weights.txt")
+
+	// Nothing saved for this experiment
+	webGetJSON(t, handler, "/api/experiments/2e/files", http.StatusOK, &paths)
+	require.Empty(t, paths)
+
+	rec := webGet(t, handler, "GET", "/api/checkpoints/1c/files/data/weights.txt")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "hello", rec.Body.String())
+	require.Equal(t, "attachment; filename=weights.txt", rec.Header().Get("Content-Disposition"))
+
+	var errorBody map[string]string
+	webGetJSON(t, handler, "/api/checkpoints/1c/files/data", http.StatusBadRequest, &errorBody)
+	require.Equal(t, "data is a directory, only files can be downloaded", errorBody["error"])
+
+	webGetJSON(t, handler, "/api/checkpoints/1c/files/nope.txt", http.StatusNotFound, &errorBody)
+}
+
+func TestServeDiffAndMetrics(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+	handler := newWebHandler(createServeTestData(t, workingDir))
+
+	var diff map[string]interface{}
+	webGetJSON(t, handler, "/api/diff?id=1c&id=4c", http.StatusOK, &diff)
+	require.Len(t, diff["columns"], 2)
+	require.Len(t, diff["sections"], 5)
+
+	var errorBody map[string]string
+	webGetJSON(t, handler, "/api/diff?id=1c", http.StatusBadRequest, &errorBody)
+
+	var metrics metricsResponse
+	webGetJSON(t, handler, "/api/metrics?id=1e", http.StatusOK, &metrics)
+	require.Equal(t, []string{"metric-1"}, metrics.Metrics)
+	require.Equal(t, "step", metrics.XAxis)
+	require.Len(t, metrics.Series, 1)
+	require.Len(t, metrics.Series[0].Points, 3)
+
+	webGetJSON(t, handler, "/api/metrics?id=1e&x_axis=nope", http.StatusBadRequest, &errorBody)
+	webGetJSON(t, handler, "/api/metrics?id=ffff", http.StatusNotFound, &errorBody)
+
+	rec := webGet(t, handler, "GET", "/api/plot.svg?id=1e&id=2e&metric=metric-1&metric=metric-3&log=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+	require.True(t, strings.HasPrefix(rec.Body.String(), "<svg"))
+	require.Contains(t, rec.Body.String(), "2eeeeee metric-3")
+
+	webGetJSON(t, handler, "/api/plot.svg?id=1e&smoothing=2", http.StatusBadRequest, &errorBody)
+	require.Equal(t, "Smoothing must be between 0 and 1, got 2", errorBody["error"])
+}
@@ -91,6 +91,15 @@ func showCheckpoint(au aurora.Aurora, out io.Writer, proj *project.Project, exp
 	fmt.Fprintf(w, "Created:\t%s\n", com.Created.In(timezone).Format(time.RFC1123))
 	fmt.Fprintf(w, "Path:\t%s\n", com.Path)
 	fmt.Fprintf(w, "Step:\t%d\n", com.Step)
+	if len(com.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(com.Tags, ", "))
+	}
+	if com.Pruned {
+		fmt.Fprintf(w, "Files:\tdeleted by retention policy\n")
+	}
+	if com.Trashed {
+		fmt.Fprintf(w, "Files:\tmoved to the trash\n")
+	}
 
 	fmt.Fprintf(w, "\t\n")
 	fmt.Fprintf(w, "%s\t\n", au.Bold("Experiment"))
@@ -142,7 +151,14 @@ func showExperiment(au aurora.Aurora, out io.Writer, proj *project.Project, exp
 	fmt.Fprintf(cw, "%s\n", strings.Join(headings, "\t"))
 
 	for _, checkpoint := range exp.Checkpoints {
-		columns := []string{checkpoint.ShortID(), strconv.FormatInt(checkpoint.Step, 10), console.FormatTime(checkpoint.Created)}
+		id := checkpoint.ShortID()
+		if checkpoint.Pruned {
+			id += " (pruned)"
+		}
+		if checkpoint.Trashed {
+			id += " (trashed)"
+		}
+		columns := []string{id, strconv.FormatInt(checkpoint.Step, 10), console.FormatTime(checkpoint.Created)}
 		for _, label := range labelNames {
 			val := checkpoint.Metrics[label]
 			s := val.ShortString(10, 5)
@@ -192,6 +208,13 @@ func writeExperimentCommon(au aurora.Aurora, w *tabwriter.Writer, exp *project.E
 	fmt.Fprintf(w, "Host:\t%s\n", exp.Host)
 	fmt.Fprintf(w, "User:\t%s\n", exp.User)
 	fmt.Fprintf(w, "Command:\t%s\n", exp.Command)
+	for i, parent := range exp.Parents {
+		label := ""
+		if i == 0 {
+			label = "Parents:"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, parent)
+	}
 
 	fmt.Fprintf(w, "\t\n")
 	fmt.Fprintf(w, "%s\t\n", au.Bold("Params"))
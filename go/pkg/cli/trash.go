@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+func newTrashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List or empty the experiments and checkpoints removed with \"keepsake rm\"",
+		Long: `List or empty the experiments and checkpoints removed with "keepsake rm".
+
+"keepsake rm" moves experiments and checkpoints to the trash, in the trash/ directory of the repository, so they can be restored with "keepsake restore" if they were removed by mistake.
+
+Set "retention" in the "trash" section of keepsake.yaml, and run "keepsake gc", to delete things that have been in the trash for longer than that.`,
+	}
+	cmd.AddCommand(newTrashListCommand(), newTrashEmptyCommand())
+	return cmd
+}
+
+func newTrashListCommand() *cobra.Command {
+	var repositoryURL string
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List experiments and checkpoints in the trash",
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			proj, _, err := getTrashProject(repositoryURL)
+			if err != nil {
+				return err
+			}
+			return listTrash(proj, os.Stdout)
+		}),
+		Args: cobra.NoArgs,
+	}
+	addRepositoryURLFlagVar(cmd, &repositoryURL)
+	return cmd
+}
+
+type trashEmptyOpts struct {
+	olderThan     string
+	force         bool
+	repositoryURL string
+}
+
+func newTrashEmptyCommand() *cobra.Command {
+	var opts trashEmptyOpts
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete experiments and checkpoints in the trash",
+		Long: `Permanently delete experiments and checkpoints in the trash.
+
+If --older-than isn't passed, "retention" in the "trash" section of keepsake.yaml is used, so only things that have been in the trash for longer than that are deleted. If that isn't set either, everything in the trash is deleted.`,
+		Run: handleErrors(func(cmd *cobra.Command, args []string) error {
+			return emptyTrash(opts, os.Stdout)
+		}),
+		Args: cobra.NoArgs,
+		Example: `Delete everything that was removed more than a week ago:
+$ keepsake trash empty --older-than 7d`,
+	}
+	cmd.Flags().StringVar(&opts.olderThan, "older-than", "", "Only delete things that were removed longer ago than this, for example 12h, 30d, or 2w")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Delete without interactive prompt")
+	addRepositoryURLFlagVar(cmd, &opts.repositoryURL)
+	return cmd
+}
+
+func getTrashProject(repositoryURL string) (proj *project.Project, projectDir string, err error) {
+	repositoryURL, projectDir, err = getRepositoryURLFromStringOrConfig(repositoryURL)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := getRepository(repositoryURL, projectDir)
+	if err != nil {
+		return nil, "", err
+	}
+	return project.NewProject(repo, projectDir), projectDir, nil
+}
+
+func listTrash(proj *project.Project, out io.Writer) error {
+	entries, err := proj.TrashEntries()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		console.Info("The trash is empty")
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tEXPERIMENT\tDELETED\tDELETED BY")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.ShortID(), entry.Kind(), entry.ExperimentID[:7], console.FormatTime(entry.Deleted), entry.DeletedBy)
+	}
+	return tw.Flush()
+}
+
+func emptyTrash(opts trashEmptyOpts, out io.Writer) error {
+	proj, projectDir, err := getTrashProject(opts.repositoryURL)
+	if err != nil {
+		return err
+	}
+	olderThan, err := trashRetention(projectDir)
+	if err != nil {
+		return err
+	}
+	if opts.olderThan != "" {
+		olderThan, err = config.ParseAge(opts.olderThan)
+		if err != nil {
+			return err
+		}
+	}
+	if err := proj.CheckCanDelete(); err != nil {
+		return err
+	}
+
+	if !opts.force {
+		prompt := "Do you want to permanently delete everything in the trash?"
+		if olderThan > 0 {
+			prompt = fmt.Sprintf("Do you want to permanently delete everything that has been in the trash for longer than %s?", olderThan)
+		}
+		continueEmpty, err := console.InteractiveBool{
+			Prompt:         prompt,
+			Default:        false,
+			NonDefaultFlag: "-f",
+		}.Read()
+		if err != nil {
+			return err
+		}
+		if !continueEmpty {
+			return fmt.Errorf("Aborting.")
+		}
+	}
+
+	deleted, err := proj.EmptyTrash(olderThan)
+	if err != nil {
+		return err
+	}
+	for _, entry := range deleted {
+		fmt.Fprintf(out, "Deleted %s %s\n", entry.Kind(), entry.ShortID())
+	}
+	console.Info("Permanently deleted %d experiments and checkpoints from the trash", len(deleted))
+	return nil
+}
+
+// trashRetention returns how long things are kept in the trash, from the
+// project's keepsake.yaml, or 0 if they are kept until the trash is emptied
+func trashRetention(projectDir string) (time.Duration, error) {
+	conf, err := getProjectConfig(projectDir)
+	if conf == nil || err != nil {
+		return 0, err
+	}
+	return conf.Trash.RetentionAge(), nil
+}
@@ -1,10 +1,281 @@
 package config
 
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Config is keepsake.yaml
 type Config struct {
 	Repository string `json:"repository"`
 
-	Storage string `json:"storage"` // deprecated
+	Storage string `json:"storage,omitempty"` // deprecated
+
+	Retention *RetentionPolicy `json:"retention,omitempty"`
+
+	Symlinks SymlinkMode `json:"symlinks,omitempty"`
+
+	Trash *TrashConfig `json:"trash,omitempty"`
+
+	// Remotes are named repositories that can be used with --remote
+	Remotes map[string]*Remote `json:"remotes,omitempty"`
+
+	// DefaultRemote is the remote used when --remote isn't passed. It is
+	// used instead of Repository.
+	DefaultRemote string `json:"default_remote,omitempty"`
+
+	Hooks *Hooks `json:"hooks,omitempty"`
+
+	Heartbeat *HeartbeatConfig `json:"heartbeat,omitempty"`
+}
+
+// Remote is a named repository, and the options for connecting to it
+type Remote struct {
+	Repository string `json:"repository"`
+	// Profile is the AWS profile used to connect to S3 repositories
+	Profile string `json:"profile,omitempty"`
+	// Endpoint is the URL of an S3-compatible service to use instead of S3
+	Endpoint string      `json:"endpoint,omitempty"`
+	Cache    CachePolicy `json:"cache,omitempty"`
+	ReadOnly bool        `json:"read_only,omitempty"`
+}
+
+// URL returns the repository URL with the remote's options, which is what
+// is passed to repository.ForURL
+func (r *Remote) URL() string {
+	query := url.Values{}
+	if r.Profile != "" {
+		query.Set("profile", r.Profile)
+	}
+	if r.Endpoint != "" {
+		query.Set("endpoint", r.Endpoint)
+	}
+	if r.Cache != "" {
+		query.Set("cache", string(r.Cache))
+	}
+	if r.ReadOnly {
+		query.Set("mode", "read-only")
+	}
+	if len(query) == 0 {
+		return r.Repository
+	}
+	return r.Repository + "?" + query.Encode()
+}
+
+// Validate returns an error if the remote can't be used
+func (r *Remote) Validate(name string) error {
+	if r.Repository == "" {
+		return fmt.Errorf("Missing required field in remote %q: repository", name)
+	}
+	if strings.Contains(r.Repository, "?") {
+		return fmt.Errorf("The repository of remote %q must not have options in it. Set them as fields of the remote instead.", name)
+	}
+	if (r.Profile != "" || r.Endpoint != "") && !strings.HasPrefix(r.Repository, "s3://") {
+		return fmt.Errorf("profile and endpoint can only be set for S3 remotes, but remote %q is %s", name, r.Repository)
+	}
+	return r.Cache.Validate()
+}
+
+// CachePolicy decides whether repository metadata is cached locally
+type CachePolicy string
+
+const (
+	// CacheAuto caches the metadata of repositories on S3 and Google Cloud
+	// Storage, but not on disk
+	CacheAuto CachePolicy = "auto"
+	// CacheAlways caches the metadata of every repository
+	CacheAlways CachePolicy = "always"
+	// CacheNever doesn't cache metadata
+	CacheNever CachePolicy = "never"
+)
+
+// Validate returns an error if the policy isn't one of the known policies.
+// The empty policy is the default, CacheAuto.
+func (c CachePolicy) Validate() error {
+	switch c {
+	case "", CacheAuto, CacheAlways, CacheNever:
+		return nil
+	}
+	return fmt.Errorf("Unknown cache policy: %q. It must be one of %q, %q, or %q", c, CacheAuto, CacheAlways, CacheNever)
+}
+
+// RepositoryURL returns the URL of the named remote, or if remote is empty,
+// the default repository
+func (c *Config) RepositoryURL(remote string) (string, error) {
+	if remote == "" {
+		remote = c.DefaultRemote
+	}
+	if remote == "" {
+		return c.Repository, nil
+	}
+	r, ok := c.Remotes[remote]
+	if !ok {
+		names := []string{}
+		for name := range c.Remotes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return "", fmt.Errorf("Unknown remote %q. There are no remotes in keepsake.yaml, but you can add one with 'keepsake remote add'.", remote)
+		}
+		return "", fmt.Errorf("Unknown remote %q. The remotes in keepsake.yaml are: %s", remote, strings.Join(names, ", "))
+	}
+	return r.URL(), nil
+}
+
+// TrashConfig configures the trash, where "keepsake rm" puts experiments and
+// checkpoints
+type TrashConfig struct {
+	// Retention is how long things are kept in the trash before they can be
+	// deleted automatically, for example "30d". If it isn't set, things are
+	// kept until the trash is emptied.
+	Retention string `json:"retention,omitempty"`
+}
+
+// Validate returns an error if the retention isn't a valid age
+func (t *TrashConfig) Validate() error {
+	if t.Retention == "" {
+		return nil
+	}
+	if _, err := ParseAge(t.Retention); err != nil {
+		return fmt.Errorf("Invalid trash retention in keepsake.yaml: %v", err)
+	}
+	return nil
+}
+
+// RetentionAge returns the retention, or 0 if it isn't set
+func (t *TrashConfig) RetentionAge() time.Duration {
+	if t == nil || t.Retention == "" {
+		return 0
+	}
+	age, _ := ParseAge(t.Retention)
+	return age
+}
+
+// ParseAge parses an age like "12h", "30d", or "2w". Units of days and weeks
+// are accepted as well as the units accepted by time.ParseDuration.
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("%q is not a valid age. It must be a number followed by a unit, like 30d", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("%q is not a valid age. It must be a number followed by a unit, like 30d", s)
+	}
+	return age, nil
+}
+
+const (
+	DefaultHeartbeatInterval = 5 * time.Second
+	DefaultHeartbeatTimeout  = 30 * time.Second
+)
+
+// HeartbeatConfig configures the heartbeats that are written while
+// experiments run, which is how Keepsake knows which experiments are running
+type HeartbeatConfig struct {
+	// Interval is how often heartbeats are written, for example "10s"
+	Interval string `json:"interval,omitempty"`
+	// Timeout is how long after its last heartbeat an experiment is
+	// considered to have stopped, for example "10m". Training scripts that
+	// can stall for a long time, like on a slow data loading step, need a
+	// longer timeout.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate returns an error if the interval or timeout isn't a duration, or
+// the timeout isn't longer than the interval
+func (h *HeartbeatConfig) Validate() error {
+	for _, field := range []struct{ name, value string }{{"interval", h.Interval}, {"timeout", h.Timeout}} {
+		if field.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(field.value); err != nil || d <= 0 {
+			return fmt.Errorf("Invalid heartbeat %s in keepsake.yaml: %q is not a valid duration. It must be a number followed by a unit, like 30s", field.name, field.value)
+		}
+	}
+	if h.TimeoutDuration() <= h.IntervalDuration() {
+		return fmt.Errorf("The heartbeat timeout in keepsake.yaml (%s) must be longer than the interval (%s), otherwise running experiments would look stopped between heartbeats", h.TimeoutDuration(), h.IntervalDuration())
+	}
+	return nil
+}
+
+// IntervalDuration returns how often heartbeats are written
+func (h *HeartbeatConfig) IntervalDuration() time.Duration {
+	if h == nil || h.Interval == "" {
+		return DefaultHeartbeatInterval
+	}
+	interval, _ := time.ParseDuration(h.Interval)
+	return interval
+}
+
+// TimeoutDuration returns how long after its last heartbeat an experiment is
+// considered to have stopped
+func (h *HeartbeatConfig) TimeoutDuration() time.Duration {
+	if h == nil || h.Timeout == "" {
+		return DefaultHeartbeatTimeout
+	}
+	timeout, _ := time.ParseDuration(h.Timeout)
+	return timeout
+}
+
+// SymlinkMode decides what happens to symlinks when files are saved
+type SymlinkMode string
+
+const (
+	// SymlinksPreserve saves symlinks as symlinks. Symlinks that point
+	// outside the project directory are followed instead, because they
+	// can't be safely recreated when the files are checked out.
+	SymlinksPreserve SymlinkMode = "preserve"
+	// SymlinksFollow saves the files and directories symlinks point to
+	SymlinksFollow SymlinkMode = "follow"
+	// SymlinksSkip leaves symlinks out
+	SymlinksSkip SymlinkMode = "skip"
+)
+
+// Validate returns an error if the mode isn't one of the known modes.
+// The empty mode is the default, SymlinksPreserve.
+func (m SymlinkMode) Validate() error {
+	switch m {
+	case "", SymlinksPreserve, SymlinksFollow, SymlinksSkip:
+		return nil
+	}
+	return fmt.Errorf("Unknown value for symlinks in keepsake.yaml: %q. It must be one of %q, %q, or %q", m, SymlinksPreserve, SymlinksFollow, SymlinksSkip)
+}
+
+// RetentionPolicy decides which checkpoints keep their files. A checkpoint's
+// files are kept if any of the rules match it, and deleted otherwise. The
+// checkpoint's metadata is always kept.
+type RetentionPolicy struct {
+	// KeepLast keeps the files of the most recent checkpoints
+	KeepLast int `json:"keep_last,omitempty"`
+	// KeepBest keeps the files of the best checkpoints by primary metric
+	KeepBest int `json:"keep_best,omitempty"`
+	// KeepEvery keeps the files of checkpoints where the step is a multiple
+	// of this number
+	KeepEvery int64 `json:"keep_every,omitempty"`
+	// KeepTagged keeps the files of checkpoints that have tags
+	KeepTagged bool `json:"keep_tagged,omitempty"`
+}
+
+// Validate returns an error if the policy can't be used
+func (r *RetentionPolicy) Validate() error {
+	if r.KeepLast < 0 || r.KeepBest < 0 || r.KeepEvery < 0 {
+		return fmt.Errorf("keep_last, keep_best, and keep_every in retention must not be negative")
+	}
+	if r.KeepLast == 0 && r.KeepBest == 0 && r.KeepEvery == 0 && !r.KeepTagged {
+		return fmt.Errorf("retention must have at least one of keep_last, keep_best, keep_every, or keep_tagged, otherwise the files of every checkpoint would be deleted")
+	}
+	return nil
 }
 
 func getDefaultConfig(workingDir string) *Config {
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AddRemote adds a remote to keepsake.yaml at configPath. If makeDefault is
+// true, it is also made the default remote.
+//
+// The file is edited in place, so comments and the order of keys are kept.
+func AddRemote(configPath string, name string, remote *Remote, makeDefault bool) error {
+	return editConfig(configPath, func(root *yaml.Node) error {
+		remotes := mappingValue(root, "remotes")
+		if remotes == nil {
+			remotes = &yaml.Node{Kind: yaml.MappingNode}
+			setMappingValue(root, "remotes", remotes)
+		}
+		if mappingValue(remotes, name) != nil {
+			return fmt.Errorf("There is already a remote called %q in keepsake.yaml. Remove it with 'keepsake remote rm %s' first.", name, name)
+		}
+		setMappingValue(remotes, name, remoteNode(remote))
+		if makeDefault {
+			if mappingValue(root, "repository") != nil {
+				return fmt.Errorf("keepsake.yaml has a 'repository', so a remote can't be the default. Remove 'repository' from keepsake.yaml, or add it as a remote too, then try again.")
+			}
+			setMappingValue(root, "default_remote", scalarNode(name))
+		}
+		return nil
+	})
+}
+
+// RemoveRemote removes a remote from keepsake.yaml at configPath
+func RemoveRemote(configPath string, name string) error {
+	return editConfig(configPath, func(root *yaml.Node) error {
+		remotes := mappingValue(root, "remotes")
+		if remotes == nil || !deleteMappingValue(remotes, name) {
+			return fmt.Errorf("There isn't a remote called %q in keepsake.yaml", name)
+		}
+		if defaultRemote := mappingValue(root, "default_remote"); defaultRemote != nil && defaultRemote.Value == name {
+			return fmt.Errorf("%q is the default remote, so it can't be removed. Change default_remote in keepsake.yaml first.", name)
+		}
+		if len(remotes.Content) == 0 {
+			deleteMappingValue(root, "remotes")
+		}
+		return nil
+	})
+}
+
+// editConfig calls edit with the top-level mapping of the config file, then
+// validates the edited config and writes it back
+func editConfig(configPath string, edit func(root *yaml.Node) error) error {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read config file '%s': %w", configPath, err)
+	}
+	text, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read config file '%s': %w", configPath, err)
+	}
+	doc := new(yaml.Node)
+	if err := yaml.Unmarshal(text, doc); err != nil {
+		return fmt.Errorf("Failed to parse keepsake.yaml: %w", err)
+	}
+	if doc.Kind == 0 {
+		// an empty file
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("Failed to parse keepsake.yaml: it must be a mapping of keys to values")
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if _, err := Parse(buf.Bytes(), filepath.Dir(configPath)); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it, so keepsake.yaml is never
+	// left half-written
+	tmp, err := ioutil.TempFile(filepath.Dir(configPath), "."+filepath.Base(configPath)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), configPath)
+}
+
+func remoteNode(remote *Remote) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(node, "repository", scalarNode(remote.Repository))
+	if remote.Profile != "" {
+		setMappingValue(node, "profile", scalarNode(remote.Profile))
+	}
+	if remote.Endpoint != "" {
+		setMappingValue(node, "endpoint", scalarNode(remote.Endpoint))
+	}
+	if remote.Cache != "" {
+		setMappingValue(node, "cache", scalarNode(string(remote.Cache)))
+	}
+	if remote.ReadOnly {
+		setMappingValue(node, "read_only", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	return node
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// mappingValue returns the value of key in a mapping node, or nil if it
+// doesn't have that key
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, scalarNode(key), value)
+}
+
+func deleteMappingValue(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAndRemoveRemote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "keepsake.yaml")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte("# where experiments go\nrepository: file://.keepsake\n"), 0644))
+
+	require.NoError(t, AddRemote(configPath, "team", &Remote{Repository: "s3://team-models", Profile: "work", ReadOnly: true}, false))
+	conf, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	require.Equal(t, &Remote{Repository: "s3://team-models", Profile: "work", ReadOnly: true}, conf.Remotes["team"])
+	text, err := ioutil.ReadFile(configPath)
+	require.NoError(t, err)
+	require.Contains(t, string(text), "# where experiments go")
+
+	// already exists
+	require.Error(t, AddRemote(configPath, "team", &Remote{Repository: "s3://other"}, false))
+	// can't be the default while there is a top-level repository
+	require.Error(t, AddRemote(configPath, "other", &Remote{Repository: "s3://other"}, true))
+	// invalid remotes aren't written
+	require.Error(t, AddRemote(configPath, "other", &Remote{Repository: "gs://other", Endpoint: "http://localhost:9000"}, false))
+	conf, err = LoadConfig(configPath)
+	require.NoError(t, err)
+	require.Len(t, conf.Remotes, 1)
+
+	require.NoError(t, RemoveRemote(configPath, "team"))
+	conf, err = LoadConfig(configPath)
+	require.NoError(t, err)
+	require.Nil(t, conf.Remotes)
+	require.Error(t, RemoveRemote(configPath, "team"))
+}
+
+func TestRemoveDefaultRemote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "keepsake.yaml")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(""), 0644))
+
+	require.NoError(t, AddRemote(configPath, "team", &Remote{Repository: "s3://team-models"}, true))
+	conf, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	require.Equal(t, "team", conf.DefaultRemote)
+
+	require.Error(t, RemoveRemote(configPath, "team"))
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// DefaultHookTimeout is how long a hook can run for if it doesn't set a timeout
+const DefaultHookTimeout = 30 * time.Second
+
+// The events that hooks can be run on. The hooks for an event are in the key
+// with "on_" in front of it, like on_checkpoint.
+const (
+	EventExperimentStart = "experiment_start"
+	EventCheckpoint      = "checkpoint"
+	EventBestCheckpoint  = "best_checkpoint"
+	EventStop            = "stop"
+	EventCrash           = "crash"
+)
+
+// HookEvents are the events that hooks can be run on
+var HookEvents = []string{EventExperimentStart, EventCheckpoint, EventBestCheckpoint, EventStop, EventCrash}
+
+// Hooks are commands that are run, or URLs that are sent a POST request,
+// when things happen to experiments
+type Hooks struct {
+	OnExperimentStart []*Hook `json:"on_experiment_start,omitempty"`
+	OnCheckpoint      []*Hook `json:"on_checkpoint,omitempty"`
+	// OnBestCheckpoint hooks run when a checkpoint is the best so far, by
+	// its primary metric
+	OnBestCheckpoint []*Hook `json:"on_best_checkpoint,omitempty"`
+	OnStop           []*Hook `json:"on_stop,omitempty"`
+	// OnCrash hooks run when the training script exits with an exception
+	OnCrash []*Hook `json:"on_crash,omitempty"`
+}
+
+// Hook is a shell command or a URL. The details of the event are passed as
+// JSON, on stdin to commands and in the body of the request to URLs.
+type Hook struct {
+	Command string `json:"command,omitempty"`
+	URL     string `json:"url,omitempty"`
+	// Timeout is how long the hook can run for, like "10s". The default is
+	// DefaultHookTimeout.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ForEvent returns the hooks for an event, like "checkpoint" for
+// on_checkpoint
+func (h *Hooks) ForEvent(event string) []*Hook {
+	if h == nil {
+		return nil
+	}
+	switch event {
+	case EventExperimentStart:
+		return h.OnExperimentStart
+	case EventCheckpoint:
+		return h.OnCheckpoint
+	case EventBestCheckpoint:
+		return h.OnBestCheckpoint
+	case EventStop:
+		return h.OnStop
+	case EventCrash:
+		return h.OnCrash
+	}
+	return nil
+}
+
+// Validate returns an error if any of the hooks can't be run
+func (h *Hooks) Validate() error {
+	for _, event := range HookEvents {
+		for _, hook := range h.ForEvent(event) {
+			if err := hook.Validate(); err != nil {
+				return fmt.Errorf("Invalid hook in on_%s: %w", event, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the hook doesn't have exactly one of command
+// and url, or its timeout isn't a duration
+func (h *Hook) Validate() error {
+	if h == nil || (h.Command == "" && h.URL == "") {
+		return fmt.Errorf("it must have either 'command' or 'url'")
+	}
+	if h.Command != "" && h.URL != "" {
+		return fmt.Errorf("it can't have both 'command' and 'url'")
+	}
+	if h.URL != "" {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("url must be an http:// or https:// URL, not %q", h.URL)
+		}
+	}
+	if h.Timeout != "" {
+		timeout, err := time.ParseDuration(h.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("%q is not a valid timeout. It must be a number followed by a unit, like 10s", h.Timeout)
+		}
+	}
+	return nil
+}
+
+// TimeoutDuration returns how long the hook can run for
+func (h *Hook) TimeoutDuration() time.Duration {
+	if h.Timeout == "" {
+		return DefaultHookTimeout
+	}
+	timeout, _ := time.ParseDuration(h.Timeout)
+	return timeout
+}
+
+// String returns the command or URL
+func (h *Hook) String() string {
+	if h.URL != "" {
+		return "POST " + h.URL
+	}
+	return h.Command
+}
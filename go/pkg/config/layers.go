@@ -0,0 +1,367 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/replicate/keepsake/go/pkg/settings"
+)
+
+// LocalConfigFilename is the config file next to keepsake.yaml for settings
+// that shouldn't be committed, like credentials profiles. It takes
+// precedence over keepsake.yaml.
+const LocalConfigFilename = "keepsake.local.yaml"
+
+// UserConfigFilename is the config file in the user settings directory that
+// applies to every project
+const UserConfigFilename = "config.yaml"
+
+// Source is the kind of place a config value came from
+type Source string
+
+const (
+	SourceDefault     Source = "default"
+	SourceUser        Source = "user"
+	SourceProject     Source = "project"
+	SourceLocal       Source = "local"
+	SourceEnvironment Source = "environment"
+	SourceFlag        Source = "flag"
+)
+
+// Origin is where a config value came from
+type Origin struct {
+	Source Source
+	// File and Line are where the value is, for values from config files
+	File string
+	Line int
+	// Name is the environment variable or flag that set the value
+	Name string
+}
+
+func (o Origin) String() string {
+	switch o.Source {
+	case SourceEnvironment:
+		return "environment variable " + o.Name
+	case SourceFlag:
+		return "flag " + o.Name
+	case SourceDefault:
+		return "default"
+	}
+	if o.Line == 0 {
+		return o.File
+	}
+	return fmt.Sprintf("%s:%d", o.File, o.Line)
+}
+
+// Setting is a config value set outside of config files, by a flag or an
+// environment variable
+type Setting struct {
+	// Key is the dotted path of the value, like trash.retention
+	Key   string
+	Value string
+	// Name is the flag or environment variable, like --repository
+	Name string
+}
+
+// environmentSettings are the environment variables that can set config values
+var environmentSettings = []Setting{
+	{Key: "repository", Name: "KEEPSAKE_REPOSITORY"},
+	{Key: "default_remote", Name: "KEEPSAKE_DEFAULT_REMOTE"},
+	{Key: "symlinks", Name: "KEEPSAKE_SYMLINKS"},
+	{Key: "trash.retention", Name: "KEEPSAKE_TRASH_RETENTION"},
+	{Key: "heartbeat.interval", Name: "KEEPSAKE_HEARTBEAT_INTERVAL"},
+	{Key: "heartbeat.timeout", Name: "KEEPSAKE_HEARTBEAT_TIMEOUT"},
+}
+
+// defaultValues are shown by keepsake config for values that aren't set
+var defaultValues = []Value{
+	{Key: "symlinks", Value: string(SymlinksPreserve), Origin: Origin{Source: SourceDefault}},
+}
+
+// alternativeKeys set the same thing in different ways, so if a layer sets
+// one of them, the others are removed from the layers below it
+var alternativeKeys = []string{"repository", "storage", "default_remote"}
+
+// Value is a config value and where it came from
+type Value struct {
+	// Key is the dotted path of the value, like remotes.team.profile
+	Key    string
+	Value  string
+	Origin Origin
+}
+
+// Resolved is the config combined from all of the layers
+type Resolved struct {
+	Config *Config
+
+	root    *yaml.Node
+	origins map[*yaml.Node]Origin
+}
+
+// Values returns every value that is set, in the order they are defined,
+// followed by defaults for values that aren't set
+func (r *Resolved) Values() []Value {
+	values := []Value{}
+	r.flatten(r.root, "", &values)
+	for _, def := range defaultValues {
+		if r.lookup(def.Key) == nil {
+			values = append(values, def)
+		}
+	}
+	return values
+}
+
+// Get returns the value with the dotted path key, or if key is a mapping
+// like trash, all of the values in it. It returns nothing if key isn't set
+// and doesn't have a default.
+func (r *Resolved) Get(key string) []Value {
+	values := []Value{}
+	if node := r.lookup(key); node != nil {
+		r.flatten(node, key, &values)
+		return values
+	}
+	for _, def := range defaultValues {
+		if def.Key == key {
+			values = append(values, def)
+		}
+	}
+	return values
+}
+
+func (r *Resolved) lookup(key string) *yaml.Node {
+	node := r.root
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		node = mappingValue(node, part)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+func (r *Resolved) flatten(node *yaml.Node, key string, values *[]Value) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childKey := node.Content[i].Value
+			if key != "" {
+				childKey = key + "." + childKey
+			}
+			r.flatten(node.Content[i+1], childKey, values)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			r.flatten(child, key+"."+strconv.Itoa(i), values)
+		}
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return
+		}
+		origin := r.origins[node]
+		if origin.Source != SourceEnvironment && origin.Source != SourceFlag {
+			origin.Line = node.Line
+		}
+		*values = append(*values, Value{Key: key, Value: node.Value, Origin: origin})
+	}
+}
+
+// layer is one of the places config is read from
+type layer struct {
+	origin Origin
+	root   *yaml.Node
+	// names are the flags or environment variables that set each value, for
+	// layers that aren't files
+	names map[*yaml.Node]string
+}
+
+// parseLayer parses a config file, interpolates environment variables in it,
+// and checks it only has known keys
+func parseLayer(text []byte, origin Origin) (*layer, error) {
+	l := &layer{origin: origin, root: &yaml.Node{Kind: yaml.MappingNode}}
+	doc := new(yaml.Node)
+	if err := yaml.Unmarshal(text, doc); err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %s", origin.File, err)
+	}
+	if len(doc.Content) == 0 {
+		return l, nil
+	}
+	root := doc.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return l, nil
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: %s must be a mapping of keys to values", location(origin.File, root.Line), origin.File)
+	}
+	if err := interpolate(root, origin.File); err != nil {
+		return nil, err
+	}
+	if err := checkKeys(root, configType, "", origin.File); err != nil {
+		return nil, err
+	}
+	l.root = root
+	return l, nil
+}
+
+// loadLayer parses the config file at p, or returns nil if it doesn't exist
+func loadLayer(p string, source Source) (*layer, error) {
+	text, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Failed to read config file '%s': %w", p, err)
+	}
+	return parseLayer(text, Origin{Source: source, File: p})
+}
+
+// settingsLayer makes a layer from values set by flags or environment
+// variables
+func settingsLayer(source Source, values []Setting) (*layer, error) {
+	l := &layer{origin: Origin{Source: source}, root: &yaml.Node{Kind: yaml.MappingNode}, names: map[*yaml.Node]string{}}
+	for _, setting := range values {
+		parts := strings.Split(setting.Key, ".")
+		mapping := l.root
+		for _, part := range parts[:len(parts)-1] {
+			child := mappingValue(mapping, part)
+			if child == nil {
+				child = &yaml.Node{Kind: yaml.MappingNode}
+				setMappingValue(mapping, part, child)
+			}
+			mapping = child
+		}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: resolveTag(setting.Value), Value: setting.Value}
+		l.names[value] = setting.Name
+		setMappingValue(mapping, parts[len(parts)-1], value)
+	}
+	if err := checkKeys(l.root, configType, "", string(source)); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func environmentLayer() (*layer, error) {
+	values := []Setting{}
+	for _, setting := range environmentSettings {
+		if value := os.Getenv(setting.Name); value != "" {
+			setting.Value = value
+			values = append(values, setting)
+		}
+	}
+	return settingsLayer(SourceEnvironment, values)
+}
+
+func userConfigPath() (string, error) {
+	dir, err := settings.UserSettingsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, UserConfigFilename), nil
+}
+
+// resolveLayers reads all of the layers of config, given the text of
+// keepsake.yaml, and combines them
+func resolveLayers(text []byte, configPath string, flags []Setting) (*Resolved, error) {
+	layers := []*layer{}
+
+	userPath, err := userConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	user, err := loadLayer(userPath, SourceUser)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		layers = append(layers, user)
+	}
+
+	project, err := parseLayer(text, Origin{Source: SourceProject, File: configPath})
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, project)
+
+	local, err := loadLayer(filepath.Join(filepath.Dir(configPath), LocalConfigFilename), SourceLocal)
+	if err != nil {
+		return nil, err
+	}
+	if local != nil {
+		layers = append(layers, local)
+	}
+
+	env, err := environmentLayer()
+	if err != nil {
+		return nil, err
+	}
+	flagLayer, err := settingsLayer(SourceFlag, flags)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, env, flagLayer)
+
+	return resolve(layers, path.Dir(configPath))
+}
+
+// resolve merges layers, from lowest to highest precedence, and decodes the
+// result. Mappings are merged key by key, and other values in higher layers
+// replace the values in lower layers.
+func resolve(layers []*layer, dir string) (*Resolved, error) {
+	resolved := &Resolved{
+		root:    &yaml.Node{Kind: yaml.MappingNode},
+		origins: map[*yaml.Node]Origin{},
+	}
+	for _, l := range layers {
+		for _, key := range alternativeKeys {
+			if mappingValue(l.root, key) == nil {
+				continue
+			}
+			for _, other := range alternativeKeys {
+				if mappingValue(l.root, other) == nil {
+					deleteMappingValue(resolved.root, other)
+				}
+			}
+		}
+		resolved.merge(resolved.root, l.root, l)
+	}
+
+	conf, err := decode(resolved.root, dir)
+	if err != nil {
+		return nil, err
+	}
+	resolved.Config = conf
+	return resolved, nil
+}
+
+func (r *Resolved) merge(dst *yaml.Node, src *yaml.Node, l *layer) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i].Value, src.Content[i+1]
+		if value.Kind == yaml.MappingNode {
+			// copy mappings, so layers aren't changed by the layers above them
+			existing := mappingValue(dst, key)
+			if existing == nil || existing.Kind != yaml.MappingNode {
+				existing = &yaml.Node{Kind: yaml.MappingNode, Line: value.Line}
+				setMappingValue(dst, key, existing)
+			}
+			r.merge(existing, value, l)
+			continue
+		}
+		valueOrigin := l.origin
+		valueOrigin.Name = l.names[value]
+		r.origins[value] = valueOrigin
+		setMappingValue(dst, key, value)
+	}
+}
+
+func location(file string, line int) string {
+	return fmt.Sprintf("%s:%d", file, line)
+}
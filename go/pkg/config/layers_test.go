@@ -0,0 +1,144 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/require"
+)
+
+// setEnv sets environment variables, and returns a function that restores them
+func setEnv(t *testing.T, env map[string]string) func() {
+	previous := map[string]*string{}
+	for name, value := range env {
+		if old, ok := os.LookupEnv(name); ok {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		require.NoError(t, os.Setenv(name, value))
+	}
+	return func() {
+		for name, old := range previous {
+			if old == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *old)
+			}
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	defer setEnv(t, map[string]string{"KEEPSAKE_TEST_BUCKET": "hotdogs", "KEEPSAKE_TEST_KEEP": "3"})()
+
+	conf, err := Parse([]byte(`
+repository: s3://${KEEPSAKE_TEST_BUCKET}/models
+retention:
+  keep_last: ${KEEPSAKE_TEST_KEEP}
+  keep_best: ${KEEPSAKE_TEST_UNSET:-2}
+`), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, "s3://hotdogs/models", conf.Repository)
+	require.Equal(t, 3, conf.Retention.KeepLast)
+	require.Equal(t, 2, conf.Retention.KeepBest)
+
+	// $$ is an escaped $
+	conf, err = Parse([]byte(`repository: "s3://$${KEEPSAKE_TEST_BUCKET}"`), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, "s3://${KEEPSAKE_TEST_BUCKET}", conf.Repository)
+
+	_, err = Parse([]byte("repository: s3://${KEEPSAKE_TEST_UNSET}"), "/foo")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "keepsake.yaml:1")
+	require.Contains(t, err.Error(), "KEEPSAKE_TEST_UNSET")
+}
+
+func TestParseUnknownKeyLineNumbers(t *testing.T) {
+	_, err := Parse([]byte("repository: s3://foo\n\nretention:\n  keep_latest: 3\n"), "/foo")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "keepsake.yaml:4")
+	require.Contains(t, err.Error(), `"keep_latest" in retention`)
+
+	_, err = Parse([]byte("repository: s3://foo\nremotes:\n  team:\n    repository: s3://bar\n    profiel: work\n"), "/foo")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "keepsake.yaml:5")
+
+	_, err = Parse([]byte("repository: s3://foo\nretention:\n  keep_last: lots\n"), "/foo")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "keepsake.yaml:3: retention.keep_last must be a whole number")
+}
+
+func TestResolveConfig(t *testing.T) {
+	home, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer setEnv(t, map[string]string{"HOME": home, "KEEPSAKE_TRASH_RETENTION": "2w"})()
+	homedir.DisableCache = true
+	defer func() { homedir.DisableCache = false }()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config/keepsake"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(home, ".config/keepsake/config.yaml"), []byte(`
+symlinks: skip
+remotes:
+  team:
+    repository: s3://team-models
+trash:
+  retention: 30d
+`), 0644))
+	configPath := filepath.Join(dir, "keepsake.yaml")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(`
+default_remote: team
+remotes:
+  team:
+    repository: s3://hotdog-models
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "keepsake.local.yaml"), []byte(`
+remotes:
+  team:
+    profile: personal
+`), 0644))
+
+	resolved, err := ResolveConfig(configPath, nil)
+	require.NoError(t, err)
+	require.Equal(t, &Config{
+		Symlinks:      SymlinksSkip,
+		DefaultRemote: "team",
+		Remotes: map[string]*Remote{
+			"team": {Repository: "s3://hotdog-models", Profile: "personal"},
+		},
+		Trash: &TrashConfig{Retention: "2w"},
+	}, resolved.Config)
+
+	values := resolved.Get("remotes.team")
+	require.Len(t, values, 2)
+	require.Equal(t, Value{Key: "remotes.team.repository", Value: "s3://hotdog-models", Origin: Origin{Source: SourceProject, File: configPath, Line: 5}}, values[0])
+	require.Equal(t, Origin{Source: SourceLocal, File: filepath.Join(dir, "keepsake.local.yaml"), Line: 4}, values[1].Origin)
+	require.Equal(t, "environment variable KEEPSAKE_TRASH_RETENTION", resolved.Get("trash.retention")[0].Origin.String())
+	require.Equal(t, SourceUser, resolved.Get("symlinks")[0].Origin.Source)
+
+	// flags take precedence over everything, and a repository replaces the
+	// default remote in lower layers
+	resolved, err = ResolveConfig(configPath, []Setting{{Key: "repository", Value: "file://.keepsake", Name: "--repository"}})
+	require.NoError(t, err)
+	require.Equal(t, "file://.keepsake", resolved.Config.Repository)
+	require.Equal(t, "", resolved.Config.DefaultRemote)
+	require.Equal(t, "flag --repository", resolved.Get("repository")[0].Origin.String())
+	require.Empty(t, resolved.Get("default_remote"))
+}
+
+func TestResolveDefaults(t *testing.T) {
+	conf, err := Parse([]byte("repository: s3://foo"), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, SymlinkMode(""), conf.Symlinks)
+
+	resolved, err := resolve([]*layer{}, "/foo")
+	require.NoError(t, err)
+	require.Equal(t, []Value{{Key: "symlinks", Value: "preserve", Origin: Origin{Source: SourceDefault}}}, resolved.Values())
+}
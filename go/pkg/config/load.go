@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
 
-	"github.com/ghodss/yaml"
+	ghodssyaml "github.com/ghodss/yaml"
+	"gopkg.in/yaml.v3"
 
 	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/errors"
@@ -73,8 +73,26 @@ repository: file://%s`, projectDir, deprecatedRepositoryProjectRoot)
 	return conf, filepath.Dir(configPath), nil
 }
 
-// LoadConfig reads and validates keepsake.yaml
+// LoadConfig reads and validates keepsake.yaml, combined with the user
+// config, keepsake.local.yaml, and environment variables
 func LoadConfig(configPath string) (conf *Config, err error) {
+	resolved, err := ResolveConfig(configPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.Config, nil
+}
+
+// ResolveConfig reads keepsake.yaml at configPath and combines it with the
+// other layers of config. From highest to lowest precedence, they are:
+//
+// * flags, which are passed in
+// * environment variables, like KEEPSAKE_REPOSITORY
+// * keepsake.local.yaml, next to keepsake.yaml
+// * keepsake.yaml
+// * the user config, config.yaml in the user settings directory
+// * defaults
+func ResolveConfig(configPath string, flags []Setting) (*Resolved, error) {
 	text, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -82,7 +100,7 @@ func LoadConfig(configPath string) (conf *Config, err error) {
 		}
 		return nil, fmt.Errorf("Failed to read config file '%s': %w", configPath, err)
 	}
-	conf, err = Parse(text, path.Dir(configPath))
+	resolved, err := resolveLayers(text, configPath, flags)
 	if err != nil {
 		// FIXME (bfirsh): implement standard way of displaying config errors so this can be used in other places
 		msg := fmt.Sprintf("%v\n\n", err)
@@ -90,20 +108,37 @@ func LoadConfig(configPath string) (conf *Config, err error) {
 		msg += fmt.Sprintf("%s/docs/reference/yaml", global.WebURL)
 		return nil, fmt.Errorf(msg)
 	}
-	return conf, nil
+	return resolved, nil
 }
 
-// Parse keepsake.yaml
+// Parse keepsake.yaml on its own, without the other config layers
 func Parse(text []byte, dir string) (conf *Config, err error) {
+	l, err := parseLayer(text, Origin{Source: SourceProject, File: global.ConfigFilenames[0]})
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolve([]*layer{l}, dir)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.Config, nil
+}
+
+// decode turns the config from all the layers into a Config and validates it
+func decode(root *yaml.Node, dir string) (conf *Config, err error) {
 	conf = getDefaultConfig(dir)
 
-	j, err := yaml.YAMLToJSON(text)
+	// If it's empty, don't decode, otherwise we get this weird null object that isn't nil
+	if len(root.Content) == 0 {
+		return conf, nil
+	}
+	text, err := yaml.Marshal(root)
 	if err != nil {
 		return nil, err
 	}
-	// If it's an empty file, don't decode, otherwise we get this weird null object that isn't nil
-	if string(j) == "null" {
-		return conf, nil
+	j, err := ghodssyaml.YAMLToJSON(text)
+	if err != nil {
+		return nil, err
 	}
 
 	decoder := json.NewDecoder(bytes.NewReader(j))
@@ -123,10 +158,55 @@ func Parse(text []byte, dir string) (conf *Config, err error) {
 		conf.Storage = ""
 	}
 
-	if conf.Repository == "" {
+	for name, remote := range conf.Remotes {
+		if remote == nil {
+			return nil, fmt.Errorf("Missing required field in remote %q: repository", name)
+		}
+		if err := remote.Validate(name); err != nil {
+			return nil, err
+		}
+	}
+	if conf.DefaultRemote != "" {
+		if conf.Repository != "" {
+			return nil, fmt.Errorf("'repository' and 'default_remote' cannot both be defined in keepsake.yaml")
+		}
+		if _, ok := conf.Remotes[conf.DefaultRemote]; !ok {
+			return nil, fmt.Errorf("default_remote in keepsake.yaml is %q, but there isn't a remote with that name", conf.DefaultRemote)
+		}
+	}
+
+	if conf.Repository == "" && conf.DefaultRemote == "" {
 		return nil, fmt.Errorf("Missing required field in keepsake.yaml: repository")
 	}
 
+	if conf.Retention != nil {
+		if err := conf.Retention.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := conf.Symlinks.Validate(); err != nil {
+		return nil, err
+	}
+
+	if conf.Trash != nil {
+		if err := conf.Trash.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Hooks != nil {
+		if err := conf.Hooks.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Heartbeat != nil {
+		if err := conf.Heartbeat.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	return conf, nil
 }
 
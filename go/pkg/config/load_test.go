@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/kami-zh/go-capturer"
 	"github.com/stretchr/testify/require"
@@ -123,6 +124,45 @@ func TestParse(t *testing.T) {
 
 }
 
+func TestParseRetention(t *testing.T) {
+	conf, err := Parse([]byte(`
+repository: s3://foobar
+retention:
+  keep_last: 3
+  keep_best: 2
+  keep_every: 10
+  keep_tagged: true
+`), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, &Config{
+		Repository: "s3://foobar",
+		Retention: &RetentionPolicy{
+			KeepLast:   3,
+			KeepBest:   2,
+			KeepEvery:  10,
+			KeepTagged: true,
+		},
+	}, conf)
+
+	_, err = Parse([]byte("repository: s3://foobar\nretention: {}"), "/foo")
+	require.Error(t, err)
+
+	_, err = Parse([]byte("repository: s3://foobar\nretention:\n  keep_last: -1"), "/foo")
+	require.Error(t, err)
+
+	_, err = Parse([]byte("repository: s3://foobar\nretention:\n  keep_newest: 1"), "/foo")
+	require.Error(t, err)
+}
+
+func TestParseSymlinks(t *testing.T) {
+	conf, err := Parse([]byte("repository: s3://foobar\nsymlinks: follow"), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, SymlinksFollow, conf.Symlinks)
+
+	_, err = Parse([]byte("repository: s3://foobar\nsymlinks: copy"), "/foo")
+	require.Error(t, err)
+}
+
 func TestStorageBackwardsCompatible(t *testing.T) {
 	conf, err := Parse([]byte("storage: 's3://foobar'"), "")
 	require.NoError(t, err)
@@ -146,3 +186,121 @@ func TestDeprecatedRepositoryBackwardsCompatible(t *testing.T) {
 	}, conf)
 	require.Equal(t, tmpDir, projectDir)
 }
+
+func TestParseHeartbeat(t *testing.T) {
+	conf, err := Parse([]byte("repository: s3://foobar"), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, DefaultHeartbeatInterval, conf.Heartbeat.IntervalDuration())
+	require.Equal(t, DefaultHeartbeatTimeout, conf.Heartbeat.TimeoutDuration())
+
+	conf, err = Parse([]byte("repository: s3://foobar\nheartbeat:\n  interval: 1m\n  timeout: 15m"), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, conf.Heartbeat.IntervalDuration())
+	require.Equal(t, 15*time.Minute, conf.Heartbeat.TimeoutDuration())
+
+	_, err = Parse([]byte("repository: s3://foobar\nheartbeat:\n  interval: often"), "/foo")
+	require.Error(t, err)
+	// the default timeout is shorter than the interval
+	_, err = Parse([]byte("repository: s3://foobar\nheartbeat:\n  interval: 1m"), "/foo")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must be longer than the interval")
+}
+
+func TestParseTrash(t *testing.T) {
+	conf, err := Parse([]byte("repository: s3://foobar\ntrash:\n  retention: 30d"), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, 30*24*time.Hour, conf.Trash.RetentionAge())
+
+	_, err = Parse([]byte("repository: s3://foobar\ntrash:\n  retention: a month"), "/foo")
+	require.Error(t, err)
+
+	age, err := ParseAge("36h")
+	require.NoError(t, err)
+	require.Equal(t, 36*time.Hour, age)
+	age, err = ParseAge("2w")
+	require.NoError(t, err)
+	require.Equal(t, 14*24*time.Hour, age)
+	_, err = ParseAge("-1d")
+	require.Error(t, err)
+}
+
+func TestParseRemotes(t *testing.T) {
+	conf, err := Parse([]byte(`
+default_remote: team
+remotes:
+  team:
+    repository: s3://team-models
+    profile: work
+    cache: never
+  minio:
+    repository: s3://models
+    endpoint: http://localhost:9000
+  archive:
+    repository: gs://archive
+    read_only: true
+`), "/foo")
+	require.NoError(t, err)
+	url, err := conf.RepositoryURL("")
+	require.NoError(t, err)
+	require.Equal(t, "s3://team-models?cache=never&profile=work", url)
+	url, err = conf.RepositoryURL("minio")
+	require.NoError(t, err)
+	require.Equal(t, "s3://models?endpoint=http%3A%2F%2Flocalhost%3A9000", url)
+	url, err = conf.RepositoryURL("archive")
+	require.NoError(t, err)
+	require.Equal(t, "gs://archive?mode=read-only", url)
+	_, err = conf.RepositoryURL("origin")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "archive, minio, team")
+
+	// remotes without a default use the top-level repository
+	conf, err = Parse([]byte("repository: file://.keepsake\nremotes:\n  team:\n    repository: s3://team-models"), "/foo")
+	require.NoError(t, err)
+	url, err = conf.RepositoryURL("")
+	require.NoError(t, err)
+	require.Equal(t, "file://.keepsake", url)
+
+	for _, text := range []string{
+		"repository: s3://foo\ndefault_remote: team\nremotes:\n  team:\n    repository: s3://bar",
+		"default_remote: team",
+		"remotes:\n  team:\n    repository: s3://bar",
+		"repository: s3://foo\nremotes:\n  team:\n    profile: work",
+		"repository: s3://foo\nremotes:\n  team:\n    repository: gs://bar\n    profile: work",
+		"repository: s3://foo\nremotes:\n  team:\n    repository: s3://bar\n    cache: sometimes",
+		"repository: s3://foo\nremotes:\n  team:\n    repository: s3://bar\n    colour: blue",
+	} {
+		_, err := Parse([]byte(text), "/foo")
+		require.Error(t, err, text)
+	}
+}
+
+func TestParseHooks(t *testing.T) {
+	conf, err := Parse([]byte(`
+repository: s3://foo
+hooks:
+  on_checkpoint:
+    - command: echo "checkpoint $KEEPSAKE_CHECKPOINT_ID"
+  on_best_checkpoint:
+    - url: https://hooks.slack.com/services/abc
+      timeout: 5s
+`), "/foo")
+	require.NoError(t, err)
+	require.Equal(t, []*Hook{{Command: `echo "checkpoint $KEEPSAKE_CHECKPOINT_ID"`}}, conf.Hooks.ForEvent(EventCheckpoint))
+	best := conf.Hooks.ForEvent(EventBestCheckpoint)
+	require.Len(t, best, 1)
+	require.Equal(t, 5*time.Second, best[0].TimeoutDuration())
+	require.Equal(t, DefaultHookTimeout, conf.Hooks.OnCheckpoint[0].TimeoutDuration())
+	require.Empty(t, conf.Hooks.ForEvent(EventStop))
+
+	for _, text := range []string{
+		"repository: s3://foo\nhooks:\n  on_checkpoint:\n    - {}",
+		"repository: s3://foo\nhooks:\n  on_checkpoint:\n    - command: echo\n      url: http://localhost",
+		"repository: s3://foo\nhooks:\n  on_checkpoint:\n    - url: localhost:8000",
+		"repository: s3://foo\nhooks:\n  on_checkpoint:\n    - command: echo\n      timeout: soon",
+		"repository: s3://foo\nhooks:\n  on_save:\n    - command: echo",
+		"repository: s3://foo\nhooks:\n  on_checkpoint:\n    command: echo",
+	} {
+		_, err := Parse([]byte(text), "/foo")
+		require.Error(t, err, text)
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var configType = reflect.TypeOf(Config{})
+
+// deprecatedKeys are accepted, but not suggested in errors
+var deprecatedKeys = []string{"storage"}
+
+// checkKeys returns an error with the line number if node has keys that
+// aren't in t, or values of the wrong type. String values are tagged as
+// strings, so numbers aren't decoded as numbers.
+func checkKeys(node *yaml.Node, t reflect.Type, key string, file string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return typeError(node, key, "a mapping of keys to values", file)
+		}
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := node.Content[i]
+			fieldType, ok := fields[child.Value]
+			if !ok {
+				return unknownKeyError(child, key, fields, file)
+			}
+			if err := checkKeys(node.Content[i+1], fieldType, joinKey(key, child.Value), file); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return typeError(node, key, "a mapping of names to values", file)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := checkKeys(node.Content[i+1], t.Elem(), joinKey(key, node.Content[i].Value), file); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return typeError(node, key, "a list", file)
+		}
+		for i, child := range node.Content {
+			if err := checkKeys(child, t.Elem(), joinKey(key, strconv.Itoa(i)), file); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			return typeError(node, key, "a string", file)
+		}
+		node.Tag = "!!str"
+	case reflect.Int, reflect.Int64:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			return typeError(node, key, "a whole number", file)
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			return typeError(node, key, "true or false", file)
+		}
+	}
+	return nil
+}
+
+// jsonFields returns the types of the fields of t, by their JSON names
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func unknownKeyError(node *yaml.Node, key string, fields map[string]reflect.Type, file string) error {
+	names := []string{}
+	for name := range fields {
+		deprecated := false
+		for _, d := range deprecatedKeys {
+			deprecated = deprecated || name == d
+		}
+		if !deprecated {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	where := ""
+	if key != "" {
+		where = " in " + key
+	}
+	return fmt.Errorf("%s: Unknown key %q%s. It must be one of: %s", location(file, node.Line), node.Value, where, strings.Join(names, ", "))
+}
+
+func typeError(node *yaml.Node, key string, expected string, file string) error {
+	return fmt.Errorf("%s: %s must be %s", location(file, node.Line), key, expected)
+}
+
+func joinKey(key string, child string) string {
+	if key == "" {
+		return child
+	}
+	return key + "." + child
+}
+
+// interpolationPattern matches ${VAR}, ${VAR:-default}, and $$, which is an
+// escaped $
+var interpolationPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replaces ${VAR} in the values in node with the environment
+// variable VAR, or with default if it is written ${VAR:-default} and VAR
+// isn't set or is empty. It is an error for VAR to not be set otherwise.
+func interpolate(node *yaml.Node, file string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		// keys aren't interpolated, only values
+		for i := 1; i < len(node.Content); i += 2 {
+			// hook commands are run by a shell, which expands variables in
+			// them itself, including the ones set for the hook
+			if node.Content[i-1].Value == "command" {
+				continue
+			}
+			if err := interpolate(node.Content[i], file); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := interpolate(child, file); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+		var err error
+		value := interpolationPattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+			if match == "$$" {
+				return "$"
+			}
+			groups := interpolationPattern.FindStringSubmatch(match)
+			if value := os.Getenv(groups[1]); value != "" {
+				return value
+			}
+			if groups[2] != "" {
+				return groups[3]
+			}
+			if _, ok := os.LookupEnv(groups[1]); !ok && err == nil {
+				err = fmt.Errorf("%s: The environment variable %s isn't set. Set it, or give it a default with ${%s:-default}", location(file, node.Line), groups[1], groups[1])
+			}
+			return ""
+		})
+		if err != nil {
+			return err
+		}
+		if value != node.Value && node.Style == 0 {
+			// unquoted values are typed by what they are after interpolation
+			node.Tag = resolveTag(value)
+		}
+		node.Value = value
+	}
+	return nil
+}
+
+// resolveTag returns the YAML tag of an unquoted scalar
+func resolveTag(value string) string {
+	switch value {
+	case "true", "false":
+		return "!!bool"
+	case "", "null", "~":
+		return "!!null"
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return "!!int"
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return "!!float"
+	}
+	return "!!str"
+}
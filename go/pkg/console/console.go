@@ -2,10 +2,14 @@
 package console
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/logrusorgru/aurora"
 	"github.com/mitchellh/go-wordwrap"
@@ -19,32 +23,121 @@ type Console struct {
 	Color     bool
 	IsMachine bool
 	Level     Level
-	mu        sync.Mutex
+	// Format is how log messages are written. The default is FormatText.
+	Format Format
+	// Writer is where log messages are written. The default is stderr.
+	Writer io.Writer
+	mu     sync.Mutex
+}
+
+// Format is how log messages are written
+type Format string
+
+const (
+	// FormatText is for humans, with colors and word wrapping
+	FormatText Format = "text"
+	// FormatJSON is a JSON object on each line, with the level, time,
+	// message, and fields of each message
+	FormatJSON Format = "json"
+)
+
+// ParseFormat parses a format string
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatText, FormatJSON:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("Unknown log format: %q. It must be %q or %q", s, FormatText, FormatJSON)
+}
+
+// Fields are structured data attached to log messages, like the ID of the
+// experiment a message is about. They are only written in the JSON format.
+type Fields map[string]interface{}
+
+// Entry is a log message with fields, which is written by calling one of its
+// level methods
+type Entry struct {
+	console *Console
+	fields  Fields
+}
+
+// WithFields returns an entry that writes messages with fields
+func (c *Console) WithFields(fields Fields) *Entry {
+	return &Entry{console: c, fields: fields}
+}
+
+// WithField returns an entry that writes messages with a field
+func (c *Console) WithField(key string, value interface{}) *Entry {
+	return c.WithFields(Fields{key: value})
+}
+
+// WithFields returns an entry with the entry's fields and fields
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := Fields{}
+	for k, v := range e.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Entry{console: e.console, fields: merged}
+}
+
+// WithField returns an entry with the entry's fields and a field
+func (e *Entry) WithField(key string, value interface{}) *Entry {
+	return e.WithFields(Fields{key: value})
+}
+
+// Debug level message
+func (e *Entry) Debug(msg string, v ...interface{}) {
+	e.console.log(DebugLevel, e.fields, msg, v...)
+}
+
+// Info level message
+func (e *Entry) Info(msg string, v ...interface{}) {
+	e.console.log(InfoLevel, e.fields, msg, v...)
+}
+
+// Warn level message
+func (e *Entry) Warn(msg string, v ...interface{}) {
+	e.console.log(WarnLevel, e.fields, msg, v...)
+}
+
+// Error level message
+func (e *Entry) Error(msg string, v ...interface{}) {
+	e.console.log(ErrorLevel, e.fields, msg, v...)
 }
 
 // Debug level message
 func (c *Console) Debug(msg string, v ...interface{}) {
-	c.log(DebugLevel, msg, v...)
+	c.log(DebugLevel, nil, msg, v...)
 }
 
 // Info level message
 func (c *Console) Info(msg string, v ...interface{}) {
-	c.log(InfoLevel, msg, v...)
+	c.log(InfoLevel, nil, msg, v...)
 }
 
 // Warn level message
 func (c *Console) Warn(msg string, v ...interface{}) {
-	c.log(WarnLevel, msg, v...)
+	c.log(WarnLevel, nil, msg, v...)
 }
 
 // Error level message
 func (c *Console) Error(msg string, v ...interface{}) {
-	c.log(ErrorLevel, msg, v...)
+	c.log(ErrorLevel, nil, msg, v...)
 }
 
-// Fatal level message, followed by exit
+// Fatal level message, followed by exit. If messages are written to a log
+// file, the message is also written to stderr, so it isn't missed.
 func (c *Console) Fatal(msg string, v ...interface{}) {
-	c.log(FatalLevel, msg, v...)
+	c.log(FatalLevel, nil, msg, v...)
+	if c.Writer != nil && c.Writer != os.Stderr {
+		formattedMsg := fmt.Sprintf(msg, v...)
+		c.mu.Lock()
+		fmt.Fprintln(os.Stderr, formattedMsg)
+		c.mu.Unlock()
+	}
 	os.Exit(1)
 }
 
@@ -62,23 +155,38 @@ func (c *Console) OutputErr(line string) {
 	fmt.Fprintln(os.Stderr, line)
 }
 
-// DebugOutput a line to stdout. Like Output, but only when level is DebugLevel.
+// DebugOutput a line to the log. Like Output, but only when level is DebugLevel.
 func (c *Console) DebugOutput(line string) {
 	if c.Level > DebugLevel {
 		return
 	}
+	if c.Format == FormatJSON {
+		c.logJSON(DebugLevel, nil, line)
+		return
+	}
 	if c.Color {
 		line = aurora.Faint(line).String()
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	fmt.Fprintln(os.Stderr, line)
+	fmt.Fprintln(c.writer(), line)
+}
+
+func (c *Console) writer() io.Writer {
+	if c.Writer == nil {
+		return os.Stderr
+	}
+	return c.Writer
 }
 
-func (c *Console) log(level Level, msg string, v ...interface{}) {
+func (c *Console) log(level Level, fields Fields, msg string, v ...interface{}) {
 	if level < c.Level {
 		return
 	}
+	if c.Format == FormatJSON {
+		c.logJSON(level, fields, fmt.Sprintf(msg, v...))
+		return
+	}
 
 	prompt := "═══╡ "
 	continuationPrompt := "   │ "
@@ -123,6 +231,49 @@ func (c *Console) log(level Level, msg string, v ...interface{}) {
 		} else {
 			line = continuationPrompt + line
 		}
-		fmt.Fprintln(os.Stderr, line)
+		fmt.Fprintln(c.writer(), line)
+	}
+}
+
+// logJSON writes a message as a JSON object on a single line. Fields can't
+// replace the level, time, or message.
+func (c *Console) logJSON(level Level, fields Fields, msg string) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if k != "level" && k != "time" && k != "message" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// written by hand so the keys are always in the same order
+	var b strings.Builder
+	b.WriteString(`{"time":`)
+	writeJSONValue(&b, time.Now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSONValue(&b, level.String())
+	b.WriteString(`,"message":`)
+	writeJSONValue(&b, msg)
+	for _, k := range keys {
+		b.WriteString(",")
+		writeJSONValue(&b, k)
+		b.WriteString(":")
+		writeJSONValue(&b, fields[k])
+	}
+	b.WriteString("}")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintln(c.writer(), b.String())
+}
+
+func writeJSONValue(b *strings.Builder, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%v", v))
 	}
+	b.Write(data)
 }
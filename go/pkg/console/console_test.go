@@ -0,0 +1,92 @@
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFormat(t *testing.T) {
+	out := new(bytes.Buffer)
+	c := &Console{Level: InfoLevel, Format: FormatJSON, Writer: out}
+
+	c.WithFields(Fields{"experiment_id": "abc123", "message": "not this"}).WithField("checkpoint_id", "def456").Warn("Failed to upload %s", "weights.pth")
+	c.Debug("not written, because of the level")
+	c.WithField("error", fmt.Errorf("oh no")).Error("Multiple\nlines")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], `{"time":"`), lines[0])
+
+	first := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	delete(first, "time")
+	require.Equal(t, map[string]interface{}{
+		"level":         "warn",
+		"message":       "Failed to upload weights.pth",
+		"experiment_id": "abc123",
+		"checkpoint_id": "def456",
+	}, first)
+
+	second := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	require.Equal(t, "Multiple\nlines", second["message"])
+	require.Equal(t, "oh no", second["error"])
+}
+
+func TestTextFormatWithFields(t *testing.T) {
+	out := new(bytes.Buffer)
+	c := &Console{Level: InfoLevel, Writer: out}
+	// fields are only for machines, so they aren't written in the text format
+	c.WithField("experiment_id", "abc123").Info("Creating experiment")
+	require.Equal(t, "═══╡ Creating experiment\n", out.String())
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("json")
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, format)
+	_, err = ParseFormat("xml")
+	require.Error(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs", "keepsake.log")
+
+	f, err := OpenRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n", "six\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	read := func(p string) string {
+		data, err := ioutil.ReadFile(p)
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Equal(t, "six\n", read(path))
+	require.Equal(t, "four\nfive\n", read(path+".1"))
+	require.Equal(t, "three\n", read(path+".2"))
+	require.NoFileExists(t, path+".3")
+
+	// it is appended to when it is opened again
+	f, err = OpenRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("seven\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Equal(t, "six\nseven\n", read(path))
+	require.Equal(t, "four\nfive\n", read(path+".1"))
+}
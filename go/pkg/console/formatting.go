@@ -1,6 +1,7 @@
 package console
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/xeonx/timeago"
@@ -9,3 +10,17 @@ import (
 func FormatTime(t time.Time) string {
 	return timeago.English.Format(t)
 }
+
+// FormatBytes formats a number of bytes with a unit, e.g. "1.5 MB"
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -1,6 +1,7 @@
 package console
 
 import (
+	"io"
 	"os"
 
 	"github.com/mattn/go-isatty"
@@ -23,6 +24,28 @@ func SetColor(color bool) {
 	ConsoleInstance.Color = color
 }
 
+// SetFormat sets how log messages are written
+func SetFormat(format Format) {
+	ConsoleInstance.Format = format
+}
+
+// SetWriter sets where log messages are written. nil is stderr.
+func SetWriter(w io.Writer) {
+	ConsoleInstance.Writer = w
+}
+
+// WithFields returns an entry that writes messages with fields. For example:
+//
+//	console.WithFields(console.Fields{"experiment_id": exp.ID}).Info("Saved experiment")
+func WithFields(fields Fields) *Entry {
+	return ConsoleInstance.WithFields(fields)
+}
+
+// WithField returns an entry that writes messages with a field
+func WithField(key string, value interface{}) *Entry {
+	return ConsoleInstance.WithField(key, value)
+}
+
 // Debug level message.
 func Debug(msg string, v ...interface{}) {
 	ConsoleInstance.Debug(msg, v...)
@@ -58,7 +81,7 @@ func OutputErr(line string) {
 	ConsoleInstance.OutputErr(line)
 }
 
-// DebugOutput a line to stdout. Like Output, but only when level is DebugLevel.
+// DebugOutput a line to the log. Like Output, but only when level is DebugLevel.
 func DebugOutput(line string) {
 	ConsoleInstance.DebugOutput(line)
 }
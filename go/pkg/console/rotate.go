@@ -0,0 +1,116 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RotatingFile is a log file that is rotated when it gets too big. The file
+// is renamed with .1 on the end, the previous .1 file is renamed .2, and so
+// on, up to MaxBackups old files.
+type RotatingFile struct {
+	Path string
+	// MaxSize is the size in bytes the file can grow to before it is rotated
+	MaxSize int64
+	// MaxBackups is how many rotated files are kept. Older ones are deleted.
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens a log file for appending, creating it and its
+// directory if they don't exist
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("The maximum size of a log file must be more than 0")
+	}
+	if maxBackups < 0 {
+		return nil, fmt.Errorf("The number of old log files to keep can't be negative")
+	}
+	f := &RotatingFile{Path: path, MaxSize: maxSize, MaxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create directory for log file %s: %w", path, err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open log file %s: %w", f.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to open log file %s: %w", f.Path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write writes p to the file, rotating it first if p would make it bigger
+// than MaxSize. p is never split across files.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	if f.MaxBackups == 0 {
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+	if err := os.Remove(f.backupPath(f.MaxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := f.MaxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backupPath(i), f.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(f.Path, f.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", f.Path, i)
+}
+
+// Close closes the file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -12,6 +12,10 @@ const (
 	CodeIncompatibleRepositoryVersion = "INCOMPATIBLE_REPOSITORY_VERSION"
 	CodeCorruptedRepositorySpec       = "CORRUPTED_REPOSITORY_SPEC"
 	CodeConfigNotFound                = "CONFIG_NOT_FOUND"
+	CodeCorruptedTarball              = "CORRUPTED_TARBALL"
+	CodeConflict                      = "CONFLICT"
+	CodeRepositoryReadOnly            = "REPOSITORY_READ_ONLY"
+	CodeUnsafeSymlink                 = "UNSAFE_SYMLINK"
 )
 
 // TODO: support wrapping https://blog.golang.org/go1.13-errors
@@ -40,6 +44,22 @@ func IsConfigNotFound(err error) bool {
 	return Code(err) == CodeConfigNotFound
 }
 
+func IsCorruptedTarball(err error) bool {
+	return Code(err) == CodeCorruptedTarball
+}
+
+func IsConflict(err error) bool {
+	return Code(err) == CodeConflict
+}
+
+func IsRepositoryReadOnly(err error) bool {
+	return Code(err) == CodeRepositoryReadOnly
+}
+
+func IsUnsafeSymlink(err error) bool {
+	return Code(err) == CodeUnsafeSymlink
+}
+
 func DoesNotExist(msg string) error { return &codedError{code: CodeDoesNotExist, msg: msg} }
 func ReadError(msg string) error    { return &codedError{code: CodeReadError, msg: msg} }
 func WriteError(msg string) error   { return &codedError{code: CodeWriteError, msg: msg} }
@@ -79,6 +99,28 @@ where VERSION is an integer.`, rootURL, specPath, err),
 	}
 }
 
+func CorruptedTarball(msg string) error {
+	return &codedError{code: CodeCorruptedTarball, msg: msg}
+}
+
+// Conflict is returned when a conditional write fails because the data has
+// been changed by someone else since it was read
+func Conflict(msg string) error {
+	return &codedError{code: CodeConflict, msg: msg}
+}
+
+// RepositoryReadOnly is returned when a change is refused because the
+// repository is read-only or append-only
+func RepositoryReadOnly(msg string) error {
+	return &codedError{code: CodeRepositoryReadOnly, msg: msg}
+}
+
+// UnsafeSymlink is returned when files would be written through a symlink
+// that was already in the output directory and points outside it
+func UnsafeSymlink(msg string) error {
+	return &codedError{code: CodeUnsafeSymlink, msg: msg}
+}
+
 func Code(err error) string {
 	if cerr, ok := err.(CodedError); ok {
 		return cerr.Code()
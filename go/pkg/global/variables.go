@@ -9,9 +9,14 @@ var Verbose = false
 var WebURL = "https://keepsake.ai"
 var Color = true
 var ProjectDirectory = ""
+var Remote = ""
 var BugsEmail = "bugs@replicate.ai"
 var SegmentKey = "MKaYmSZ2hW6P8OegI9g0sufjZeUh28g7"
 var S3Region = "us-east-1"
+var LogFormat = "text"
+var LogFile = ""
+var LogMaxSize = 100 // megabytes
+var LogMaxBackups = 3
 
 func init() {
 	if Environment == "development" {
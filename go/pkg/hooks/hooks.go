@@ -0,0 +1,193 @@
+// Package hooks runs the commands and webhooks in the hooks section of
+// keepsake.yaml when things happen to experiments
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/global"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+// Event is what hooks are passed, as JSON
+type Event struct {
+	Event        string              `json:"event"`
+	Time         time.Time           `json:"time"`
+	ExperimentID string              `json:"experiment_id"`
+	CheckpointID string              `json:"checkpoint_id,omitempty"`
+	Experiment   *project.Experiment `json:"experiment,omitempty"`
+	Checkpoint   *project.Checkpoint `json:"checkpoint,omitempty"`
+	// Error is the exception the training script exited with, for crashes
+	Error string `json:"error,omitempty"`
+	// Test is true for events sent by "keepsake hooks test"
+	Test bool `json:"test,omitempty"`
+}
+
+// NewEvent returns an event for an experiment and, if it is about a
+// checkpoint, chk. event is one of config.HookEvents.
+func NewEvent(event string, exp *project.Experiment, chk *project.Checkpoint) *Event {
+	e := &Event{
+		Event:        event,
+		Time:         time.Now().UTC(),
+		ExperimentID: exp.ID,
+		Experiment:   exp,
+		Checkpoint:   chk,
+	}
+	if chk != nil {
+		e.CheckpointID = chk.ID
+	}
+	return e
+}
+
+// logEntry returns a log entry with the event's IDs
+func (e *Event) logEntry() *console.Entry {
+	fields := console.Fields{"event": e.Event, "experiment_id": e.ExperimentID}
+	if e.CheckpointID != "" {
+		fields["checkpoint_id"] = e.CheckpointID
+	}
+	return console.WithFields(fields)
+}
+
+// Runner runs hooks in the background, one at a time in the order events
+// happen. Hooks that fail or time out are logged, and don't stop other hooks
+// or the caller.
+type Runner struct {
+	hooks *config.Hooks
+	dir   string
+
+	queue chan *Event
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewRunner returns a runner for hooks, which runs commands in dir
+func NewRunner(hooks *config.Hooks, dir string) *Runner {
+	return &Runner{hooks: hooks, dir: dir, queue: make(chan *Event, 100)}
+}
+
+// Fire runs the hooks for an event in the background. If too many events are
+// waiting for their hooks to run, the event is dropped.
+func (r *Runner) Fire(event *Event) {
+	if r == nil || len(r.hooks.ForEvent(event.Event)) == 0 {
+		return
+	}
+	r.once.Do(func() { go r.run() })
+	r.wg.Add(1)
+	select {
+	case r.queue <- event:
+	default:
+		r.wg.Done()
+		event.logEntry().Warn("Too many hooks are waiting to run, so the on_%s hooks for experiment %s were skipped", event.Event, event.ExperimentID)
+	}
+}
+
+// Wait waits for the hooks of the events that have been fired to finish
+func (r *Runner) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
+
+func (r *Runner) run() {
+	for event := range r.queue {
+		for _, hook := range r.hooks.ForEvent(event.Event) {
+			if output, err := Run(hook, event, r.dir); err != nil {
+				event.logEntry().Warn("Hook on_%s failed: %s: %v%s", event.Event, hook, err, formatOutput(output))
+			} else {
+				event.logEntry().Debug("Hook on_%s ran: %s%s", event.Event, hook, formatOutput(output))
+			}
+		}
+		r.wg.Done()
+	}
+}
+
+// Run runs a hook for an event, waiting until it finishes or times out, and
+// returns its output
+func Run(hook *config.Hook, event *Event, dir string) ([]byte, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hook.TimeoutDuration())
+	defer cancel()
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	// Processes started by a command can outlive it and keep its output open,
+	// so don't wait for the output after the timeout
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		if hook.URL != "" {
+			res.output, res.err = post(ctx, hook.URL, body)
+		} else {
+			res.output, res.err = runCommand(ctx, hook.Command, body, event, dir)
+		}
+		done <- res
+	}()
+	select {
+	case res := <-done:
+		if ctx.Err() == context.DeadlineExceeded {
+			return res.output, fmt.Errorf("timed out after %s", hook.TimeoutDuration())
+		}
+		return res.output, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out after %s", hook.TimeoutDuration())
+	}
+}
+
+func runCommand(ctx context.Context, command string, body []byte, event *Event, dir string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"KEEPSAKE_EVENT="+event.Event,
+		"KEEPSAKE_EXPERIMENT_ID="+event.ExperimentID,
+		"KEEPSAKE_CHECKPOINT_ID="+event.CheckpointID,
+	)
+	return cmd.CombinedOutput()
+}
+
+func post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "keepsake/"+global.Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	output := new(bytes.Buffer)
+	// the response is only used in messages, so it doesn't matter if it
+	// can't all be read
+	_, _ = output.ReadFrom(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return output.Bytes(), fmt.Errorf("%s responded with %s", url, resp.Status)
+	}
+	return output.Bytes(), nil
+}
+
+func formatOutput(output []byte) string {
+	s := strings.TrimSpace(string(output))
+	if s == "" {
+		return ""
+	}
+	return "\n" + s
+}
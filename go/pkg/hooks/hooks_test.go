@@ -0,0 +1,125 @@
+package hooks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+func testEvent(event string) *Event {
+	exp := project.NewExperiment(param.ValueMap{"learning_rate": param.Float(0.01)})
+	chk := project.NewCheckpoint(param.ValueMap{"loss": param.Float(0.1)})
+	exp.Checkpoints = []*project.Checkpoint{chk}
+	return NewEvent(event, exp, chk)
+}
+
+func TestRunURL(t *testing.T) {
+	received := make(chan *Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		event := new(Event)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(event))
+		received <- event
+	}))
+	defer server.Close()
+
+	event := testEvent(config.EventCheckpoint)
+	_, err := Run(&config.Hook{URL: server.URL}, event, "")
+	require.NoError(t, err)
+	got := <-received
+	require.Equal(t, config.EventCheckpoint, got.Event)
+	require.Equal(t, event.ExperimentID, got.ExperimentID)
+	require.Equal(t, event.CheckpointID, got.CheckpointID)
+	require.Equal(t, event.CheckpointID, got.Checkpoint.ID)
+}
+
+func TestRunURLFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(time.Second)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("something went wrong"))
+	}))
+	defer server.Close()
+
+	output, err := Run(&config.Hook{URL: server.URL}, testEvent(config.EventStop), "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "500")
+	require.Equal(t, "something went wrong", string(output))
+
+	_, err = Run(&config.Hook{URL: server.URL + "/slow", Timeout: "50ms"}, testEvent(config.EventStop), "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out")
+}
+
+func TestRunCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	event := testEvent(config.EventCheckpoint)
+	// the command is run in the project directory, with the event on stdin
+	output, err := Run(&config.Hook{Command: `cat > event.json; echo "$KEEPSAKE_EVENT $KEEPSAKE_EXPERIMENT_ID"`}, event, dir)
+	require.NoError(t, err)
+	require.Equal(t, "checkpoint "+event.ExperimentID+"\n", string(output))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "event.json"))
+	require.NoError(t, err)
+	got := new(Event)
+	require.NoError(t, json.Unmarshal(data, got))
+	require.Equal(t, event.CheckpointID, got.CheckpointID)
+
+	output, err = Run(&config.Hook{Command: "echo oh no; exit 1"}, event, dir)
+	require.Error(t, err)
+	require.Equal(t, "oh no\n", string(output))
+
+	start := time.Now()
+	_, err = Run(&config.Hook{Command: "sleep 10", Timeout: "50ms"}, event, dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out")
+	require.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
+
+func TestRunner(t *testing.T) {
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := new(Event)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(event))
+		received <- event.Event
+	}))
+	defer server.Close()
+
+	// a failing hook doesn't stop the hooks after it from running
+	runner := NewRunner(&config.Hooks{
+		OnExperimentStart: []*config.Hook{{Command: "exit 1"}, {URL: server.URL}},
+		OnStop:            []*config.Hook{{URL: server.URL}},
+	}, "")
+	runner.Fire(testEvent(config.EventExperimentStart))
+	runner.Fire(testEvent(config.EventCheckpoint))
+	runner.Fire(testEvent(config.EventStop))
+	runner.Wait()
+	close(received)
+
+	events := []string{}
+	for event := range received {
+		events = append(events, event)
+	}
+	require.Equal(t, []string{config.EventExperimentStart, config.EventStop}, events)
+
+	// a runner without hooks does nothing
+	var nilRunner *Runner
+	nilRunner.Fire(testEvent(config.EventStop))
+	nilRunner.Wait()
+}
@@ -0,0 +1,96 @@
+package keepsake_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/replicate/keepsake/go/pkg/keepsake"
+)
+
+func ExampleInit() {
+	// Init finds keepsake.yaml in the working directory or its parents
+	exp, err := keepsake.Init(map[string]interface{}{"learning_rate": 0.01})
+	if err != nil {
+		panic(err)
+	}
+	var trainErr error
+	for epoch := 0; epoch < 10; epoch++ {
+		loss := 1.0 / float64(epoch+1)
+		// ... train, and save the model to model.pth ...
+		if _, err := exp.Checkpoint(map[string]interface{}{"loss": loss}, "model.pth"); err != nil {
+			trainErr = err
+			break
+		}
+	}
+	// passing the error runs the on_crash hooks in keepsake.yaml
+	if err := exp.Stop(trainErr); err != nil {
+		panic(err)
+	}
+}
+
+func ExampleProject_Checkout() {
+	dir, err := ioutil.TempDir("", "keepsake-example")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a training program saves a model...
+	training, err := keepsake.OpenRepository("file://"+filepath.Join(dir, "repository"), dir)
+	if err != nil {
+		panic(err)
+	}
+	exp, err := training.CreateExperiment(keepsake.ExperimentArgs{
+		Params: map[string]interface{}{"learning_rate": 0.01},
+		Quiet:  true,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "model.pth"), []byte("weights"), 0644); err != nil {
+		panic(err)
+	}
+	chk, err := exp.CreateCheckpoint(keepsake.CheckpointArgs{
+		Path:    "model.pth",
+		Metrics: map[string]interface{}{"accuracy": 0.9},
+		Quiet:   true,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if err := exp.Stop(nil); err != nil {
+		panic(err)
+	}
+	if err := training.Close(); err != nil {
+		panic(err)
+	}
+
+	// ...and a serving program loads it by the checkpoint's ID
+	serving, err := keepsake.OpenRepository("file://"+filepath.Join(dir, "repository"), "")
+	if err != nil {
+		panic(err)
+	}
+	defer serving.Close()
+	checkpoint, experiment, err := serving.GetCheckpoint(chk.ID)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("learning rate:", experiment.Params["learning_rate"])
+	fmt.Println("accuracy:", checkpoint.Metrics["accuracy"])
+
+	modelDir := filepath.Join(dir, "serving")
+	if err := serving.Checkout(chk.ID, modelDir); err != nil {
+		panic(err)
+	}
+	weights, err := ioutil.ReadFile(filepath.Join(modelDir, "model.pth"))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("weights:", string(weights))
+	// Output:
+	// learning rate: 0.01
+	// accuracy: 0.9
+	// weights: weights
+}
@@ -0,0 +1,198 @@
+package keepsake
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+// ExperimentArgs are the options for creating an experiment
+type ExperimentArgs struct {
+	// Path is a file or directory, relative to the project directory, that
+	// is saved with the experiment, usually the code. If it is empty,
+	// nothing is saved.
+	Path string
+	// Params are the experiment's hyperparameters. Values are saved the same
+	// way as they are by Python: numbers, strings, bools, and nil as they
+	// are, and anything else as JSON.
+	Params map[string]interface{}
+	// Command is how the experiment was run. It defaults to the program's
+	// arguments.
+	Command string
+	// DisableHeartbeat stops heartbeats being written, so the experiment
+	// isn't shown as running
+	DisableHeartbeat bool
+	// Quiet stops messages being written to stderr
+	Quiet bool
+}
+
+// CheckpointArgs are the options for creating a checkpoint
+type CheckpointArgs struct {
+	// Path is a file or directory, relative to the project directory, that
+	// is saved with the checkpoint, like the weights of a model. If it is
+	// empty, nothing is saved.
+	Path string
+	// Step is the training step the checkpoint was created at
+	Step int64
+	// Metrics are saved the same way as the params of experiments
+	Metrics map[string]interface{}
+	// PrimaryMetric is the metric that the best checkpoint is chosen by
+	PrimaryMetric *project.PrimaryMetric
+	// Tags can be kept by a retention policy that deletes the files of
+	// other checkpoints
+	Tags []string
+	// Quiet stops messages being written to stderr
+	Quiet bool
+}
+
+// Experiment is an experiment that is running in this program. The fields of
+// the experiment that has been saved, like ID and Checkpoints, can be read
+// from it, but shouldn't be changed.
+type Experiment struct {
+	*project.Experiment
+
+	project *Project
+	// closeProject is set if the project was opened by Init, so it is
+	// closed when the experiment is stopped
+	closeProject bool
+
+	// lock is held while the experiment is saved
+	lock    sync.Mutex
+	step    int64
+	stopped bool
+}
+
+// Init creates an experiment in the project in the working directory, with
+// params as its hyperparameters. Nothing but the metadata is saved with the
+// experiment. To save code with it too, open the project with Open, then use
+// CreateExperiment.
+//
+// Heartbeats are written for the experiment until Stop is called, which also
+// waits for any files to finish uploading.
+func Init(params map[string]interface{}) (*Experiment, error) {
+	p, err := Open("")
+	if err != nil {
+		return nil, err
+	}
+	exp, err := p.Init(params)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	exp.closeProject = true
+	return exp, nil
+}
+
+// Init creates an experiment, with params as its hyperparameters
+func (p *Project) Init(params map[string]interface{}) (*Experiment, error) {
+	return p.CreateExperiment(ExperimentArgs{Params: params})
+}
+
+// CreateExperiment creates an experiment, and starts uploading its files in
+// the background
+func (p *Project) CreateExperiment(args ExperimentArgs) (*Experiment, error) {
+	session, err := p.getSession()
+	if err != nil {
+		return nil, err
+	}
+	command := args.Command
+	if command == "" {
+		command = strings.Join(os.Args, " ")
+	}
+	var parents []*project.ParentRef
+	if rerun := project.TakeRerunParentFromEnv(); rerun != nil {
+		parents = append(parents, rerun)
+	}
+	exp, err := session.CreateExperiment(project.CreateExperimentArgs{
+		Path:    args.Path,
+		Command: command,
+		Params:  valueMap(args.Params),
+		Parents: parents,
+	}, 0, args.DisableHeartbeat, args.Quiet)
+	if err != nil {
+		return nil, err
+	}
+	return &Experiment{Experiment: exp, project: p, step: -1}, nil
+}
+
+// Checkpoint creates a checkpoint with metrics, and starts uploading the file
+// or directory at path in the background. path is relative to the project
+// directory, and nothing is uploaded if it is empty.
+//
+// The checkpoint's step is one more than the previous checkpoint's, starting
+// at 0.
+func (e *Experiment) Checkpoint(metrics map[string]interface{}, path string) (*project.Checkpoint, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.createCheckpoint(CheckpointArgs{Path: path, Step: e.step + 1, Metrics: metrics})
+}
+
+// CreateCheckpoint creates a checkpoint, and starts uploading its files in
+// the background. The experiment is saved with the checkpoint, and the
+// retention policy is applied to its checkpoints.
+func (e *Experiment) CreateCheckpoint(args CheckpointArgs) (*project.Checkpoint, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.createCheckpoint(args)
+}
+
+// createCheckpoint creates a checkpoint. lock must be held.
+func (e *Experiment) createCheckpoint(args CheckpointArgs) (*project.Checkpoint, error) {
+	if e.stopped {
+		return nil, fmt.Errorf("Experiment %s has been stopped, so checkpoints can't be created in it", e.ShortID())
+	}
+	session, err := e.project.getSession()
+	if err != nil {
+		return nil, err
+	}
+	chk, err := session.CreateCheckpoint(project.CreateCheckpointArgs{
+		Path:          args.Path,
+		Step:          args.Step,
+		Metrics:       valueMap(args.Metrics),
+		PrimaryMetric: args.PrimaryMetric,
+		Tags:          args.Tags,
+	}, args.Quiet)
+	if err != nil {
+		return nil, err
+	}
+	e.step = chk.Step
+	e.Checkpoints = append(e.Checkpoints, chk)
+	if _, err := session.SaveExperiment(e.Experiment, args.Quiet); err != nil {
+		return nil, err
+	}
+	return chk, nil
+}
+
+// Stop stops the experiment, and waits for its files to finish uploading.
+// status is nil if the experiment finished, or the error it failed with, in
+// which case the on_crash hooks in keepsake.yaml are run instead of on_stop.
+//
+// It returns an error if stopping the experiment or uploading its files
+// failed. Stopping an experiment that has been stopped does nothing.
+func (e *Experiment) Stop(status error) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.stopped {
+		return nil
+	}
+	e.stopped = true
+
+	if e.closeProject {
+		defer e.project.Close()
+	}
+	session, err := e.project.getSession()
+	if err != nil {
+		return err
+	}
+	errorMessage := ""
+	if status != nil {
+		errorMessage = status.Error()
+	}
+	if err := session.StopExperiment(e.ID, errorMessage); err != nil {
+		return err
+	}
+	return session.Flush()
+}
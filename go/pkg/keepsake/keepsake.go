@@ -0,0 +1,339 @@
+// Package keepsake saves experiments and checkpoints from Go programs, and
+// reads them back. It is the Go version of the Python library: it uploads
+// files and writes heartbeats in the background, applies the retention
+// policy, and runs the hooks in keepsake.yaml, without needing the daemon.
+//
+// To save an experiment from a training program:
+//
+//	exp, err := keepsake.Init(map[string]interface{}{"learning_rate": 0.01})
+//	...
+//	_, err = exp.Checkpoint(map[string]interface{}{"loss": loss}, "model.pth")
+//	...
+//	err = exp.Stop(nil)
+//
+// To read experiments, and check out the files of checkpoints, open the
+// project with Open.
+package keepsake
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/replicate/keepsake/go/pkg/cli/list"
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+	"github.com/replicate/keepsake/go/pkg/shared"
+)
+
+// Project is a directory of code, and the repository its experiments are
+// saved in. It is safe to use from multiple goroutines.
+type Project struct {
+	directory  string
+	repository repository.Repository
+	conf       *config.Config
+
+	// sessionLock protects session, which is created when the first
+	// experiment is, and watcher, which is created by the first Watch
+	sessionLock sync.Mutex
+	session     *shared.Session
+	watcher     *project.Watcher
+	closed      bool
+
+	// readLock protects reader, which experiments are read with. It is
+	// separate from the session's project, so reads don't see its cache
+	// being changed in the background.
+	readLock sync.Mutex
+	reader   *project.Project
+}
+
+// Open opens the project in dir, which is the directory with keepsake.yaml
+// in it. If dir is empty, the working directory and its parents are searched
+// for keepsake.yaml.
+//
+// The repository is the one in keepsake.yaml, or the remote in the
+// environment variable KEEPSAKE_REMOTE if it is set.
+func Open(dir string) (*Project, error) {
+	conf, projectDir, err := config.FindConfigInWorkingDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	repositoryURL, err := conf.RepositoryURL(os.Getenv("KEEPSAKE_REMOTE"))
+	if err != nil {
+		return nil, err
+	}
+	return open(repositoryURL, projectDir, conf)
+}
+
+// OpenRepository opens a repository without a keepsake.yaml, for example
+// file:///data/keepsake or s3://my-keepsake-bucket. Files of experiments are
+// saved from, and checked out relative to, dir.
+func OpenRepository(repositoryURL string, dir string) (*Project, error) {
+	return open(repositoryURL, dir, nil)
+}
+
+func open(repositoryURL string, dir string, conf *config.Config) (*Project, error) {
+	// abs of "" is cwd
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine absolute directory of '%s': %w", dir, err)
+	}
+	repo, err := repository.ForURL(repositoryURL, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Project{
+		directory:  dir,
+		repository: repo,
+		conf:       conf,
+		reader:     project.NewProject(repo, dir),
+	}, nil
+}
+
+// Directory returns the project's directory
+func (p *Project) Directory() string {
+	return p.directory
+}
+
+// getSession returns the session that experiments are saved with, creating
+// it if needed
+func (p *Project) getSession() (*shared.Session, error) {
+	p.sessionLock.Lock()
+	defer p.sessionLock.Unlock()
+	if p.closed {
+		return nil, fmt.Errorf("The project has been closed, so experiments can't be saved to it")
+	}
+	if p.session == nil {
+		proj := project.NewProject(p.repository, p.directory)
+		if p.conf != nil {
+			proj.SetRetentionPolicy(p.conf.Retention)
+			proj.SetSymlinkMode(p.conf.Symlinks)
+			proj.SetHooks(p.conf.Hooks)
+			proj.SetHeartbeatConfig(p.conf.Heartbeat)
+		}
+		p.session = shared.NewSession(proj)
+		p.session.SetWatcher(p.watcher)
+	}
+	return p.session, nil
+}
+
+// getWatcher returns the watcher that Watch subscribes to, creating it if
+// needed
+func (p *Project) getWatcher() *project.Watcher {
+	p.sessionLock.Lock()
+	defer p.sessionLock.Unlock()
+	if p.watcher == nil {
+		p.watcher = project.NewWatcher(project.NewProject(p.repository, p.directory), project.DefaultWatchInterval)
+		if p.session != nil {
+			p.session.SetWatcher(p.watcher)
+		}
+	}
+	return p.watcher
+}
+
+// Close waits for the files of experiments and checkpoints to finish
+// uploading, and for any hooks that are running. Experiments that haven't
+// been stopped stop writing heartbeats. It returns the first upload that
+// failed, if any did.
+func (p *Project) Close() error {
+	p.sessionLock.Lock()
+	defer p.sessionLock.Unlock()
+	if p.closed || p.session == nil {
+		p.closed = true
+		return nil
+	}
+	p.closed = true
+	err := p.session.Flush()
+	p.session.Close()
+	return err
+}
+
+// Experiments returns all the experiments in the project, oldest first
+func (p *Project) Experiments() ([]*project.Experiment, error) {
+	return p.Query()
+}
+
+// Query returns the experiments that match all of filters, oldest first.
+// Filters are in the same format as `keepsake ls --filter`, for example
+// "learning_rate = 0.01", "step >= 100", or "status = running".
+func (p *Project) Query(filters ...string) ([]*project.Experiment, error) {
+	parsed, err := param.MakeFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	p.readLock.Lock()
+	defer p.readLock.Unlock()
+	if err := p.reader.Refresh(); err != nil {
+		return nil, err
+	}
+	return list.FilterExperiments(p.reader, parsed)
+}
+
+// GetExperiment returns the experiment whose ID starts with idPrefix
+func (p *Project) GetExperiment(idPrefix string) (*project.Experiment, error) {
+	p.readLock.Lock()
+	defer p.readLock.Unlock()
+	if err := p.reader.Refresh(); err != nil {
+		return nil, err
+	}
+	return p.reader.ExperimentFromPrefix(idPrefix)
+}
+
+// GetCheckpoint returns the checkpoint whose ID starts with idPrefix, and its
+// experiment
+func (p *Project) GetCheckpoint(idPrefix string) (*project.Checkpoint, *project.Experiment, error) {
+	p.readLock.Lock()
+	defer p.readLock.Unlock()
+	if err := p.reader.Refresh(); err != nil {
+		return nil, nil, err
+	}
+	return p.reader.CheckpointFromPrefix(idPrefix)
+}
+
+// IsRunning returns whether the experiment with the given ID is running
+func (p *Project) IsRunning(experimentID string) (bool, error) {
+	p.readLock.Lock()
+	defer p.readLock.Unlock()
+	if err := p.reader.Refresh(); err != nil {
+		return false, err
+	}
+	return p.reader.ExperimentIsRunning(experimentID)
+}
+
+// Watch sends changes to the project's experiments on the returned channel
+// until ctx is done, when the channel is closed. Changes made by this
+// project are sent as they happen, and changes made by other processes are
+// found by reading the repository every project.DefaultWatchInterval.
+//
+// If cursor is empty, the first events are a reset event and a created event
+// for each experiment that exists. To carry on from where a previous call
+// left off, pass the cursor of the last event it sent.
+func (p *Project) Watch(ctx context.Context, cursor string) (<-chan *project.WatchEvent, error) {
+	watcher := p.getWatcher()
+	sub, err := watcher.Subscribe(cursor)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan *project.WatchEvent)
+	go func() {
+		defer close(events)
+		defer func() { sub.Close() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-sub.Events():
+				if !ok {
+					// The subscription is closed if events aren't
+					// being read quickly enough, so carry on from the
+					// last event that was sent
+					next, err := watcher.Subscribe(cursor)
+					if err != nil {
+						console.Warn("Failed to watch experiments: %v", err)
+						return
+					}
+					sub = next
+					continue
+				}
+				select {
+				case events <- event:
+					cursor = event.Cursor
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Checkout copies the files of a checkpoint or experiment into outputDir.
+// The checkpoint's files are copied on top of its experiment's. If idPrefix
+// is an experiment's ID, its best checkpoint is checked out, or its latest
+// checkpoint if it doesn't have a primary metric.
+func (p *Project) Checkout(idPrefix string, outputDir string) error {
+	exp, chk, err := p.checkoutTarget(idPrefix)
+	if err != nil {
+		return err
+	}
+	return p.reader.CheckoutCheckpoint(chk, exp, outputDir, true)
+}
+
+// CheckoutPath copies a single file or directory from a checkpoint or
+// experiment into outputDir, for example just the weights of a model. The
+// checkpoint is chosen the same way as with Checkout.
+func (p *Project) CheckoutPath(idPrefix string, path string, outputDir string) error {
+	exp, chk, err := p.checkoutTarget(idPrefix)
+	if err != nil {
+		return err
+	}
+	return p.reader.CheckoutFileOrDirectory(chk, exp, outputDir, path)
+}
+
+// checkoutTarget returns the experiment and checkpoint to check out for
+// idPrefix. The checkpoint is nil if the experiment doesn't have any.
+func (p *Project) checkoutTarget(idPrefix string) (*project.Experiment, *project.Checkpoint, error) {
+	p.readLock.Lock()
+	defer p.readLock.Unlock()
+	if err := p.reader.Refresh(); err != nil {
+		return nil, nil, err
+	}
+	result, err := p.reader.CheckpointOrExperimentFromPrefix(idPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result.Checkpoint != nil {
+		return result.Experiment, result.Checkpoint, nil
+	}
+	chk := result.Experiment.BestCheckpoint()
+	if chk == nil {
+		chk = result.Experiment.LatestCheckpoint()
+	}
+	return result.Experiment, chk, nil
+}
+
+// valueMap converts params or metrics to the values they are saved as
+func valueMap(values map[string]interface{}) param.ValueMap {
+	if values == nil {
+		return nil
+	}
+	ret := param.ValueMap{}
+	for name, v := range values {
+		ret[name] = value(v)
+	}
+	return ret
+}
+
+func value(v interface{}) param.Value {
+	switch v := v.(type) {
+	case nil:
+		return param.None()
+	case bool:
+		return param.Bool(v)
+	case int:
+		return param.Int(int64(v))
+	case int32:
+		return param.Int(int64(v))
+	case int64:
+		return param.Int(v)
+	case uint:
+		return param.Int(int64(v))
+	case uint32:
+		return param.Int(int64(v))
+	case float32:
+		return param.Float(float64(v))
+	case float64:
+		return param.Float(v)
+	case string:
+		return param.String(v)
+	case param.Value:
+		return v
+	}
+	// lists, maps, and other things are saved as JSON
+	return param.Object(v)
+}
@@ -0,0 +1,226 @@
+package keepsake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+func createProjectDir(t *testing.T, conf string) string {
+	dir, err := files.TempDir("test-keepsake")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "keepsake.yaml"), []byte("repository: file://.keepsake\n"+conf), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "train.go"), []byte("package main"), 0644))
+	return dir
+}
+
+func TestExperimentAndCheckpoints(t *testing.T) {
+	dir := createProjectDir(t, "")
+	defer os.RemoveAll(dir)
+
+	p, err := Open(dir)
+	require.NoError(t, err)
+	exp, err := p.CreateExperiment(ExperimentArgs{
+		Path:   "train.go",
+		Params: map[string]interface{}{"learning_rate": 0.01, "epochs": 3, "optimizer": "adam", "layers": []int{64, 32}},
+		Quiet:  true,
+	})
+	require.NoError(t, err)
+
+	for i, loss := range []float64{0.5, 0.2, 0.3} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "model.pth"), []byte(fmt.Sprintf("weights %d", i)), 0644))
+		chk, err := exp.CreateCheckpoint(CheckpointArgs{
+			Path:          "model.pth",
+			Step:          int64(i * 10),
+			Metrics:       map[string]interface{}{"loss": loss},
+			PrimaryMetric: &project.PrimaryMetric{Name: "loss", Goal: project.GoalMinimize},
+			Quiet:         true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(i*10), chk.Step)
+	}
+	// the step carries on from the last checkpoint
+	chk, err := exp.Checkpoint(map[string]interface{}{"loss": 0.4}, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(21), chk.Step)
+
+	running, err := p.IsRunning(exp.ID)
+	require.NoError(t, err)
+	// the first heartbeat is written after a few seconds
+	require.False(t, running)
+
+	require.NoError(t, exp.Stop(nil))
+	require.NoError(t, exp.Stop(nil))
+	_, err = exp.Checkpoint(nil, "")
+	require.Error(t, err)
+	require.NoError(t, p.Close())
+
+	// read it back with another project, like a serving process would
+	p, err = Open(dir)
+	require.NoError(t, err)
+	defer p.Close()
+
+	saved, err := p.GetExperiment(exp.ID[:7])
+	require.NoError(t, err)
+	require.Equal(t, exp.ID, saved.ID)
+	require.Equal(t, param.Float(0.01), saved.Params["learning_rate"])
+	require.Equal(t, param.Int(3), saved.Params["epochs"])
+	require.Equal(t, param.String("adam"), saved.Params["optimizer"])
+	require.Equal(t, "[64,32]", saved.Params["layers"].String())
+	require.NotEmpty(t, saved.SHA256)
+	require.Len(t, saved.Checkpoints, 4)
+	for _, chk := range saved.Checkpoints[:3] {
+		require.NotEmpty(t, chk.SHA256, "the digests are recorded after the uploads")
+	}
+
+	best, bestExp, err := p.GetCheckpoint(saved.BestCheckpoint().ID)
+	require.NoError(t, err)
+	require.Equal(t, exp.ID, bestExp.ID)
+	require.Equal(t, int64(10), best.Step)
+
+	experiments, err := p.Experiments()
+	require.NoError(t, err)
+	require.Len(t, experiments, 1)
+	experiments, err = p.Query("optimizer = adam", "loss < 0.3")
+	require.NoError(t, err)
+	require.Len(t, experiments, 1)
+	experiments, err = p.Query("status = running")
+	require.NoError(t, err)
+	require.Empty(t, experiments)
+	_, err = p.Query("not a filter")
+	require.Error(t, err)
+
+	// checking out an experiment checks out its best checkpoint
+	outputDir := filepath.Join(dir, "output")
+	require.NoError(t, p.Checkout(exp.ID, outputDir))
+	require.FileExists(t, filepath.Join(outputDir, "train.go"))
+	weights, err := ioutil.ReadFile(filepath.Join(outputDir, "model.pth"))
+	require.NoError(t, err)
+	require.Equal(t, "weights 1", string(weights))
+
+	weightsDir := filepath.Join(dir, "weights")
+	require.NoError(t, p.CheckoutPath(saved.Checkpoints[2].ID, "model.pth", weightsDir))
+	weights, err = ioutil.ReadFile(filepath.Join(weightsDir, "model.pth"))
+	require.NoError(t, err)
+	require.Equal(t, "weights 2", string(weights))
+	require.NoFileExists(t, filepath.Join(weightsDir, "train.go"))
+}
+
+func TestStopWithError(t *testing.T) {
+	dir := createProjectDir(t, `hooks:
+  on_stop:
+    - command: cat > stop.json
+  on_crash:
+    - command: cat > crash.json
+`)
+	defer os.RemoveAll(dir)
+
+	p, err := Open(dir)
+	require.NoError(t, err)
+	exp, err := p.CreateExperiment(ExperimentArgs{Quiet: true})
+	require.NoError(t, err)
+	require.NoError(t, exp.Stop(fmt.Errorf("out of memory")))
+	require.NoError(t, p.Close())
+
+	require.NoFileExists(t, filepath.Join(dir, "stop.json"))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "crash.json"))
+	require.NoError(t, err)
+	event := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &event))
+	require.Equal(t, "crash", event["event"])
+	require.Equal(t, exp.ID, event["experiment_id"])
+	require.Equal(t, "out of memory", event["error"])
+
+	_, err = p.CreateExperiment(ExperimentArgs{Quiet: true})
+	require.Error(t, err, "the project has been closed")
+}
+
+func TestWatch(t *testing.T) {
+	dir := createProjectDir(t, "")
+	defer os.RemoveAll(dir)
+
+	p, err := Open(dir)
+	require.NoError(t, err)
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := p.Watch(ctx, "")
+	require.NoError(t, err)
+	next := func() *project.WatchEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for an event")
+		}
+		return nil
+	}
+	require.Equal(t, project.WatchEventReset, next().Type)
+
+	exp, err := p.CreateExperiment(ExperimentArgs{Quiet: true})
+	require.NoError(t, err)
+	event := next()
+	require.Equal(t, project.WatchEventCreated, event.Type)
+	require.Equal(t, exp.ID, event.ExperimentID)
+	require.True(t, event.Running)
+
+	chk, err := exp.Checkpoint(map[string]interface{}{"loss": 0.1}, "")
+	require.NoError(t, err)
+	event = next()
+	require.Equal(t, project.WatchEventCheckpointAdded, event.Type)
+	require.Equal(t, chk.ID, event.Checkpoint.ID)
+	cursor := event.Cursor
+
+	require.NoError(t, exp.Stop(nil))
+	event = next()
+	require.Equal(t, project.WatchEventStatusChanged, event.Type)
+	require.False(t, event.Running)
+
+	cancel()
+	_, ok := <-events
+	require.False(t, ok, "the channel is closed when the context is done")
+
+	// carry on from the checkpoint
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events, err = p.Watch(ctx, cursor)
+	require.NoError(t, err)
+	require.Equal(t, project.WatchEventStatusChanged, next().Type)
+}
+
+func TestInit(t *testing.T) {
+	dir := createProjectDir(t, "")
+	defer os.RemoveAll(dir)
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "src"), 0755))
+	// the project is found in parent directories
+	require.NoError(t, os.Chdir(filepath.Join(dir, "src")))
+	defer os.Chdir(cwd)
+
+	exp, err := Init(map[string]interface{}{"seed": 42, "dropout": nil})
+	require.NoError(t, err)
+	_, err = exp.Checkpoint(map[string]interface{}{"accuracy": float32(0.5)}, "")
+	require.NoError(t, err)
+	require.NoError(t, exp.Stop(nil))
+
+	p, err := OpenRepository("file://"+filepath.Join(dir, ".keepsake"), "")
+	require.NoError(t, err)
+	saved, err := p.GetExperiment(exp.ID)
+	require.NoError(t, err)
+	require.Equal(t, param.Int(42), saved.Params["seed"])
+	require.True(t, saved.Params["dropout"].IsNone())
+	require.Len(t, saved.Checkpoints, 1)
+	require.Equal(t, int64(0), saved.Checkpoints[0].Step)
+	require.Equal(t, param.Float(0.5), saved.Checkpoints[0].Metrics["accuracy"])
+}
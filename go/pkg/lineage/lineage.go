@@ -0,0 +1,319 @@
+// Package lineage builds the graph of experiments that were resumed,
+// fine-tuned, or rerun from other experiments, and renders it as a tree or
+// in Graphviz DOT format
+package lineage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+// Edge links a child experiment to the experiment, and optionally the
+// checkpoint, it was derived from
+type Edge struct {
+	ParentID     string
+	CheckpointID string
+	ChildID      string
+	Kind         project.ParentKind
+}
+
+// Dangling is a reference from an experiment to an experiment or checkpoint
+// that doesn't exist, typically because it has been deleted
+type Dangling struct {
+	Edge *Edge
+	// MissingExperiment is true if the parent experiment doesn't exist,
+	// otherwise only the checkpoint is missing
+	MissingExperiment bool
+}
+
+func (d *Dangling) String() string {
+	if d.MissingExperiment {
+		return fmt.Sprintf("Experiment %s references experiment %s, which does not exist", shortID(d.Edge.ChildID), shortID(d.Edge.ParentID))
+	}
+	return fmt.Sprintf("Experiment %s references checkpoint %s in experiment %s, which does not exist", shortID(d.Edge.ChildID), shortID(d.Edge.CheckpointID), shortID(d.Edge.ParentID))
+}
+
+// Graph is the lineage of every experiment in a project
+type Graph struct {
+	experiments map[string]*project.Experiment
+	parents     map[string][]*Edge
+	children    map[string][]*Edge
+}
+
+// NewGraph builds the graph of experiments from their parents
+func NewGraph(experiments []*project.Experiment) *Graph {
+	g := &Graph{
+		experiments: map[string]*project.Experiment{},
+		parents:     map[string][]*Edge{},
+		children:    map[string][]*Edge{},
+	}
+	sorted := make([]*project.Experiment, len(experiments))
+	copy(sorted, experiments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Created.Before(sorted[j].Created)
+	})
+
+	for _, exp := range sorted {
+		g.experiments[exp.ID] = exp
+	}
+	for _, exp := range sorted {
+		for _, parent := range exp.Parents {
+			g.addEdge(&Edge{ParentID: parent.ExperimentID, CheckpointID: parent.CheckpointID, ChildID: exp.ID, Kind: parent.Kind})
+		}
+	}
+	return g
+}
+
+func (g *Graph) addEdge(edge *Edge) {
+	g.parents[edge.ChildID] = append(g.parents[edge.ChildID], edge)
+	g.children[edge.ParentID] = append(g.children[edge.ParentID], edge)
+}
+
+// Dangling returns the references in the graph to experiments and
+// checkpoints that don't exist
+func (g *Graph) Dangling() []*Dangling {
+	ret := []*Dangling{}
+	ids := []string{}
+	for id := range g.parents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		for _, edge := range g.parents[id] {
+			if d := g.dangling(edge); d != nil {
+				ret = append(ret, d)
+			}
+		}
+	}
+	return ret
+}
+
+func (g *Graph) dangling(edge *Edge) *Dangling {
+	parent, ok := g.experiments[edge.ParentID]
+	if !ok {
+		return &Dangling{Edge: edge, MissingExperiment: true}
+	}
+	if edge.CheckpointID != "" && findCheckpoint(parent, edge.CheckpointID) == nil {
+		return &Dangling{Edge: edge}
+	}
+	return nil
+}
+
+// Related returns the IDs of an experiment, its ancestors, and its
+// descendants, including any that don't exist
+func (g *Graph) Related(experimentID string) map[string]bool {
+	ret := map[string]bool{experimentID: true}
+	g.walk(experimentID, g.parents, func(e *Edge) string { return e.ParentID }, ret)
+	g.walk(experimentID, g.children, func(e *Edge) string { return e.ChildID }, ret)
+	return ret
+}
+
+func (g *Graph) walk(id string, edges map[string][]*Edge, next func(*Edge) string, seen map[string]bool) {
+	for _, edge := range edges[id] {
+		nextID := next(edge)
+		if seen[nextID] {
+			continue
+		}
+		seen[nextID] = true
+		g.walk(nextID, edges, next, seen)
+	}
+}
+
+// WriteTree writes the ancestors and descendants of an experiment as trees.
+// If checkpointID is set, only descendants derived from that checkpoint are
+// included.
+func (g *Graph) WriteTree(w io.Writer, experimentID string, checkpointID string) error {
+	root := shortID(experimentID)
+	if checkpointID != "" {
+		root += ", checkpoint " + shortID(checkpointID)
+	}
+
+	if _, err := fmt.Fprintf(w, "Ancestors:\n\n%s\n", root); err != nil {
+		return err
+	}
+	if len(g.parents[experimentID]) == 0 {
+		fmt.Fprintln(w, "(none)")
+	}
+	g.writeAncestors(w, experimentID, "", map[string]bool{experimentID: true})
+
+	if _, err := fmt.Fprintf(w, "\nDescendants:\n\n%s\n", root); err != nil {
+		return err
+	}
+	children := g.childEdges(experimentID, checkpointID)
+	if len(children) == 0 {
+		fmt.Fprintln(w, "(none)")
+	}
+	g.writeDescendants(w, children, "", map[string]bool{experimentID: true})
+	return nil
+}
+
+func (g *Graph) writeAncestors(w io.Writer, id string, indent string, path map[string]bool) {
+	edges := g.parents[id]
+	for i, edge := range edges {
+		branch, nextIndent := treeBranch(indent, i == len(edges)-1)
+		label := shortID(edge.ParentID)
+		if edge.CheckpointID != "" {
+			label += ", checkpoint " + shortID(edge.CheckpointID)
+			if chk := g.checkpoint(edge.ParentID, edge.CheckpointID); chk != nil {
+				label += fmt.Sprintf(" (step %d)", chk.Step)
+			}
+		}
+		label += g.annotations(edge, edge.ParentID, path)
+		fmt.Fprintf(w, "%s%s\n", branch, label)
+		if !path[edge.ParentID] {
+			path[edge.ParentID] = true
+			g.writeAncestors(w, edge.ParentID, nextIndent, path)
+			delete(path, edge.ParentID)
+		}
+	}
+}
+
+func (g *Graph) writeDescendants(w io.Writer, edges []*Edge, indent string, path map[string]bool) {
+	for i, edge := range edges {
+		branch, nextIndent := treeBranch(indent, i == len(edges)-1)
+		label := shortID(edge.ChildID)
+		if edge.CheckpointID != "" {
+			label += ", from checkpoint " + shortID(edge.CheckpointID)
+		}
+		label += g.annotations(edge, edge.ChildID, path)
+		fmt.Fprintf(w, "%s%s\n", branch, label)
+		if !path[edge.ChildID] {
+			path[edge.ChildID] = true
+			g.writeDescendants(w, g.children[edge.ChildID], nextIndent, path)
+			delete(path, edge.ChildID)
+		}
+	}
+}
+
+// annotations returns the notes displayed after an experiment in a tree
+func (g *Graph) annotations(edge *Edge, id string, path map[string]bool) string {
+	notes := []string{}
+	if edge.Kind == project.ParentKindRerun {
+		notes = append(notes, "rerun")
+	}
+	if d := g.dangling(edge); d != nil {
+		if d.MissingExperiment {
+			notes = append(notes, "missing experiment")
+		} else {
+			notes = append(notes, "missing checkpoint")
+		}
+	}
+	if path[id] {
+		notes = append(notes, "cycle")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(notes, ", ") + "]"
+}
+
+func (g *Graph) childEdges(experimentID string, checkpointID string) []*Edge {
+	if checkpointID == "" {
+		return g.children[experimentID]
+	}
+	ret := []*Edge{}
+	for _, edge := range g.children[experimentID] {
+		if edge.CheckpointID == checkpointID {
+			ret = append(ret, edge)
+		}
+	}
+	return ret
+}
+
+func treeBranch(indent string, last bool) (branch string, nextIndent string) {
+	if last {
+		return indent + "└── ", indent + "    "
+	}
+	return indent + "├── ", indent + "│   "
+}
+
+// WriteDOT writes the ancestors and descendants of an experiment as a
+// Graphviz graph
+func (g *Graph) WriteDOT(w io.Writer, experimentID string) error {
+	related := g.Related(experimentID)
+	ids := []string{}
+	for id := range related {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if _, err := fmt.Fprintln(w, "digraph lineage {"); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "  node [shape=box];")
+	for _, id := range ids {
+		attrs := []string{fmt.Sprintf("label=%q", nodeLabel(g.experiments[id], id))}
+		if id == experimentID {
+			attrs = append(attrs, "style=bold")
+		}
+		if _, ok := g.experiments[id]; !ok {
+			attrs = append(attrs, "style=dashed", "color=red")
+		}
+		fmt.Fprintf(w, "  %q [%s];\n", id, strings.Join(attrs, ", "))
+	}
+	for _, id := range ids {
+		for _, edge := range g.parents[id] {
+			if !related[edge.ParentID] {
+				continue
+			}
+			attrs := []string{}
+			labels := []string{}
+			if edge.CheckpointID != "" {
+				labels = append(labels, "checkpoint "+shortID(edge.CheckpointID))
+			}
+			if edge.Kind == project.ParentKindRerun {
+				labels = append(labels, "rerun")
+				attrs = append(attrs, "style=dashed")
+			}
+			if d := g.dangling(edge); d != nil && !d.MissingExperiment {
+				labels = append(labels, "missing")
+				attrs = append(attrs, "color=red")
+			}
+			if len(labels) > 0 {
+				attrs = append([]string{fmt.Sprintf("label=%q", strings.Join(labels, "\n"))}, attrs...)
+			}
+			attrString := ""
+			if len(attrs) > 0 {
+				attrString = " [" + strings.Join(attrs, ", ") + "]"
+			}
+			fmt.Fprintf(w, "  %q -> %q%s;\n", edge.ParentID, edge.ChildID, attrString)
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+func nodeLabel(exp *project.Experiment, id string) string {
+	if exp == nil {
+		return shortID(id) + "\n(missing)"
+	}
+	return exp.ShortID() + "\n" + exp.Created.Format("2006-01-02 15:04")
+}
+
+func (g *Graph) checkpoint(experimentID string, checkpointID string) *project.Checkpoint {
+	exp, ok := g.experiments[experimentID]
+	if !ok {
+		return nil
+	}
+	return findCheckpoint(exp, checkpointID)
+}
+
+func findCheckpoint(exp *project.Experiment, checkpointID string) *project.Checkpoint {
+	for _, chk := range exp.Checkpoints {
+		if chk.ID == checkpointID {
+			return chk
+		}
+	}
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
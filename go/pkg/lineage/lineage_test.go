@@ -0,0 +1,134 @@
+package lineage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+func testExperiments() []*project.Experiment {
+	t := time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)
+	return []*project.Experiment{
+		{
+			ID:      "1eeeeeeeee",
+			Created: t,
+			Checkpoints: []*project.Checkpoint{
+				{ID: "1ccccccccc", Step: 10},
+			},
+		},
+		{
+			ID:      "2eeeeeeeee",
+			Created: t.Add(1 * time.Hour),
+			Parents: []*project.ParentRef{
+				{ExperimentID: "1eeeeeeeee", CheckpointID: "1ccccccccc"},
+				{ExperimentID: "9eeeeeeeee"},
+			},
+			Checkpoints: []*project.Checkpoint{
+				{ID: "2ccccccccc", Step: 5},
+			},
+		},
+		{
+			ID:      "3eeeeeeeee",
+			Created: t.Add(2 * time.Hour),
+			Parents: []*project.ParentRef{
+				{ExperimentID: "2eeeeeeeee", CheckpointID: "2ccccccccc"},
+			},
+		},
+		{
+			ID:      "4eeeeeeeee",
+			Created: t.Add(3 * time.Hour),
+			Parents: []*project.ParentRef{
+				{ExperimentID: "2eeeeeeeee", CheckpointID: "8ccccccccc", Kind: project.ParentKindRerun},
+			},
+		},
+		{
+			ID:      "5eeeeeeeee",
+			Created: t.Add(4 * time.Hour),
+		},
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	g := NewGraph(testExperiments())
+
+	out := new(bytes.Buffer)
+	require.NoError(t, g.WriteTree(out, "2eeeeeeeee", ""))
+	require.Equal(t, `Ancestors:
+
+2eeeeee
+├── 1eeeeee, checkpoint 1cccccc (step 10)
+└── 9eeeeee [missing experiment]
+
+Descendants:
+
+2eeeeee
+├── 3eeeeee, from checkpoint 2cccccc
+└── 4eeeeee, from checkpoint 8cccccc [rerun, missing checkpoint]
+`, out.String())
+
+	out = new(bytes.Buffer)
+	require.NoError(t, g.WriteTree(out, "1eeeeeeeee", "1ccccccccc"))
+	require.Equal(t, `Ancestors:
+
+1eeeeee, checkpoint 1cccccc
+(none)
+
+Descendants:
+
+1eeeeee, checkpoint 1cccccc
+└── 2eeeeee, from checkpoint 1cccccc
+    ├── 3eeeeee, from checkpoint 2cccccc
+    └── 4eeeeee, from checkpoint 8cccccc [rerun, missing checkpoint]
+`, out.String())
+}
+
+func TestWriteTreeCycle(t *testing.T) {
+	g := NewGraph([]*project.Experiment{
+		{ID: "1eeeeeeeee", Parents: []*project.ParentRef{{ExperimentID: "2eeeeeeeee"}}},
+		{ID: "2eeeeeeeee", Parents: []*project.ParentRef{{ExperimentID: "1eeeeeeeee"}}},
+	})
+	out := new(bytes.Buffer)
+	require.NoError(t, g.WriteTree(out, "1eeeeeeeee", ""))
+	require.Equal(t, `Ancestors:
+
+1eeeeee
+└── 2eeeeee
+    └── 1eeeeee [cycle]
+
+Descendants:
+
+1eeeeee
+└── 2eeeeee
+    └── 1eeeeee [cycle]
+`, out.String())
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := NewGraph(testExperiments())
+
+	out := new(bytes.Buffer)
+	require.NoError(t, g.WriteDOT(out, "3eeeeeeeee"))
+	require.Equal(t, `digraph lineage {
+  node [shape=box];
+  "1eeeeeeeee" [label="1eeeeee\n2020-12-07 01:13"];
+  "2eeeeeeeee" [label="2eeeeee\n2020-12-07 02:13"];
+  "3eeeeeeeee" [label="3eeeeee\n2020-12-07 03:13", style=bold];
+  "9eeeeeeeee" [label="9eeeeee\n(missing)", style=dashed, color=red];
+  "1eeeeeeeee" -> "2eeeeeeeee" [label="checkpoint 1cccccc"];
+  "9eeeeeeeee" -> "2eeeeeeeee";
+  "2eeeeeeeee" -> "3eeeeeeeee" [label="checkpoint 2cccccc"];
+}
+`, out.String())
+}
+
+func TestDangling(t *testing.T) {
+	g := NewGraph(testExperiments())
+	dangling := g.Dangling()
+	require.Len(t, dangling, 2)
+	require.Equal(t, "Experiment 2eeeeee references experiment 9eeeeee, which does not exist", dangling[0].String())
+	require.Equal(t, "Experiment 4eeeeee references checkpoint 8cccccc in experiment 2eeeeee, which does not exist", dangling[1].String())
+}
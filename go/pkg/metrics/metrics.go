@@ -0,0 +1,24 @@
+// Package metrics has the Prometheus registry that the daemon's metrics are
+// registered in
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is where keepsake's metrics are registered. It is separate from
+// Prometheus's default registry so the daemon status only includes
+// keepsake's metrics, and not the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+// DefaultBuckets are the upper bounds of histogram buckets, in seconds, for
+// things that take between a few milliseconds and a few minutes
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Handler serves the metrics in Registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
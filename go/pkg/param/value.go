@@ -235,6 +235,18 @@ func (v Value) FloatVal() float64 {
 	return *v.floatVal
 }
 
+// NumericVal returns the value of an int or float as a float64, and false
+// for any other type
+func (v Value) NumericVal() (float64, bool) {
+	switch v.Type() {
+	case TypeInt:
+		return float64(*v.intVal), true
+	case TypeFloat:
+		return *v.floatVal, true
+	}
+	return 0, false
+}
+
 func (v Value) StringVal() string {
 	if v.Type() != TypeString {
 		panic(fmt.Sprintf("Can't use %s as string", v))
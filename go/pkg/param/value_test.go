@@ -140,6 +140,13 @@ func TestVal(t *testing.T) {
 	require.Equal(t, map[string]interface{}{"foo": "bar"}, Object(map[string]interface{}{"foo": "bar"}).ObjectVal())
 }
 
+func TestNumericVal(t *testing.T) {
+	require.Equal(t, shim(float64(2), true), shim(Int(2).NumericVal()))
+	require.Equal(t, shim(0.5, true), shim(Float(0.5).NumericVal()))
+	require.Equal(t, shim(float64(0), false), shim(String("2").NumericVal()))
+	require.Equal(t, shim(float64(0), false), shim(None().NumericVal()))
+}
+
 func TestPythonString(t *testing.T) {
 	require.Equal(t, `{"foo":"bar"}`, Object(map[string]interface{}{"foo": "bar"}).PythonString())
 }
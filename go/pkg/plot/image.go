@@ -0,0 +1,268 @@
+package plot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const defaultImageWidth = 800
+const defaultImageHeight = 500
+const legendLineHeight = 18
+
+var palette = []color.RGBA{
+	{0x1f, 0x77, 0xb4, 0xff}, // blue
+	{0xd6, 0x27, 0x28, 0xff}, // red
+	{0x2c, 0xa0, 0x2c, 0xff}, // green
+	{0xff, 0x7f, 0x0e, 0xff}, // orange
+	{0x94, 0x67, 0xbd, 0xff}, // purple
+	{0x17, 0xbe, 0xcf, 0xff}, // cyan
+}
+
+var (
+	black     = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	white     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	lightGray = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+)
+
+// imageLayout is the position of the parts of a chart in an image
+type imageLayout struct {
+	width, height            int
+	left, top, right, bottom float64
+	bounds                   bounds
+	xTicks, yTicks           []float64
+}
+
+func newImageLayout(series []*Series, opts Options) (*imageLayout, error) {
+	b, ok := getBounds(series)
+	if !ok {
+		return nil, fmt.Errorf("No data to plot")
+	}
+	l := &imageLayout{width: opts.Width, height: opts.Height, bounds: b}
+	if l.width <= 0 {
+		l.width = defaultImageWidth
+	}
+	if l.height <= 0 {
+		l.height = defaultImageHeight
+	}
+	l.left = 70
+	l.top = 40
+	l.right = float64(l.width) - 20
+	l.bottom = float64(l.height) - 50 - float64(legendLineHeight*len(series))
+	if l.bottom-l.top < 50 {
+		return nil, fmt.Errorf("Image is too small to fit %d series, try making it taller", len(series))
+	}
+	l.xTicks = niceTicks(b.minX, b.maxX, 6)
+	l.yTicks = niceTicks(b.minY, b.maxY, 6)
+	return l, nil
+}
+
+func (l *imageLayout) point(p Point) (x, y float64) {
+	x, y = l.bounds.scale(p, l.right-l.left, l.bottom-l.top)
+	return x + l.left, y + l.top
+}
+
+// WriteFile writes series as an SVG or PNG image, depending on the extension
+// of path
+func WriteFile(path string, series []*Series, opts Options) error {
+	var write func(io.Writer, []*Series, Options) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		write = WriteSVG
+	case ".png":
+		write = WritePNG
+	default:
+		return fmt.Errorf("Unknown image format for %q, the file name must end with .svg or .png", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Failed to create %q: %w", path, err)
+	}
+	if err := write(f, series, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteSVG draws series as an SVG line chart
+func WriteSVG(out io.Writer, series []*Series, opts Options) error {
+	series, err := prepare(series, opts)
+	if err != nil {
+		return err
+	}
+	l, err := newImageLayout(series, opts)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", l.width, l.height, l.width, l.height)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="white"/>`+"\n", l.width, l.height)
+	if opts.Title != "" {
+		fmt.Fprintf(&sb, `<text x="%d" y="24" text-anchor="middle" font-size="16">%s</text>`+"\n", l.width/2, xmlEscape(opts.Title))
+	}
+
+	for _, t := range l.yTicks {
+		_, y := l.point(Point{X: l.bounds.minX, Y: t})
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e5e5e5"/>`+"\n", l.left, y, l.right, y)
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n", l.left-6, y, formatValue(t, opts.LogScale))
+	}
+	for _, t := range l.xTicks {
+		x, _ := l.point(Point{X: t, Y: l.bounds.minY})
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e5e5e5"/>`+"\n", x, l.top, x, l.bottom)
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", x, l.bottom+16, formatValue(t, false))
+	}
+	fmt.Fprintf(&sb, `<polyline points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="none" stroke="black"/>`+"\n", l.left, l.top, l.left, l.bottom, l.right, l.bottom)
+	if opts.XLabel != "" {
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", (l.left+l.right)/2, l.bottom+34, xmlEscape(opts.XLabel))
+	}
+
+	for i, s := range series {
+		c := palette[i%len(palette)]
+		points := []string{}
+		for _, p := range s.Points {
+			x, y := l.point(p)
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1.5"/>`+"\n", strings.Join(points, " "), hexColor(c))
+
+		legendY := l.bottom + 50 + float64(i*legendLineHeight)
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="3"/>`+"\n", l.left, legendY, l.left+20, legendY, hexColor(c))
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%.1f" dominant-baseline="middle">%s</text>`+"\n", l.left+26, legendY, xmlEscape(s.Name))
+	}
+	sb.WriteString("</svg>\n")
+
+	_, err = io.WriteString(out, sb.String())
+	return err
+}
+
+// WritePNG draws series as a PNG line chart
+func WritePNG(out io.Writer, series []*Series, opts Options) error {
+	series, err := prepare(series, opts)
+	if err != nil {
+		return err
+	}
+	l, err := newImageLayout(series, opts)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{white}, image.Point{}, draw.Src)
+
+	if opts.Title != "" {
+		drawText(img, opts.Title, float64(l.width)/2, 24, alignCenter)
+	}
+	for _, t := range l.yTicks {
+		_, y := l.point(Point{X: l.bounds.minX, Y: t})
+		drawLine(img, l.left, y, l.right, y, lightGray, 1)
+		drawText(img, formatValue(t, opts.LogScale), l.left-6, y+4, alignRight)
+	}
+	for _, t := range l.xTicks {
+		x, _ := l.point(Point{X: t, Y: l.bounds.minY})
+		drawLine(img, x, l.top, x, l.bottom, lightGray, 1)
+		drawText(img, formatValue(t, false), x, l.bottom+18, alignCenter)
+	}
+	drawLine(img, l.left, l.top, l.left, l.bottom, black, 1)
+	drawLine(img, l.left, l.bottom, l.right, l.bottom, black, 1)
+	if opts.XLabel != "" {
+		drawText(img, opts.XLabel, (l.left+l.right)/2, l.bottom+36, alignCenter)
+	}
+
+	for i, s := range series {
+		c := palette[i%len(palette)]
+		for j := 1; j < len(s.Points); j++ {
+			x0, y0 := l.point(s.Points[j-1])
+			x1, y1 := l.point(s.Points[j])
+			drawLine(img, x0, y0, x1, y1, c, 2)
+		}
+		if len(s.Points) == 1 {
+			x, y := l.point(s.Points[0])
+			drawLine(img, x, y, x, y, c, 3)
+		}
+		legendY := l.bottom + 50 + float64(i*legendLineHeight)
+		drawLine(img, l.left, legendY, l.left+20, legendY, c, 3)
+		drawText(img, s.Name, l.left+26, legendY+4, alignLeft)
+	}
+
+	return png.Encode(out, img)
+}
+
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+func drawText(img draw.Image, text string, x, y float64, align alignment) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(black),
+		Face: basicfont.Face7x13,
+	}
+	width := d.MeasureString(text).Round()
+	switch align {
+	case alignCenter:
+		x -= float64(width) / 2
+	case alignRight:
+		x -= float64(width)
+	}
+	d.Dot = fixed.P(round(x), round(y))
+	d.DrawString(text)
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm,
+// with a square pen of the given thickness
+func drawLine(img *image.RGBA, fx0, fy0, fx1, fy1 float64, c color.RGBA, thickness int) {
+	x0, y0, x1, y1 := round(fx0), round(fy0), round(fx1), round(fy1)
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		for i := 0; i < thickness; i++ {
+			for j := 0; j < thickness; j++ {
+				img.SetRGBA(x0+i-thickness/2, y0+j-thickness/2, c)
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
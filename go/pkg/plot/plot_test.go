@@ -0,0 +1,92 @@
+package plot
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmooth(t *testing.T) {
+	s := &Series{Name: "loss", Points: []Point{{0, 1}, {1, 3}, {2, 3}}}
+	smoothed := s.Smooth(0.5)
+	require.Equal(t, "loss", smoothed.Name)
+	require.Equal(t, []Point{{0, 1}, {1, 2}, {2, 2.5}}, smoothed.Points)
+	// Original is unchanged
+	require.Equal(t, []Point{{0, 1}, {1, 3}, {2, 3}}, s.Points)
+
+	require.Equal(t, []Point{}, (&Series{}).Smooth(0.5).Points)
+}
+
+func TestPrepare(t *testing.T) {
+	series := []*Series{{Name: "loss", Points: []Point{{0, 100}, {1, 0}, {2, math.NaN()}, {3, 10}, {4, -1}}}}
+
+	prepared, err := prepare(series, Options{LogScale: true})
+	require.NoError(t, err)
+	require.Equal(t, []Point{{0, 2}, {3, 1}}, prepared[0].Points)
+
+	prepared, err = prepare(series, Options{})
+	require.NoError(t, err)
+	require.Equal(t, []Point{{0, 100}, {1, 0}, {3, 10}, {4, -1}}, prepared[0].Points)
+
+	_, err = prepare(series, Options{Smoothing: 1})
+	require.Error(t, err)
+}
+
+func TestNiceTicks(t *testing.T) {
+	require.Equal(t, []float64{0, 20, 40, 60, 80, 100}, niceTicks(0, 100, 6))
+	require.Equal(t, []float64{0.2, 0.4, 0.6}, niceTicks(0.13, 0.67, 4))
+	require.Equal(t, []float64{5}, niceTicks(5, 5, 6))
+}
+
+func TestWriteTerminal(t *testing.T) {
+	series := []*Series{
+		{Name: "up", Points: []Point{{0, 0}, {10, 10}}},
+		{Name: "down", Points: []Point{{0, 10}, {10, 0}}},
+	}
+	out := new(bytes.Buffer)
+	require.NoError(t, WriteTerminal(out, series, Options{Width: 15, Height: 5, ASCII: true, XLabel: "step"}))
+	expected := `
+10 +++       **
+   |  ++   **
+   |    +++
+   |  **   ++
+ 0 +**       ++
+   +-----------
+    0    5   10
+       step
+
+    * up
+    + down
+`
+	require.Equal(t, expected[1:], out.String())
+
+	require.EqualError(t, WriteTerminal(out, []*Series{{Name: "empty"}}, Options{}), "No data to plot")
+}
+
+func TestWriteSVG(t *testing.T) {
+	series := []*Series{{Name: "<loss>", Points: []Point{{0, 1}, {1, 2}}}}
+	out := new(bytes.Buffer)
+	require.NoError(t, WriteSVG(out, series, Options{Title: "a & b"}))
+	svg := out.String()
+	require.True(t, strings.HasPrefix(svg, "<svg "))
+	require.Contains(t, svg, "&lt;loss&gt;")
+	require.Contains(t, svg, "a &amp; b")
+	require.Equal(t, 2, strings.Count(svg, "<polyline"))
+}
+
+func TestWritePNG(t *testing.T) {
+	series := []*Series{{Name: "loss", Points: []Point{{0, 1}, {1, 2}}}}
+	out := new(bytes.Buffer)
+	require.NoError(t, WritePNG(out, series, Options{Width: 300, Height: 200}))
+	img, err := png.Decode(out)
+	require.NoError(t, err)
+	require.Equal(t, 300, img.Bounds().Dx())
+	require.Equal(t, 200, img.Bounds().Dy())
+
+	err = WritePNG(out, series, Options{Width: 300, Height: 100})
+	require.Error(t, err)
+}
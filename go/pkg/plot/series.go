@@ -0,0 +1,172 @@
+// Package plot draws line charts of metrics, in the terminal or as image files
+package plot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Point is a single value in a series
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Series is a named line on a chart
+type Series struct {
+	Name   string  `json:"name"`
+	Points []Point `json:"points"`
+}
+
+// Options control how a chart is drawn
+type Options struct {
+	// Title is displayed above the chart in image output
+	Title string
+	// XLabel is displayed below the x axis
+	XLabel string
+	// LogScale plots the y axis on a log scale
+	LogScale bool
+	// Smoothing is the weight of an exponential moving average applied to
+	// each series, between 0 (no smoothing) and 1
+	Smoothing float64
+	// Width and Height are in characters for terminal output, and pixels for
+	// image output
+	Width  int
+	Height int
+	// Color draws each series in a different color in terminal output
+	Color bool
+	// ASCII draws with ASCII characters instead of Unicode braille in
+	// terminal output
+	ASCII bool
+}
+
+// Smooth returns a copy of the series with an exponential moving average
+// applied, in the same way as TensorBoard
+func (s *Series) Smooth(weight float64) *Series {
+	smoothed := &Series{Name: s.Name, Points: make([]Point, len(s.Points))}
+	if len(s.Points) == 0 {
+		return smoothed
+	}
+	last := s.Points[0].Y
+	for i, p := range s.Points {
+		last = last*weight + (1-weight)*p.Y
+		smoothed.Points[i] = Point{X: p.X, Y: last}
+	}
+	return smoothed
+}
+
+// prepare applies smoothing and log scaling to series, dropping points
+// that can't be displayed
+func prepare(series []*Series, opts Options) ([]*Series, error) {
+	if opts.Smoothing < 0 || opts.Smoothing >= 1 {
+		return nil, fmt.Errorf("Smoothing must be between 0 and 1, got %v", opts.Smoothing)
+	}
+	ret := []*Series{}
+	for _, s := range series {
+		points := []Point{}
+		for _, p := range s.Points {
+			if math.IsNaN(p.Y) || math.IsInf(p.Y, 0) || math.IsNaN(p.X) || math.IsInf(p.X, 0) {
+				continue
+			}
+			if opts.LogScale && p.Y <= 0 {
+				continue
+			}
+			points = append(points, p)
+		}
+		prepared := &Series{Name: s.Name, Points: points}
+		if opts.Smoothing > 0 {
+			prepared = prepared.Smooth(opts.Smoothing)
+		}
+		if opts.LogScale {
+			for i := range prepared.Points {
+				prepared.Points[i].Y = math.Log10(prepared.Points[i].Y)
+			}
+		}
+		ret = append(ret, prepared)
+	}
+	return ret, nil
+}
+
+// bounds is the range of values displayed on a chart
+type bounds struct {
+	minX, maxX, minY, maxY float64
+}
+
+func getBounds(series []*Series) (b bounds, ok bool) {
+	b = bounds{math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)}
+	for _, s := range series {
+		for _, p := range s.Points {
+			ok = true
+			b.minX = math.Min(b.minX, p.X)
+			b.maxX = math.Max(b.maxX, p.X)
+			b.minY = math.Min(b.minY, p.Y)
+			b.maxY = math.Max(b.maxY, p.Y)
+		}
+	}
+	// Give flat lines some room so they don't divide by zero
+	if b.minX == b.maxX {
+		b.minX -= 1
+		b.maxX += 1
+	}
+	if b.minY == b.maxY {
+		b.minY -= 1
+		b.maxY += 1
+	}
+	return b, ok
+}
+
+// scale returns the position of p in a width x height area, where (0, 0) is
+// the top left
+func (b bounds) scale(p Point, width, height float64) (x, y float64) {
+	x = (p.X - b.minX) / (b.maxX - b.minX) * width
+	y = height - (p.Y-b.minY)/(b.maxY-b.minY)*height
+	return x, y
+}
+
+// niceTicks returns around n evenly spaced, round numbers between min and max
+func niceTicks(min, max float64, n int) []float64 {
+	if n < 2 || max <= min {
+		return []float64{min}
+	}
+	step := niceNumber((max-min)/float64(n-1), true)
+	ticks := []float64{}
+	for t := math.Ceil(min/step) * step; t <= max+step*1e-9; t += step {
+		// Avoid "-0" and floating point noise like 0.6000000000000001
+		tick, _ := strconv.ParseFloat(strconv.FormatFloat(math.Round(t/step)*step, 'g', 12, 64), 64)
+		if tick == 0 {
+			tick = 0
+		}
+		ticks = append(ticks, tick)
+	}
+	return ticks
+}
+
+func niceNumber(x float64, round bool) float64 {
+	exp := math.Floor(math.Log10(x))
+	f := x / math.Pow(10, exp)
+	var nice float64
+	switch {
+	case round && f < 1.5, !round && f <= 1:
+		nice = 1
+	case round && f < 3, !round && f <= 2:
+		nice = 2
+	case round && f < 7, !round && f <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exp)
+}
+
+// formatValue formats a value for an axis label, undoing the log transform
+// if needed
+func formatValue(v float64, logScale bool) string {
+	if logScale {
+		v = math.Pow(10, v)
+	}
+	if v == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -0,0 +1,244 @@
+package plot
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/logrusorgru/aurora"
+)
+
+const defaultTerminalWidth = 80
+const defaultTerminalHeight = 20
+
+var asciiMarkers = []rune{'*', '+', 'o', 'x', '#', '@', '%', '&'}
+
+// braille dot bits, indexed by [x][y] within a 2x4 character cell
+var brailleDots = [2][4]rune{
+	{0x1, 0x2, 0x4, 0x40},
+	{0x8, 0x10, 0x20, 0x80},
+}
+
+// canvas is a grid of characters that lines are drawn on. In braille mode,
+// each character is made up of 2x4 dots.
+type canvas struct {
+	cols, rows int
+	dotsX      int
+	dotsY      int
+	ascii      bool
+	dots       [][]rune
+	owner      [][]int
+}
+
+func newCanvas(cols, rows int, ascii bool) *canvas {
+	c := &canvas{cols: cols, rows: rows, ascii: ascii, dotsX: cols * 2, dotsY: rows * 4}
+	if ascii {
+		c.dotsX = cols
+		c.dotsY = rows
+	}
+	c.dots = make([][]rune, rows)
+	c.owner = make([][]int, rows)
+	for r := range c.dots {
+		c.dots[r] = make([]rune, cols)
+		c.owner[r] = make([]int, cols)
+		for col := range c.owner[r] {
+			c.owner[r][col] = -1
+		}
+	}
+	return c
+}
+
+func (c *canvas) set(x, y int, seriesIndex int) {
+	if x < 0 || y < 0 || x >= c.dotsX || y >= c.dotsY {
+		return
+	}
+	if c.ascii {
+		c.dots[y][x] = asciiMarkers[seriesIndex%len(asciiMarkers)]
+		c.owner[y][x] = seriesIndex
+		return
+	}
+	col, row := x/2, y/4
+	c.dots[row][col] |= brailleDots[x%2][y%4]
+	c.owner[row][col] = seriesIndex
+}
+
+// line draws a line between two dots with Bresenham's algorithm
+func (c *canvas) line(x0, y0, x1, y1 int, seriesIndex int) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.set(x0, y0, seriesIndex)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func (c *canvas) cell(au aurora.Aurora, row, col int) string {
+	owner := c.owner[row][col]
+	if owner == -1 {
+		return " "
+	}
+	s := string(c.dots[row][col])
+	if !c.ascii {
+		s = string(0x2800 + c.dots[row][col])
+	}
+	return colorize(au, owner, s)
+}
+
+func colorize(au aurora.Aurora, seriesIndex int, s string) string {
+	colors := []func(interface{}) aurora.Value{au.Blue, au.Red, au.Green, au.Yellow, au.Magenta, au.Cyan}
+	return colors[seriesIndex%len(colors)](s).String()
+}
+
+// WriteTerminal draws series as a line chart with Unicode or ASCII characters
+func WriteTerminal(out io.Writer, series []*Series, opts Options) error {
+	series, err := prepare(series, opts)
+	if err != nil {
+		return err
+	}
+	b, ok := getBounds(series)
+	if !ok {
+		return fmt.Errorf("No data to plot")
+	}
+	au := aurora.NewAurora(opts.Color)
+
+	width := opts.Width
+	if width <= 0 {
+		width = defaultTerminalWidth
+	}
+	rows := opts.Height
+	if rows <= 0 {
+		rows = defaultTerminalHeight
+	}
+
+	// Label every few rows, and always the top and bottom row
+	labels := make([]string, rows)
+	labelWidth := 0
+	for r := 0; r < rows; r++ {
+		if r%5 != 0 && r != rows-1 {
+			continue
+		}
+		v := b.maxY
+		if rows > 1 {
+			v = b.maxY - float64(r)/float64(rows-1)*(b.maxY-b.minY)
+		}
+		labels[r] = formatValue(v, opts.LogScale)
+		if len(labels[r]) > labelWidth {
+			labelWidth = len(labels[r])
+		}
+	}
+
+	cols := width - labelWidth - 2
+	if cols < 10 {
+		cols = 10
+	}
+	c := newCanvas(cols, rows, opts.ASCII)
+	for i, s := range series {
+		prevX, prevY := -1, -1
+		for j, p := range s.Points {
+			fx, fy := b.scale(p, float64(c.dotsX-1), float64(c.dotsY-1))
+			x, y := round(fx), round(fy)
+			if j == 0 {
+				c.set(x, y, i)
+			} else {
+				c.line(prevX, prevY, x, y, i)
+			}
+			prevX, prevY = x, y
+		}
+	}
+
+	vertical, tick, corner, horizontal := "│", "┤", "└", "─"
+	if opts.ASCII {
+		vertical, tick, corner, horizontal = "|", "+", "+", "-"
+	}
+
+	var sb strings.Builder
+	for r := 0; r < rows; r++ {
+		axis := vertical
+		if labels[r] != "" {
+			axis = tick
+		}
+		line := fmt.Sprintf("%*s %s", labelWidth, labels[r], axis)
+		for col := 0; col < cols; col++ {
+			line += c.cell(au, r, col)
+		}
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	sb.WriteString(strings.Repeat(" ", labelWidth+1) + corner + strings.Repeat(horizontal, cols) + "\n")
+
+	// x axis labels at the start, middle and end
+	xLabels := []rune(strings.Repeat(" ", cols))
+	placeLabel(xLabels, 0, formatValue(b.minX, false))
+	midLabel := formatValue((b.minX+b.maxX)/2, false)
+	placeLabel(xLabels, cols/2-len(midLabel)/2, midLabel)
+	maxLabel := formatValue(b.maxX, false)
+	placeLabel(xLabels, cols-len(maxLabel), maxLabel)
+	sb.WriteString(strings.TrimRight(strings.Repeat(" ", labelWidth+2)+string(xLabels), " ") + "\n")
+	if opts.XLabel != "" {
+		pad := labelWidth + 2 + (cols-utf8.RuneCountInString(opts.XLabel))/2
+		if pad < 0 {
+			pad = 0
+		}
+		sb.WriteString(strings.Repeat(" ", pad) + opts.XLabel + "\n")
+	}
+
+	sb.WriteString("\n")
+	for i, s := range series {
+		marker := "●"
+		if opts.ASCII {
+			marker = string(asciiMarkers[i%len(asciiMarkers)])
+		}
+		sb.WriteString(fmt.Sprintf("%s%s %s\n", strings.Repeat(" ", labelWidth+2), colorize(au, i, marker), s.Name))
+	}
+
+	_, err = io.WriteString(out, sb.String())
+	return err
+}
+
+// placeLabel writes label into line at position, if it doesn't overwrite
+// another label
+func placeLabel(line []rune, position int, label string) {
+	if position < 0 {
+		position = 0
+	}
+	if position+len(label) > len(line) {
+		return
+	}
+	for i := position; i < position+len(label); i++ {
+		if line[i] != ' ' || (i > 0 && i == position && line[i-1] != ' ') {
+			return
+		}
+	}
+	copy(line[position:], []rune(label))
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func round(f float64) int {
+	return int(math.Round(f))
+}
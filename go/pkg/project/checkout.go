@@ -11,6 +11,13 @@ import (
 func (p *Project) CheckoutCheckpoint(checkpoint *Checkpoint, experiment *Experiment, outputDir string, quiet bool) error {
 	// TODO: This function checks out both experiments and checkpoints. This logic should probably be split out so those two things can be done explicitly. This will involve moving some logic to cli/checkpoint.go
 
+	if checkpoint != nil && checkpoint.Pruned {
+		return errors.DoesNotExist(fmt.Sprintf("The files of checkpoint %s have been deleted by a retention policy, so they can't be checked out. Its metrics are still recorded in the experiment.", checkpoint.ShortID()))
+	}
+	if checkpoint != nil && checkpoint.Trashed {
+		return errors.DoesNotExist(fmt.Sprintf("Checkpoint %s has been moved to the trash with 'keepsake rm', so it can't be checked out. Run 'keepsake restore %s' to restore it, if it hasn't been deleted from the trash.", checkpoint.ShortID(), checkpoint.ShortID()))
+	}
+
 	if checkpoint == nil {
 		if experiment.Path == "" {
 			return errors.DoesNotExist(fmt.Sprintf("The experiment %s does not have any files associated with it. You need to pass the 'path' argument to 'init()' to check out files.", experiment.ShortID()))
@@ -25,9 +32,11 @@ func (p *Project) CheckoutCheckpoint(checkpoint *Checkpoint, experiment *Experim
 		if !quiet {
 			console.Info("Copying files from experiment %s to %q...", experiment.ShortID(), filepath.Join(outputDir, experiment.Path))
 		}
-		if err := p.repository.GetPathTar(experiment.StorageTarPath(), outputDir); err != nil {
+		if err := p.repository.GetPathTar(experiment.StorageTarPath(), outputDir, experiment.StorageDigest()); err != nil {
 			if errors.IsDoesNotExist(err) {
 				return errors.DoesNotExist(fmt.Sprintf("Experiment %s is supposed to have files associated with it, but could not find the files at %q.\nMaybe it hasn't been written yet, or the repository is corrupted?", experiment.ShortID(), experiment.StorageTarPath()))
+			} else if errors.IsCorruptedTarball(err) {
+				return errors.CorruptedTarball(fmt.Sprintf("The files of experiment %s are corrupted, so they can't be checked out: %v", experiment.ShortID(), err))
 			} else {
 				return err
 			}
@@ -40,9 +49,11 @@ func (p *Project) CheckoutCheckpoint(checkpoint *Checkpoint, experiment *Experim
 			console.Info("Copying files from checkpoint %s to %q...", checkpoint.ShortID(), filepath.Join(outputDir, checkpoint.Path))
 		}
 
-		if err := p.repository.GetPathTar(checkpoint.StorageTarPath(), outputDir); err != nil {
+		if err := p.repository.GetPathTar(checkpoint.StorageTarPath(), outputDir, checkpoint.StorageDigest()); err != nil {
 			if errors.IsDoesNotExist(err) {
 				return errors.DoesNotExist(fmt.Sprintf("Checkpoint %s is supposed to have files associated with it, but could not find the files at %q.\nMaybe it hasn't been written yet, or the repository is corrupted?", checkpoint.ShortID(), checkpoint.StorageTarPath()))
+			} else if errors.IsCorruptedTarball(err) {
+				return errors.CorruptedTarball(fmt.Sprintf("The files of checkpoint %s are corrupted, so they can't be checked out: %v", checkpoint.ShortID(), err))
 			} else {
 				return err
 
@@ -66,7 +77,7 @@ func (p *Project) CheckoutFileOrDirectory(checkpoint *Checkpoint, experiment *Ex
 	experimentFilesExist := true
 	checkpointFilesExist := true
 
-	if err := p.repository.GetPathItemTar(filepath.Join("experiments", experiment.ID+".tar.gz"), checkoutPath, outputDir); err != nil {
+	if err := p.repository.GetPathItemTar(filepath.Join("experiments", experiment.ID+".tar.gz"), checkoutPath, outputDir, experiment.StorageDigest()); err != nil {
 		// Ignore does not exist errors
 		if errors.IsDoesNotExist(err) {
 			console.Debug("No experiment data found")
@@ -81,7 +92,7 @@ func (p *Project) CheckoutFileOrDirectory(checkpoint *Checkpoint, experiment *Ex
 	// Overlay checkpoint on top of experiment
 	if checkpoint != nil {
 
-		if err := p.repository.GetPathItemTar(filepath.Join("checkpoints", checkpoint.ID+".tar.gz"), checkoutPath, outputDir); err != nil {
+		if err := p.repository.GetPathItemTar(filepath.Join("checkpoints", checkpoint.ID+".tar.gz"), checkoutPath, outputDir, checkpoint.StorageDigest()); err != nil {
 			if errors.IsDoesNotExist(err) {
 				console.Debug("No checkpoint data found")
 				checkpointFilesExist = false
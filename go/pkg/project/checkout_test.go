@@ -1,12 +1,14 @@
 package project
 
 import (
+	"io/ioutil"
 	"os"
 	"path"
 	"testing"
 	"time"
 
 	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/errors"
 	"github.com/replicate/keepsake/go/pkg/files"
 	"github.com/replicate/keepsake/go/pkg/repository"
 	"github.com/stretchr/testify/require"
@@ -48,3 +50,40 @@ func TestCheckoutWithNoPaths(t *testing.T) {
 	require.NotNil(t, err)
 	require.Contains(t, err.Error(), "The experiment 1eeeeee does not have any files associated with it.")
 }
+
+func TestCheckoutCorruptedCheckpoint(t *testing.T) {
+	projectDir, err := files.TempDir("test-checkout-corrupted")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectDir)
+	require.NoError(t, ioutil.WriteFile(path.Join(projectDir, "model.pth"), []byte("weights"), 0644))
+
+	repoDir := path.Join(projectDir, ".keepsake")
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	experiment := &Experiment{ID: "1eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	checkpoint := &Checkpoint{ID: "2ccccccccc", Created: time.Now().UTC(), Path: "model.pth"}
+	experiment.Checkpoints = []*Checkpoint{checkpoint}
+	digest, err := repo.PutPathTar(projectDir, checkpoint.StorageTarPath(), checkpoint.Path)
+	require.NoError(t, err)
+	checkpoint.Size, checkpoint.SHA256 = digest.Size, digest.SHA256
+	require.NoError(t, experiment.Save(repo))
+
+	project := NewProject(repo, projectDir)
+	outputDir, err := files.TempDir("test-checkout-corrupted-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(outputDir)
+	require.NoError(t, project.CheckoutCheckpoint(checkpoint, experiment, outputDir, true))
+
+	// truncate it, like an interrupted upload
+	tarPath := path.Join(repoDir, checkpoint.StorageTarPath())
+	data, err := ioutil.ReadFile(tarPath)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(tarPath, data[:len(data)-10], 0644))
+
+	err = project.CheckoutCheckpoint(checkpoint, experiment, outputDir, true)
+	require.True(t, errors.IsCorruptedTarball(err), err)
+	require.Contains(t, err.Error(), "The files of checkpoint 2cccccc are corrupted")
+	err = project.CheckoutFileOrDirectory(checkpoint, experiment, outputDir, "model.pth")
+	require.True(t, errors.IsCorruptedTarball(err), err)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/replicate/keepsake/go/pkg/hash"
 	"github.com/replicate/keepsake/go/pkg/param"
+	"github.com/replicate/keepsake/go/pkg/repository"
 )
 
 type MetricGoal string
@@ -28,6 +29,17 @@ type Checkpoint struct {
 	Step          int64          `json:"step"`
 	Path          string         `json:"path"`
 	PrimaryMetric *PrimaryMetric `json:"primary_metric"`
+	Tags          []string       `json:"tags,omitempty"`
+	// Pruned is set when the checkpoint's files have been deleted by a
+	// retention policy
+	Pruned bool `json:"pruned,omitempty"`
+	// Trashed is set when the checkpoint's files have been moved to the
+	// trash with "keepsake rm", and cleared if they are restored
+	Trashed bool `json:"trashed,omitempty"`
+	// Size and SHA256 are the size in bytes and SHA-256 checksum of the
+	// checkpoint's tarball, recorded when it was uploaded
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
 }
 
 // NewCheckpoint creates a checkpoint with default values
@@ -57,3 +69,9 @@ func (c *Checkpoint) ShortID() string {
 func (c *Checkpoint) StorageTarPath() string {
 	return "checkpoints/" + c.ID + ".tar.gz"
 }
+
+// StorageDigest returns the recorded digest of the checkpoint's tarball, to
+// verify it when it is downloaded
+func (c *Checkpoint) StorageDigest() *repository.Digest {
+	return &repository.Digest{Size: c.Size, SHA256: c.SHA256}
+}
@@ -2,8 +2,10 @@ package project
 
 import (
 	"encoding/json"
+	"os"
 	"path"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/replicate/keepsake/go/pkg/config"
@@ -28,6 +30,69 @@ type Experiment struct {
 	Checkpoints      []*Checkpoint     `json:"checkpoints"`
 	KeepsakeVersion  string            `json:"keepsake_version"`
 	ReplicateVersion string            `json:"replicate_version,omitempty"`
+	Parents          []*ParentRef      `json:"parents,omitempty"`
+	// Size and SHA256 are the size in bytes and SHA-256 checksum of the code
+	// tarball, recorded when it was uploaded. They are empty if the
+	// experiment was uploaded by an older version of Keepsake.
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Environment variables that `keepsake rerun` sets on the command it runs, so
+// experiments created by that command record where they were rerun from
+const (
+	RerunExperimentIDEnvVar = "KEEPSAKE_RERUN_EXPERIMENT_ID"
+	RerunCheckpointIDEnvVar = "KEEPSAKE_RERUN_CHECKPOINT_ID"
+)
+
+// TakeRerunParentFromEnv returns the experiment and checkpoint that
+// `keepsake rerun` set in the environment, as a parent, or nil if this
+// process wasn't started by it. The environment variables are unset, so only
+// the first experiment the process creates is recorded as a rerun, and
+// processes it starts aren't recorded as reruns.
+func TakeRerunParentFromEnv() *ParentRef {
+	experimentID := os.Getenv(RerunExperimentIDEnvVar)
+	checkpointID := os.Getenv(RerunCheckpointIDEnvVar)
+	os.Unsetenv(RerunExperimentIDEnvVar)
+	os.Unsetenv(RerunCheckpointIDEnvVar)
+	if experimentID == "" {
+		return nil
+	}
+	return &ParentRef{
+		ExperimentID: experimentID,
+		CheckpointID: checkpointID,
+		Kind:         ParentKindRerun,
+	}
+}
+
+// ParentKind is how an experiment was derived from its parent
+type ParentKind string
+
+const (
+	// ParentKindParent is a parent passed when the experiment was created,
+	// which it was resumed or fine-tuned from
+	ParentKindParent ParentKind = ""
+	// ParentKindRerun is an experiment that `keepsake rerun` was run on
+	ParentKindRerun ParentKind = "rerun"
+)
+
+// ParentRef is an experiment, or a checkpoint within it, that an experiment
+// was derived from
+type ParentRef struct {
+	ExperimentID string     `json:"experiment_id"`
+	CheckpointID string     `json:"checkpoint_id,omitempty"`
+	Kind         ParentKind `json:"kind,omitempty"`
+}
+
+func (r *ParentRef) String() string {
+	ret := "experiment " + shortID(r.ExperimentID)
+	if r.CheckpointID != "" {
+		ret = "checkpoint " + shortID(r.CheckpointID) + " (experiment " + shortID(r.ExperimentID) + ")"
+	}
+	if r.Kind == ParentKindRerun {
+		ret += ", rerun"
+	}
+	return ret
 }
 
 type NamedParam struct {
@@ -46,13 +111,51 @@ func NewExperiment(params param.ValueMap) *Experiment {
 
 // Save experiment to repository
 func (e *Experiment) Save(repo repository.Repository) error {
-	data, err := json.MarshalIndent(e, "", " ")
+	data, err := e.marshal()
 	if err != nil {
 		return err
 	}
 	return repo.Put(path.Join("metadata", "experiments", e.ID+".json"), data)
 }
 
+func (e *Experiment) marshal() ([]byte, error) {
+	return json.MarshalIndent(e, "", " ")
+}
+
+// mergeSaved merges a copy of the experiment that was saved by another
+// process into e. Checkpoints that are only in saved are added, so
+// checkpoints are never lost when two processes save the same experiment.
+// Checkpoints pruned in either are pruned, and tarball digests that e doesn't
+// have are copied from saved. Everything else is taken from e.
+func (e *Experiment) mergeSaved(saved *Experiment) {
+	if e.SHA256 == "" {
+		e.Size, e.SHA256 = saved.Size, saved.SHA256
+	}
+	checkpoints := map[string]*Checkpoint{}
+	for _, chk := range e.Checkpoints {
+		checkpoints[chk.ID] = chk
+	}
+	added := false
+	for _, savedChk := range saved.Checkpoints {
+		chk, ok := checkpoints[savedChk.ID]
+		if !ok {
+			e.Checkpoints = append(e.Checkpoints, savedChk)
+			added = true
+			continue
+		}
+		chk.Pruned = chk.Pruned || savedChk.Pruned
+		chk.Trashed = chk.Trashed || savedChk.Trashed
+		if chk.SHA256 == "" {
+			chk.Size, chk.SHA256 = savedChk.Size, savedChk.SHA256
+		}
+	}
+	if added {
+		sort.SliceStable(e.Checkpoints, func(i, j int) bool {
+			return e.Checkpoints[i].Created.Before(e.Checkpoints[j].Created)
+		})
+	}
+}
+
 func (c *Experiment) SortedParams() []*NamedParam {
 	ret := []*NamedParam{}
 	for k, v := range c.Params {
@@ -68,6 +171,13 @@ func (e *Experiment) ShortID() string {
 	return e.ID[:7]
 }
 
+func shortID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
+
 func (e *Experiment) MetadataPath() string {
 	return "metadata/experiments/" + e.ID + ".json"
 }
@@ -80,6 +190,24 @@ func (e *Experiment) StorageTarPath() string {
 	return "experiments/" + e.ID + ".tar.gz"
 }
 
+// StorageDigest returns the recorded digest of the code tarball, to verify
+// it when it is downloaded
+func (e *Experiment) StorageDigest() *repository.Digest {
+	return &repository.Digest{Size: e.Size, SHA256: e.SHA256}
+}
+
+// CheckpointsNotTrashed returns the experiment's checkpoints, except the ones
+// that have been moved to the trash
+func (e *Experiment) CheckpointsNotTrashed() []*Checkpoint {
+	ret := []*Checkpoint{}
+	for _, chk := range e.Checkpoints {
+		if !chk.Trashed {
+			ret = append(ret, chk)
+		}
+	}
+	return ret
+}
+
 // LatestCheckpoint returns the latest checkpoint for an experiment
 func (e *Experiment) LatestCheckpoint() *Checkpoint {
 	if len(e.Checkpoints) == 0 {
@@ -99,24 +227,31 @@ func (e *Experiment) BestCheckpoint() *Checkpoint {
 	if len(e.Checkpoints) == 0 {
 		return nil
 	}
-	checkpoints := copyCheckpoints(e.Checkpoints)
-
 	// Use primary metric from first checkpoint
 	// TODO (bfirsh): warn if primary metric differs across checkpoints
-	primaryMetric := checkpoints[0].PrimaryMetric
-	if primaryMetric == nil {
+	ranked := checkpointsByPrimaryMetric(e.Checkpoints, e.Checkpoints[0].PrimaryMetric)
+	if len(ranked) == 0 {
 		return nil
 	}
+	return ranked[len(ranked)-1]
+}
 
-	sort.Slice(checkpoints, func(i, j int) bool {
-		iVal, iOK := checkpoints[i].Metrics[primaryMetric.Name]
-		jVal, jOK := checkpoints[j].Metrics[primaryMetric.Name]
-		if !iOK {
-			return true
-		}
-		if !jOK {
-			return false
+// checkpointsByPrimaryMetric returns the checkpoints that have a value for
+// primaryMetric, sorted from worst to best
+func checkpointsByPrimaryMetric(checkpoints []*Checkpoint, primaryMetric *PrimaryMetric) []*Checkpoint {
+	if primaryMetric == nil {
+		return nil
+	}
+	ret := []*Checkpoint{}
+	for _, chk := range checkpoints {
+		if _, ok := chk.Metrics[primaryMetric.Name]; ok {
+			ret = append(ret, chk)
 		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		iVal := ret[i].Metrics[primaryMetric.Name]
+		jVal := ret[j].Metrics[primaryMetric.Name]
 		if primaryMetric.Goal == GoalMaximize {
 			less, err := iVal.LessThan(jVal)
 			if err != nil {
@@ -131,14 +266,7 @@ func (e *Experiment) BestCheckpoint() *Checkpoint {
 			return greater
 		}
 	})
-	best := checkpoints[len(checkpoints)-1]
-
-	// if the last (best) checkpoint in the sorted list doesn't have
-	// a value for the primary metric, none of them do
-	if _, ok := best.Metrics[primaryMetric.Name]; !ok {
-		return nil
-	}
-	return best
+	return ret
 }
 
 func listExperiments(repo repository.Repository) ([]*Experiment, error) {
@@ -148,11 +276,10 @@ func listExperiments(repo repository.Repository) ([]*Experiment, error) {
 	}
 	experiments := []*Experiment{}
 	for _, p := range paths {
-		exp := new(Experiment)
-		if err := loadFromPath(repo, p, exp); err == nil {
-			if exp.KeepsakeVersion == "" && exp.ReplicateVersion != "" {
-				exp.KeepsakeVersion = exp.ReplicateVersion
-			}
+		if !strings.HasSuffix(p, ".json") {
+			continue
+		}
+		if exp, err := loadExperimentFromPath(repo, p); err == nil {
 			experiments = append(experiments, exp)
 		} else {
 			// Should we complain more loudly? https://github.com/replicate/keepsake/issues/347
@@ -162,6 +289,17 @@ func listExperiments(repo repository.Repository) ([]*Experiment, error) {
 	return experiments, nil
 }
 
+func loadExperimentFromPath(repo repository.Repository, path string) (*Experiment, error) {
+	exp := new(Experiment)
+	if err := loadFromPath(repo, path, exp); err != nil {
+		return nil, err
+	}
+	if exp.KeepsakeVersion == "" && exp.ReplicateVersion != "" {
+		exp.KeepsakeVersion = exp.ReplicateVersion
+	}
+	return exp, nil
+}
+
 func copyCheckpoints(checkpoints []*Checkpoint) []*Checkpoint {
 	copied := make([]*Checkpoint, len(checkpoints))
 	copy(copied, checkpoints)
@@ -3,9 +3,11 @@ package project
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path"
 	"time"
 
+	"github.com/replicate/keepsake/go/pkg/config"
 	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/repository"
 )
@@ -14,12 +16,37 @@ import (
 var heartbeatRefreshInterval = 10 * time.Second
 
 // the number of missed heartbeats we tolerate before declaring
-// the experiment "stopped"
+// the experiment "stopped", for heartbeats that don't have a timeout
 var heartbeatMissTolerance = 3
 
+// ExperimentStatus is whether an experiment is running
+type ExperimentStatus string
+
+const (
+	StatusRunning ExperimentStatus = "running"
+	StatusStopped ExperimentStatus = "stopped"
+	// StatusDead is an experiment whose heartbeat is recent, but whose
+	// process is known to have exited without stopping it
+	StatusDead ExperimentStatus = "dead"
+)
+
+// Heartbeat is written regularly while an experiment runs. Heartbeats
+// written by older versions of Keepsake only have ExperimentID and
+// LastHeartbeat.
 type Heartbeat struct {
 	ExperimentID  string    `json:"experiment_id"`
 	LastHeartbeat time.Time `json:"last_heartbeat"`
+	// IntervalSeconds is how often the heartbeat is written, and
+	// TimeoutSeconds is how long after LastHeartbeat the experiment is
+	// considered to have stopped
+	IntervalSeconds float64 `json:"interval_seconds,omitempty"`
+	TimeoutSeconds  float64 `json:"timeout_seconds,omitempty"`
+	// Host, PIDNamespace, PID, and ProcessStarted identify the process the
+	// experiment runs in, so it can be found to have exited
+	Host           string     `json:"host,omitempty"`
+	PIDNamespace   string     `json:"pid_namespace,omitempty"`
+	PID            int        `json:"pid,omitempty"`
+	ProcessStarted *time.Time `json:"process_started,omitempty"`
 }
 
 func CreateHeartbeat(repo repository.Repository, experimentID string, t time.Time) error {
@@ -27,11 +54,35 @@ func CreateHeartbeat(repo repository.Repository, experimentID string, t time.Tim
 		ExperimentID:  experimentID,
 		LastHeartbeat: t,
 	}
-	data, err := json.MarshalIndent(heartbeat, "", " ")
+	return heartbeat.save(repo)
+}
+
+// NewHeartbeat returns a heartbeat for an experiment that runs in the
+// process with the given PID on this machine, which is written at the
+// interval in conf. It is saved with Project.RefreshHeartbeat.
+func NewHeartbeat(experimentID string, pid int, conf *config.HeartbeatConfig) *Heartbeat {
+	hb := &Heartbeat{
+		ExperimentID:    experimentID,
+		IntervalSeconds: conf.IntervalDuration().Seconds(),
+		TimeoutSeconds:  conf.TimeoutDuration().Seconds(),
+		PIDNamespace:    pidNamespace(),
+		PID:             pid,
+	}
+	if host, err := os.Hostname(); err == nil {
+		hb.Host = host
+	}
+	if started, err := processStartTime(pid); err == nil {
+		hb.ProcessStarted = &started
+	}
+	return hb
+}
+
+func (h *Heartbeat) save(repo repository.Repository) error {
+	data, err := json.MarshalIndent(h, "", " ")
 	if err != nil {
 		return err
 	}
-	return repo.Put(path.Join("metadata", "heartbeats", experimentID+".json"), data)
+	return repo.Put(path.Join("metadata", "heartbeats", h.ExperimentID+".json"), data)
 }
 
 func DeleteHeartbeat(repo repository.Repository, experimentID string) error {
@@ -56,9 +107,55 @@ func listHeartbeats(repo repository.Repository) ([]*Heartbeat, error) {
 }
 
 func (h *Heartbeat) IsRunning() bool {
+	return h.Status() == StatusRunning
+}
+
+// Status returns whether the experiment is running. It has stopped if the
+// heartbeat is older than its timeout, and it is dead if the heartbeat was
+// written on this machine by a process that has since exited.
+func (h *Heartbeat) Status() ExperimentStatus {
 	now := time.Now().UTC()
-	lastTolerableHeartbeat := now.Add(-heartbeatRefreshInterval * time.Duration(heartbeatMissTolerance))
-	return h.LastHeartbeat.After(lastTolerableHeartbeat)
+	lastTolerableHeartbeat := now.Add(-h.timeout())
+	if !h.LastHeartbeat.After(lastTolerableHeartbeat) {
+		return StatusStopped
+	}
+	if h.processHasExited() {
+		return StatusDead
+	}
+	return StatusRunning
+}
+
+func (h *Heartbeat) timeout() time.Duration {
+	if h.TimeoutSeconds > 0 {
+		return time.Duration(h.TimeoutSeconds * float64(time.Second))
+	}
+	return heartbeatRefreshInterval * time.Duration(heartbeatMissTolerance)
+}
+
+// processHasExited returns true if the heartbeat's process ran on this
+// machine, and either doesn't exist any more or its PID is now used by
+// another process. It is false if that can't be known.
+func (h *Heartbeat) processHasExited() bool {
+	if h.PID == 0 || h.Host == "" || h.PIDNamespace != pidNamespace() {
+		return false
+	}
+	if host, err := os.Hostname(); err != nil || host != h.Host {
+		return false
+	}
+	exists, err := processExists(h.PID)
+	if err != nil {
+		return false
+	}
+	if !exists {
+		return true
+	}
+	if h.ProcessStarted != nil {
+		if started, err := processStartTime(h.PID); err == nil {
+			diff := started.Sub(*h.ProcessStarted)
+			return diff > time.Second || diff < -time.Second
+		}
+	}
+	return false
 }
 
 func loadHeartbeatFromPath(repo repository.Repository, path string) (*Heartbeat, error) {
@@ -0,0 +1,62 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+)
+
+func TestHeartbeatTimeout(t *testing.T) {
+	// old heartbeats use the default timeout of 30 seconds
+	hb := &Heartbeat{ExperimentID: "1eeeeeeeee", LastHeartbeat: time.Now().UTC().Add(-20 * time.Second)}
+	require.Equal(t, StatusRunning, hb.Status())
+	hb.LastHeartbeat = time.Now().UTC().Add(-40 * time.Second)
+	require.Equal(t, StatusStopped, hb.Status())
+
+	hb = NewHeartbeat("1eeeeeeeee", os.Getpid(), &config.HeartbeatConfig{Interval: "1s", Timeout: "2m"})
+	require.Equal(t, float64(1), hb.IntervalSeconds)
+	require.Equal(t, float64(120), hb.TimeoutSeconds)
+	hb.LastHeartbeat = time.Now().UTC().Add(-90 * time.Second)
+	require.Equal(t, StatusRunning, hb.Status())
+	hb.LastHeartbeat = time.Now().UTC().Add(-3 * time.Minute)
+	require.Equal(t, StatusStopped, hb.Status())
+}
+
+func TestHeartbeatDeadProcess(t *testing.T) {
+	hb := NewHeartbeat("1eeeeeeeee", os.Getpid(), nil)
+	hb.LastHeartbeat = time.Now().UTC()
+	require.NotEmpty(t, hb.Host)
+	require.Equal(t, StatusRunning, hb.Status())
+	require.True(t, hb.IsRunning())
+
+	cmd := exec.Command("go", "version")
+	require.NoError(t, cmd.Run())
+	exited := NewHeartbeat("1eeeeeeeee", cmd.Process.Pid, nil)
+	exited.LastHeartbeat = time.Now().UTC()
+	require.Equal(t, StatusDead, exited.Status())
+	require.False(t, exited.IsRunning())
+
+	// it can't be known if processes on other machines have exited
+	exited.Host = "some-other-host"
+	require.Equal(t, StatusRunning, exited.Status())
+
+	// a heartbeat that is out of date has stopped, whether or not the
+	// process has exited
+	exited.Host = hb.Host
+	exited.LastHeartbeat = time.Now().UTC().Add(-time.Hour)
+	require.Equal(t, StatusStopped, exited.Status())
+
+	if runtime.GOOS == "linux" {
+		// the PID has been reused by another process
+		require.NotNil(t, hb.ProcessStarted)
+		started := hb.ProcessStarted.Add(-time.Hour)
+		hb.ProcessStarted = &started
+		require.Equal(t, StatusDead, hb.Status())
+	}
+}
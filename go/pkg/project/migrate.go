@@ -0,0 +1,245 @@
+package project
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/repository"
+	"github.com/replicate/keepsake/go/pkg/slices"
+)
+
+// Migration upgrades a repository from one version of the repository format
+// to the next
+type Migration struct {
+	From  int
+	To    int
+	Steps []*MigrationStep
+}
+
+// MigrationStep is one change made by a migration. Steps are recorded in the
+// repository spec as they finish, so an interrupted migration can be resumed.
+// A step must be safe to run again if it was interrupted part way through.
+type MigrationStep struct {
+	Name        string
+	Description string
+	// Run makes the change, calling report with a description of each thing
+	// it changes. If dryRun is set, it only reports what it would change.
+	Run func(p *Project, dryRun bool, report func(format string, a ...interface{})) error
+}
+
+// migrations are the migrations between each version of the repository
+// format, in order
+var migrations = []*Migration{
+	{
+		From: 1,
+		To:   2,
+		Steps: []*MigrationStep{
+			{
+				Name:        "experiment-metadata",
+				Description: "Rename the fields in experiment metadata from when Keepsake was called Replicate",
+				Run:         migrateLegacyExperimentMetadata,
+			},
+			{
+				Name:        "orphaned-heartbeats",
+				Description: "Delete heartbeats of experiments that no longer exist",
+				Run:         deleteOrphanedHeartbeats,
+			},
+		},
+	},
+}
+
+// Migrate migrates the repository to the current version of the repository
+// format, writing progress to out.
+//
+// The spec lock is held for the whole migration, and the spec records the
+// migration while it is running. That stops other migrations and new
+// experiments from being created, and older versions of Keepsake refuse to use
+// the repository because the spec has the new version. If the migration is
+// interrupted, running it again resumes it from the first step that hadn't
+// finished. Other processes only read the spec every specCheckInterval, so
+// ones that were already writing may carry on for a few seconds after the
+// migration starts.
+func (p *Project) Migrate(dryRun bool, out io.Writer) error {
+	unlock, err := repository.LockSpec(p.repository)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	p.migrating = true
+	defer func() { p.migrating = false }()
+
+	spec, err := repository.LoadSpec(p.repository)
+	if err != nil {
+		return err
+	}
+	if spec == nil {
+		spec, err = repository.DefaultSpec(p.repository)
+		if err != nil {
+			return err
+		}
+	}
+
+	var progress *repository.MigrationProgress
+	version := spec.Version
+	if spec.Migration != nil {
+		progress = spec.Migration
+		version = progress.From
+		fmt.Fprintf(out, "Resuming the migration of %s from version %d\n", p.repository.RootURL(), version)
+	} else if spec.Version > repository.Version {
+		return errors.IncompatibleRepositoryVersion(p.repository.RootURL())
+	} else if spec.Version == repository.Version {
+		fmt.Fprintf(out, "%s is already using the latest version of the repository format (version %d)\n", p.repository.RootURL(), spec.Version)
+		return nil
+	}
+	if dryRun {
+		fmt.Fprintln(out, "This is a dry run, so nothing will be changed.")
+	}
+
+	for version < repository.Version {
+		migration := migrationFrom(version)
+		if migration == nil {
+			return fmt.Errorf("Don't know how to migrate %s from version %d of the repository format", p.repository.RootURL(), version)
+		}
+		if progress == nil {
+			progress = &repository.MigrationProgress{From: migration.From, CompletedSteps: []string{}}
+		}
+		fmt.Fprintf(out, "\nMigrating from version %d to %d:\n", migration.From, migration.To)
+
+		for _, step := range migration.Steps {
+			if slices.ContainsString(progress.CompletedSteps, step.Name) {
+				fmt.Fprintf(out, "  %s: already done\n", step.Description)
+				continue
+			}
+			// Record the migration in the spec before changing anything, so
+			// other processes stop writing to the repository
+			if !dryRun {
+				if err := repository.WriteSpec(p.repository, specWith(spec, migration.To, progress)); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(out, "  %s\n", step.Description)
+			changes := 0
+			report := func(format string, a ...interface{}) {
+				changes++
+				fmt.Fprintf(out, "    %s\n", fmt.Sprintf(format, a...))
+			}
+			if err := step.Run(p, dryRun, report); err != nil {
+				return fmt.Errorf("Failed to migrate %s (%s): %w. Run 'keepsake migrate' again to resume the migration.", p.repository.RootURL(), step.Name, err)
+			}
+			if changes == 0 {
+				fmt.Fprintln(out, "    Nothing to change")
+			}
+			progress.CompletedSteps = append(progress.CompletedSteps, step.Name)
+		}
+
+		if !dryRun {
+			if err := repository.WriteSpec(p.repository, specWith(spec, migration.To, nil)); err != nil {
+				return err
+			}
+		}
+		progress = nil
+		version = migration.To
+	}
+
+	p.invalidateCache()
+	if dryRun {
+		fmt.Fprintf(out, "\nRun 'keepsake migrate' without --dry-run to migrate %s to version %d.\n", p.repository.RootURL(), version)
+	} else {
+		fmt.Fprintf(out, "\nMigrated %s to version %d.\n", p.repository.RootURL(), version)
+	}
+	return nil
+}
+
+// specWith returns a copy of spec with the version and migration progress
+// changed, so the rest of the spec, like the mode, is kept
+func specWith(spec *repository.Spec, version int, progress *repository.MigrationProgress) *repository.Spec {
+	updated := *spec
+	updated.Version = version
+	updated.Migration = progress
+	return &updated
+}
+
+func migrationFrom(version int) *Migration {
+	for _, m := range migrations {
+		if m.From == version {
+			return m
+		}
+	}
+	return nil
+}
+
+func migrateLegacyExperimentMetadata(p *Project, dryRun bool, report func(format string, a ...interface{})) error {
+	paths, err := p.repository.List("metadata/experiments/")
+	if err != nil {
+		return err
+	}
+	for _, metadataPath := range paths {
+		if !strings.HasSuffix(metadataPath, ".json") {
+			continue
+		}
+		err := p.updateExperimentMetadata(metadataPath, func(saved *Experiment) (*Experiment, error) {
+			if saved == nil {
+				// deleted since it was listed
+				return nil, nil
+			}
+			changes := []string{}
+			if saved.ReplicateVersion != "" {
+				if saved.KeepsakeVersion == "" {
+					saved.KeepsakeVersion = saved.ReplicateVersion
+				}
+				saved.ReplicateVersion = ""
+				changes = append(changes, "replicate_version to keepsake_version")
+			}
+			if saved.Config != nil && saved.Config.Storage != "" {
+				if saved.Config.Repository == "" {
+					saved.Config.Repository = saved.Config.Storage
+				}
+				saved.Config.Storage = ""
+				changes = append(changes, "config storage to repository")
+			}
+			if len(changes) == 0 {
+				return nil, nil
+			}
+			report("Experiment %s: rename %s", saved.ShortID(), strings.Join(changes, ", and "))
+			if dryRun {
+				return nil, nil
+			}
+			return saved, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteOrphanedHeartbeats(p *Project, dryRun bool, report func(format string, a ...interface{})) error {
+	heartbeatPaths, err := p.repository.List("metadata/heartbeats/")
+	if err != nil {
+		return err
+	}
+	experimentPaths, err := p.repository.List("metadata/experiments/")
+	if err != nil {
+		return err
+	}
+	experiments := map[string]bool{}
+	for _, experimentPath := range experimentPaths {
+		experiments[path.Base(experimentPath)] = true
+	}
+	for _, heartbeatPath := range heartbeatPaths {
+		if !strings.HasSuffix(heartbeatPath, ".json") || experiments[path.Base(heartbeatPath)] {
+			continue
+		}
+		report("Delete %s", heartbeatPath)
+		if dryRun {
+			continue
+		}
+		if err := p.repository.Delete(heartbeatPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
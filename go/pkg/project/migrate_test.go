@@ -0,0 +1,143 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestMigrationsChainToCurrentVersion(t *testing.T) {
+	version := 1
+	for version < repository.Version {
+		migration := migrationFrom(version)
+		require.NotNil(t, migration, "no migration from version %d", version)
+		require.Equal(t, version+1, migration.To)
+		version = migration.To
+	}
+}
+
+func legacyTestRepository(t *testing.T) (repository.Repository, string) {
+	repoDir, err := files.TempDir("test-migrate")
+	require.NoError(t, err)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Put("metadata/experiments/1eeeeeeeee.json", []byte(`{
+  "id": "1eeeeeeeee",
+  "created": "2020-12-07T01:13:29Z",
+  "replicate_version": "0.1.0",
+  "config": {"storage": "s3://foobar"}
+}`)))
+	require.NoError(t, repo.Put("metadata/experiments/2eeeeeeeee.json", []byte(`{
+  "id": "2eeeeeeeee",
+  "created": "2020-12-07T01:13:29Z",
+  "keepsake_version": "0.2.0",
+  "config": {"repository": "s3://foobar"}
+}`)))
+	require.NoError(t, repo.Put("metadata/heartbeats/1eeeeeeeee.json", []byte(`{}`)))
+	require.NoError(t, repo.Put("metadata/heartbeats/3eeeeeeeee.json", []byte(`{}`)))
+	return repo, repoDir
+}
+
+func TestMigrate(t *testing.T) {
+	repo, repoDir := legacyTestRepository(t)
+	defer os.RemoveAll(repoDir)
+	proj := NewProject(repo, "")
+	legacy, err := repo.Get("metadata/experiments/1eeeeeeeee.json")
+	require.NoError(t, err)
+
+	// dry run doesn't change anything
+	out := new(bytes.Buffer)
+	require.NoError(t, proj.Migrate(true, out))
+	require.Contains(t, out.String(), "Experiment 1eeeeee: rename replicate_version to keepsake_version, and config storage to repository")
+	require.Contains(t, out.String(), "Delete metadata/heartbeats/3eeeeeeeee.json")
+	content, err := repo.Get("metadata/experiments/1eeeeeeeee.json")
+	require.NoError(t, err)
+	require.Equal(t, legacy, content)
+	require.FileExists(t, path.Join(repoDir, "metadata/heartbeats/3eeeeeeeee.json"))
+	require.NoFileExists(t, path.Join(repoDir, repository.SpecPath))
+
+	out = new(bytes.Buffer)
+	require.NoError(t, proj.Migrate(false, out))
+	require.Contains(t, out.String(), "Migrated")
+
+	content, err = repo.Get("metadata/experiments/1eeeeeeeee.json")
+	require.NoError(t, err)
+	fields := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(content, &fields))
+	require.Equal(t, "0.1.0", fields["keepsake_version"])
+	require.NotContains(t, fields, "replicate_version")
+	require.Equal(t, map[string]interface{}{"repository": "s3://foobar"}, fields["config"])
+
+	require.FileExists(t, path.Join(repoDir, "metadata/heartbeats/1eeeeeeeee.json"))
+	require.NoFileExists(t, path.Join(repoDir, "metadata/heartbeats/3eeeeeeeee.json"))
+
+	spec, err := repository.LoadSpec(repo)
+	require.NoError(t, err)
+	require.Equal(t, &repository.Spec{Version: repository.Version}, spec)
+
+	out = new(bytes.Buffer)
+	require.NoError(t, proj.Migrate(false, out))
+	require.Contains(t, out.String(), "already using the latest version")
+}
+
+func TestMigrateResumes(t *testing.T) {
+	repo, repoDir := legacyTestRepository(t)
+	defer os.RemoveAll(repoDir)
+	proj := NewProject(repo, "")
+
+	// an interrupted migration blocks new experiments
+	require.NoError(t, repository.WriteSpec(repo, &repository.Spec{
+		Version:   2,
+		Migration: &repository.MigrationProgress{From: 1, CompletedSteps: []string{"experiment-metadata"}},
+	}))
+	_, err := proj.CreateExperiment(CreateExperimentArgs{Path: ""}, false, nil, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "being migrated")
+
+	// and changes to existing ones
+	exp := &Experiment{ID: "1eeeeeeeee", Created: time.Now().UTC()}
+	_, err = proj.SaveExperiment(exp, true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "being migrated")
+	_, err = proj.CreateCheckpoint(CreateCheckpointArgs{Path: ""}, false, nil, true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "being migrated")
+	content, err := repo.Get("metadata/experiments/1eeeeeeeee.json")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "replicate_version")
+
+	// completed steps aren't run again
+	out := new(bytes.Buffer)
+	require.NoError(t, proj.Migrate(false, out))
+	require.Contains(t, out.String(), "Resuming")
+	content, err = repo.Get("metadata/experiments/1eeeeeeeee.json")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "replicate_version")
+	require.NoFileExists(t, path.Join(repoDir, "metadata/heartbeats/3eeeeeeeee.json"))
+
+	spec, err := repository.LoadSpec(repo)
+	require.NoError(t, err)
+	require.Nil(t, spec.Migration)
+}
+
+func TestMigrateKeepsMode(t *testing.T) {
+	repo, repoDir := legacyTestRepository(t)
+	defer os.RemoveAll(repoDir)
+	require.NoError(t, repository.WriteSpec(repo, &repository.Spec{Version: 1, Mode: repository.ModeReadOnly}))
+	proj := NewProject(repo, "")
+
+	require.NoError(t, proj.Migrate(false, new(bytes.Buffer)))
+
+	spec, err := repository.LoadSpec(repo)
+	require.NoError(t, err)
+	require.Equal(t, &repository.Spec{Version: repository.Version, Mode: repository.ModeReadOnly}, spec)
+}
@@ -0,0 +1,73 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicksPerSecond is the unit of process start times in /proc. It is
+// 100 on every architecture Linux runs on in practice.
+const clockTicksPerSecond = 100
+
+// processStartTime returns when the process with the given PID started
+func processStartTime(pid int) (time.Time, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The second field is the command in parentheses, which can have
+	// spaces in it, so the fields are counted from after it
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	// starttime is field 22, and these start at field 3
+	if len(fields) < 20 {
+		return time.Time{}, fmt.Errorf("Failed to parse /proc/%d/stat", pid)
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to parse /proc/%d/stat: %w", pid, err)
+	}
+	boot, err := bootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / clockTicksPerSecond).UTC(), nil
+}
+
+func bootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			seconds, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("Failed to parse btime in /proc/stat: %w", err)
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("btime is missing from /proc/stat")
+}
+
+// pidNamespace identifies the PID namespace this process is in, so PIDs from
+// other containers on the same host aren't looked up in this one
+func pidNamespace() string {
+	ns, err := os.Readlink("/proc/self/ns/pid")
+	if err != nil {
+		return ""
+	}
+	return ns
+}
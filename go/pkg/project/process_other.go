@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package project
+
+import (
+	"fmt"
+	"time"
+)
+
+// processStartTime returns when the process with the given PID started. It
+// is only known on Linux.
+func processStartTime(pid int) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("The start time of processes isn't known on this platform")
+}
+
+// pidNamespace identifies the PID namespace this process is in. There are
+// only PID namespaces on Linux.
+func pidNamespace() string {
+	return ""
+}
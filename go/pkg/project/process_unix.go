@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package project
+
+import (
+	"syscall"
+)
+
+// processExists returns whether there is a process with the given PID
+func processExists(pid int) (bool, error) {
+	// Signal 0 checks whether the process could be sent a signal, without
+	// sending one
+	err := syscall.Kill(pid, 0)
+	if err == nil || err == syscall.EPERM {
+		return true, nil
+	}
+	if err == syscall.ESRCH {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,16 @@
+package project
+
+import (
+	"os"
+)
+
+// processExists returns whether there is a process with the given PID
+func processExists(pid int) (bool, error) {
+	// Unlike on Unix, FindProcess fails if there isn't a process with the
+	// PID
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false, nil
+	}
+	return true, p.Release()
+}
@@ -1,12 +1,14 @@
 package project
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
-	"os/user"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/replicate/keepsake/go/pkg/config"
@@ -26,17 +28,83 @@ type Project struct {
 	directory         string
 	experimentsByID   map[string]*Experiment
 	heartbeatsByExpID map[string]*Heartbeat
-	hasLoaded         bool
+	// hasLoaded is 1 if the metadata has been loaded. It is accessed
+	// atomically, because the cache is invalidated by work done in the
+	// background.
+	hasLoaded int32
+
+	// metadata path -> experiment and its MD5, to skip loading experiments
+	// that haven't changed in Refresh()
+	experimentsByPath map[string]*Experiment
+	experimentMD5s    map[string][]byte
+
+	retentionPolicy *config.RetentionPolicy
+	symlinkMode     config.SymlinkMode
+	hooks           *config.Hooks
+	heartbeat       *config.HeartbeatConfig
+
+	// tarball path -> digest of the tarballs uploaded by this project, so
+	// they can be recorded in metadata when the upload has finished
+	tarballDigests map[string]*repository.Digest
+	// metadataLock is held while saving experiment metadata, and guards
+	// tarballDigests
+	metadataLock sync.Mutex
+
+	// migrating is set while this project is migrating the repository, so
+	// the migration can write to it
+	migrating bool
+	// notMigratingCheckedAt is when the spec was last read and the
+	// repository wasn't being migrated, guarded by specCheckLock
+	notMigratingCheckedAt time.Time
+	specCheckLock         sync.Mutex
 }
 
+// specCheckInterval is how long the repository is assumed not to be being
+// migrated after checking its spec, so the spec isn't read again for every
+// write
+const specCheckInterval = 5 * time.Second
+
 func NewProject(repo repository.Repository, directory string) *Project {
 	return &Project{
 		repository: repo,
 		directory:  directory,
-		hasLoaded:  false,
 	}
 }
 
+// Directory returns the project's source directory
+func (p *Project) Directory() string {
+	return p.directory
+}
+
+// SetHooks sets the hooks that are run when things happen to experiments.
+// The project doesn't run them itself, the daemon does.
+func (p *Project) SetHooks(hooks *config.Hooks) {
+	p.hooks = hooks
+}
+
+// Hooks returns the hooks set with SetHooks
+func (p *Project) Hooks() *config.Hooks {
+	return p.hooks
+}
+
+// SetHeartbeatConfig sets how often heartbeats are written, and how long
+// after the last one experiments are considered stopped. The default is
+// config.DefaultHeartbeatInterval and config.DefaultHeartbeatTimeout.
+func (p *Project) SetHeartbeatConfig(conf *config.HeartbeatConfig) {
+	p.heartbeat = conf
+}
+
+// HeartbeatConfig returns the config set with SetHeartbeatConfig
+func (p *Project) HeartbeatConfig() *config.HeartbeatConfig {
+	return p.heartbeat
+}
+
+// SetSymlinkMode sets what happens to symlinks in the files saved with
+// experiments and checkpoints. The default is config.SymlinksPreserve.
+func (p *Project) SetSymlinkMode(mode config.SymlinkMode) {
+	p.symlinkMode = mode
+}
+
 // Experiments returns all experiments in this project
 func (p *Project) Experiments() ([]*Experiment, error) {
 	if err := p.ensureLoaded(); err != nil {
@@ -58,12 +126,21 @@ func (p *Project) ExperimentIsRunning(experimentID string) (bool, error) {
 	heartbeat, ok := p.heartbeatsByExpID[experimentID]
 	if !ok {
 		// TODO(bfirsh): unknown state? https://github.com/replicate/keepsake/issues/36
-		console.Debug("No heartbeat found for experiment %s", experimentID)
+		console.WithField("experiment_id", experimentID).Debug("No heartbeat found for experiment %s", experimentID)
 		return false, nil
 	}
 	return heartbeat.IsRunning(), nil
 }
 
+// ExperimentHeartbeat returns the last heartbeat of an experiment, or nil if
+// it doesn't have one
+func (p *Project) ExperimentHeartbeat(experimentID string) (*Heartbeat, error) {
+	if err := p.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	return p.heartbeatsByExpID[experimentID], nil
+}
+
 // ExperimentFromPrefix returns an experiment that matches a given ID prefix.
 func (p *Project) ExperimentFromPrefix(prefix string) (*Experiment, error) {
 	if err := p.ensureLoaded(); err != nil {
@@ -96,7 +173,7 @@ func (p *Project) ExperimentByID(id string) (*Experiment, error) {
 	if exp, ok := p.experimentsByID[id]; ok {
 		return exp, nil
 	}
-	return nil, fmt.Errorf("Experiment not found: %s", id)
+	return nil, errors.DoesNotExist("Experiment not found: " + id)
 }
 
 // CheckpointFromPrefix returns an experiment that matches a given ID prefix.
@@ -125,7 +202,7 @@ func (p *Project) CheckpointFromPrefix(prefix string) (*Checkpoint, *Experiment,
 	}
 
 	if len(matches) == 0 {
-		return nil, nil, fmt.Errorf("Checkpoint not found: %s", prefix)
+		return nil, nil, errors.DoesNotExist("Checkpoint not found: " + prefix)
 	}
 	if len(matches) > 1 {
 		return nil, nil, fmt.Errorf("Prefix is ambiguous: %s (%d matching checkpoints)", prefix, len(matches))
@@ -163,7 +240,7 @@ func (p *Project) CheckpointOrExperimentFromPrefix(prefix string) (*CheckpointOr
 	}
 
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("Checkpoint/experiment not found: %s", prefix)
+		return nil, errors.DoesNotExist("Checkpoint/experiment not found: " + prefix)
 	}
 	if len(matches) > 1 {
 		return nil, fmt.Errorf("Prefix is ambiguous: %s (%d matching checkpoints/experiments)", prefix, len(matches))
@@ -171,15 +248,65 @@ func (p *Project) CheckpointOrExperimentFromPrefix(prefix string) (*CheckpointOr
 	return matches[0], nil
 }
 
+// CheckCanDelete returns a RepositoryReadOnly error if experiments and
+// checkpoints can't be deleted from the repository, because it is read-only or
+// append-only
+func (p *Project) CheckCanDelete() error {
+	return repository.CheckCanDelete(p.repository)
+}
+
+// checkNotMigrating returns an error if the repository is being migrated by
+// another process, so nothing is written to it while the migration is
+// changing it. The spec is only read again if it hasn't been checked in the
+// last specCheckInterval.
+func (p *Project) checkNotMigrating() error {
+	p.specCheckLock.Lock()
+	checkedAt := p.notMigratingCheckedAt
+	p.specCheckLock.Unlock()
+	if time.Since(checkedAt) < specCheckInterval {
+		return nil
+	}
+	spec, err := repository.LoadSpec(p.repository)
+	if err != nil {
+		return err
+	}
+	return p.checkSpecNotMigrating(spec)
+}
+
+// checkSpecNotMigrating is checkNotMigrating for a spec that has already been
+// read
+func (p *Project) checkSpecNotMigrating(spec *repository.Spec) error {
+	p.specCheckLock.Lock()
+	defer p.specCheckLock.Unlock()
+	if spec == nil || spec.Migration == nil || p.migrating {
+		p.notMigratingCheckedAt = time.Now()
+		return nil
+	}
+	p.notMigratingCheckedAt = time.Time{}
+	return fmt.Errorf("The repository %s is being migrated to version %d of the repository format. Wait for 'keepsake migrate' to finish, or if it was interrupted, run it again to finish the migration.", p.repository.RootURL(), spec.Version)
+}
+
 func (p *Project) DeleteCheckpoint(chk *Checkpoint) error {
+	if err := p.CheckCanDelete(); err != nil {
+		return err
+	}
+	if err := p.checkNotMigrating(); err != nil {
+		return err
+	}
 	if err := p.repository.Delete(chk.StorageTarPath()); err != nil {
-		console.Warn("Failed to delete checkpoint storage directory %s: %s", chk.StorageTarPath(), err)
+		console.WithField("checkpoint_id", chk.ID).Warn("Failed to delete checkpoint storage directory %s: %s", chk.StorageTarPath(), err)
 	}
 	p.invalidateCache()
 	return nil
 }
 
 func (p *Project) DeleteExperiment(exp *Experiment) error {
+	if err := p.CheckCanDelete(); err != nil {
+		return err
+	}
+	if err := p.checkNotMigrating(); err != nil {
+		return err
+	}
 	console.Debug("Deleting experiment: %s", exp.ShortID())
 	if err := p.repository.Delete(exp.HeartbeatPath()); err != nil {
 		console.Warn("Failed to delete heartbeat file %s: %s", exp.HeartbeatPath(), err)
@@ -200,31 +327,40 @@ type CreateExperimentArgs struct {
 	Params         map[string]param.Value
 	PythonPackages map[string]string
 	PythonVersion  string
+	Parents        []*ParentRef
 }
 
 func (p *Project) CreateExperiment(args CreateExperimentArgs, async bool, workChan chan func() error, quiet bool) (*Experiment, error) {
-	spec, err := repository.LoadSpec(p.repository)
+	mode, err := repository.ModeOf(p.repository)
 	if err != nil {
 		return nil, err
 	}
-	if spec == nil {
-		if err := repository.WriteSpec(p.repository); err != nil {
-			return nil, err
-		}
-	} else if spec.Version > repository.Version {
+	if mode == repository.ModeReadOnly {
+		return nil, errors.RepositoryReadOnly(fmt.Sprintf("Experiments can't be created in %s, because the repository is read-only.", p.repository.RootURL()))
+	}
+	spec, err := repository.EnsureSpec(p.repository)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkSpecNotMigrating(spec); err != nil {
+		return nil, err
+	}
+	if spec.Version > repository.Version {
 		return nil, errors.IncompatibleRepositoryVersion(p.repository.RootURL())
 	}
+	if spec.Version < repository.Version {
+		console.Warn("The repository %s uses version %d of the repository format. Run 'keepsake migrate' to upgrade it to version %d.", p.repository.RootURL(), spec.Version, repository.Version)
+	}
 
 	host := "" // currently disabled and unused
-	currentUser, err := user.Current()
-	username := ""
-	if err == nil {
-		username = currentUser.Username
-	} else {
-		console.Warn("Failed to determine username: %s", err)
-	}
+	username := currentUsername()
 	conf := &config.Config{Repository: p.repository.RootURL()}
 
+	parents, err := p.resolveParents(args.Parents)
+	if err != nil {
+		return nil, err
+	}
+
 	exp := &Experiment{
 		ID:              generateRandomID(),
 		Created:         time.Now().UTC(),
@@ -237,6 +373,7 @@ func (p *Project) CreateExperiment(args CreateExperimentArgs, async bool, workCh
 		PythonVersion:   args.PythonVersion,
 		PythonPackages:  args.PythonPackages,
 		KeepsakeVersion: global.Version,
+		Parents:         parents,
 	}
 
 	// save json synchronously to uncover repository write issues
@@ -246,27 +383,29 @@ func (p *Project) CreateExperiment(args CreateExperimentArgs, async bool, workCh
 
 	if exp.Path == "" {
 		if !quiet {
-			console.Info("Creating experiment %s...", exp.ShortID())
+			console.WithField("experiment_id", exp.ID).Info("Creating experiment %s...", exp.ShortID())
 		}
 		return exp, nil
 	}
 
-	tempDir, err := repository.CopyToTempDir(p.directory, exp.Path)
+	tempDir, err := repository.CopyToTempDir(p.directory, exp.Path, p.symlinkMode)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to copy files to temporary directory: %v", err)
 	}
 
 	if !quiet {
-		console.Info("Creating experiment %s, copying '%s' to '%s' in the background...", exp.ShortID(), exp.Path, p.repository.RootURL())
+		console.WithField("experiment_id", exp.ID).Info("Creating experiment %s, copying '%s' to '%s' in the background...", exp.ShortID(), exp.Path, p.repository.RootURL())
 	}
 
 	work := func() error {
 		defer os.RemoveAll(tempDir)
 		start := time.Now()
-		if err := p.repository.PutPathTar(tempDir, exp.StorageTarPath(), exp.Path); err != nil {
+		digest, err := p.repository.PutPathTar(tempDir, exp.StorageTarPath(), exp.Path)
+		if err != nil {
 			return err
 		}
-		console.Debug("Copied files for experiment %s from '%s' to '%s/%s' (took %.3f seconds)", exp.ShortID(), exp.Path, p.repository.RootURL(), exp.StorageTarPath(), time.Since(start).Seconds())
+		p.recordTarballDigest(exp.StorageTarPath(), digest)
+		console.WithField("experiment_id", exp.ID).Debug("Copied files for experiment %s from '%s' to '%s/%s' (took %.3f seconds)", exp.ShortID(), exp.Path, p.repository.RootURL(), exp.StorageTarPath(), time.Since(start).Seconds())
 		return nil
 	}
 
@@ -280,14 +419,59 @@ func (p *Project) CreateExperiment(args CreateExperimentArgs, async bool, workCh
 	return exp, nil
 }
 
+// resolveParents expands the ID prefixes in parents to full IDs, so they
+// still point at the same experiments and checkpoints as more are created.
+// A parent passed with only an experiment ID may be the prefix of either an
+// experiment or a checkpoint.
+func (p *Project) resolveParents(parents []*ParentRef) ([]*ParentRef, error) {
+	if len(parents) == 0 {
+		return nil, nil
+	}
+	ret := []*ParentRef{}
+	for _, parent := range parents {
+		if parent.ExperimentID == "" && parent.CheckpointID == "" {
+			return nil, fmt.Errorf("A parent must have an experiment or checkpoint ID")
+		}
+		if parent.CheckpointID != "" {
+			chk, exp, err := p.CheckpointFromPrefix(parent.CheckpointID)
+			if errors.IsDoesNotExist(err) {
+				return nil, errors.DoesNotExist("Parent checkpoint not found: " + parent.CheckpointID)
+			} else if err != nil {
+				return nil, err
+			}
+			if parent.ExperimentID != "" && !strings.HasPrefix(exp.ID, parent.ExperimentID) {
+				return nil, fmt.Errorf("Parent checkpoint %s is not in experiment %s", chk.ShortID(), parent.ExperimentID)
+			}
+			ret = append(ret, &ParentRef{ExperimentID: exp.ID, CheckpointID: chk.ID, Kind: parent.Kind})
+			continue
+		}
+		result, err := p.CheckpointOrExperimentFromPrefix(parent.ExperimentID)
+		if errors.IsDoesNotExist(err) {
+			return nil, errors.DoesNotExist("Parent experiment or checkpoint not found: " + parent.ExperimentID)
+		} else if err != nil {
+			return nil, err
+		}
+		ref := &ParentRef{ExperimentID: result.Experiment.ID, Kind: parent.Kind}
+		if result.Checkpoint != nil {
+			ref.CheckpointID = result.Checkpoint.ID
+		}
+		ret = append(ret, ref)
+	}
+	return ret, nil
+}
+
 type CreateCheckpointArgs struct {
 	Path          string
 	Step          int64
 	Metrics       map[string]param.Value
 	PrimaryMetric *PrimaryMetric
+	Tags          []string
 }
 
 func (p *Project) CreateCheckpoint(args CreateCheckpointArgs, async bool, workChan chan func() error, quiet bool) (*Checkpoint, error) {
+	if err := p.checkNotMigrating(); err != nil {
+		return nil, err
+	}
 	chk := &Checkpoint{
 		ID:            generateRandomID(),
 		Created:       time.Now().UTC(),
@@ -295,22 +479,23 @@ func (p *Project) CreateCheckpoint(args CreateCheckpointArgs, async bool, workCh
 		Step:          args.Step,
 		Path:          args.Path,
 		PrimaryMetric: args.PrimaryMetric,
+		Tags:          args.Tags,
 	}
 
 	// if path is empty (i.e. it was None in python), just return
 	// the checkpoint without saving anything
 	if chk.Path == "" {
 		if !quiet {
-			console.Info("Creating checkpoint %s...", chk.ShortID())
+			console.WithField("checkpoint_id", chk.ID).Info("Creating checkpoint %s...", chk.ShortID())
 		}
 		return chk, nil
 	}
 
 	if !quiet {
-		console.Info("Creating checkpoint %s, copying '%s' to '%s' in the background...", chk.ShortID(), chk.Path, p.repository.RootURL())
+		console.WithField("checkpoint_id", chk.ID).Info("Creating checkpoint %s, copying '%s' to '%s' in the background...", chk.ShortID(), chk.Path, p.repository.RootURL())
 	}
 
-	tempDir, err := repository.CopyToTempDir(p.directory, chk.Path)
+	tempDir, err := repository.CopyToTempDir(p.directory, chk.Path, p.symlinkMode)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to copy files to temporary directory: %v", err)
 	}
@@ -318,10 +503,12 @@ func (p *Project) CreateCheckpoint(args CreateCheckpointArgs, async bool, workCh
 	work := func() error {
 		defer os.RemoveAll(tempDir)
 		start := time.Now()
-		if err := p.repository.PutPathTar(tempDir, chk.StorageTarPath(), chk.Path); err != nil {
+		digest, err := p.repository.PutPathTar(tempDir, chk.StorageTarPath(), chk.Path)
+		if err != nil {
 			return err
 		}
-		console.Debug("Copied files for checkpoint %s from '%s' to '%s/%s' (took %.3f seconds)", chk.ShortID(), chk.Path, p.repository.RootURL(), chk.StorageTarPath(), time.Since(start).Seconds())
+		p.recordTarballDigest(chk.StorageTarPath(), digest)
+		console.WithField("checkpoint_id", chk.ID).Debug("Copied files for checkpoint %s from '%s' to '%s/%s' (took %.3f seconds)", chk.ShortID(), chk.Path, p.repository.RootURL(), chk.StorageTarPath(), time.Since(start).Seconds())
 		return nil
 	}
 	if async {
@@ -335,17 +522,157 @@ func (p *Project) CreateCheckpoint(args CreateCheckpointArgs, async bool, workCh
 	return chk, nil
 }
 
+// SaveExperiment saves the experiment's metadata.
+//
+// Other processes may have saved the same experiment since it was read, for
+// example the daemon of another training process. The write only succeeds if
+// the saved metadata hasn't changed since it was merged into exp, and the
+// merge is done again if it has.
 func (p *Project) SaveExperiment(exp *Experiment, quiet bool) (*Experiment, error) {
 	// TODO(andreas): use quiet flag
-	if err := exp.Save(p.repository); err != nil {
+	p.metadataLock.Lock()
+	defer p.metadataLock.Unlock()
+	p.fillTarballDigests(exp)
+	err := p.updateExperimentMetadata(exp.MetadataPath(), func(saved *Experiment) (*Experiment, error) {
+		if saved != nil {
+			exp.mergeSaved(saved)
+		}
+		return exp, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	p.invalidateCache()
 	return exp, nil
 }
 
-func (p *Project) RefreshHeartbeat(experimentID string) error {
-	return CreateHeartbeat(p.repository, experimentID, time.Now().UTC())
+// maxSaveAttempts is how many times an experiment is read, merged, and
+// written before giving up because other processes keep changing it
+const maxSaveAttempts = 10
+
+// updateExperimentMetadata reads the experiment saved at metadataPath, passes
+// it to update, and writes the experiment that update returns. saved is nil if
+// the experiment hasn't been saved yet, and nothing is written if update
+// returns nil.
+//
+// The experiment is only written if it hasn't changed since it was read. If it
+// has, it is read and updated again.
+func (p *Project) updateExperimentMetadata(metadataPath string, update func(saved *Experiment) (*Experiment, error)) error {
+	if err := p.checkNotMigrating(); err != nil {
+		return err
+	}
+	for attempt := 1; attempt <= maxSaveAttempts; attempt++ {
+		var saved *Experiment
+		data, version, err := p.repository.GetVersioned(metadataPath)
+		if err == nil {
+			saved = new(Experiment)
+			if err := json.Unmarshal(data, saved); err != nil {
+				return fmt.Errorf("Failed to parse %s: %s", metadataPath, err)
+			}
+		} else if !errors.IsDoesNotExist(err) {
+			return err
+		}
+
+		exp, err := update(saved)
+		if err != nil || exp == nil {
+			return err
+		}
+		data, err = exp.marshal()
+		if err != nil {
+			return err
+		}
+		err = p.repository.PutIfMatch(metadataPath, data, version)
+		if !errors.IsConflict(err) {
+			return err
+		}
+		console.Debug("%s was changed by another process while it was being saved, merging and trying again (attempt %d)", metadataPath, attempt)
+		time.Sleep(time.Duration(rand.Intn(50*attempt)) * time.Millisecond)
+	}
+	return errors.Conflict(fmt.Sprintf("Failed to save %s, because other processes kept changing it", metadataPath))
+}
+
+// MergeSavedExperiment merges the saved copy of exp into exp, so it includes
+// the checkpoints, pruned flags and tarball digests that have been saved by
+// other processes. See Experiment.mergeSaved.
+//
+// The Python library keeps its own copy of an experiment, which doesn't know
+// about checkpoints that have been pruned or uploaded since it was created.
+// This is done when saving experiments too, but it is also useful before
+// deciding what to do with exp's checkpoints.
+func (p *Project) MergeSavedExperiment(exp *Experiment) error {
+	saved := new(Experiment)
+	if err := loadFromPath(p.repository, exp.MetadataPath(), saved); err != nil {
+		if errors.IsDoesNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	exp.mergeSaved(saved)
+	return nil
+}
+
+// RecordTarballDigests saves the digests of the tarballs that have been
+// uploaded for an experiment and its checkpoints in the experiment's metadata.
+//
+// Tarballs are uploaded in the background, so their digests usually aren't
+// known when the experiment is saved. The daemon calls this after the uploads
+// have finished.
+func (p *Project) RecordTarballDigests(experimentID string) error {
+	p.metadataLock.Lock()
+	defer p.metadataLock.Unlock()
+	exp := &Experiment{ID: experimentID}
+	changed := false
+	err := p.updateExperimentMetadata(exp.MetadataPath(), func(saved *Experiment) (*Experiment, error) {
+		if saved == nil {
+			return nil, errors.DoesNotExist("Experiment not found: " + experimentID)
+		}
+		if !p.fillTarballDigests(saved) {
+			return nil, nil
+		}
+		changed = true
+		return saved, nil
+	})
+	if err != nil {
+		return err
+	}
+	if changed {
+		p.invalidateCache()
+	}
+	return nil
+}
+
+func (p *Project) recordTarballDigest(tarPath string, digest *repository.Digest) {
+	p.metadataLock.Lock()
+	defer p.metadataLock.Unlock()
+	if p.tarballDigests == nil {
+		p.tarballDigests = map[string]*repository.Digest{}
+	}
+	p.tarballDigests[tarPath] = digest
+}
+
+// fillTarballDigests sets the digests of exp and its checkpoints from the
+// tarballs uploaded by this project, and returns true if any were changed.
+// metadataLock must be held.
+func (p *Project) fillTarballDigests(exp *Experiment) bool {
+	changed := false
+	if digest, ok := p.tarballDigests[exp.StorageTarPath()]; ok && exp.SHA256 != digest.SHA256 {
+		exp.Size, exp.SHA256 = digest.Size, digest.SHA256
+		changed = true
+	}
+	for _, chk := range exp.Checkpoints {
+		if digest, ok := p.tarballDigests[chk.StorageTarPath()]; ok && !chk.Pruned && chk.SHA256 != digest.SHA256 {
+			chk.Size, chk.SHA256 = digest.Size, digest.SHA256
+			changed = true
+		}
+	}
+	return changed
+}
+
+// RefreshHeartbeat saves hb with the current time, to show that its
+// experiment is still running
+func (p *Project) RefreshHeartbeat(hb *Heartbeat) error {
+	hb.LastHeartbeat = time.Now().UTC()
+	return hb.save(p.repository)
 }
 
 func (p *Project) StopExperiment(experimentID string) error {
@@ -356,15 +683,57 @@ func (p *Project) StopExperiment(experimentID string) error {
 	return nil
 }
 
+// Refresh reloads metadata from the repository. Unlike invalidating the
+// cache, only experiments that have changed since the last refresh are read
+// again, so it is cheap to call repeatedly.
+func (p *Project) Refresh() error {
+	results := make(chan repository.ListResult)
+	go p.repository.ListRecursive(results, "metadata/experiments")
+	experimentsByPath := map[string]*Experiment{}
+	experimentMD5s := map[string][]byte{}
+	experiments := []*Experiment{}
+	for result := range results {
+		if result.Error != nil {
+			return result.Error
+		}
+		if !strings.HasSuffix(result.Path, ".json") {
+			continue
+		}
+		exp, ok := p.experimentsByPath[result.Path]
+		if !ok || !bytes.Equal(p.experimentMD5s[result.Path], result.MD5) {
+			var err error
+			exp, err = loadExperimentFromPath(p.repository, result.Path)
+			if err != nil {
+				console.Warn("Failed to load metadata from %q: %s", result.Path, err)
+				continue
+			}
+		}
+		experimentsByPath[result.Path] = exp
+		experimentMD5s[result.Path] = result.MD5
+		experiments = append(experiments, exp)
+	}
+
+	heartbeats, err := listHeartbeats(p.repository)
+	if err != nil {
+		heartbeats = []*Heartbeat{}
+		console.Warn("Failed to load heartbeats: %s", err)
+	}
+	p.setObjects(experiments, heartbeats)
+	p.experimentsByPath = experimentsByPath
+	p.experimentMD5s = experimentMD5s
+	atomic.StoreInt32(&p.hasLoaded, 1)
+	return nil
+}
+
 func (p *Project) invalidateCache() {
-	p.hasLoaded = false
+	atomic.StoreInt32(&p.hasLoaded, 0)
 }
 
 // ensureLoaded eagerly loads all the metadata for this project.
 // This is highly inefficient, see https://github.com/replicate/keepsake/issues/305
 func (p *Project) ensureLoaded() error {
 	// TODO(andreas): 5(?) second caching instead
-	if p.hasLoaded {
+	if atomic.LoadInt32(&p.hasLoaded) == 1 {
 		return nil
 	}
 	experiments, err := listExperiments(p.repository)
@@ -377,7 +746,7 @@ func (p *Project) ensureLoaded() error {
 		console.Warn("Failed to load heartbeats: %s", err)
 	}
 	p.setObjects(experiments, heartbeats)
-	p.hasLoaded = true
+	atomic.StoreInt32(&p.hasLoaded, 1)
 	return nil
 }
 
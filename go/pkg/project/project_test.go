@@ -0,0 +1,270 @@
+package project
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestRefresh(t *testing.T) {
+	repoDir, err := files.TempDir("test-refresh")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	exp1 := &Experiment{ID: "1eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	exp2 := &Experiment{ID: "2eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	require.NoError(t, exp1.Save(repo))
+	require.NoError(t, exp2.Save(repo))
+
+	proj := NewProject(repo, "")
+	require.NoError(t, proj.Refresh())
+	experiments, err := proj.Experiments()
+	require.NoError(t, err)
+	require.Len(t, experiments, 2)
+	_, err = proj.ExperimentByID(exp1.ID)
+	require.NoError(t, err)
+	loadedExp2, err := proj.ExperimentByID(exp2.ID)
+	require.NoError(t, err)
+	require.Len(t, loadedExp2.Checkpoints, 0)
+
+	exp2.Checkpoints = []*Checkpoint{{ID: "1ccccccccc", Created: time.Now().UTC(), Step: 10}}
+	require.NoError(t, exp2.Save(repo))
+	exp3 := &Experiment{ID: "3eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	require.NoError(t, exp3.Save(repo))
+	require.NoError(t, CreateHeartbeat(repo, exp3.ID, time.Now().UTC()))
+	require.NoError(t, repo.Delete(exp1.MetadataPath()))
+
+	require.NoError(t, proj.Refresh())
+	experiments, err = proj.Experiments()
+	require.NoError(t, err)
+	require.Len(t, experiments, 2)
+
+	_, err = proj.ExperimentByID(exp1.ID)
+	require.Error(t, err)
+	reloadedExp2, err := proj.ExperimentByID(exp2.ID)
+	require.NoError(t, err)
+	require.Len(t, reloadedExp2.Checkpoints, 1)
+	running, err := proj.ExperimentIsRunning(exp3.ID)
+	require.NoError(t, err)
+	require.True(t, running)
+
+	// Unchanged experiments aren't loaded again
+	require.NoError(t, proj.Refresh())
+	sameExp2, err := proj.ExperimentByID(exp2.ID)
+	require.NoError(t, err)
+	require.True(t, sameExp2 == reloadedExp2)
+}
+
+func TestCreateExperimentResolvesParents(t *testing.T) {
+	repoDir, err := files.TempDir("test-parents")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	exp1 := &Experiment{ID: "1eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	exp2 := &Experiment{
+		ID:          "2eeeeeeeee",
+		Created:     time.Now().UTC(),
+		Config:      &config.Config{},
+		Checkpoints: []*Checkpoint{{ID: "1ccccccccc", Created: time.Now().UTC(), Step: 10}},
+	}
+	require.NoError(t, exp1.Save(repo))
+	require.NoError(t, exp2.Save(repo))
+
+	proj := NewProject(repo, "")
+	exp, err := proj.CreateExperiment(CreateExperimentArgs{
+		Parents: []*ParentRef{
+			{ExperimentID: "1ee"},
+			{ExperimentID: "1cc"},
+			{CheckpointID: "1cc", Kind: ParentKindRerun},
+		},
+	}, false, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, []*ParentRef{
+		{ExperimentID: "1eeeeeeeee"},
+		{ExperimentID: "2eeeeeeeee", CheckpointID: "1ccccccccc"},
+		{ExperimentID: "2eeeeeeeee", CheckpointID: "1ccccccccc", Kind: ParentKindRerun},
+	}, exp.Parents)
+
+	_, err = proj.CreateExperiment(CreateExperimentArgs{
+		Parents: []*ParentRef{{ExperimentID: "9ee"}},
+	}, false, nil, true)
+	require.True(t, errors.IsDoesNotExist(err))
+
+	_, err = proj.CreateExperiment(CreateExperimentArgs{
+		Parents: []*ParentRef{{ExperimentID: "1ee", CheckpointID: "1cc"}},
+	}, false, nil, true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not in experiment")
+}
+
+func TestTakeRerunParentFromEnv(t *testing.T) {
+	defer os.Unsetenv(RerunExperimentIDEnvVar)
+	defer os.Unsetenv(RerunCheckpointIDEnvVar)
+	require.Nil(t, TakeRerunParentFromEnv())
+
+	os.Setenv(RerunExperimentIDEnvVar, "1eeeeeeeee")
+	os.Setenv(RerunCheckpointIDEnvVar, "1ccccccccc")
+	require.Equal(t, &ParentRef{ExperimentID: "1eeeeeeeee", CheckpointID: "1ccccccccc", Kind: ParentKindRerun}, TakeRerunParentFromEnv())
+	// it's only taken once
+	require.Nil(t, TakeRerunParentFromEnv())
+	require.Empty(t, os.Getenv(RerunCheckpointIDEnvVar))
+}
+
+func TestMergeSavedExperiment(t *testing.T) {
+	repoDir, err := files.TempDir("test-merge-saved")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	proj := NewProject(repo, "")
+
+	// experiments that haven't been saved yet are left alone
+	exp := retentionTestExperiment()
+	require.NoError(t, proj.MergeSavedExperiment(exp))
+
+	saved := retentionTestExperiment()
+	saved.Size, saved.SHA256 = 100, "aaa"
+	saved.Checkpoints[0].Size, saved.Checkpoints[0].SHA256 = 200, "bbb"
+	saved.Checkpoints[1].Pruned = true
+	require.NoError(t, saved.Save(repo))
+
+	exp.Checkpoints[0].Size, exp.Checkpoints[0].SHA256 = 300, "ccc"
+	require.NoError(t, proj.MergeSavedExperiment(exp))
+	require.Equal(t, &repository.Digest{Size: 100, SHA256: "aaa"}, exp.StorageDigest())
+	require.Equal(t, &repository.Digest{Size: 300, SHA256: "ccc"}, exp.Checkpoints[0].StorageDigest())
+	require.False(t, exp.Checkpoints[0].Pruned)
+	require.True(t, exp.Checkpoints[1].Pruned)
+	require.Equal(t, &repository.Digest{}, exp.Checkpoints[1].StorageDigest())
+
+	// checkpoints that were only saved by someone else are added in order
+	exp.Checkpoints = exp.Checkpoints[:2]
+	require.NoError(t, proj.MergeSavedExperiment(exp))
+	require.Equal(t, checkpointIDs(saved.Checkpoints), checkpointIDs(exp.Checkpoints))
+}
+
+func TestSaveExperimentMergesConcurrentSaves(t *testing.T) {
+	repoDir, err := files.TempDir("test-concurrent-saves")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	created := time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)
+	exp := &Experiment{ID: "1eeeeeeeee", Created: created, Config: &config.Config{}}
+	_, err = NewProject(repo, "").SaveExperiment(exp, true)
+	require.NoError(t, err)
+
+	// several processes add a checkpoint to their own, out of date, copy of
+	// the experiment at the same time
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			proj := NewProject(repo, "")
+			stale := &Experiment{ID: exp.ID, Created: created, Config: &config.Config{}}
+			stale.Checkpoints = []*Checkpoint{{
+				ID:      fmt.Sprintf("%dccccccccc", i),
+				Created: created.Add(time.Duration(i) * time.Minute),
+				Step:    int64(i),
+			}}
+			_, err := proj.SaveExperiment(stale, true)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	saved, err := NewProject(repo, "").ExperimentByID(exp.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"0ccccccccc", "1ccccccccc", "2ccccccccc", "3ccccccccc", "4ccccccccc",
+		"5ccccccccc", "6ccccccccc", "7ccccccccc", "8ccccccccc", "9ccccccccc",
+	}, checkpointIDs(saved.Checkpoints))
+}
+
+func TestRecordTarballDigests(t *testing.T) {
+	repoDir, err := files.TempDir("test-tarball-digests-repo")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	projectDir, err := files.TempDir("test-tarball-digests-project")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectDir)
+	require.NoError(t, ioutil.WriteFile(path.Join(projectDir, "model.pth"), []byte("weights"), 0644))
+
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	proj := NewProject(repo, projectDir)
+
+	// uploads happen in the background, after the experiment has been saved
+	workChan := make(chan func() error, 10)
+	exp, err := proj.CreateExperiment(CreateExperimentArgs{Path: "."}, true, workChan, true)
+	require.NoError(t, err)
+	chk, err := proj.CreateCheckpoint(CreateCheckpointArgs{Path: "model.pth"}, true, workChan, true)
+	require.NoError(t, err)
+	exp.Checkpoints = append(exp.Checkpoints, chk)
+	_, err = proj.SaveExperiment(exp, true)
+	require.NoError(t, err)
+	require.Equal(t, "", exp.SHA256)
+	require.Equal(t, "", chk.SHA256)
+
+	close(workChan)
+	for work := range workChan {
+		require.NoError(t, work())
+	}
+	require.NoError(t, proj.RecordTarballDigests(exp.ID))
+
+	saved, err := proj.ExperimentByID(exp.ID)
+	require.NoError(t, err)
+	for tarPath, digest := range map[string]*repository.Digest{
+		exp.StorageTarPath(): saved.StorageDigest(),
+		chk.StorageTarPath(): saved.Checkpoints[0].StorageDigest(),
+	} {
+		data, err := ioutil.ReadFile(path.Join(repoDir, tarPath))
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), digest.Size)
+		require.Equal(t, fmt.Sprintf("%x", sha256.Sum256(data)), digest.SHA256)
+	}
+}
+
+func TestRestrictedRepositoryRefusesChanges(t *testing.T) {
+	repoDir, err := files.TempDir("test-restricted")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+
+	disk, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	exp := retentionTestExperiment()
+	require.NoError(t, exp.Save(disk))
+	require.NoError(t, repository.WriteSpec(disk, &repository.Spec{Version: repository.Version, Mode: repository.ModeAppendOnly}))
+
+	proj := NewProject(repository.NewRestrictedRepository(disk, repository.ModeReadWrite), "")
+	err = proj.DeleteExperiment(exp)
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = proj.DeleteCheckpoint(exp.Checkpoints[0])
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	require.FileExists(t, path.Join(repoDir, exp.MetadataPath()))
+
+	proj = NewProject(repository.NewRestrictedRepository(disk, repository.ModeReadOnly), "")
+	_, err = proj.CreateExperiment(CreateExperimentArgs{}, false, nil, true)
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+}
@@ -0,0 +1,72 @@
+package project
+
+import (
+	"sort"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+)
+
+// CheckpointsToPrune returns the checkpoints with files that policy doesn't
+// keep, and that haven't already been pruned, in the order they were created
+func (e *Experiment) CheckpointsToPrune(policy *config.RetentionPolicy) []*Checkpoint {
+	withFiles := []*Checkpoint{}
+	for _, chk := range e.Checkpoints {
+		if chk.Path != "" && !chk.Trashed {
+			withFiles = append(withFiles, chk)
+		}
+	}
+	if len(withFiles) == 0 {
+		return nil
+	}
+
+	keep := map[string]bool{}
+	if policy.KeepLast > 0 {
+		byCreated := copyCheckpoints(withFiles)
+		sort.SliceStable(byCreated, func(i, j int) bool {
+			return byCreated[i].Created.Before(byCreated[j].Created)
+		})
+		for _, chk := range lastCheckpoints(byCreated, policy.KeepLast) {
+			keep[chk.ID] = true
+		}
+	}
+	if policy.KeepBest > 0 {
+		ranked := checkpointsByPrimaryMetric(withFiles, e.Checkpoints[0].PrimaryMetric)
+		for _, chk := range lastCheckpoints(ranked, policy.KeepBest) {
+			keep[chk.ID] = true
+		}
+	}
+	for _, chk := range withFiles {
+		if policy.KeepEvery > 0 && chk.Step%policy.KeepEvery == 0 {
+			keep[chk.ID] = true
+		}
+		if policy.KeepTagged && len(chk.Tags) > 0 {
+			keep[chk.ID] = true
+		}
+	}
+
+	ret := []*Checkpoint{}
+	for _, chk := range withFiles {
+		if !keep[chk.ID] && !chk.Pruned {
+			ret = append(ret, chk)
+		}
+	}
+	return ret
+}
+
+func lastCheckpoints(checkpoints []*Checkpoint, n int) []*Checkpoint {
+	if n >= len(checkpoints) {
+		return checkpoints
+	}
+	return checkpoints[len(checkpoints)-n:]
+}
+
+// SetRetentionPolicy sets the policy that decides which checkpoints keep
+// their files. It is nil if there is no policy.
+func (p *Project) SetRetentionPolicy(policy *config.RetentionPolicy) {
+	p.retentionPolicy = policy
+}
+
+// RetentionPolicy returns the policy set with SetRetentionPolicy
+func (p *Project) RetentionPolicy() *config.RetentionPolicy {
+	return p.retentionPolicy
+}
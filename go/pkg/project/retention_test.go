@@ -0,0 +1,63 @@
+package project
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/param"
+)
+
+func retentionTestExperiment() *Experiment {
+	t := time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)
+	primaryMetric := &PrimaryMetric{Name: "loss", Goal: GoalMinimize}
+	losses := []float64{0.9, 0.1, 0.5, 0.2, 0.7, 0.6}
+	exp := &Experiment{ID: "1eeeeeeeee", Created: t, Config: &config.Config{}}
+	for i, loss := range losses {
+		exp.Checkpoints = append(exp.Checkpoints, &Checkpoint{
+			ID:            string(rune('1'+i)) + "ccccccccc",
+			Created:       t.Add(time.Duration(i) * time.Minute),
+			Step:          int64(i + 1),
+			Path:          "model.pth",
+			Metrics:       param.ValueMap{"loss": param.Float(loss)},
+			PrimaryMetric: primaryMetric,
+		})
+	}
+	return exp
+}
+
+func checkpointIDs(checkpoints []*Checkpoint) []string {
+	ids := []string{}
+	for _, chk := range checkpoints {
+		ids = append(ids, chk.ID)
+	}
+	return ids
+}
+
+func TestCheckpointsToPrune(t *testing.T) {
+	exp := retentionTestExperiment()
+
+	toPrune := exp.CheckpointsToPrune(&config.RetentionPolicy{KeepLast: 2})
+	require.Equal(t, []string{"1ccccccccc", "2ccccccccc", "3ccccccccc", "4ccccccccc"}, checkpointIDs(toPrune))
+
+	toPrune = exp.CheckpointsToPrune(&config.RetentionPolicy{KeepLast: 1, KeepBest: 2})
+	require.Equal(t, []string{"1ccccccccc", "3ccccccccc", "5ccccccccc"}, checkpointIDs(toPrune))
+
+	toPrune = exp.CheckpointsToPrune(&config.RetentionPolicy{KeepEvery: 3})
+	require.Equal(t, []string{"1ccccccccc", "2ccccccccc", "4ccccccccc", "5ccccccccc"}, checkpointIDs(toPrune))
+
+	exp.Checkpoints[0].Tags = []string{"baseline"}
+	toPrune = exp.CheckpointsToPrune(&config.RetentionPolicy{KeepLast: 5, KeepTagged: true})
+	require.Empty(t, toPrune)
+
+	// checkpoints without files and checkpoints that have already been
+	// pruned are never returned
+	exp.Checkpoints[1].Path = ""
+	exp.Checkpoints[2].Pruned = true
+	toPrune = exp.CheckpointsToPrune(&config.RetentionPolicy{KeepLast: 1})
+	require.Equal(t, []string{"1ccccccccc", "4ccccccccc", "5ccccccccc"}, checkpointIDs(toPrune))
+
+	require.Empty(t, (&Experiment{}).CheckpointsToPrune(&config.RetentionPolicy{KeepLast: 1}))
+}
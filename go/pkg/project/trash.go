@@ -0,0 +1,323 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+const trashDir = "trash"
+
+// TrashEntry is an experiment or checkpoint that has been moved to the trash.
+//
+// The files of each entry are in trash/<ID>/, at the same paths they had
+// outside the trash, and the entry itself is trash/<ID>/trash.json.
+type TrashEntry struct {
+	// ID is the ID of the experiment or checkpoint
+	ID           string    `json:"id"`
+	ExperimentID string    `json:"experiment_id"`
+	IsCheckpoint bool      `json:"is_checkpoint"`
+	Deleted      time.Time `json:"deleted"`
+	DeletedBy    string    `json:"deleted_by"`
+	// Paths are where the files were before they were moved to the trash
+	Paths []string `json:"paths"`
+}
+
+func (e *TrashEntry) ShortID() string {
+	return e.ID[:7]
+}
+
+func (e *TrashEntry) Kind() string {
+	if e.IsCheckpoint {
+		return "checkpoint"
+	}
+	return "experiment"
+}
+
+func (e *TrashEntry) dir() string {
+	return path.Join(trashDir, e.ID)
+}
+
+func (e *TrashEntry) entryPath() string {
+	return path.Join(e.dir(), "trash.json")
+}
+
+func (e *TrashEntry) trashPath(p string) string {
+	return path.Join(e.dir(), p)
+}
+
+// TrashExperiment moves an experiment, its metadata, and the files of its
+// checkpoints to the trash, where it can be restored with RestoreFromTrash
+func (p *Project) TrashExperiment(exp *Experiment) error {
+	if err := p.CheckCanDelete(); err != nil {
+		return err
+	}
+	paths := []string{exp.StorageTarPath()}
+	for _, chk := range exp.Checkpoints {
+		paths = append(paths, chk.StorageTarPath())
+	}
+	// the metadata is moved last, so the experiment is listed until all of
+	// its files are in the trash
+	paths = append(paths, exp.MetadataPath())
+	if err := p.moveToTrash(&TrashEntry{ID: exp.ID, ExperimentID: exp.ID}, paths); err != nil {
+		return err
+	}
+	if err := p.repository.Delete(exp.HeartbeatPath()); err != nil {
+		console.Warn("Failed to delete heartbeat file %s: %s", exp.HeartbeatPath(), err)
+	}
+	return nil
+}
+
+// TrashCheckpoint moves the files of a checkpoint to the trash. Like
+// DeleteCheckpoint, the checkpoint's metadata is kept in the experiment, but
+// it is marked as trashed.
+func (p *Project) TrashCheckpoint(chk *Checkpoint, exp *Experiment) error {
+	if err := p.CheckCanDelete(); err != nil {
+		return err
+	}
+	if err := p.moveToTrash(&TrashEntry{ID: chk.ID, ExperimentID: exp.ID, IsCheckpoint: true}, []string{chk.StorageTarPath()}); err != nil {
+		return err
+	}
+	return p.setCheckpointTrashed(exp.ID, chk.ID, true)
+}
+
+// setCheckpointTrashed records in an experiment's metadata whether the files
+// of one of its checkpoints are in the trash
+func (p *Project) setCheckpointTrashed(experimentID, checkpointID string, trashed bool) error {
+	metadataPath := (&Experiment{ID: experimentID}).MetadataPath()
+	err := p.updateExperimentMetadata(metadataPath, func(saved *Experiment) (*Experiment, error) {
+		if saved == nil {
+			// the experiment has been deleted or is in the trash too
+			return nil, nil
+		}
+		for _, chk := range saved.Checkpoints {
+			if chk.ID == checkpointID && chk.Trashed != trashed {
+				chk.Trashed = trashed
+				return saved, nil
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to record that checkpoint %s is in the trash: %w", shortID(checkpointID), err)
+	}
+	p.invalidateCache()
+	return nil
+}
+
+func (p *Project) moveToTrash(entry *TrashEntry, paths []string) error {
+	if err := p.checkNotMigrating(); err != nil {
+		return err
+	}
+	entry.Deleted = time.Now().UTC()
+	entry.DeletedBy = currentUsername()
+	entry.Paths = paths
+	// The entry is written before anything is moved, so files are never in
+	// the trash without an entry that says where they came from
+	data, err := json.MarshalIndent(entry, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := p.repository.Put(entry.entryPath(), data); err != nil {
+		return err
+	}
+	moved := []fileMove{}
+	for _, repoPath := range paths {
+		move := fileMove{src: repoPath, dest: entry.trashPath(repoPath)}
+		if err := p.repository.Move(move.src, move.dest); err != nil {
+			// Tarballs might not exist, for example if the checkpoint
+			// didn't have any files or they were pruned
+			if errors.IsDoesNotExist(err) {
+				continue
+			}
+			err = fmt.Errorf("Failed to move %s to the trash: %w", repoPath, err)
+			if undoErr := p.undoMoves(moved); undoErr != nil {
+				return fmt.Errorf("%w. Some of the files that had already been moved couldn't be moved back (%v), so run 'keepsake restore %s' to restore them.", err, undoErr, entry.ShortID())
+			}
+			if deleteErr := p.repository.Delete(entry.entryPath()); deleteErr != nil {
+				console.Warn("Failed to delete trash entry %s: %s", entry.entryPath(), deleteErr)
+			}
+			return err
+		}
+		moved = append(moved, move)
+	}
+	p.invalidateCache()
+	return nil
+}
+
+// fileMove is a file that has been moved from src to dest
+type fileMove struct {
+	src  string
+	dest string
+}
+
+// undoMoves moves files back to where they were, most recently moved first,
+// so an experiment or checkpoint isn't left half in the trash if moving it
+// fails partway
+func (p *Project) undoMoves(moved []fileMove) error {
+	for i := len(moved) - 1; i >= 0; i-- {
+		if err := p.repository.Move(moved[i].dest, moved[i].src); err != nil {
+			return fmt.Errorf("Failed to move %s back to %s: %w", moved[i].dest, moved[i].src, err)
+		}
+	}
+	return nil
+}
+
+// TrashEntries returns everything in the trash, most recently deleted first
+func (p *Project) TrashEntries() ([]*TrashEntry, error) {
+	results := make(chan repository.ListResult)
+	go p.repository.MatchFilenamesRecursive(results, trashDir, "trash.json")
+	entries := []*TrashEntry{}
+	for result := range results {
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		entry := new(TrashEntry)
+		if err := loadFromPath(p.repository, result.Path, entry); err != nil {
+			console.Warn("Failed to load trash entry from %q: %s", result.Path, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Deleted.After(entries[j].Deleted)
+	})
+	return entries, nil
+}
+
+// TrashEntryFromPrefix returns the entry in the trash with an ID that starts
+// with prefix
+func (p *Project) TrashEntryFromPrefix(prefix string) (*TrashEntry, error) {
+	entries, err := p.TrashEntries()
+	if err != nil {
+		return nil, err
+	}
+	matches := []*TrashEntry{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.ID, prefix) {
+			matches = append(matches, entry)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, errors.DoesNotExist("Could not find an experiment or checkpoint in the trash with the ID " + prefix)
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("Prefix is ambiguous: %s (%d matching experiments/checkpoints in the trash)", prefix, len(matches))
+	}
+	return matches[0], nil
+}
+
+// RestoreFromTrash moves the files of an entry in the trash back to where
+// they were before they were deleted
+func (p *Project) RestoreFromTrash(entry *TrashEntry) error {
+	if err := p.checkNotMigrating(); err != nil {
+		return err
+	}
+	// Check nothing has been put back in the way before moving anything.
+	// Each path is checked on its own, so the directories they are in aren't
+	// listed on object stores.
+	for _, original := range entry.Paths {
+		exists, err := p.repository.Exists(original)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.Conflict(fmt.Sprintf("Can't restore %s %s from the trash, because %s already exists", entry.Kind(), entry.ShortID(), original))
+		}
+	}
+	// In the same order they were moved to the trash, so an experiment's
+	// metadata is restored after its files
+	restored := []fileMove{}
+	for _, original := range entry.Paths {
+		move := fileMove{src: entry.trashPath(original), dest: original}
+		if err := p.restoreFile(move); err != nil {
+			if errors.IsDoesNotExist(err) {
+				continue
+			}
+			if errors.IsConflict(err) {
+				err = errors.Conflict(fmt.Sprintf("Can't restore %s %s from the trash, because %s has been created since it was checked", entry.Kind(), entry.ShortID(), original))
+			} else {
+				err = fmt.Errorf("Failed to restore %s from the trash: %w", original, err)
+			}
+			if undoErr := p.undoMoves(restored); undoErr != nil {
+				return fmt.Errorf("%w. Some of the files that had already been restored couldn't be moved back to the trash: %v", err, undoErr)
+			}
+			return err
+		}
+		restored = append(restored, move)
+	}
+	if entry.IsCheckpoint {
+		if err := p.setCheckpointTrashed(entry.ExperimentID, entry.ID, false); err != nil {
+			return err
+		}
+	}
+	if err := p.repository.Delete(entry.dir()); err != nil {
+		return err
+	}
+	p.invalidateCache()
+	return nil
+}
+
+// restoreFile moves a file out of the trash. Metadata is written with a
+// conditional write, so an experiment that has been created since the paths
+// were checked isn't overwritten. Other files are tarballs, which have random
+// IDs in their paths, so they are moved.
+func (p *Project) restoreFile(move fileMove) error {
+	if !strings.HasPrefix(move.dest, "metadata/") {
+		return p.repository.Move(move.src, move.dest)
+	}
+	data, err := p.repository.Get(move.src)
+	if err != nil {
+		return err
+	}
+	if err := p.repository.PutIfMatch(move.dest, data, ""); err != nil {
+		return err
+	}
+	return p.repository.Delete(move.src)
+}
+
+// DeleteFromTrash permanently deletes an entry in the trash
+func (p *Project) DeleteFromTrash(entry *TrashEntry) error {
+	if err := p.CheckCanDelete(); err != nil {
+		return err
+	}
+	return p.repository.Delete(entry.dir())
+}
+
+// EmptyTrash permanently deletes everything that was moved to the trash
+// longer ago than olderThan, and returns what was deleted
+func (p *Project) EmptyTrash(olderThan time.Duration) ([]*TrashEntry, error) {
+	entries, err := p.TrashEntries()
+	if err != nil {
+		return nil, err
+	}
+	deleted := []*TrashEntry{}
+	cutoff := time.Now().Add(-olderThan)
+	for _, entry := range entries {
+		if entry.Deleted.After(cutoff) {
+			continue
+		}
+		if err := p.DeleteFromTrash(entry); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, entry)
+	}
+	return deleted, nil
+}
+
+func currentUsername() string {
+	currentUser, err := user.Current()
+	if err != nil {
+		console.Warn("Failed to determine username: %s", err)
+		return ""
+	}
+	return currentUser.Username
+}
@@ -0,0 +1,225 @@
+package project
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestTrashAndRestoreExperiment(t *testing.T) {
+	repoDir, err := files.TempDir("test-trash")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	proj := NewProject(repo, "")
+
+	exp := retentionTestExperiment()
+	require.NoError(t, exp.Save(repo))
+	require.NoError(t, repo.Put(exp.StorageTarPath(), []byte("code")))
+	require.NoError(t, repo.Put(exp.Checkpoints[0].StorageTarPath(), []byte("weights")))
+	require.NoError(t, CreateHeartbeat(repo, exp.ID, time.Now()))
+
+	require.NoError(t, proj.TrashExperiment(exp))
+	experiments, err := proj.Experiments()
+	require.NoError(t, err)
+	require.Empty(t, experiments)
+	require.NoFileExists(t, path.Join(repoDir, exp.HeartbeatPath()))
+	require.FileExists(t, path.Join(repoDir, "trash", exp.ID, exp.Checkpoints[0].StorageTarPath()))
+
+	entries, err := proj.TrashEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, exp.ID, entries[0].ID)
+	require.Equal(t, "experiment", entries[0].Kind())
+	require.NotEmpty(t, entries[0].DeletedBy)
+
+	entry, err := proj.TrashEntryFromPrefix(exp.ID[:5])
+	require.NoError(t, err)
+	require.NoError(t, proj.RestoreFromTrash(entry))
+	experiments, err = proj.Experiments()
+	require.NoError(t, err)
+	require.Len(t, experiments, 1)
+	content, err := repo.Get(exp.Checkpoints[0].StorageTarPath())
+	require.NoError(t, err)
+	require.Equal(t, "weights", string(content))
+	require.NoDirExists(t, path.Join(repoDir, "trash", exp.ID))
+
+	_, err = proj.TrashEntryFromPrefix(exp.ID[:5])
+	require.True(t, errors.IsDoesNotExist(err))
+}
+
+func TestTrashCheckpointAndEmptyTrash(t *testing.T) {
+	repoDir, err := files.TempDir("test-trash")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	proj := NewProject(repo, "")
+
+	exp := retentionTestExperiment()
+	require.NoError(t, exp.Save(repo))
+	for _, chk := range exp.Checkpoints[:2] {
+		require.NoError(t, repo.Put(chk.StorageTarPath(), []byte("weights")))
+		require.NoError(t, proj.TrashCheckpoint(chk, exp))
+		require.NoFileExists(t, path.Join(repoDir, chk.StorageTarPath()))
+	}
+
+	// restoring over something that has been put back is refused
+	require.NoError(t, repo.Put(exp.Checkpoints[0].StorageTarPath(), []byte("new weights")))
+	entry, err := proj.TrashEntryFromPrefix(exp.Checkpoints[0].ID)
+	require.NoError(t, err)
+	require.True(t, errors.IsConflict(proj.RestoreFromTrash(entry)))
+
+	// only things older than the cutoff are deleted
+	deleted, err := proj.EmptyTrash(time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+	deleted, err = proj.EmptyTrash(0)
+	require.NoError(t, err)
+	require.Len(t, deleted, 2)
+	entries, err := proj.TrashEntries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestTrashAndRestoreCheckpoint(t *testing.T) {
+	repoDir, err := files.TempDir("test-trash")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	proj := NewProject(repo, "")
+
+	exp := retentionTestExperiment()
+	require.NoError(t, exp.Save(repo))
+	chk := exp.Checkpoints[1]
+	require.NoError(t, repo.Put(chk.StorageTarPath(), []byte("weights")))
+
+	// the checkpoint is marked as trashed, so it can't be checked out
+	require.NoError(t, proj.TrashCheckpoint(chk, exp))
+	saved, err := proj.ExperimentByID(exp.ID)
+	require.NoError(t, err)
+	require.True(t, saved.Checkpoints[1].Trashed)
+	require.False(t, saved.Checkpoints[0].Trashed)
+	require.Len(t, saved.CheckpointsNotTrashed(), len(exp.Checkpoints)-1)
+	err = proj.CheckoutCheckpoint(saved.Checkpoints[1], saved, repoDir, true)
+	require.True(t, errors.IsDoesNotExist(err), err)
+	require.Contains(t, err.Error(), "moved to the trash")
+
+	// trashed checkpoints aren't pruned, because their files are in the trash
+	for _, toPrune := range saved.CheckpointsToPrune(&config.RetentionPolicy{KeepLast: 1}) {
+		require.NotEqual(t, chk.ID, toPrune.ID)
+	}
+
+	// saving an old copy of the experiment doesn't clear the mark
+	_, err = proj.SaveExperiment(retentionTestExperiment(), true)
+	require.NoError(t, err)
+	saved, err = proj.ExperimentByID(exp.ID)
+	require.NoError(t, err)
+	require.True(t, saved.Checkpoints[1].Trashed)
+
+	entry, err := proj.TrashEntryFromPrefix(chk.ID)
+	require.NoError(t, err)
+	require.NoError(t, proj.RestoreFromTrash(entry))
+	saved, err = proj.ExperimentByID(exp.ID)
+	require.NoError(t, err)
+	require.False(t, saved.Checkpoints[1].Trashed)
+	content, err := repo.Get(chk.StorageTarPath())
+	require.NoError(t, err)
+	require.Equal(t, "weights", string(content))
+}
+
+// failingMoveRepository fails to move failPath, like a repository that
+// fails partway through moving an experiment
+type failingMoveRepository struct {
+	repository.Repository
+	failPath string
+}
+
+func (r *failingMoveRepository) Move(src, dest string) error {
+	if src == r.failPath {
+		return errors.WriteError("Failed to move " + src)
+	}
+	return r.Repository.Move(src, dest)
+}
+
+func TestTrashExperimentRollsBack(t *testing.T) {
+	repoDir, err := files.TempDir("test-trash")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	disk, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	exp := retentionTestExperiment()
+	require.NoError(t, exp.Save(disk))
+	require.NoError(t, disk.Put(exp.StorageTarPath(), []byte("code")))
+	require.NoError(t, disk.Put(exp.Checkpoints[0].StorageTarPath(), []byte("weights")))
+
+	// the files that were moved before it failed are moved back
+	repo := &failingMoveRepository{Repository: disk, failPath: exp.Checkpoints[0].StorageTarPath()}
+	proj := NewProject(repo, "")
+	err = proj.TrashExperiment(exp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Failed to move "+exp.Checkpoints[0].StorageTarPath())
+	require.FileExists(t, path.Join(repoDir, exp.StorageTarPath()))
+	require.FileExists(t, path.Join(repoDir, exp.Checkpoints[0].StorageTarPath()))
+	require.FileExists(t, path.Join(repoDir, exp.MetadataPath()))
+	entries, err := proj.TrashEntries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// and the same when restoring
+	require.NoError(t, NewProject(disk, "").TrashExperiment(exp))
+	entry, err := proj.TrashEntryFromPrefix(exp.ID)
+	require.NoError(t, err)
+	repo.failPath = entry.trashPath(exp.Checkpoints[0].StorageTarPath())
+	err = proj.RestoreFromTrash(entry)
+	require.Error(t, err)
+	require.NoFileExists(t, path.Join(repoDir, exp.StorageTarPath()))
+	require.FileExists(t, path.Join(repoDir, entry.trashPath(exp.StorageTarPath())))
+	repo.failPath = ""
+	require.NoError(t, proj.RestoreFromTrash(entry))
+	require.FileExists(t, path.Join(repoDir, exp.MetadataPath()))
+}
+
+// existsRaceRepository says nothing exists, like a repository where something
+// is written at the same time as it is checked
+type existsRaceRepository struct {
+	repository.Repository
+}
+
+func (r *existsRaceRepository) Exists(p string) (bool, error) {
+	return false, nil
+}
+
+func TestRestoreExperimentDoesNotOverwriteMetadata(t *testing.T) {
+	repoDir, err := files.TempDir("test-trash")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	disk, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+	proj := NewProject(&existsRaceRepository{Repository: disk}, "")
+	exp := retentionTestExperiment()
+	require.NoError(t, exp.Save(disk))
+	require.NoError(t, disk.Put(exp.StorageTarPath(), []byte("code")))
+	require.NoError(t, proj.TrashExperiment(exp))
+
+	require.NoError(t, disk.Put(exp.MetadataPath(), []byte(`{"id": "new"}`)))
+	entry, err := proj.TrashEntryFromPrefix(exp.ID)
+	require.NoError(t, err)
+	require.True(t, errors.IsConflict(proj.RestoreFromTrash(entry)))
+	content, err := disk.Get(exp.MetadataPath())
+	require.NoError(t, err)
+	require.Equal(t, `{"id": "new"}`, string(content))
+	// the files that were restored before are moved back to the trash
+	require.NoFileExists(t, path.Join(repoDir, exp.StorageTarPath()))
+	require.FileExists(t, path.Join(repoDir, entry.trashPath(exp.MetadataPath())))
+}
@@ -0,0 +1,445 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+)
+
+// WatchEventType is what happened to an experiment
+type WatchEventType string
+
+const (
+	// WatchEventReset means that the events a subscriber has seen are out
+	// of date. It is followed by a created event for each experiment.
+	WatchEventReset           WatchEventType = "reset"
+	WatchEventCreated         WatchEventType = "created"
+	WatchEventCheckpointAdded WatchEventType = "checkpoint_added"
+	WatchEventStatusChanged   WatchEventType = "status_changed"
+	WatchEventDeleted         WatchEventType = "deleted"
+)
+
+// DefaultWatchInterval is how often watchers read the repository for changes
+// made by other processes
+var DefaultWatchInterval = 10 * time.Second
+
+// maxWatchEvents is the number of events a watcher keeps, so subscribers can
+// carry on from where they left off
+const maxWatchEvents = 1000
+
+// subscriptionBufferSize is the number of events that can be waiting for a
+// subscriber before it is unsubscribed for being too slow
+const subscriptionBufferSize = 100
+
+// WatchEvent is something that happened to an experiment
+type WatchEvent struct {
+	Type WatchEventType
+	// Cursor is passed to Subscribe to carry on from after this event
+	Cursor       string
+	Time         time.Time
+	ExperimentID string
+	// Experiment is the experiment after the change. It is nil for deleted
+	// and reset events.
+	Experiment *Experiment
+	// Checkpoint is the checkpoint that was added, for checkpoint_added
+	// events
+	Checkpoint *Checkpoint
+	// Running is whether the experiment was running when the event happened
+	Running bool
+}
+
+// ErrSubscriptionTooSlow is the error of a subscription that was closed
+// because its events weren't read quickly enough
+var ErrSubscriptionTooSlow = fmt.Errorf("Events weren't read quickly enough, so the subscription was closed. Subscribe again with the cursor of the last event to carry on.")
+
+type watchedExperiment struct {
+	experiment    *Experiment
+	checkpointIDs map[string]bool
+	running       bool
+	// local is true if the experiment was created by this process, so
+	// whether it is running is known without reading its heartbeat
+	local bool
+	// updated is when this process last changed the experiment. Polls that
+	// started before then have out of date copies of it.
+	updated time.Time
+}
+
+// Watcher turns changes to a project's experiments into events. Changes made
+// by this process are reported to it with ExperimentCreated and friends as
+// they happen, and changes made by other processes are found by reading the
+// repository every interval while there are subscribers.
+//
+// Each event has a cursor. Subscribing with the cursor of an event sends the
+// events after it, as long as the watcher still has them.
+type Watcher struct {
+	project  *Project
+	interval time.Duration
+	// id is the start of every cursor, so cursors from other watchers
+	// aren't mistaken for this one's
+	id string
+
+	// pollLock is held while polling, because project isn't safe to use
+	// from more than one goroutine
+	pollLock sync.Mutex
+
+	// lock protects everything below
+	lock        sync.Mutex
+	loaded      bool
+	experiments map[string]*watchedExperiment
+	// seq is the sequence number of the last event, and events are the
+	// last maxWatchEvents events
+	seq           int64
+	events        []*WatchEvent
+	subscriptions map[*Subscription]bool
+	stopPolling   chan struct{}
+}
+
+// NewWatcher creates a watcher for the experiments in proj. The watcher
+// reads proj from a background goroutine, so proj shouldn't be used for
+// anything else.
+func NewWatcher(proj *Project, interval time.Duration) *Watcher {
+	return &Watcher{
+		project:       proj,
+		interval:      interval,
+		id:            generateRandomID()[:12],
+		experiments:   map[string]*watchedExperiment{},
+		subscriptions: map[*Subscription]bool{},
+	}
+}
+
+// Subscription is a stream of events from a Watcher
+type Subscription struct {
+	watcher *Watcher
+	events  chan *WatchEvent
+	// err is why the subscription was closed. It is protected by the
+	// watcher's lock.
+	err error
+}
+
+// Events returns the channel events are sent on. It is closed when the
+// subscription is closed.
+func (s *Subscription) Events() <-chan *WatchEvent {
+	return s.events
+}
+
+// Err returns ErrSubscriptionTooSlow if the subscription was closed because
+// its events weren't read quickly enough, and nil otherwise
+func (s *Subscription) Err() error {
+	s.watcher.lock.Lock()
+	defer s.watcher.lock.Unlock()
+	return s.err
+}
+
+// Close stops sending events to the subscription
+func (s *Subscription) Close() {
+	s.watcher.lock.Lock()
+	defer s.watcher.lock.Unlock()
+	s.watcher.unsubscribe(s, nil)
+}
+
+// Subscribe starts sending events to a subscription. If cursor is the cursor
+// of an event the watcher still has, the events after it are sent first.
+// Otherwise, a reset event is sent, followed by a created event for each
+// experiment that exists.
+func (w *Watcher) Subscribe(cursor string) (*Subscription, error) {
+	w.lock.Lock()
+	loaded := w.loaded
+	w.lock.Unlock()
+	if !loaded {
+		if err := w.Poll(); err != nil {
+			return nil, err
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	backlog, err := w.eventsAfter(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if backlog == nil {
+		backlog = w.snapshot()
+	}
+	sub := &Subscription{
+		watcher: w,
+		events:  make(chan *WatchEvent, len(backlog)+subscriptionBufferSize),
+	}
+	for _, event := range backlog {
+		sub.events <- event
+	}
+	w.subscriptions[sub] = true
+	if len(w.subscriptions) == 1 {
+		w.stopPolling = make(chan struct{})
+		go w.pollEvery(w.stopPolling)
+	}
+	return sub, nil
+}
+
+// Poll reads the repository, and sends events for the changes since it was
+// last read
+func (w *Watcher) Poll() error {
+	w.pollLock.Lock()
+	defer w.pollLock.Unlock()
+
+	start := time.Now()
+	if err := w.project.Refresh(); err != nil {
+		return err
+	}
+	experiments, err := w.project.Experiments()
+	if err != nil {
+		return err
+	}
+	running := map[string]bool{}
+	for _, exp := range experiments {
+		if running[exp.ID], err = w.project.ExperimentIsRunning(exp.ID); err != nil {
+			return err
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	seen := map[string]bool{}
+	for _, exp := range experiments {
+		seen[exp.ID] = true
+		watched, ok := w.experiments[exp.ID]
+		if ok && watched.updated.After(start) {
+			continue
+		}
+		if ok && watched.local {
+			w.update(exp, watched.running, time.Time{})
+		} else {
+			w.update(exp, running[exp.ID], time.Time{})
+		}
+	}
+	for id, watched := range w.experiments {
+		if !seen[id] && !watched.updated.After(start) {
+			w.remove(id)
+		}
+	}
+	// events aren't sent for the first poll, which finds out what
+	// already exists
+	w.loaded = true
+	return nil
+}
+
+// ExperimentCreated records that this process has created an experiment.
+// running is whether it is writing heartbeats for it.
+func (w *Watcher) ExperimentCreated(exp *Experiment, running bool) {
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.update(copyExperiment(exp), running, time.Now())
+	w.experiments[exp.ID].local = true
+}
+
+// ExperimentSaved records that this process has saved an experiment
+func (w *Watcher) ExperimentSaved(exp *Experiment) {
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	running := false
+	if watched, ok := w.experiments[exp.ID]; ok {
+		running = watched.running
+	}
+	w.update(copyExperiment(exp), running, time.Now())
+}
+
+// ExperimentStopped records that this process has stopped an experiment
+func (w *Watcher) ExperimentStopped(experimentID string) {
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	watched, ok := w.experiments[experimentID]
+	if !ok {
+		return
+	}
+	watched.local = true
+	w.update(watched.experiment, false, time.Now())
+}
+
+// ExperimentDeleted records that this process has deleted an experiment
+func (w *Watcher) ExperimentDeleted(experimentID string) {
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.experiments[experimentID]; ok {
+		w.remove(experimentID)
+	}
+}
+
+func (w *Watcher) pollEvery(stop chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := w.Poll(); err != nil {
+				console.Warn("Failed to check for changes to experiments: %v", err)
+			}
+		}
+	}
+}
+
+// update sends events for the differences between exp and what is known
+// about it. updated is when this process changed it, or zero if it was read
+// from the repository. The lock must be held.
+func (w *Watcher) update(exp *Experiment, running bool, updated time.Time) {
+	watched, ok := w.experiments[exp.ID]
+	if !ok {
+		watched = &watchedExperiment{
+			experiment:    exp,
+			checkpointIDs: map[string]bool{},
+			running:       running,
+			updated:       updated,
+		}
+		for _, chk := range exp.Checkpoints {
+			watched.checkpointIDs[chk.ID] = true
+		}
+		w.experiments[exp.ID] = watched
+		w.emit(&WatchEvent{Type: WatchEventCreated, ExperimentID: exp.ID, Experiment: exp, Running: running})
+		return
+	}
+
+	watched.experiment = exp
+	if updated.After(watched.updated) {
+		watched.updated = updated
+	}
+	// Checkpoints are never forgotten, so a copy of the experiment that
+	// is older than one this process has saved doesn't cause them to be
+	// added twice
+	for _, chk := range exp.Checkpoints {
+		if !watched.checkpointIDs[chk.ID] {
+			watched.checkpointIDs[chk.ID] = true
+			w.emit(&WatchEvent{Type: WatchEventCheckpointAdded, ExperimentID: exp.ID, Experiment: exp, Checkpoint: chk, Running: watched.running})
+		}
+	}
+	if running != watched.running {
+		watched.running = running
+		w.emit(&WatchEvent{Type: WatchEventStatusChanged, ExperimentID: exp.ID, Experiment: exp, Running: running})
+	}
+}
+
+// remove forgets an experiment and sends a deleted event. The lock must be
+// held.
+func (w *Watcher) remove(experimentID string) {
+	delete(w.experiments, experimentID)
+	w.emit(&WatchEvent{Type: WatchEventDeleted, ExperimentID: experimentID})
+}
+
+// emit gives event a cursor and sends it to the subscribers. Subscribers
+// that can't keep up are unsubscribed, and can subscribe again with the
+// cursor of the last event they got. The lock must be held.
+func (w *Watcher) emit(event *WatchEvent) {
+	if !w.loaded {
+		return
+	}
+	w.seq++
+	event.Cursor = w.cursor(w.seq)
+	event.Time = time.Now().UTC()
+	w.events = append(w.events, event)
+	if len(w.events) > maxWatchEvents {
+		w.events = w.events[len(w.events)-maxWatchEvents:]
+	}
+	for sub := range w.subscriptions {
+		select {
+		case sub.events <- event:
+		default:
+			w.unsubscribe(sub, ErrSubscriptionTooSlow)
+		}
+	}
+}
+
+// unsubscribe closes sub, and stops polling if it was the last
+// subscription. The lock must be held.
+func (w *Watcher) unsubscribe(sub *Subscription, err error) {
+	if !w.subscriptions[sub] {
+		return
+	}
+	delete(w.subscriptions, sub)
+	sub.err = err
+	close(sub.events)
+	if len(w.subscriptions) == 0 {
+		close(w.stopPolling)
+	}
+}
+
+func (w *Watcher) cursor(seq int64) string {
+	return fmt.Sprintf("%s-%d", w.id, seq)
+}
+
+// eventsAfter returns the events after cursor, or nil if the watcher doesn't
+// have them. The lock must be held.
+func (w *Watcher) eventsAfter(cursor string) ([]*WatchEvent, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(cursor, "-", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid cursor: %s", cursor)
+	}
+	seq, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cursor: %s", cursor)
+	}
+	// the watcher's events start after oldest
+	oldest := w.seq - int64(len(w.events))
+	if parts[0] != w.id || seq < oldest || seq > w.seq {
+		return nil, nil
+	}
+	events := make([]*WatchEvent, w.seq-seq)
+	copy(events, w.events[seq-oldest:])
+	return events, nil
+}
+
+// snapshot returns a reset event, and a created event for each experiment,
+// oldest first. The lock must be held.
+func (w *Watcher) snapshot() []*WatchEvent {
+	cursor := w.cursor(w.seq)
+	now := time.Now().UTC()
+	experiments := []*watchedExperiment{}
+	for _, watched := range w.experiments {
+		experiments = append(experiments, watched)
+	}
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].experiment.Created.Before(experiments[j].experiment.Created)
+	})
+	events := []*WatchEvent{{Type: WatchEventReset, Cursor: cursor, Time: now}}
+	for _, watched := range experiments {
+		events = append(events, &WatchEvent{
+			Type:         WatchEventCreated,
+			Cursor:       cursor,
+			Time:         now,
+			ExperimentID: watched.experiment.ID,
+			Experiment:   watched.experiment,
+			Running:      watched.running,
+		})
+	}
+	return events
+}
+
+// copyExperiment copies exp and its checkpoints, so events aren't changed
+// when this process carries on changing exp
+func copyExperiment(exp *Experiment) *Experiment {
+	copied := *exp
+	copied.Checkpoints = make([]*Checkpoint, len(exp.Checkpoints))
+	for i, chk := range exp.Checkpoints {
+		c := *chk
+		copied.Checkpoints[i] = &c
+	}
+	return &copied
+}
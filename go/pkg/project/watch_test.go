@@ -0,0 +1,166 @@
+package project
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+// nextEvents returns the next n events on sub, failing if they don't arrive
+func nextEvents(t *testing.T, sub *Subscription, n int) []*WatchEvent {
+	events := []*WatchEvent{}
+	for len(events) < n {
+		select {
+		case event, ok := <-sub.Events():
+			require.True(t, ok, "the subscription was closed")
+			events = append(events, event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for events, got %d of %d", len(events), n)
+		}
+	}
+	return events
+}
+
+func requireNoEvents(t *testing.T, sub *Subscription) {
+	select {
+	case event := <-sub.Events():
+		t.Fatalf("Unexpected %s event for %s", event.Type, event.ExperimentID)
+	default:
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	repoDir, err := files.TempDir("test-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	exp1 := &Experiment{ID: "1eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	require.NoError(t, exp1.Save(repo))
+
+	// polling is done by calling Poll in this test
+	w := NewWatcher(NewProject(repo, ""), time.Hour)
+	sub, err := w.Subscribe("")
+	require.NoError(t, err)
+	defer sub.Close()
+	events := nextEvents(t, sub, 2)
+	require.Equal(t, WatchEventReset, events[0].Type)
+	require.Equal(t, WatchEventCreated, events[1].Type)
+	require.Equal(t, exp1.ID, events[1].Experiment.ID)
+	require.False(t, events[1].Running)
+
+	// changes made by other processes are found by polling
+	exp2 := &Experiment{ID: "2eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	require.NoError(t, exp2.Save(repo))
+	require.NoError(t, CreateHeartbeat(repo, exp2.ID, time.Now().UTC()))
+	exp1.Checkpoints = []*Checkpoint{{ID: "1ccccccccc", Created: time.Now().UTC(), Step: 10}}
+	require.NoError(t, exp1.Save(repo))
+	require.NoError(t, w.Poll())
+	events = nextEvents(t, sub, 2)
+	byType := map[WatchEventType]*WatchEvent{}
+	for _, event := range events {
+		byType[event.Type] = event
+	}
+	require.Equal(t, exp1.ID, byType[WatchEventCheckpointAdded].ExperimentID)
+	require.Equal(t, "1ccccccccc", byType[WatchEventCheckpointAdded].Checkpoint.ID)
+	require.Equal(t, exp2.ID, byType[WatchEventCreated].ExperimentID)
+	require.True(t, byType[WatchEventCreated].Running)
+	cursor := events[1].Cursor
+
+	require.NoError(t, DeleteHeartbeat(repo, exp2.ID))
+	require.NoError(t, repo.Delete(exp1.MetadataPath()))
+	require.NoError(t, w.Poll())
+	events = nextEvents(t, sub, 2)
+	byType = map[WatchEventType]*WatchEvent{}
+	for _, event := range events {
+		byType[event.Type] = event
+	}
+	require.Equal(t, exp1.ID, byType[WatchEventDeleted].ExperimentID)
+	require.Equal(t, exp2.ID, byType[WatchEventStatusChanged].ExperimentID)
+	require.False(t, byType[WatchEventStatusChanged].Running)
+
+	// nothing has changed
+	require.NoError(t, w.Poll())
+	requireNoEvents(t, sub)
+
+	// changes made by this process are sent without polling
+	exp3 := &Experiment{ID: "3eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	w.ExperimentCreated(exp3, true)
+	exp3.Checkpoints = []*Checkpoint{{ID: "3ccccccccc", Created: time.Now().UTC()}}
+	require.NoError(t, exp3.Save(repo))
+	w.ExperimentSaved(exp3)
+	events = nextEvents(t, sub, 2)
+	require.Equal(t, WatchEventCreated, events[0].Type)
+	require.True(t, events[0].Running)
+	require.Empty(t, events[0].Experiment.Checkpoints, "events are copies")
+	require.Equal(t, WatchEventCheckpointAdded, events[1].Type)
+	// it hasn't written a heartbeat yet, but this process knows it is
+	// running
+	require.NoError(t, w.Poll())
+	requireNoEvents(t, sub)
+	w.ExperimentStopped(exp3.ID)
+	events = nextEvents(t, sub, 1)
+	require.Equal(t, WatchEventStatusChanged, events[0].Type)
+	require.False(t, events[0].Running)
+
+	// resuming from a cursor sends the events after it
+	resumed, err := w.Subscribe(cursor)
+	require.NoError(t, err)
+	defer resumed.Close()
+	events = nextEvents(t, resumed, 5)
+	require.Equal(t, WatchEventCreated, events[2].Type)
+	require.Equal(t, exp3.ID, events[2].ExperimentID)
+	requireNoEvents(t, resumed)
+
+	// cursors from other watchers start from scratch
+	other := NewWatcher(NewProject(repo, ""), time.Hour)
+	otherSub, err := other.Subscribe(cursor)
+	require.NoError(t, err)
+	defer otherSub.Close()
+	events = nextEvents(t, otherSub, 3)
+	require.Equal(t, WatchEventReset, events[0].Type)
+	require.Equal(t, exp2.ID, events[1].ExperimentID)
+	require.Equal(t, exp3.ID, events[2].ExperimentID)
+	requireNoEvents(t, otherSub)
+
+	_, err = w.Subscribe("not a cursor")
+	require.Error(t, err)
+}
+
+func TestWatcherUnsubscribesSlowSubscribers(t *testing.T) {
+	repoDir, err := files.TempDir("test-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	repo, err := repository.NewDiskRepository(repoDir)
+	require.NoError(t, err)
+
+	w := NewWatcher(NewProject(repo, ""), time.Hour)
+	sub, err := w.Subscribe("")
+	require.NoError(t, err)
+	reset := nextEvents(t, sub, 1)[0]
+
+	exp := &Experiment{ID: "1eeeeeeeee", Created: time.Now().UTC(), Config: &config.Config{}}
+	w.ExperimentCreated(exp, false)
+	for i := 0; i < subscriptionBufferSize+maxWatchEvents; i++ {
+		exp.Checkpoints = append(exp.Checkpoints, &Checkpoint{ID: generateRandomID(), Created: time.Now().UTC()})
+		w.ExperimentSaved(exp)
+	}
+	for range sub.Events() {
+	}
+	require.Equal(t, ErrSubscriptionTooSlow, sub.Err())
+
+	// the events have been discarded, so the subscriber has to start again
+	sub, err = w.Subscribe(reset.Cursor)
+	require.NoError(t, err)
+	defer sub.Close()
+	events := nextEvents(t, sub, 2)
+	require.Equal(t, WatchEventReset, events[0].Type)
+	require.Len(t, events[1].Experiment.Checkpoints, subscriptionBufferSize+maxWatchEvents)
+}
@@ -10,14 +10,18 @@ import (
 //
 // SyncCache() syncs cachePrefix locally, which you must call before doing any
 // reads. It is not done automatically so you can control output to the user about
-// syncing.
+// syncing. Calling it again only fetches what has changed since the last sync.
 //
 // If a read hits a path starting with cachePrefix, it will use the local cached version.
+// Writes to cachePrefix are made to both the cache and the underlying repository,
+// and the syncer is told about them so the next sync puts right anything that
+// didn't make it to the underlying repository.
 type CachedRepository struct {
 	repository      Repository
 	cachePrefix     string
 	cacheDir        string
 	cacheRepository *DiskRepository
+	syncer          *Syncer
 	isSynced        bool
 }
 
@@ -32,6 +36,7 @@ func NewCachedRepository(repo Repository, cachePrefix string, projectDir string,
 		cachePrefix:     cachePrefix,
 		cacheDir:        cacheDir,
 		cacheRepository: cacheRepository,
+		syncer:          NewSyncer(repo, cachePrefix, cacheRepository, cachePrefix),
 		isSynced:        false,
 	}, nil
 }
@@ -52,6 +57,7 @@ func (s *CachedRepository) Get(p string) ([]byte, error) {
 func (s *CachedRepository) Put(p string, data []byte) error {
 	// FIXME: potential for cache and remote to get out of sync on error
 	if strings.HasPrefix(p, s.cachePrefix) {
+		s.syncer.DestChanged(p)
 		if err := s.cacheRepository.Put(p, data); err != nil {
 			return err
 		}
@@ -59,6 +65,29 @@ func (s *CachedRepository) Put(p string, data []byte) error {
 	return s.repository.Put(p, data)
 }
 
+// GetVersioned always reads from the underlying repository, because the
+// version is only useful if it is current
+func (s *CachedRepository) GetVersioned(p string) ([]byte, string, error) {
+	return s.repository.GetVersioned(p)
+}
+
+// Exists checks the remote repository, because files are created there
+// before they are in the cache
+func (s *CachedRepository) Exists(p string) (bool, error) {
+	return s.repository.Exists(p)
+}
+
+func (s *CachedRepository) PutIfMatch(p string, data []byte, version string) error {
+	if err := s.repository.PutIfMatch(p, data, version); err != nil {
+		return err
+	}
+	if strings.HasPrefix(p, s.cachePrefix) {
+		s.syncer.DestChanged(p)
+		return s.cacheRepository.Put(p, data)
+	}
+	return nil
+}
+
 func (s *CachedRepository) GetPath(repoPath string, localPath string) error {
 	if strings.HasPrefix(repoPath, s.cachePrefix) {
 		return s.cacheRepository.GetPath(repoPath, localPath)
@@ -66,23 +95,24 @@ func (s *CachedRepository) GetPath(repoPath string, localPath string) error {
 	return s.repository.GetPath(repoPath, localPath)
 }
 
-func (s *CachedRepository) GetPathTar(tarPath, localPath string) error {
+func (s *CachedRepository) GetPathTar(tarPath, localPath string, expected *Digest) error {
 	if strings.HasPrefix(tarPath, s.cachePrefix) {
-		return s.cacheRepository.GetPathTar(tarPath, localPath)
+		return s.cacheRepository.GetPathTar(tarPath, localPath, expected)
 	}
-	return s.repository.GetPathTar(tarPath, localPath)
+	return s.repository.GetPathTar(tarPath, localPath, expected)
 }
 
-func (s *CachedRepository) GetPathItemTar(tarPath, itemPath, localPath string) error {
+func (s *CachedRepository) GetPathItemTar(tarPath, itemPath, localPath string, expected *Digest) error {
 	if strings.HasPrefix(tarPath, s.cachePrefix) {
-		return s.cacheRepository.GetPathTar(tarPath, localPath)
+		return s.cacheRepository.GetPathTar(tarPath, localPath, expected)
 	}
-	return s.repository.GetPathItemTar(tarPath, itemPath, localPath)
+	return s.repository.GetPathItemTar(tarPath, itemPath, localPath, expected)
 }
 
 func (s *CachedRepository) PutPath(localPath string, repoPath string) error {
 	// FIXME: potential for cache and remote to get out of sync on error
 	if strings.HasPrefix(repoPath, s.cachePrefix) {
+		s.syncer.Reset()
 		if err := s.cacheRepository.PutPath(localPath, repoPath); err != nil {
 			return err
 		}
@@ -91,11 +121,12 @@ func (s *CachedRepository) PutPath(localPath string, repoPath string) error {
 
 }
 
-func (s *CachedRepository) PutPathTar(localPath, tarPath, includePath string) error {
+func (s *CachedRepository) PutPathTar(localPath, tarPath, includePath string) (*Digest, error) {
 	// FIXME: potential for cache and remote to get out of sync on error
 	if strings.HasPrefix(tarPath, s.cachePrefix) {
-		if err := s.cacheRepository.PutPathTar(localPath, tarPath, includePath); err != nil {
-			return err
+		s.syncer.DestChanged(tarPath)
+		if _, err := s.cacheRepository.PutPathTar(localPath, tarPath, includePath); err != nil {
+			return nil, err
 		}
 	}
 	return s.repository.PutPathTar(localPath, tarPath, includePath)
@@ -133,6 +164,7 @@ func (s *CachedRepository) MatchFilenamesRecursive(results chan<- ListResult, pa
 
 func (s *CachedRepository) Delete(p string) error {
 	if strings.HasPrefix(p, s.cachePrefix) {
+		s.syncer.DestChanged(p)
 		if err := s.cacheRepository.Delete(p); err != nil {
 			return err
 		}
@@ -140,11 +172,47 @@ func (s *CachedRepository) Delete(p string) error {
 	return s.repository.Delete(p)
 }
 
+func (s *CachedRepository) Move(src, dest string) error {
+	if err := s.repository.Move(src, dest); err != nil {
+		return err
+	}
+	if strings.HasPrefix(src, s.cachePrefix) {
+		s.syncer.DestChanged(src)
+		if err := s.cacheRepository.Delete(src); err != nil {
+			return err
+		}
+	}
+	if strings.HasPrefix(dest, s.cachePrefix) {
+		s.syncer.DestChanged(dest)
+		data, err := s.repository.Get(dest)
+		if err != nil {
+			return err
+		}
+		return s.cacheRepository.Put(dest, data)
+	}
+	return nil
+}
+
 func (s *CachedRepository) RootURL() string {
 	return s.repository.RootURL()
 }
 
 func (s *CachedRepository) SyncCache() error {
 	console.Debug("Syncing %s/%s to %s/%s", s.repository.RootURL(), s.cachePrefix, s.cacheRepository.RootURL(), s.cachePrefix)
-	return Sync(s.repository, s.cachePrefix, s.cacheRepository, s.cachePrefix)
+	return s.syncer.Sync()
+}
+
+// SyncCacheDir syncs only the directory dir in cachePrefix locally, which
+// is cheaper than SyncCache when there is a lot in cachePrefix. It syncs
+// everything if nothing has been synced yet.
+func (s *CachedRepository) SyncCacheDir(dir string) error {
+	console.Debug("Syncing %s/%s to %s/%s", s.repository.RootURL(), dir, s.cacheRepository.RootURL(), dir)
+	return s.syncer.SyncDir(strings.TrimPrefix(dir, s.cachePrefix))
+}
+
+// SyncCacheFile syncs only the file p in cachePrefix locally, which costs a
+// single read of p. It syncs everything if nothing has been synced yet.
+func (s *CachedRepository) SyncCacheFile(p string) error {
+	console.Debug("Syncing %s/%s to %s/%s", s.repository.RootURL(), p, s.cacheRepository.RootURL(), p)
+	return s.syncer.SyncFile(strings.TrimPrefix(p, s.cachePrefix))
 }
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+
+	"github.com/replicate/keepsake/go/pkg/hash"
+)
+
+// CheckWritable writes a test file to the repository, reads it back, and
+// deletes it, so problems with credentials or permissions are found before
+// anything is saved in the repository
+func CheckWritable(r Repository) error {
+	p := path.Join(diskTempDir, "check-"+hash.Random()[:16])
+	data := []byte("keepsake")
+	if err := r.Put(p, data); err != nil {
+		return fmt.Errorf("Failed to write to %s: %w", r.RootURL(), err)
+	}
+	read, err := r.Get(p)
+	if err != nil {
+		return fmt.Errorf("Failed to read a file that was written to %s: %w", r.RootURL(), err)
+	}
+	if !bytes.Equal(read, data) {
+		return fmt.Errorf("A file that was written to %s came back with different contents", r.RootURL())
+	}
+	if err := r.Delete(p); err != nil {
+		return fmt.Errorf("Failed to delete from %s: %w", r.RootURL(), err)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,9 +11,12 @@ import (
 	pathpkg "path"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/otiai10/copy"
 
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/errors"
 	"github.com/replicate/keepsake/go/pkg/files"
 )
@@ -39,6 +44,28 @@ func (s *DiskRepository) Get(path string) ([]byte, error) {
 	return data, err
 }
 
+// GetVersioned gets data at path, and its version, which is the SHA-256 of
+// the data
+func (s *DiskRepository) GetVersioned(path string) ([]byte, string, error) {
+	data, err := s.Get(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, diskVersion(data), nil
+}
+
+// Exists returns true if there is a file at path
+func (s *DiskRepository) Exists(path string) (bool, error) {
+	info, err := os.Stat(pathpkg.Join(s.rootDir, path))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.ReadError(fmt.Sprintf("Failed to read %s/%s: %v", s.rootDir, path, err))
+	}
+	return !info.IsDir(), nil
+}
+
 // GetPath recursively copies repoDir to localDir
 func (s *DiskRepository) GetPath(repoDir string, localDir string) error {
 	if err := copy.Copy(pathpkg.Join(s.rootDir, repoDir), localDir); err != nil {
@@ -50,7 +77,7 @@ func (s *DiskRepository) GetPath(repoDir string, localDir string) error {
 // GetPathTar extracts tarball `tarPath` to `localPath`
 //
 // See repository.go for full documentation.
-func (s *DiskRepository) GetPathTar(tarPath, localPath string) error {
+func (s *DiskRepository) GetPathTar(tarPath, localPath string, expected *Digest) error {
 	fullTarPath := pathpkg.Join(s.rootDir, tarPath)
 	exists, err := files.FileExists(fullTarPath)
 	if err != nil {
@@ -59,13 +86,13 @@ func (s *DiskRepository) GetPathTar(tarPath, localPath string) error {
 	if !exists {
 		return errors.DoesNotExist(fmt.Sprintf("Path does not exist: " + fullTarPath))
 	}
-	if err := extractTar(fullTarPath, localPath); err != nil {
+	if err := extractTar(fullTarPath, localPath, expected); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *DiskRepository) GetPathItemTar(tarPath, itemPath, localPath string) error {
+func (s *DiskRepository) GetPathItemTar(tarPath, itemPath, localPath string, expected *Digest) error {
 	fullTarPath := pathpkg.Join(s.rootDir, tarPath)
 	exists, err := files.FileExists(fullTarPath)
 	if err != nil {
@@ -74,25 +101,56 @@ func (s *DiskRepository) GetPathItemTar(tarPath, itemPath, localPath string) err
 	if !exists {
 		return errors.DoesNotExist("Path does not exist: " + fullTarPath)
 	}
-	return extractTarItem(fullTarPath, itemPath, localPath)
+	return extractTarItem(fullTarPath, itemPath, localPath, expected)
 }
 
 // Put data at path
+//
+// The data is written atomically, so concurrent readers see either the old
+// data or the new data, never part of it.
 func (s *DiskRepository) Put(path string, data []byte) error {
+	return s.writeAtomic(pathpkg.Join(s.rootDir, path), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// PutIfMatch puts data at path if the version of the data there is still
+// `version`
+//
+// The version is compared and the data written while holding an operating
+// system lock on a file in the `.keepsake-internal/locks` directory of the repository, which is
+// released even if the process crashes.
+func (s *DiskRepository) PutIfMatch(path string, data []byte, version string) error {
 	fullPath := pathpkg.Join(s.rootDir, path)
-	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
-	if err != nil {
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
 		return errors.WriteError(err.Error())
 	}
-	if err := ioutil.WriteFile(fullPath, data, 0644); err != nil {
-		return errors.WriteError(err.Error())
+	unlock, err := s.lockWrites()
+	if err != nil {
+		return err
 	}
-	return nil
+	defer unlock()
+
+	currentVersion := ""
+	current, err := ioutil.ReadFile(fullPath)
+	if err == nil {
+		currentVersion = diskVersion(current)
+	} else if !os.IsNotExist(err) {
+		return errors.ReadError(err.Error())
+	}
+	if currentVersion != version {
+		return errors.Conflict(fmt.Sprintf("%s has been changed since it was read", path))
+	}
+	return s.writeAtomic(fullPath, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
 }
 
 // PutPath recursively puts the local `localPath` directory into path `repoPath` in the repository
 func (s *DiskRepository) PutPath(localPath string, repoPath string) error {
-	files, err := getListOfFilesToPut(localPath, repoPath)
+	files, err := getListOfFilesToPut(localPath, repoPath, ".", config.SymlinksFollow)
 	if err != nil {
 		return errors.WriteError(err.Error())
 	}
@@ -113,32 +171,21 @@ func (s *DiskRepository) PutPath(localPath string, repoPath string) error {
 // If `includePath` is set, only that will be included.
 //
 // See repository.go for full documentation.
-func (s *DiskRepository) PutPathTar(localPath, tarPath, includePath string) error {
+func (s *DiskRepository) PutPathTar(localPath, tarPath, includePath string) (*Digest, error) {
 	if !strings.HasSuffix(tarPath, ".tar.gz") {
-		return errors.WriteError("PutPathTar: tarPath must end with .tar.gz")
+		return nil, errors.WriteError("PutPathTar: tarPath must end with .tar.gz")
 	}
 
-	fullPath := pathpkg.Join(s.rootDir, tarPath)
-	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
-	if err != nil {
-		return errors.WriteError(err.Error())
-	}
-
-	tarFile, err := os.Create(fullPath)
-	if err != nil {
-		return errors.WriteError(err.Error())
-	}
-	defer tarFile.Close()
-
-	if err := putPathTar(localPath, tarFile, filepath.Base(tarPath), includePath); err != nil {
+	var digest *Digest
+	err := s.writeAtomic(pathpkg.Join(s.rootDir, tarPath), func(w io.Writer) error {
+		var err error
+		digest, err = putPathTar(localPath, w, filepath.Base(tarPath), includePath)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	// Explicitly call Close() on success to capture error
-	if err := tarFile.Close(); err != nil {
-		return errors.WriteError(err.Error())
-	}
-	return nil
+	return digest, nil
 }
 
 // Delete deletes path. If path is a directory, it recursively deletes
@@ -150,6 +197,22 @@ func (s *DiskRepository) Delete(pathToDelete string) error {
 	return nil
 }
 
+// Move moves the file at src to dest by renaming it
+func (s *DiskRepository) Move(src, dest string) error {
+	srcPath := pathpkg.Join(s.rootDir, src)
+	destPath := pathpkg.Join(s.rootDir, dest)
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		return errors.DoesNotExist(fmt.Sprintf("Move: path does not exist: %s/%s", s.rootDir, src))
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return errors.WriteError(fmt.Sprintf("Failed to create directory for %s/%s: %v", s.rootDir, dest, err))
+	}
+	if err := os.Rename(srcPath, destPath); err != nil {
+		return errors.WriteError(fmt.Sprintf("Failed to move %s/%s to %s: %v", s.rootDir, src, dest, err))
+	}
+	return nil
+}
+
 // List files in a path non-recursively
 //
 // Returns a list of paths, prefixed with the given path, that can be passed straight to Get().
@@ -210,7 +273,7 @@ func (s *DiskRepository) ListRecursive(results chan<- ListResult, folder string)
 			if err != nil {
 				return err
 			}
-			results <- ListResult{Path: relPath, MD5: md5sum}
+			results <- ListResult{Path: relPath, MD5: md5sum, Size: info.Size()}
 		}
 		return nil
 	})
@@ -265,3 +328,137 @@ func md5File(path string) ([]byte, error) {
 	}
 	return h.Sum(nil), nil
 }
+
+// internalDir is where Keepsake keeps files that are only used while it is
+// writing to the repository, out of the way of the files people look at
+const internalDir = ".keepsake-internal"
+
+// diskLocksDir is where lock files are kept, outside the metadata directory
+// so they aren't listed or synced with it
+const diskLocksDir = internalDir + "/locks"
+
+// diskWriteLockPath is the file that is locked while PutIfMatch compares
+// versions and writes. It isn't named like the locks taken with Lock, so it
+// can't clash with them.
+var diskWriteLockPath = pathpkg.Join(diskLocksDir, "disk-writes")
+
+// lockWrites takes the lock that PutIfMatch holds, waiting for other
+// processes to release it, and returns a function that releases it.
+//
+// This is an operating system file lock, rather than a lock made with Lock,
+// because Lock is itself built on PutIfMatch.
+func (s *DiskRepository) lockWrites() (unlock func(), err error) {
+	lockPath := pathpkg.Join(s.rootDir, diskWriteLockPath)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, errors.WriteError(err.Error())
+	}
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, errors.WriteError(fmt.Sprintf("Failed to open lock %s: %v", lockPath, err))
+	}
+	if err := lockOSFile(f); err != nil {
+		f.Close()
+		return nil, errors.WriteError(fmt.Sprintf("Failed to lock %s: %v", lockPath, err))
+	}
+	return func() {
+		if err := unlockOSFile(f); err != nil {
+			console.Warn("Failed to unlock %s: %v", lockPath, err)
+		}
+		f.Close()
+	}, nil
+}
+
+// diskTempDir is where files are written before they are renamed into place.
+// It is in the repository rather than the system's temporary directory, so it
+// is on the same filesystem, and outside the directories that are listed, so
+// readers don't see partially written files.
+const diskTempDir = internalDir + "/tmp"
+
+// writeAtomic calls write with a temporary file, then syncs it and renames it
+// to fullPath. If write fails, fullPath is left as it was.
+func (s *DiskRepository) writeAtomic(fullPath string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return errors.WriteError(err.Error())
+	}
+	tempDir := pathpkg.Join(s.rootDir, diskTempDir)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return errors.WriteError(err.Error())
+	}
+	tmp, err := ioutil.TempFile(tempDir, filepath.Base(fullPath)+"-")
+	if err != nil {
+		return errors.WriteError(err.Error())
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		if errors.Code(err) != "" {
+			return err
+		}
+		return errors.WriteError(fmt.Sprintf("Failed to write %s: %v", fullPath, err))
+	}
+	if err := syncAndClose(tmp); err != nil {
+		return errors.WriteError(fmt.Sprintf("Failed to write %s: %v", fullPath, err))
+	}
+	if err := renameAcrossDevices(tmpPath, fullPath); err != nil {
+		return errors.WriteError(fmt.Sprintf("Failed to write %s: %v", fullPath, err))
+	}
+	// Sync the directory so the rename survives a crash
+	if dir, err := os.Open(filepath.Dir(fullPath)); err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+func syncAndClose(f *os.File) error {
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(f.Name(), 0644)
+}
+
+// renameAcrossDevices renames src to dest. The repository's temporary
+// directory is usually on the same filesystem as the rest of the repository,
+// but if part of the repository is mounted from somewhere else, src is copied
+// to a hidden temporary file next to dest, which is renamed instead.
+func renameAcrossDevices(src, dest string) error {
+	err := os.Rename(src, dest)
+	if err == nil || !isCrossDeviceError(err) {
+		return err
+	}
+	console.Debug("%s is on a different device to %s, copying it", src, dest)
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), "."+filepath.Base(dest)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := syncAndClose(tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func isCrossDeviceError(err error) bool {
+	linkErr, ok := err.(*os.LinkError)
+	return ok && linkErr.Err == syscall.EXDEV
+}
+
+func diskVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,10 +1,17 @@
 package repository
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -30,6 +37,18 @@ func TestDiskRepositoryGet(t *testing.T) {
 	content, err := repository.Get("some-file")
 	require.NoError(t, err)
 	require.Equal(t, []byte("hello"), content)
+
+	exists, err := repository.Exists("some-file")
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = repository.Exists("does-not-exist")
+	require.NoError(t, err)
+	require.False(t, exists)
+	// directories aren't files
+	require.NoError(t, os.Mkdir(path.Join(dir, "some-dir"), 0755))
+	exists, err = repository.Exists("some-dir")
+	require.NoError(t, err)
+	require.False(t, exists)
 }
 
 func TestDiskGetPathTar(t *testing.T) {
@@ -42,7 +61,7 @@ func TestDiskGetPathTar(t *testing.T) {
 
 	tmpDir, err := files.TempDir("test")
 	require.NoError(t, err)
-	err = repository.GetPathTar("does-not-exist.tar.gz", tmpDir)
+	err = repository.GetPathTar("does-not-exist.tar.gz", tmpDir, nil)
 	require.True(t, errors.IsDoesNotExist(err))
 }
 
@@ -77,15 +96,19 @@ func TestDiskGetPathItemTar(t *testing.T) {
 	// |
 	// |-- a.txt
 	// |-- b.txt
-	err = repository.PutPathTar(fileDir, "temp.tar.gz", "")
+	digest, err := repository.PutPathTar(fileDir, "temp.tar.gz", "")
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(path.Join(dir, "temp.tar.gz"))
 	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), digest.Size)
+	require.Equal(t, fmt.Sprintf("%x", sha256.Sum256(data)), digest.SHA256)
 
 	// Create a temporary directory
 	tmpDir, err := files.TempDir("test")
 	require.NoError(t, err)
 
 	// Extract just one of the two files from the repo dir.
-	err = repository.GetPathItemTar("temp.tar.gz", "a.txt", tmpDir)
+	err = repository.GetPathItemTar("temp.tar.gz", "a.txt", tmpDir, nil)
 	require.NoError(t, err)
 
 	content, err := ioutil.ReadFile(path.Join(tmpDir, "a.txt"))
@@ -93,7 +116,7 @@ func TestDiskGetPathItemTar(t *testing.T) {
 	require.Equal(t, []byte("file a"), content)
 
 	// Extract an entire directory
-	err = repository.GetPathItemTar("temp.tar.gz", "c", tmpDir)
+	err = repository.GetPathItemTar("temp.tar.gz", "c", tmpDir, nil)
 	require.NoError(t, err)
 
 	content, err = ioutil.ReadFile(path.Join(tmpDir, "c/d.txt"))
@@ -101,8 +124,49 @@ func TestDiskGetPathItemTar(t *testing.T) {
 	require.Equal(t, []byte("file d"), content)
 
 	// Extract a file that does not exist
-	err = repository.GetPathItemTar("temp.tar.gz", "does-not-exist.txt", tmpDir)
+	err = repository.GetPathItemTar("temp.tar.gz", "does-not-exist.txt", tmpDir, nil)
 	require.True(t, errors.IsDoesNotExist(err))
+
+	// Extract with the digest recorded when it was uploaded
+	err = repository.GetPathItemTar("temp.tar.gz", "a.txt", tmpDir, digest)
+	require.NoError(t, err)
+}
+
+func TestDiskGetPathTarVerifiesDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileDir := path.Join(dir, "files")
+	require.NoError(t, os.MkdirAll(fileDir, os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(path.Join(fileDir, "a.txt"), []byte("file a"), 0644))
+
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+	digest, err := repository.PutPathTar(fileDir, "temp.tar.gz", "")
+	require.NoError(t, err)
+
+	tmpDir, err := files.TempDir("test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	require.NoError(t, repository.GetPathTar("temp.tar.gz", tmpDir, digest))
+
+	// digests that weren't recorded aren't checked
+	require.NoError(t, repository.GetPathTar("temp.tar.gz", tmpDir, &Digest{}))
+
+	// a different checksum
+	err = repository.GetPathTar("temp.tar.gz", tmpDir, &Digest{Size: digest.Size, SHA256: strings.Repeat("0", 64)})
+	require.True(t, errors.IsCorruptedTarball(err), err)
+
+	// a truncated tarball
+	tarPath := path.Join(dir, "temp.tar.gz")
+	data, err := ioutil.ReadFile(tarPath)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(tarPath, data[:len(data)/2], 0644))
+	err = repository.GetPathTar("temp.tar.gz", tmpDir, digest)
+	require.True(t, errors.IsCorruptedTarball(err), err)
+	err = repository.GetPathItemTar("temp.tar.gz", "a.txt", tmpDir, digest)
+	require.True(t, errors.IsCorruptedTarball(err), err)
 }
 
 func TestDiskRepositoryPut(t *testing.T) {
@@ -128,6 +192,206 @@ func TestDiskRepositoryPut(t *testing.T) {
 	require.Equal(t, []byte("hello again"), content)
 }
 
+func TestDiskRepositoryPutConcurrentReaders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	// big enough that a non-atomic write would be seen half-written
+	documents := [][]byte{}
+	for i := 0; i < 2; i++ {
+		doc := map[string]string{}
+		for j := 0; j < 5000; j++ {
+			doc[fmt.Sprintf("key-%d", j)] = strings.Repeat(strconv.Itoa(i), 20)
+		}
+		data, err := json.Marshal(doc)
+		require.NoError(t, err)
+		documents = append(documents, data)
+	}
+	require.NoError(t, repository.Put("metadata/experiments/1eeeeeeeee.json", documents[0]))
+
+	done := make(chan struct{})
+	var writers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for j := 0; j < 50; j++ {
+				require.NoError(t, repository.Put("metadata/experiments/1eeeeeeeee.json", documents[j%2]))
+			}
+		}()
+	}
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				data, err := repository.Get("metadata/experiments/1eeeeeeeee.json")
+				require.NoError(t, err)
+				require.True(t, json.Valid(data), "read partially written JSON")
+
+				// temporary files aren't listed alongside the file
+				paths, err := repository.List("metadata/experiments")
+				require.NoError(t, err)
+				require.Equal(t, []string{"metadata/experiments/1eeeeeeeee.json"}, paths)
+			}
+		}()
+	}
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	tempFiles, err := ioutil.ReadDir(path.Join(dir, diskTempDir))
+	require.NoError(t, err)
+	require.Empty(t, tempFiles)
+}
+
+func TestDiskRepositoryPutPathTarConcurrentReaders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	codeDir, err := files.TempDir("test-put-path-tar-code")
+	require.NoError(t, err)
+	defer os.RemoveAll(codeDir)
+	require.NoError(t, ioutil.WriteFile(path.Join(codeDir, "weights"), []byte(strings.Repeat("weights", 100000)), 0644))
+	_, err = repository.PutPathTar(codeDir, "checkpoints/1ccccccccc.tar.gz", "")
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			outDir, err := files.TempDir("test-put-path-tar-out")
+			require.NoError(t, err)
+			defer os.RemoveAll(outDir)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				require.NoError(t, repository.GetPathTar("checkpoints/1ccccccccc.tar.gz", outDir, nil))
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		_, err := repository.PutPathTar(codeDir, "checkpoints/1ccccccccc.tar.gz", "")
+		require.NoError(t, err)
+	}
+	close(done)
+	readers.Wait()
+}
+
+func TestIsCrossDeviceError(t *testing.T) {
+	require.True(t, isCrossDeviceError(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EXDEV}))
+	require.False(t, isCrossDeviceError(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.ENOENT}))
+	require.False(t, isCrossDeviceError(fmt.Errorf("rename failed")))
+}
+
+func TestDiskRepositoryPutIfMatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	_, _, err = repository.GetVersioned("metadata/some-file")
+	require.True(t, errors.IsDoesNotExist(err))
+
+	// an empty version only writes files that don't exist
+	require.NoError(t, repository.PutIfMatch("metadata/some-file", []byte("hello"), ""))
+	err = repository.PutIfMatch("metadata/some-file", []byte("hello"), "")
+	require.True(t, errors.IsConflict(err), err)
+
+	content, version, err := repository.GetVersioned("metadata/some-file")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), content)
+
+	require.NoError(t, repository.PutIfMatch("metadata/some-file", []byte("hello again"), version))
+	content, err = repository.Get("metadata/some-file")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello again"), content)
+
+	// the version has changed, so writing with the old one fails
+	err = repository.PutIfMatch("metadata/some-file", []byte("hello from another process"), version)
+	require.True(t, errors.IsConflict(err), err)
+
+	// locks and temporary files are cleaned up
+	paths, err := repository.List("metadata")
+	require.NoError(t, err)
+	require.Equal(t, []string{"metadata/some-file"}, paths)
+	require.NoDirExists(t, path.Join(dir, diskLocksDir, "metadata"))
+}
+
+func TestDiskRepositoryPutIfMatchConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+	require.NoError(t, repository.Put("counter", []byte("0")))
+
+	// every increment is retried until it succeeds, so none are lost
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				content, version, err := repository.GetVersioned("counter")
+				require.NoError(t, err)
+				n, err := strconv.Atoi(string(content))
+				require.NoError(t, err)
+				err = repository.PutIfMatch("counter", []byte(strconv.Itoa(n+1)), version)
+				if !errors.IsConflict(err) {
+					require.NoError(t, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	content, err := repository.Get("counter")
+	require.NoError(t, err)
+	require.Equal(t, "10", string(content))
+}
+
+func TestDiskRepositoryMove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, repository.Put("checkpoints/1ccccccccc.tar.gz", []byte("tarball")))
+	require.NoError(t, repository.Move("checkpoints/1ccccccccc.tar.gz", "trash/1ccccccccc/checkpoints/1ccccccccc.tar.gz"))
+	content, err := repository.Get("trash/1ccccccccc/checkpoints/1ccccccccc.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, "tarball", string(content))
+	_, err = repository.Get("checkpoints/1ccccccccc.tar.gz")
+	require.True(t, errors.IsDoesNotExist(err))
+
+	err = repository.Move("checkpoints/1ccccccccc.tar.gz", "somewhere")
+	require.True(t, errors.IsDoesNotExist(err))
+}
+
 func TestDiskRepositoryList(t *testing.T) {
 	dir, err := ioutil.TempDir("", "keepsake-test")
 	require.NoError(t, err)
@@ -185,7 +449,7 @@ func TestDiskRepositoryListTarFile(t *testing.T) {
 	// |
 	// |-- a.txt
 	// |-- b.txt
-	err = repository.PutPathTar(fileDir, "temp.tar.gz", "")
+	_, err = repository.PutPathTar(fileDir, "temp.tar.gz", "")
 	require.NoError(t, err)
 
 	paths, err := repository.ListTarFile("temp.tar.gz")
@@ -242,6 +506,7 @@ func TestDiskListRecursive(t *testing.T) {
 	require.Equal(t, ListResult{
 		Path: "checkpoints/abc123.json",
 		MD5:  []byte{0x93, 0x48, 0xae, 0x78, 0x51, 0xcf, 0x3b, 0xa7, 0x98, 0xd9, 0x56, 0x4e, 0xf3, 0x8, 0xec, 0x25},
+		Size: 3,
 	}, <-results)
 	require.Empty(t, <-results)
 }
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package repository
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockOSFile takes an exclusive lock on f, waiting for other processes to
+// release it. The operating system releases the lock if the process exits.
+func lockOSFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockOSFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
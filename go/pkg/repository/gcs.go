@@ -5,17 +5,21 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"cloud.google.com/go/storage"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
 	"github.com/replicate/keepsake/go/pkg/concurrency"
+	"github.com/replicate/keepsake/go/pkg/config"
 	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/errors"
 	"github.com/replicate/keepsake/go/pkg/files"
@@ -80,6 +84,39 @@ func (s *GCSRepository) Get(path string) ([]byte, error) {
 	return data, nil
 }
 
+// GetVersioned gets data at path, and its version, which is its generation
+func (s *GCSRepository) GetVersioned(path string) ([]byte, string, error) {
+	key := filepath.Join(s.root, path)
+	pathString := fmt.Sprintf("gs://%s/%s", s.bucketName, key)
+	reader, err := s.client.Bucket(s.bucketName).Object(key).NewReader(context.TODO())
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil, "", errors.DoesNotExist(fmt.Sprintf("Get: path does not exist: %s", pathString))
+		}
+		return nil, "", errors.ReadError(fmt.Sprintf("Failed to open %s: %s", pathString, err))
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, "", errors.ReadError(fmt.Sprintf("Failed to read %s: %s", pathString, err))
+	}
+	return data, strconv.FormatInt(reader.Attrs.Generation, 10), nil
+}
+
+// Exists returns true if there is an object at path, by getting its
+// attributes
+func (s *GCSRepository) Exists(path string) (bool, error) {
+	key := filepath.Join(s.root, path)
+	_, err := s.client.Bucket(s.bucketName).Object(key).Attrs(context.TODO())
+	if err == storage.ErrObjectNotExist {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.ReadError(fmt.Sprintf("Failed to read gs://%s/%s: %s", s.bucketName, key, err))
+	}
+	return true, nil
+}
+
 // Delete deletes path. If path is a directory, it recursively deletes
 // all everything under path
 func (s *GCSRepository) Delete(path string) error {
@@ -94,6 +131,27 @@ func (s *GCSRepository) Delete(path string) error {
 	return nil
 }
 
+// Move copies the object at src to dest, then deletes src. The copy is done
+// by Google Cloud Storage, so the data isn't downloaded.
+func (s *GCSRepository) Move(src, dest string) error {
+	bucket := s.client.Bucket(s.bucketName)
+	srcObj := bucket.Object(filepath.Join(s.root, src))
+	destObj := bucket.Object(filepath.Join(s.root, dest))
+	if _, err := destObj.CopierFrom(srcObj).Run(context.TODO()); err != nil {
+		if err == storage.ErrObjectNotExist {
+			return errors.DoesNotExist(fmt.Sprintf("Move: path does not exist: %v", src))
+		}
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return errors.DoesNotExist(fmt.Sprintf("Move: path does not exist: %v", src))
+		}
+		return errors.WriteError(fmt.Sprintf("Failed to copy %s/%s to %s: %v", s.RootURL(), src, dest, err))
+	}
+	if err := srcObj.Delete(context.TODO()); err != nil {
+		return errors.WriteError(fmt.Sprintf("Failed to delete %s/%s: %v", s.RootURL(), src, err))
+	}
+	return nil
+}
+
 // Put data at path
 func (s *GCSRepository) Put(path string, data []byte) error {
 	key := filepath.Join(s.root, path)
@@ -125,8 +183,34 @@ func (s *GCSRepository) Put(path string, data []byte) error {
 	return nil
 }
 
+// PutIfMatch puts data at path if its generation is still `version`, using
+// Google Cloud Storage's preconditions
+func (s *GCSRepository) PutIfMatch(path string, data []byte, version string) error {
+	key := filepath.Join(s.root, path)
+	pathString := fmt.Sprintf("gs://%s/%s", s.bucketName, key)
+	conds := storage.Conditions{DoesNotExist: true}
+	if version != "" {
+		generation, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid version for %s: %q", pathString, version)
+		}
+		conds = storage.Conditions{GenerationMatch: generation}
+	}
+	writer := s.client.Bucket(s.bucketName).Object(key).If(conds).NewWriter(context.TODO())
+	if _, err := writer.Write(data); err != nil {
+		return errors.WriteError(fmt.Sprintf("Failed to write %q: %v", pathString, err))
+	}
+	if err := writer.Close(); err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusPreconditionFailed {
+			return errors.Conflict(fmt.Sprintf("%s has been changed since it was read", pathString))
+		}
+		return errors.WriteError(fmt.Sprintf("Failed to write %q: %v", pathString, err))
+	}
+	return nil
+}
+
 func (s *GCSRepository) PutPath(localPath string, repoPath string) error {
-	files, err := getListOfFilesToPut(localPath, filepath.Join(s.root, repoPath))
+	files, err := getListOfFilesToPut(localPath, filepath.Join(s.root, repoPath), ".", config.SymlinksFollow)
 	if err != nil {
 		return err
 	}
@@ -163,12 +247,12 @@ func (s *GCSRepository) PutPath(localPath string, repoPath string) error {
 	return nil
 }
 
-func (s *GCSRepository) PutPathTar(localPath, tarPath, includePath string) error {
+func (s *GCSRepository) PutPathTar(localPath, tarPath, includePath string) (*Digest, error) {
 	if !strings.HasSuffix(tarPath, ".tar.gz") {
-		return fmt.Errorf("PutPathTar: tarPath must end with .tar.gz")
+		return nil, fmt.Errorf("PutPathTar: tarPath must end with .tar.gz")
 	}
 	if err := s.ensureBucketExists(); err != nil {
-		return err
+		return nil, err
 	}
 
 	key := filepath.Join(s.root, tarPath)
@@ -176,13 +260,14 @@ func (s *GCSRepository) PutPathTar(localPath, tarPath, includePath string) error
 	obj := bucket.Object(key)
 	writer := obj.NewWriter(context.TODO())
 
-	if err := putPathTar(localPath, writer, filepath.Base(tarPath), includePath); err != nil {
-		return errors.WriteError(err.Error())
+	digest, err := putPathTar(localPath, writer, filepath.Base(tarPath), includePath)
+	if err != nil {
+		return nil, errors.WriteError(err.Error())
 	}
 	if err := writer.Close(); err != nil {
-		return errors.WriteError(err.Error())
+		return nil, errors.WriteError(err.Error())
 	}
-	return nil
+	return digest, nil
 }
 
 // List files in a path non-recursively
@@ -296,7 +381,7 @@ func (s *GCSRepository) listRecursive(results chan<- ListResult, dir string, fil
 			if s.root != "" {
 				p = strings.TrimPrefix(strings.TrimPrefix(p, s.root), "/")
 			}
-			results <- ListResult{Path: p, MD5: attrs.MD5}
+			results <- ListResult{Path: p, MD5: attrs.MD5, Size: attrs.Size}
 		}
 	}
 	close(results)
@@ -342,7 +427,7 @@ func (s *GCSRepository) GetPath(repoDir string, localDir string) error {
 	return nil
 }
 
-func (s *GCSRepository) GetPathTar(tarPath, localPath string) error {
+func (s *GCSRepository) GetPathTar(tarPath, localPath string, expected *Digest) error {
 	// archiver doesn't let us use readers, so download to temporary file
 	// TODO: make a better tar implementation
 	tmpdir, err := files.TempDir("tar")
@@ -361,10 +446,10 @@ func (s *GCSRepository) GetPathTar(tarPath, localPath string) error {
 	if !exists {
 		return errors.DoesNotExist(fmt.Sprintf("Path does not exist: %s", tmptarball))
 	}
-	return extractTar(tmptarball, localPath)
+	return extractTar(tmptarball, localPath, expected)
 }
 
-func (s *GCSRepository) GetPathItemTar(tarPath, itemPath, localPath string) error {
+func (s *GCSRepository) GetPathItemTar(tarPath, itemPath, localPath string, expected *Digest) error {
 	// archiver doesn't let us use readers, so download to temporary file
 	// TODO: make a better tar implementation
 	tmpdir, err := files.TempDir("tar")
@@ -383,10 +468,11 @@ func (s *GCSRepository) GetPathItemTar(tarPath, itemPath, localPath string) erro
 	if !exists {
 		return errors.DoesNotExist("Path does not exist: " + tmptarball)
 	}
-	return extractTarItem(tmptarball, itemPath, localPath)
+	return extractTarItem(tmptarball, itemPath, localPath, expected)
 }
 
-func (s *GCSRepository) bucketExists() (bool, error) {
+// BucketExists returns true if the repository's bucket exists
+func (s *GCSRepository) BucketExists() (bool, error) {
 	bucket := s.client.Bucket(s.bucketName)
 	_, err := bucket.Attrs(context.TODO())
 	if err == nil {
@@ -399,7 +485,7 @@ func (s *GCSRepository) bucketExists() (bool, error) {
 }
 
 func (s *GCSRepository) ensureBucketExists() error {
-	exists, err := s.bucketExists()
+	exists, err := s.BucketExists()
 	if err != nil {
 		return err
 	}
@@ -92,6 +92,13 @@ func TestGCSRepository(t *testing.T) {
 		data, err := repository.Get("foo.txt")
 		require.NoError(t, err)
 		require.Equal(t, []byte("hello"), data)
+
+		exists, err := repository.Exists("foo.txt")
+		require.NoError(t, err)
+		require.True(t, exists)
+		exists, err = repository.Exists("does-not-exist.txt")
+		require.NoError(t, err)
+		require.False(t, exists)
 	})
 
 	clearGCSBucket(t, bucket)
@@ -102,7 +109,7 @@ func TestGCSRepository(t *testing.T) {
 
 		tmpDir, err := files.TempDir("test")
 		require.NoError(t, err)
-		err = repository.GetPathTar("does-not-exist.tar.gz", tmpDir)
+		err = repository.GetPathTar("does-not-exist.tar.gz", tmpDir, nil)
 		require.True(t, errors.IsDoesNotExist(err))
 	})
 
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/replicate/keepsake/go/pkg/metrics"
+)
+
+var (
+	operationsTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "keepsake_repository_operations_total",
+		Help: "Number of operations on the repository, by operation and whether it succeeded or failed.",
+	}, []string{"operation", "result"})
+	operationDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "keepsake_repository_operation_duration_seconds",
+		Help:    "How long operations on the repository take, by operation.",
+		Buckets: metrics.DefaultBuckets,
+	}, []string{"operation"})
+	bytesTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "keepsake_repository_bytes_total",
+		Help: "Bytes uploaded to and downloaded from the repository. Directories that are downloaded aren't counted.",
+	}, []string{"direction"})
+)
+
+// InstrumentedRepository wraps another repository, recording how many
+// operations are done on it, how long they take, and how much data is
+// uploaded and downloaded, in metrics
+type InstrumentedRepository struct {
+	repository Repository
+}
+
+func NewInstrumentedRepository(repo Repository) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repo}
+}
+
+// record records an operation that started at start, and returns err
+func record(operation string, start time.Time, err error) error {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	operationsTotal.WithLabelValues(operation, result).Inc()
+	operationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	return err
+}
+
+func (s *InstrumentedRepository) Get(p string) ([]byte, error) {
+	start := time.Now()
+	data, err := s.repository.Get(p)
+	bytesTotal.WithLabelValues("download").Add(float64(len(data)))
+	return data, record("get", start, err)
+}
+
+func (s *InstrumentedRepository) GetVersioned(p string) ([]byte, string, error) {
+	start := time.Now()
+	data, version, err := s.repository.GetVersioned(p)
+	bytesTotal.WithLabelValues("download").Add(float64(len(data)))
+	return data, version, record("get", start, err)
+}
+
+func (s *InstrumentedRepository) Exists(p string) (bool, error) {
+	start := time.Now()
+	exists, err := s.repository.Exists(p)
+	return exists, record("exists", start, err)
+}
+
+func (s *InstrumentedRepository) Put(p string, data []byte) error {
+	start := time.Now()
+	err := s.repository.Put(p, data)
+	if err == nil {
+		bytesTotal.WithLabelValues("upload").Add(float64(len(data)))
+	}
+	return record("put", start, err)
+}
+
+func (s *InstrumentedRepository) PutIfMatch(p string, data []byte, version string) error {
+	start := time.Now()
+	err := s.repository.PutIfMatch(p, data, version)
+	if err == nil {
+		bytesTotal.WithLabelValues("upload").Add(float64(len(data)))
+	}
+	return record("put", start, err)
+}
+
+func (s *InstrumentedRepository) GetPath(repoPath string, localPath string) error {
+	start := time.Now()
+	return record("get_path", start, s.repository.GetPath(repoPath, localPath))
+}
+
+func (s *InstrumentedRepository) GetPathTar(tarPath, localPath string, expected *Digest) error {
+	start := time.Now()
+	err := s.repository.GetPathTar(tarPath, localPath, expected)
+	if err == nil && expected != nil {
+		bytesTotal.WithLabelValues("download").Add(float64(expected.Size))
+	}
+	return record("get_path_tar", start, err)
+}
+
+func (s *InstrumentedRepository) GetPathItemTar(tarPath, itemPath, localPath string, expected *Digest) error {
+	start := time.Now()
+	err := s.repository.GetPathItemTar(tarPath, itemPath, localPath, expected)
+	if err == nil && expected != nil {
+		bytesTotal.WithLabelValues("download").Add(float64(expected.Size))
+	}
+	return record("get_path_tar", start, err)
+}
+
+func (s *InstrumentedRepository) PutPath(localPath string, repoPath string) error {
+	start := time.Now()
+	return record("put_path", start, s.repository.PutPath(localPath, repoPath))
+}
+
+func (s *InstrumentedRepository) PutPathTar(localPath, tarPath, includePath string) (*Digest, error) {
+	start := time.Now()
+	digest, err := s.repository.PutPathTar(localPath, tarPath, includePath)
+	if err == nil && digest != nil {
+		bytesTotal.WithLabelValues("upload").Add(float64(digest.Size))
+	}
+	return digest, record("put_path_tar", start, err)
+}
+
+func (s *InstrumentedRepository) Delete(p string) error {
+	start := time.Now()
+	return record("delete", start, s.repository.Delete(p))
+}
+
+func (s *InstrumentedRepository) Move(src, dest string) error {
+	start := time.Now()
+	return record("move", start, s.repository.Move(src, dest))
+}
+
+func (s *InstrumentedRepository) List(p string) ([]string, error) {
+	start := time.Now()
+	paths, err := s.repository.List(p)
+	return paths, record("list", start, err)
+}
+
+func (s *InstrumentedRepository) ListTarFile(p string) ([]string, error) {
+	start := time.Now()
+	paths, err := s.repository.ListTarFile(p)
+	return paths, record("list_tar_file", start, err)
+}
+
+// ListRecursive and MatchFilenamesRecursive send their results on a channel
+// that the caller reads from, so how long they take depends on the caller,
+// and errors are sent with the results. Only the number of them is recorded.
+func (s *InstrumentedRepository) ListRecursive(results chan<- ListResult, folder string) {
+	operationsTotal.WithLabelValues("list_recursive", "success").Inc()
+	s.repository.ListRecursive(results, folder)
+}
+
+func (s *InstrumentedRepository) MatchFilenamesRecursive(results chan<- ListResult, folder string, filename string) {
+	operationsTotal.WithLabelValues("list_recursive", "success").Inc()
+	s.repository.MatchFilenamesRecursive(results, folder, filename)
+}
+
+func (s *InstrumentedRepository) RootURL() string {
+	return s.repository.RootURL()
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/errors"
+)
+
+func TestInstrumentedRepository(t *testing.T) {
+	restricted, dir := restrictedTestRepository(t, "", ModeReadOnly)
+	defer os.RemoveAll(dir)
+	repo := NewInstrumentedRepository(restricted)
+
+	gets := promtestutil.ToFloat64(operationsTotal.WithLabelValues("get", "success"))
+	failedPuts := promtestutil.ToFloat64(operationsTotal.WithLabelValues("put", "error"))
+	downloaded := promtestutil.ToFloat64(bytesTotal.WithLabelValues("download"))
+
+	content, err := repo.Get("checkpoints/1ccccccccc.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, "tarball", string(content))
+	err = repo.Put("checkpoints/2ccccccccc.tar.gz", []byte("tarball"))
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+
+	require.Equal(t, gets+1, promtestutil.ToFloat64(operationsTotal.WithLabelValues("get", "success")))
+	require.Equal(t, failedPuts+1, promtestutil.ToFloat64(operationsTotal.WithLabelValues("put", "error")))
+	require.Equal(t, downloaded+float64(len("tarball")), promtestutil.ToFloat64(bytesTotal.WithLabelValues("download")))
+
+	// the mode of the repository it wraps is used
+	mode, err := ModeOf(repo)
+	require.NoError(t, err)
+	require.Equal(t, ModeReadOnly, mode)
+}
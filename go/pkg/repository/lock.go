@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/hash"
+)
+
+// lockStaleAfter is how old a lock must be before it is assumed to have been
+// left behind by a process that crashed
+var lockStaleAfter = 30 * time.Second
+
+// lockTimeout is how long to wait for a lock before giving up. It is longer
+// than lockStaleAfter, so a lock left behind by a crashed process is taken over
+// rather than making everyone else time out.
+var lockTimeout = 2 * lockStaleAfter
+
+// lockInfo is the contents of a lock, so a stuck lock can be traced back to
+// the process that took it
+type lockInfo struct {
+	Host    string    `json:"host"`
+	PID     int       `json:"pid"`
+	Created time.Time `json:"created"`
+	// Token is unique to each time the lock is taken, so a process doesn't
+	// refresh or release a lock that another process has taken over
+	Token string `json:"token"`
+	// Released is set when the lock is released. The lock is overwritten
+	// rather than deleted, because repositories can only write a file if it
+	// hasn't changed, not delete it.
+	Released bool `json:"released,omitempty"`
+}
+
+// Lock takes the advisory lock `name` in the repository, waiting for other
+// processes to release it, and returns a function that releases it.
+//
+// The lock is a file in the `.keepsake-internal/locks` directory of the repository that is
+// written with PutIfMatch, so it works the same way on every kind of
+// repository. It only excludes other processes that take the same lock.
+func Lock(r Repository, name string) (unlock func(), err error) {
+	lockPath := path.Join(diskLocksDir, name+".lock")
+	token := hash.Random()
+
+	start := time.Now()
+	version := ""
+	for {
+		data, err := lockData(token, false)
+		if err != nil {
+			return nil, err
+		}
+		err = r.PutIfMatch(lockPath, data, version)
+		if err == nil {
+			stop := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				refreshLock(r, lockPath, token, stop)
+			}()
+			return func() {
+				close(stop)
+				<-stopped
+				if err := releaseLock(r, lockPath, token); err != nil {
+					console.Warn("Failed to release lock %s/%s: %v", r.RootURL(), lockPath, err)
+				}
+			}, nil
+		}
+		if !errors.IsConflict(err) {
+			return nil, err
+		}
+
+		// Someone else has the lock. Take it over if it has been released or
+		// is stale, using its version so only one process can do that.
+		version = ""
+		held, heldVersion, err := r.GetVersioned(lockPath)
+		if err != nil && !errors.IsDoesNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			info := new(lockInfo)
+			if json.Unmarshal(held, info) != nil || info.Released || time.Since(info.Created) > lockStaleAfter {
+				if !info.Released {
+					console.Debug("Taking over stale lock %s/%s", r.RootURL(), lockPath)
+				}
+				version = heldVersion
+				continue
+			}
+			if time.Since(start) > lockTimeout {
+				return nil, errors.WriteError(fmt.Sprintf("Timed out waiting for lock %s/%s, which is held by process %d on %s. If that process isn't running, delete the lock and try again.", r.RootURL(), lockPath, info.PID, info.Host))
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// lockData returns the contents of a lock held by token, created now
+func lockData(token string, released bool) ([]byte, error) {
+	host, _ := os.Hostname()
+	return json.Marshal(&lockInfo{Host: host, PID: os.Getpid(), Created: time.Now().UTC(), Token: token, Released: released})
+}
+
+// heldLockVersion returns the version of a lock, or a conflict error if it
+// isn't held by token any more because another process has taken it over
+func heldLockVersion(r Repository, lockPath string, token string) (string, error) {
+	data, version, err := r.GetVersioned(lockPath)
+	if err != nil {
+		return "", err
+	}
+	info := new(lockInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return "", err
+	}
+	if info.Token != token || info.Released {
+		return "", errors.Conflict(fmt.Sprintf("The lock has been taken over by process %d on %s", info.PID, info.Host))
+	}
+	return version, nil
+}
+
+// refreshLock updates the creation time of a lock that is held by token
+// until stop is closed, so other processes don't think it is stale while it
+// is held for longer than lockStaleAfter. It stops if another process takes
+// the lock over.
+func refreshLock(r Repository, lockPath string, token string, stop <-chan struct{}) {
+	ticker := time.NewTicker(lockStaleAfter / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		version, err := heldLockVersion(r, lockPath, token)
+		if err == nil {
+			var data []byte
+			if data, err = lockData(token, false); err != nil {
+				panic(err) // should never happen
+			}
+			err = r.PutIfMatch(lockPath, data, version)
+		}
+		if errors.IsConflict(err) {
+			console.Warn("Lost lock %s/%s: %v", r.RootURL(), lockPath, err)
+			return
+		}
+		if err != nil {
+			console.Warn("Failed to refresh lock %s/%s: %v", r.RootURL(), lockPath, err)
+		}
+	}
+}
+
+// releaseLock releases a lock that is held by token, leaving it alone if
+// another process has taken it over
+func releaseLock(r Repository, lockPath string, token string) error {
+	version, err := heldLockVersion(r, lockPath, token)
+	if err != nil {
+		return err
+	}
+	data, err := lockData(token, true)
+	if err != nil {
+		return err
+	}
+	return r.PutIfMatch(lockPath, data, version)
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+	require.NoError(t, repository.Put("counter", []byte("0")))
+
+	// increments with plain Get and Put aren't lost while holding the lock
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(repository, "counter")
+			require.NoError(t, err)
+			defer unlock()
+			content, err := repository.Get("counter")
+			require.NoError(t, err)
+			n, err := strconv.Atoi(string(content))
+			require.NoError(t, err)
+			require.NoError(t, repository.Put("counter", []byte(strconv.Itoa(n+1))))
+		}()
+	}
+	wg.Wait()
+	content, err := repository.Get("counter")
+	require.NoError(t, err)
+	require.Equal(t, "10", string(content))
+	require.True(t, readLockInfo(t, repository, "counter").Released)
+	// PutIfMatch doesn't take a lock of its own inside the lock directory
+	require.NoDirExists(t, dir+"/.keepsake-internal/locks/.keepsake-internal")
+
+	// stale locks are taken over
+	stale, err := json.Marshal(&lockInfo{Host: "other-host", PID: 123, Created: time.Now().Add(-2 * lockStaleAfter)})
+	require.NoError(t, err)
+	require.NoError(t, repository.Put(".keepsake-internal/locks/counter.lock", stale))
+	unlock, err := Lock(repository, "counter")
+	require.NoError(t, err)
+	unlock()
+
+	// live locks time out
+	origTimeout := lockTimeout
+	lockTimeout = 100 * time.Millisecond
+	defer func() { lockTimeout = origTimeout }()
+	unlock, err = Lock(repository, "counter")
+	require.NoError(t, err)
+	defer unlock()
+	_, err = Lock(repository, "counter")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Timed out waiting for lock")
+}
+
+func TestLockTakenOver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	origStaleAfter := lockStaleAfter
+	lockStaleAfter = 150 * time.Millisecond
+	defer func() { lockStaleAfter = origStaleAfter }()
+
+	unlock, err := Lock(repository, "counter")
+	require.NoError(t, err)
+
+	// another process decides the lock is stale and takes it over, which
+	// the original holder mustn't undo by refreshing or releasing it
+	other, err := json.Marshal(&lockInfo{Host: "other-host", PID: 123, Created: time.Now().UTC(), Token: "other-token"})
+	require.NoError(t, err)
+	require.NoError(t, repository.Put(".keepsake-internal/locks/counter.lock", other))
+	time.Sleep(2 * lockStaleAfter / 3)
+	require.Equal(t, "other-token", readLockInfo(t, repository, "counter").Token)
+
+	unlock()
+	info := readLockInfo(t, repository, "counter")
+	require.Equal(t, "other-token", info.Token)
+	require.False(t, info.Released)
+}
+
+func readLockInfo(t *testing.T, repository Repository, name string) *lockInfo {
+	data, err := repository.Get(".keepsake-internal/locks/" + name + ".lock")
+	require.NoError(t, err)
+	info := new(lockInfo)
+	require.NoError(t, json.Unmarshal(data, info))
+	return info
+}
+
+func TestEnsureSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spec, err := EnsureSpec(repository)
+			require.NoError(t, err)
+			require.Equal(t, Version, spec.Version)
+		}()
+	}
+	wg.Wait()
+
+	// an existing spec is left alone
+	require.NoError(t, repository.Put(SpecPath, []byte(`{"version": 99}`)))
+	spec, err := EnsureSpec(repository)
+	require.NoError(t, err)
+	require.Equal(t, 99, spec.Version)
+}
+
+func TestDefaultSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	spec, err := DefaultSpec(repository)
+	require.NoError(t, err)
+	require.Equal(t, Version, spec.Version)
+
+	// repositories with experiments but no spec were made before specs existed
+	require.NoError(t, repository.Put("metadata/experiments/1eeeeeeeee.json", []byte("{}")))
+	spec, err = DefaultSpec(repository)
+	require.NoError(t, err)
+	require.Equal(t, 1, spec.Version)
+	spec, err = EnsureSpec(repository)
+	require.NoError(t, err)
+	require.Equal(t, 1, spec.Version)
+}
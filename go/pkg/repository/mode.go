@@ -0,0 +1,401 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/slices"
+)
+
+// AccessMode restricts what can be changed in a repository
+type AccessMode string
+
+const (
+	// ModeReadWrite allows anything to be changed. It is the default.
+	ModeReadWrite AccessMode = "read-write"
+	// ModeAppendOnly allows experiments and checkpoints to be added, but
+	// nothing to be deleted or overwritten. Experiment metadata can still be
+	// saved with PutIfMatch, as long as it only adds checkpoints and fields
+	// to what was already saved.
+	ModeAppendOnly AccessMode = "append-only"
+	// ModeReadOnly doesn't allow anything to be changed
+	ModeReadOnly AccessMode = "read-only"
+)
+
+// Validate returns an error if the mode isn't one of the known modes.
+// The empty mode is the default, ModeReadWrite.
+func (m AccessMode) Validate() error {
+	switch m {
+	case "", ModeReadWrite, ModeAppendOnly, ModeReadOnly:
+		return nil
+	}
+	return fmt.Errorf("Unknown repository mode: %q. It must be one of %q, %q, or %q", m, ModeReadWrite, ModeAppendOnly, ModeReadOnly)
+}
+
+func (m AccessMode) strictness() int {
+	switch m {
+	case ModeAppendOnly:
+		return 1
+	case ModeReadOnly:
+		return 2
+	}
+	return 0
+}
+
+// Paths that can be changed in append-only repositories. Heartbeats and locks
+// are how experiments are added, so they can be overwritten and deleted, and
+// the rest of the internal directory is scratch space.
+var appendOnlyMutablePrefixes = []string{"metadata/heartbeats/", internalDir + "/"}
+
+// Paths that can be overwritten in append-only repositories with PutIfMatch,
+// if the new data only adds to what is there. Experiment metadata is
+// overwritten as checkpoints are added to it.
+var appendOnlyExtendablePrefixes = []string{"metadata/experiments/"}
+
+// Fields in the JSON documents at appendOnlyExtendablePrefixes that can be
+// changed or removed after they have been saved. They are flags that are set
+// on checkpoints after they have been saved.
+var appendOnlyChangeableFields = []string{"pruned", "trashed"}
+
+func hasAnyPrefix(p string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictedRepository wraps another repository, refusing the changes that
+// its access mode doesn't allow with a RepositoryReadOnly error.
+//
+// The mode is the stricter of the mode it was created with and the mode in
+// the repository spec. The spec is loaded the first time it is needed.
+type RestrictedRepository struct {
+	repository Repository
+	urlMode    AccessMode
+
+	modeLock sync.Mutex
+	mode     AccessMode
+}
+
+func NewRestrictedRepository(repo Repository, mode AccessMode) *RestrictedRepository {
+	return &RestrictedRepository{repository: repo, urlMode: mode}
+}
+
+// Mode returns the access mode of the repository
+func (s *RestrictedRepository) Mode() (AccessMode, error) {
+	s.modeLock.Lock()
+	defer s.modeLock.Unlock()
+	if s.mode != "" {
+		return s.mode, nil
+	}
+	spec, err := LoadSpec(s.repository)
+	if err != nil {
+		return "", err
+	}
+	mode := s.urlMode
+	if mode == "" {
+		mode = ModeReadWrite
+	}
+	if spec != nil && spec.Mode.strictness() > mode.strictness() {
+		mode = spec.Mode
+	}
+	s.mode = mode
+	return mode, nil
+}
+
+func (s *RestrictedRepository) refuse(action string, p string, mode AccessMode) error {
+	return errors.RepositoryReadOnly(fmt.Sprintf(`Can't %s %s/%s, because the repository is %s.
+
+The repository mode is set with "mode" in %s/%s, or with ?mode= in the repository URL.`, action, s.repository.RootURL(), p, mode, s.repository.RootURL(), SpecPath))
+}
+
+// checkPut returns an error if data can't be written to p. If p can only be
+// written if there isn't anything there yet, mustCreate is true.
+func (s *RestrictedRepository) checkPut(p string) (mustCreate bool, err error) {
+	mode, err := s.Mode()
+	if err != nil {
+		return false, err
+	}
+	switch mode {
+	case ModeReadOnly:
+		return false, s.refuse("write to", p, mode)
+	case ModeAppendOnly:
+		return !hasAnyPrefix(p, appendOnlyMutablePrefixes), nil
+	}
+	return false, nil
+}
+
+// checkCreate returns an error if p can only be created, and there is
+// already something at p. Only the exact path is checked, so it doesn't list
+// the directory on object stores. It is checked before writing, so another
+// process could write to p in between, but the paths of tarballs and
+// directories have random IDs in them, so that won't happen by accident. Put
+// and PutIfMatch use a conditional write instead.
+func (s *RestrictedRepository) checkCreate(p string, isDir bool) error {
+	mustCreate, err := s.checkPut(p)
+	if err != nil || !mustCreate {
+		return err
+	}
+	exists, err := s.repository.Exists(p)
+	if err != nil {
+		return err
+	}
+	if !exists && isDir {
+		children, err := s.repository.List(p)
+		if err != nil {
+			return err
+		}
+		exists = len(children) > 0
+	}
+	if exists {
+		return s.refuse("overwrite", p, ModeAppendOnly)
+	}
+	return nil
+}
+
+func (s *RestrictedRepository) Get(p string) ([]byte, error) {
+	return s.repository.Get(p)
+}
+
+func (s *RestrictedRepository) GetVersioned(p string) ([]byte, string, error) {
+	return s.repository.GetVersioned(p)
+}
+
+func (s *RestrictedRepository) Exists(p string) (bool, error) {
+	return s.repository.Exists(p)
+}
+
+func (s *RestrictedRepository) Put(p string, data []byte) error {
+	mustCreate, err := s.checkPut(p)
+	if err != nil {
+		return err
+	}
+	if !mustCreate {
+		return s.repository.Put(p, data)
+	}
+	// a conditional write, so nothing written at the same time is overwritten
+	if err := s.repository.PutIfMatch(p, data, ""); err != nil {
+		if errors.IsConflict(err) {
+			return s.refuse("overwrite", p, ModeAppendOnly)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *RestrictedRepository) PutIfMatch(p string, data []byte, version string) error {
+	mustCreate, err := s.checkPut(p)
+	if err != nil {
+		return err
+	}
+	if mustCreate && version != "" {
+		if !hasAnyPrefix(p, appendOnlyExtendablePrefixes) {
+			return s.refuse("overwrite", p, ModeAppendOnly)
+		}
+		if err := s.checkExtends(p, data, version); err != nil {
+			return err
+		}
+	}
+	return s.repository.PutIfMatch(p, data, version)
+}
+
+// checkExtends returns an error if data doesn't have everything that is in
+// the JSON document at p. The document must still be at version, so the
+// PutIfMatch that follows fails if it has changed since it was checked.
+func (s *RestrictedRepository) checkExtends(p string, data []byte, version string) error {
+	current, currentVersion, err := s.repository.GetVersioned(p)
+	if errors.IsDoesNotExist(err) || (err == nil && currentVersion != version) {
+		return errors.Conflict(fmt.Sprintf("%s has been changed since it was read", p))
+	}
+	if err != nil {
+		return err
+	}
+	var currentDoc, newDoc interface{}
+	if json.Unmarshal(current, &currentDoc) != nil || json.Unmarshal(data, &newDoc) != nil || !extendsJSON(newDoc, currentDoc) {
+		return s.refuse("change or remove saved checkpoints or fields in", p, ModeAppendOnly)
+	}
+	return nil
+}
+
+// extendsJSON returns true if the decoded JSON value v only adds to old.
+// Objects must have all of old's keys, and lists must have all of old's
+// values, matching objects with IDs, such as checkpoints, by their ID. Values
+// that old has must be the same in v, apart from null values, which can be
+// set, empty objects and lists, which can be null, and
+// appendOnlyChangeableFields, which can be changed or removed.
+func extendsJSON(v, old interface{}) bool {
+	switch old := old.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		if v == nil {
+			return len(old) == 0
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, oldValue := range old {
+			if slices.ContainsString(appendOnlyChangeableFields, key) {
+				continue
+			}
+			value, ok := obj[key]
+			if !ok || !extendsJSON(value, oldValue) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		if v == nil {
+			return len(old) == 0
+		}
+		list, ok := v.([]interface{})
+		if !ok || len(list) < len(old) {
+			return false
+		}
+		byID := map[string]interface{}{}
+		for _, value := range list {
+			if id, ok := jsonID(value); ok {
+				byID[id] = value
+			}
+		}
+		for _, oldValue := range old {
+			if id, ok := jsonID(oldValue); ok {
+				value, ok := byID[id]
+				if !ok || !extendsJSON(value, oldValue) {
+					return false
+				}
+			} else if !containsJSON(list, oldValue) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(v, old)
+}
+
+func containsJSON(list []interface{}, v interface{}) bool {
+	for _, value := range list {
+		if reflect.DeepEqual(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonID(v interface{}) (string, bool) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	id, ok := obj["id"].(string)
+	return id, ok
+}
+
+func (s *RestrictedRepository) GetPath(repoPath string, localPath string) error {
+	return s.repository.GetPath(repoPath, localPath)
+}
+
+func (s *RestrictedRepository) GetPathTar(tarPath, localPath string, expected *Digest) error {
+	return s.repository.GetPathTar(tarPath, localPath, expected)
+}
+
+func (s *RestrictedRepository) GetPathItemTar(tarPath, itemPath, localPath string, expected *Digest) error {
+	return s.repository.GetPathItemTar(tarPath, itemPath, localPath, expected)
+}
+
+func (s *RestrictedRepository) PutPath(localPath string, repoPath string) error {
+	if err := s.checkCreate(repoPath, true); err != nil {
+		return err
+	}
+	return s.repository.PutPath(localPath, repoPath)
+}
+
+func (s *RestrictedRepository) PutPathTar(localPath, tarPath, includePath string) (*Digest, error) {
+	if err := s.checkCreate(tarPath, false); err != nil {
+		return nil, err
+	}
+	return s.repository.PutPathTar(localPath, tarPath, includePath)
+}
+
+func (s *RestrictedRepository) checkDelete(p string) error {
+	mode, err := s.Mode()
+	if err != nil {
+		return err
+	}
+	if mode == ModeReadOnly || (mode == ModeAppendOnly && !hasAnyPrefix(p, appendOnlyMutablePrefixes)) {
+		return s.refuse("delete", p, mode)
+	}
+	return nil
+}
+
+func (s *RestrictedRepository) Delete(p string) error {
+	if err := s.checkDelete(p); err != nil {
+		return err
+	}
+	return s.repository.Delete(p)
+}
+
+// Move is refused in the same way as deleting src and putting dest
+func (s *RestrictedRepository) Move(src, dest string) error {
+	if err := s.checkDelete(src); err != nil {
+		return err
+	}
+	if err := s.checkCreate(dest, false); err != nil {
+		return err
+	}
+	return s.repository.Move(src, dest)
+}
+
+func (s *RestrictedRepository) List(p string) ([]string, error) {
+	return s.repository.List(p)
+}
+
+func (s *RestrictedRepository) ListTarFile(p string) ([]string, error) {
+	return s.repository.ListTarFile(p)
+}
+
+func (s *RestrictedRepository) ListRecursive(results chan<- ListResult, folder string) {
+	s.repository.ListRecursive(results, folder)
+}
+
+func (s *RestrictedRepository) MatchFilenamesRecursive(results chan<- ListResult, folder string, filename string) {
+	s.repository.MatchFilenamesRecursive(results, folder, filename)
+}
+
+func (s *RestrictedRepository) RootURL() string {
+	return s.repository.RootURL()
+}
+
+// ModeOf returns the access mode of a repository. Repositories that aren't
+// wrapped in a RestrictedRepository are read-write.
+func ModeOf(r Repository) (AccessMode, error) {
+	switch repo := r.(type) {
+	case *RestrictedRepository:
+		return repo.Mode()
+	case *CachedRepository:
+		return ModeOf(repo.repository)
+	case *InstrumentedRepository:
+		return ModeOf(repo.repository)
+	}
+	return ModeReadWrite, nil
+}
+
+// CheckCanDelete returns a RepositoryReadOnly error if the repository's mode
+// doesn't allow experiments and checkpoints to be deleted
+func CheckCanDelete(r Repository) error {
+	mode, err := ModeOf(r)
+	if err != nil {
+		return err
+	}
+	if mode == ModeReadWrite {
+		return nil
+	}
+	return errors.RepositoryReadOnly(fmt.Sprintf("Experiments and checkpoints can't be deleted from %s, because the repository is %s.", r.RootURL(), mode))
+}
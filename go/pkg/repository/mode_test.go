@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/errors"
+)
+
+func restrictedTestRepository(t *testing.T, specMode AccessMode, urlMode AccessMode) (*RestrictedRepository, string) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	disk, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+	require.NoError(t, WriteSpec(disk, &Spec{Version: Version, Mode: specMode}))
+	require.NoError(t, disk.Put("checkpoints/1ccccccccc.tar.gz", []byte("tarball")))
+	require.NoError(t, disk.Put("metadata/experiments/1eeeeeeeee.json", []byte(`{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": [{"id": "1ccccccccc", "metrics": {"loss": 0.5}, "tags": ["best"]}]}`)))
+	require.NoError(t, disk.Put("metadata/heartbeats/1eeeeeeeee.json", []byte("{}")))
+	return NewRestrictedRepository(disk, urlMode), dir
+}
+
+func TestRestrictedRepositoryReadWrite(t *testing.T) {
+	repo, dir := restrictedTestRepository(t, "", ModeReadWrite)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, repo.Put("checkpoints/1ccccccccc.tar.gz", []byte("new tarball")))
+	require.NoError(t, repo.Delete("checkpoints/1ccccccccc.tar.gz"))
+	require.NoError(t, CheckCanDelete(repo))
+}
+
+func TestRestrictedRepositoryAppendOnly(t *testing.T) {
+	repo, dir := restrictedTestRepository(t, ModeAppendOnly, ModeReadWrite)
+	defer os.RemoveAll(dir)
+
+	mode, err := ModeOf(repo)
+	require.NoError(t, err)
+	require.Equal(t, ModeAppendOnly, mode)
+	require.True(t, errors.IsRepositoryReadOnly(CheckCanDelete(repo)))
+
+	// new things can be added
+	require.NoError(t, repo.Put("checkpoints/2ccccccccc.tar.gz", []byte("tarball")))
+	workDir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(workDir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "train.py"), []byte("print(1)"), 0644))
+	_, err = repo.PutPathTar(workDir, "experiments/2eeeeeeeee.tar.gz", "")
+	require.NoError(t, err)
+
+	// but not overwritten or deleted
+	err = repo.Put("checkpoints/1ccccccccc.tar.gz", []byte("new tarball"))
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	require.Contains(t, err.Error(), "because the repository is append-only")
+	_, err = repo.PutPathTar(workDir, "experiments/2eeeeeeeee.tar.gz", "")
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	require.NoError(t, repo.PutPath(workDir, "code/2eeeeeeeee"))
+	err = repo.PutPath(workDir, "code/2eeeeeeeee")
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = repo.Move("metadata/heartbeats/1eeeeeeeee.json", "checkpoints/1ccccccccc.tar.gz")
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = repo.Delete("checkpoints/1ccccccccc.tar.gz")
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = repo.Delete("metadata/experiments/1eeeeeeeee.json")
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = repo.Put(SpecPath, []byte(`{"version": 2}`))
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	content, err := repo.Get("checkpoints/1ccccccccc.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, "tarball", string(content))
+
+	// heartbeats, locks and temporary files can still be changed
+	require.NoError(t, repo.Put("metadata/heartbeats/1eeeeeeeee.json", []byte("{}")))
+	require.NoError(t, repo.Delete("metadata/heartbeats/1eeeeeeeee.json"))
+	unlock, err := Lock(repo, "test")
+	require.NoError(t, err)
+	unlock()
+	require.NoError(t, repo.Put(".keepsake-internal/tmp/check", []byte("keepsake")))
+	require.NoError(t, repo.Put(".keepsake-internal/tmp/check", []byte("keepsake again")))
+	require.NoError(t, repo.Delete(".keepsake-internal/tmp/check"))
+	require.NoError(t, CheckWritable(repo))
+
+	// experiment metadata can only be overwritten with PutIfMatch, by
+	// metadata that keeps everything that was there
+	metadataPath := "metadata/experiments/1eeeeeeeee.json"
+	err = repo.Put(metadataPath, []byte(`{"id": "1eeeeeeeee", "checkpoints": [{"id": "1ccccccccc"}]}`))
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	_, version, err := repo.GetVersioned(metadataPath)
+	require.NoError(t, err)
+	for _, changed := range []string{
+		`{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": []}`,
+		`{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": [{"id": "2ccccccccc"}]}`,
+		`{"id": "1eeeeeeeee", "command": "train.py"}`,
+		`{"command": "train.py", "checkpoints": [{"id": "1ccccccccc", "metrics": {"loss": 0.5}, "tags": ["best"]}]}`,
+		// saved values can't be changed
+		`{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": [{"id": "1ccccccccc", "metrics": {"loss": 0.1}, "tags": ["best"]}]}`,
+		`{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": [{"id": "1ccccccccc", "metrics": {"loss": null}, "tags": ["best"]}]}`,
+		`{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": [{"id": "1ccccccccc", "metrics": {"loss": "0.5"}, "tags": ["best"]}]}`,
+		`{"id": "1eeeeeeeee", "command": "evil.py", "checkpoints": [{"id": "1ccccccccc", "metrics": {"loss": 0.5}, "tags": ["best"]}]}`,
+		`{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": [{"id": "1ccccccccc", "metrics": {"loss": 0.5}, "tags": ["worst"]}]}`,
+		`not json`,
+	} {
+		err = repo.PutIfMatch(metadataPath, []byte(changed), version)
+		require.True(t, errors.IsRepositoryReadOnly(err), changed)
+	}
+	content, err = repo.Get(metadataPath)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"loss": 0.5`)
+
+	// checkpoints and fields can be added, and flags set on checkpoints
+	added := `{"id": "1eeeeeeeee", "command": "train.py", "checkpoints": [{"id": "2ccccccccc"}, {"id": "1ccccccccc", "metrics": {"loss": 0.5, "accuracy": 0.9}, "tags": ["best", "new"], "pruned": true}], "sha256": "abc"}`
+	require.NoError(t, repo.PutIfMatch(metadataPath, []byte(added), version))
+	content, err = repo.Get(metadataPath)
+	require.NoError(t, err)
+	require.Equal(t, added, string(content))
+	// the version is still checked
+	err = repo.PutIfMatch(metadataPath, []byte(added), version)
+	require.True(t, errors.IsConflict(err), err)
+}
+
+func TestRestrictedRepositoryReadOnly(t *testing.T) {
+	// the stricter of the spec and URL modes is used
+	repo, dir := restrictedTestRepository(t, ModeAppendOnly, ModeReadOnly)
+	defer os.RemoveAll(dir)
+
+	mode, err := repo.Mode()
+	require.NoError(t, err)
+	require.Equal(t, ModeReadOnly, mode)
+
+	err = repo.Put("checkpoints/2ccccccccc.tar.gz", []byte("tarball"))
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = repo.Put("metadata/heartbeats/1eeeeeeeee.json", []byte("{}"))
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = repo.PutIfMatch("metadata/experiments/2eeeeeeeee.json", []byte("{}"), "")
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	err = repo.Delete("metadata/heartbeats/1eeeeeeeee.json")
+	require.True(t, errors.IsRepositoryReadOnly(err), err)
+	require.FileExists(t, filepath.Join(dir, "metadata/heartbeats/1eeeeeeeee.json"))
+
+	content, err := repo.Get("checkpoints/1ccccccccc.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, "tarball", string(content))
+}
+
+func TestParseURLOptions(t *testing.T) {
+	opts, err := ParseURLOptions("s3://gold/models?mode=read-only")
+	require.NoError(t, err)
+	require.Equal(t, ModeReadOnly, opts.Mode)
+
+	opts, err = ParseURLOptions("file://.keepsake")
+	require.NoError(t, err)
+	require.Equal(t, ModeReadWrite, opts.Mode)
+	require.Equal(t, config.CachePolicy(""), opts.Cache)
+
+	opts, err = ParseURLOptions("s3://gold?profile=work&endpoint=http%3A%2F%2Flocalhost%3A9000&cache=never")
+	require.NoError(t, err)
+	require.Equal(t, "work", opts.Profile)
+	require.Equal(t, "http://localhost:9000", opts.Endpoint)
+	require.Equal(t, config.CacheNever, opts.Cache)
+
+	_, err = ParseURLOptions("s3://gold?mode=write-once")
+	require.Error(t, err)
+	_, err = ParseURLOptions("s3://gold?colour=blue")
+	require.Error(t, err)
+	_, err = ParseURLOptions("gs://gold?cache=sometimes")
+	require.Error(t, err)
+	_, err = ParseURLOptions("gs://gold?profile=work")
+	require.Error(t, err)
+
+	scheme, bucket, root, err := SplitURL("s3://gold/models?mode=read-only")
+	require.NoError(t, err)
+	require.Equal(t, SchemeS3, scheme)
+	require.Equal(t, "gold", bucket)
+	require.Equal(t, "models", root)
+
+	needsCaching, err := NeedsCaching("file://.keepsake?cache=always")
+	require.NoError(t, err)
+	require.True(t, needsCaching)
+	needsCaching, err = NeedsCaching("s3://gold?cache=never")
+	require.NoError(t, err)
+	require.False(t, needsCaching)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+)
+
+// URLOptions are the options that can be passed in the query string of a
+// repository URL, for example s3://bucket?profile=work&mode=read-only
+type URLOptions struct {
+	Mode AccessMode
+	// Profile is the AWS profile to use for S3 repositories
+	Profile string
+	// Endpoint is the URL of an S3-compatible service to use instead of S3
+	Endpoint string
+	Cache    config.CachePolicy
+}
+
+var urlOptionKeys = []string{"mode", "profile", "endpoint", "cache"}
+
+// ParseURLOptions returns the options in the query string of a repository URL
+func ParseURLOptions(repositoryURL string) (*URLOptions, error) {
+	u, err := url.Parse(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	for key := range query {
+		known := false
+		for _, k := range urlOptionKeys {
+			if key == k {
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("Invalid repository URL %s: unknown option %q. The options are: mode, profile, endpoint, and cache", repositoryURL, key)
+		}
+	}
+
+	opts := &URLOptions{
+		Mode:     AccessMode(query.Get("mode")),
+		Profile:  query.Get("profile"),
+		Endpoint: query.Get("endpoint"),
+		Cache:    config.CachePolicy(query.Get("cache")),
+	}
+	if err := opts.Mode.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid repository URL %s: %w", repositoryURL, err)
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeReadWrite
+	}
+	if err := opts.Cache.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid repository URL %s: %w", repositoryURL, err)
+	}
+	if (opts.Profile != "" || opts.Endpoint != "") && u.Scheme != "s3" {
+		return nil, fmt.Errorf("Invalid repository URL %s: profile and endpoint can only be used with s3:// repositories", repositoryURL)
+	}
+	return opts, nil
+}
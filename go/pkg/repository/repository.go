@@ -2,8 +2,13 @@ package repository
 
 import (
 	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
@@ -14,6 +19,7 @@ import (
 	"github.com/mholt/archiver/v3"
 	gitignore "github.com/sabhiram/go-gitignore"
 
+	"github.com/replicate/keepsake/go/pkg/config"
 	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/errors"
 	"github.com/replicate/keepsake/go/pkg/files"
@@ -29,9 +35,18 @@ const (
 	SchemeGCS  Scheme = "gs"
 )
 
+// Digest is the size and SHA-256 of a tarball, which is recorded when it is
+// uploaded so it can be verified when it is downloaded
+type Digest struct {
+	Size   int64
+	SHA256 string
+}
+
 type ListResult struct {
-	Path  string
-	MD5   []byte
+	Path string
+	MD5  []byte
+	// Size is the size of the file in bytes
+	Size  int64
 	Error error
 }
 
@@ -53,16 +68,33 @@ type Repository interface {
 	// GetPathTar extracts tarball `tarPath` to `localPath`
 	//
 	// The first component of the tarball is stripped. E.g. Extracting a tarball with `abc123/weights` in it to `/code` would create `/code/weights`.
-	GetPathTar(tarPath, localPath string) error
+	//
+	// If `expected` is set, the tarball is checked against it before it is extracted, and a CorruptedTarball error is returned if it doesn't match.
+	GetPathTar(tarPath, localPath string, expected *Digest) error
 
 	// GetPathItemTar extracts `itemPath` from tarball `tarPath` to `localPath`
 	//
-	// itemPath can be a single file or a directory.
-	GetPathItemTar(tarPath, itemPath, localPath string) error
+	// itemPath can be a single file or a directory. `expected` is checked in the same way as GetPathTar.
+	GetPathItemTar(tarPath, itemPath, localPath string, expected *Digest) error
+
+	// GetVersioned gets data at path, and its version
+	//
+	// The version is an opaque string that changes whenever the data at path is written: the ETag on S3, the generation on Google Cloud Storage, and the SHA-256 of the data on disk. It can be passed to PutIfMatch.
+	GetVersioned(path string) (data []byte, version string, err error)
+
+	// Exists returns true if there is a file at path
+	//
+	// Only the exact path is checked, so it is a single request on object stores. Directories aren't files, so it returns false for them.
+	Exists(path string) (bool, error)
 
 	// Put data at path
 	Put(path string, data []byte) error
 
+	// PutIfMatch puts data at path only if the version of the data there is still `version`, or if `version` is empty, only if there is nothing at path
+	//
+	// A Conflict error is returned if the data has been changed since `version` was read.
+	PutIfMatch(path string, data []byte, version string) error
+
 	// PutPath recursively puts the local `localPath` directory into path `repoPath` in the repository
 	PutPath(localPath, repoPath string) error
 
@@ -74,12 +106,19 @@ type Repository interface {
 	// - /code/data/weights
 	// will result in a tarball containing:
 	// - `abc123/data/weights`
-	PutPathTar(localPath, tarPath, basePath string) error
+	//
+	// It returns the size and SHA-256 of the compressed tarball.
+	PutPathTar(localPath, tarPath, basePath string) (*Digest, error)
 
 	// Delete deletes path. If path is a directory, it recursively deletes
 	// all everything under path
 	Delete(path string) error
 
+	// Move moves the file at src to dest, replacing anything at dest
+	//
+	// src must be a single file. A DoesNotExist error is returned if there is nothing at src.
+	Move(src, dest string) error
+
 	// List files in a path non-recursively
 	//
 	// Returns a list of paths, prefixed with the given path, that can be passed straight to Get().
@@ -118,7 +157,22 @@ func SplitURL(repositoryURL string) (scheme Scheme, bucket string, root string,
 	return "", "", "", unknownRepositoryScheme(u.Scheme)
 }
 
+// ForURL returns the repository at a URL. Changes to the repository are
+// restricted by the mode in the repository spec, or the mode passed with
+// ?mode= in the URL, whichever is stricter.
 func ForURL(repositoryURL string, projectDir string) (Repository, error) {
+	opts, err := ParseURLOptions(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := forURL(repositoryURL, projectDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewRestrictedRepository(repo, opts.Mode), nil
+}
+
+func forURL(repositoryURL string, projectDir string, opts *URLOptions) (Repository, error) {
 	scheme, bucket, root, err := SplitURL(repositoryURL)
 	if err != nil {
 		return nil, err
@@ -130,7 +184,7 @@ func ForURL(repositoryURL string, projectDir string) (Repository, error) {
 		}
 		return NewDiskRepository(root)
 	case SchemeS3:
-		return NewS3Repository(bucket, root)
+		return NewS3RepositoryWithOptions(bucket, root, S3Options{Profile: opts.Profile, Endpoint: opts.Endpoint})
 	case SchemeGCS:
 		return NewGCSRepository(bucket, root)
 	}
@@ -145,9 +199,14 @@ type fileToPut struct {
 	Source string
 	Dest   string
 	Info   os.FileInfo
+	// LinkTarget is set if the file is a symlink that is saved as a symlink
+	LinkTarget string
 }
 
-func getListOfFilesToPut(localPath string, repoPath string) ([]fileToPut, error) {
+// getListOfFilesToPut lists the files in `includePath` inside `localPath`,
+// with destinations in `repoPath` relative to `localPath`. Symlinks are
+// preserved, followed, or skipped depending on `symlinks`.
+func getListOfFilesToPut(localPath string, repoPath string, includePath string, symlinks config.SymlinkMode) ([]fileToPut, error) {
 	// Perhaps this should be configurable, or done at a higher-level? It seems odd this is done at such a low level.
 	var ignore *gitignore.GitIgnore
 	var err error
@@ -167,11 +226,57 @@ func getListOfFilesToPut(localPath string, repoPath string) ([]fileToPut, error)
 		}
 	}
 
-	result := []fileToPut{}
-	err = filepath.Walk(localPath, func(currentPath string, info os.FileInfo, err error) error {
+	if symlinks == "" {
+		symlinks = config.SymlinksPreserve
+	}
+	root := filepath.Join(localPath, includePath)
+	lister := &fileLister{
+		localPath: localPath,
+		repoPath:  repoPath,
+		root:      root,
+		symlinks:  symlinks,
+		ignore:    ignore,
+		followed:  map[string]bool{},
+		result:    []fileToPut{},
+	}
+	if _, err := os.Lstat(root); os.IsNotExist(err) {
+		return lister.result, nil
+	}
+	if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+		lister.followed[realRoot] = true
+	}
+	err = lister.walk(root, root)
+	return lister.result, err
+}
+
+// fileLister walks a directory for getListOfFilesToPut
+type fileLister struct {
+	localPath string
+	repoPath  string
+	// root is the directory being put. Symlinks that point outside it
+	// can't be preserved.
+	root     string
+	symlinks config.SymlinkMode
+	ignore   *gitignore.GitIgnore
+	// followed are the real paths of directories that have been walked
+	// through symlinks, so symlink loops are only walked once
+	followed map[string]bool
+	result   []fileToPut
+}
+
+// walk lists the files in realPath as if they were in virtualPath. They
+// are different when walking a directory that a symlink points to.
+func (l *fileLister) walk(virtualPath, realPath string) error {
+	return filepath.Walk(realPath, func(currentPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		relativeToReal, err := filepath.Rel(realPath, currentPath)
+		if err != nil {
+			return err
+		}
+		listedPath := filepath.Join(virtualPath, relativeToReal)
+
 		if info.IsDir() {
 			for _, dir := range putPathAlwaysIgnore {
 				if info.Name() == dir {
@@ -191,75 +296,217 @@ func getListOfFilesToPut(localPath string, repoPath string) ([]fileToPut, error)
 		}
 
 		// Strip local path
-		relativePath, err := filepath.Rel(localPath, currentPath)
+		relativePath, err := filepath.Rel(l.localPath, listedPath)
 		if err != nil {
 			return err
 		}
 
-		if ignore != nil && ignore.MatchesPath(relativePath) {
+		if l.ignore != nil && l.ignore.MatchesPath(relativePath) {
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return l.addSymlink(currentPath, listedPath, relativePath)
+		}
+		if !info.Mode().IsRegular() {
+			console.Debug("Skipping %s, because it is not a regular file", listedPath)
 			return nil
 		}
 
-		result = append(result, fileToPut{
+		l.result = append(l.result, fileToPut{
 			Source: currentPath,
-			Dest:   path.Join(repoPath, relativePath),
+			Dest:   path.Join(l.repoPath, filepath.ToSlash(relativePath)),
 			Info:   info,
 		})
 		return nil
 	})
-	return result, err
 }
 
-func putPathTar(localPath string, out io.Writer, tarFileName string, includePath string) error {
-	// archiver doesn't make it easy to include/exclude files, or write to a writer, so we have
-	// to implement all this ourselves
-	// TODO: adapt archiver so we can use its Archive() method with writers
+func (l *fileLister) addSymlink(currentPath, listedPath, relativePath string) error {
+	dest := path.Join(l.repoPath, filepath.ToSlash(relativePath))
+
+	switch l.symlinks {
+	case config.SymlinksSkip:
+		return nil
+	case config.SymlinksPreserve:
+		target, err := os.Readlink(currentPath)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(listedPath), target)
+		}
+		if within(l.root, target) {
+			info, err := os.Lstat(currentPath)
+			if err != nil {
+				return err
+			}
+			linkTarget, err := filepath.Rel(filepath.Dir(listedPath), target)
+			if err != nil {
+				return err
+			}
+			l.result = append(l.result, fileToPut{
+				Source:     currentPath,
+				Dest:       dest,
+				Info:       info,
+				LinkTarget: filepath.ToSlash(linkTarget),
+			})
+			return nil
+		}
+		console.Warn("Following symlink %s, because it points outside %s. Set 'symlinks: skip' in keepsake.yaml to leave it out instead.", relativePath, l.root)
+	}
+
+	info, err := os.Stat(currentPath)
+	if err != nil {
+		console.Warn("Skipping symlink %s: %v", relativePath, err)
+		return nil
+	}
+	if info.IsDir() {
+		realPath, err := filepath.EvalSymlinks(currentPath)
+		if err != nil {
+			return err
+		}
+		if l.followed[realPath] {
+			console.Warn("Skipping symlink %s, because it has already been followed to %s", relativePath, realPath)
+			return nil
+		}
+		l.followed[realPath] = true
+		return l.walk(listedPath, realPath)
+	}
+	if !info.Mode().IsRegular() {
+		console.Debug("Skipping %s, because it does not point to a regular file", listedPath)
+		return nil
+	}
+	l.result = append(l.result, fileToPut{
+		Source: currentPath,
+		Dest:   dest,
+		Info:   info,
+	})
+	return nil
+}
 
-	z := archiver.NewTarGz()
-	if err := z.Create(out); err != nil {
-		return errors.WriteError(err.Error())
+// within returns true if p is parent or inside parent, without resolving
+// symlinks
+func within(parent, p string) bool {
+	rel, err := filepath.Rel(parent, p)
+	if err != nil {
+		return false
 	}
-	defer z.Close()
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// digestWriter computes the Digest of the bytes written to w
+type digestWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	n    int64
+}
+
+func newDigestWriter(w io.Writer) *digestWriter {
+	return &digestWriter{w: w, hash: sha256.New()}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	d.hash.Write(p[:n])
+	d.n += int64(n)
+	return n, err
+}
+
+func (d *digestWriter) Digest() *Digest {
+	return &Digest{Size: d.n, SHA256: hex.EncodeToString(d.hash.Sum(nil))}
+}
+
+// putPathTar writes a tarball to out, and returns its digest. File modes and
+// modification times are kept, and symlinks are saved as symlinks.
+func putPathTar(localPath string, out io.Writer, tarFileName string, includePath string) (*Digest, error) {
+	// archiver doesn't make it easy to include/exclude files, write to a writer, or
+	// keep symlinks, so we write the tarball ourselves
+
+	digest := newDigestWriter(out)
+	gz := gzip.NewWriter(digest)
+	tw := tar.NewWriter(gz)
 
 	// Prefix all paths with name of tarball so it isn't a rude tarball
-	destPath := filepath.Join(strings.TrimSuffix(tarFileName, ".tar.gz"), includePath)
+	destPath := strings.TrimSuffix(tarFileName, ".tar.gz")
 
-	files, err := getListOfFilesToPut(filepath.Join(localPath, includePath), destPath)
+	// Files have already been copied to localPath with CopyToTempDir, which
+	// resolved symlinks as configured, so any symlinks left are preserved
+	files, err := getListOfFilesToPut(localPath, destPath, includePath, config.SymlinksPreserve)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, file := range files {
-		fh, err := os.Open(file.Source)
-		if err != nil {
-			return err
+		if err := writeTarFile(tw, file); err != nil {
+			return nil, errors.WriteError(err.Error())
 		}
+	}
+	// Explicitly close on success to capture errors
+	if err := tw.Close(); err != nil {
+		return nil, errors.WriteError(err.Error())
+	}
+	if err := gz.Close(); err != nil {
+		return nil, errors.WriteError(err.Error())
+	}
+	return digest.Digest(), nil
+}
 
-		// write it to the archive
-		err = z.Write(archiver.File{
-			FileInfo: archiver.FileInfo{
-				FileInfo:   file.Info,
-				CustomName: file.Dest,
-			},
-			ReadCloser: fh,
-		})
-		fh.Close()
-		if err != nil {
-			return errors.WriteError(err.Error())
-		}
+func writeTarFile(tw *tar.Writer, file fileToPut) error {
+	header, err := tar.FileInfoHeader(file.Info, file.LinkTarget)
+	if err != nil {
+		return fmt.Errorf("Failed to create tar header for %s: %w", file.Source, err)
+	}
+	header.Name = file.Dest
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("Failed to write tar header for %s: %w", file.Source, err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil
+	}
+	fh, err := os.Open(file.Source)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if _, err := io.CopyN(tw, fh, header.Size); err != nil {
+		return fmt.Errorf("Failed to write %s to tarball: %w", file.Source, err)
+	}
+	return nil
+}
+
+// verifyTarball returns a CorruptedTarball error if the local tarball at
+// tarPath doesn't match expected. Sizes and digests that weren't recorded,
+// by older versions of Keepsake, aren't checked.
+func verifyTarball(tarPath string, expected *Digest) error {
+	if expected == nil || (expected.Size == 0 && expected.SHA256 == "") {
+		return nil
+	}
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	digest := newDigestWriter(ioutil.Discard)
+	if _, err := io.Copy(digest, f); err != nil {
+		return errors.ReadError(fmt.Sprintf("Failed to read %s: %v", tarPath, err))
 	}
-	// Explicitly call Close() on success to capture error.
-	if err := z.Close(); err != nil {
-		return errors.WriteError(err.Error())
+	actual := digest.Digest()
+	if expected.Size != 0 && actual.Size != expected.Size {
+		return errors.CorruptedTarball(fmt.Sprintf("%s is %d bytes, but it was %d bytes when it was uploaded. It may have been truncated by an interrupted upload or download.", filepath.Base(tarPath), actual.Size, expected.Size))
+	}
+	if expected.SHA256 != "" && actual.SHA256 != expected.SHA256 {
+		return errors.CorruptedTarball(fmt.Sprintf("%s has the SHA-256 checksum %s, but it was %s when it was uploaded. It may have been corrupted in storage or while downloading.", filepath.Base(tarPath), actual.SHA256, expected.SHA256))
 	}
 	return nil
 }
 
-func extractTar(tarPath, localPath string) error {
-	tar := archiver.NewTarGz()
-	tar.StripComponents = 1
-	tar.OverwriteExisting = true
-	return tar.Unarchive(tarPath, localPath)
+func extractTar(tarPath, localPath string, expected *Digest) error {
+	if err := verifyTarball(tarPath, expected); err != nil {
+		return err
+	}
+	_, err := extractTarball(tarPath, localPath, "")
+	return err
 }
 
 func getListOfFilesInTar(tarPath string) ([]string, error) {
@@ -279,87 +526,283 @@ func getListOfFilesInTar(tarPath string) ([]string, error) {
 	return result, err
 }
 
-func extractTarItem(tarPath, itemPath, localPath string) error {
-	tarBaseName := filepath.Base(strings.TrimSuffix(tarPath, ".tar.gz"))
-	fullItemPath := path.Join(tarBaseName, itemPath)
-
-	filesInTar, err := getListOfFilesInTar(tarPath)
-	if err != nil {
+func extractTarItem(tarPath, itemPath, localPath string, expected *Digest) error {
+	if err := verifyTarball(tarPath, expected); err != nil {
 		return err
 	}
-
-	// Check if itemPath is inside the tar
-	itemPathExists := false
-	for _, fileInTar := range filesInTar {
-		if strings.HasPrefix(fileInTar, fullItemPath) {
-			itemPathExists = true
-			break
-		}
+	count, err := extractTarball(tarPath, localPath, path.Clean(filepath.ToSlash(itemPath)))
+	if err != nil {
+		return err
 	}
-
-	if !itemPathExists {
+	if count == 0 {
 		return errors.DoesNotExist("Path does not exist inside the tarfile: " + itemPath)
 	}
+	return nil
+}
 
-	tmpDir, err := files.TempDir("temp-extract-dir")
+// extractTarball extracts the files in the tarball at tarPath to localPath,
+// with the first component of their paths stripped. If itemPath is set, only
+// that file or directory is extracted. It returns the number of files
+// extracted.
+//
+// File modes and modification times are restored. Tarballs could have been
+// tampered with in the repository, so files that would be written outside
+// localPath, and symlinks that point outside it, are refused.
+func extractTarball(tarPath, localPath, itemPath string) (int, error) {
+	f, err := os.Open(tarPath)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, errors.CorruptedTarball(fmt.Sprintf("%s is not a valid tarball: %v", filepath.Base(tarPath), err))
 	}
-	defer os.RemoveAll(tmpDir)
+	defer gz.Close()
+	tr := tar.NewReader(gz)
 
-	tar := archiver.NewTarGz()
-	tar.StripComponents = 1
-	tar.OverwriteExisting = true
-	err = tar.Extract(tarPath, fullItemPath, tmpDir)
+	localPath, err = filepath.Abs(localPath)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	walkPath := path.Join(tmpDir, tarBaseName)
-	err = filepath.Walk(walkPath, func(currentPath string, info os.FileInfo, err error) error {
+	// symlinks from this tarball can't be written through, because a later
+	// entry could use them to escape localPath
+	created := map[string]bool{}
+	count := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			return err
+			return count, errors.CorruptedTarball(fmt.Sprintf("Failed to read %s: %v", filepath.Base(tarPath), err))
 		}
-		if info.IsDir() {
-			return nil
+
+		name := stripFirstComponent(header.Name)
+		if name == "" {
+			continue
+		}
+		if itemPath != "" && itemPath != "." && name != itemPath && !strings.HasPrefix(name, itemPath+"/") {
+			continue
+		}
+		dest := filepath.Join(localPath, filepath.FromSlash(name))
+		if path.IsAbs(header.Name) || hasParentComponent(header.Name) || !within(localPath, dest) {
+			return count, errors.CorruptedTarball(fmt.Sprintf("%s contains %q, which would be extracted outside %s", filepath.Base(tarPath), header.Name, localPath))
+		}
+		if err := checkSymlinksInPath(localPath, dest, created); err != nil {
+			if errors.IsUnsafeSymlink(err) {
+				return count, errors.UnsafeSymlink(fmt.Sprintf("Refusing to extract %q from %s: %v", header.Name, filepath.Base(tarPath), err))
+			}
+			return count, errors.CorruptedTarball(fmt.Sprintf("%s contains %q, which would be extracted through a symlink: %v", filepath.Base(tarPath), header.Name, err))
 		}
 
-		relativePath, err := filepath.Rel(walkPath, currentPath)
-		if err != nil {
-			return err
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return count, fmt.Errorf("Failed to create directory %q: %w", dest, err)
+			}
+			continue
+		case tar.TypeReg, tar.TypeRegA:
+			if err := extractTarFile(tr, header, dest); err != nil {
+				return count, err
+			}
+		case tar.TypeSymlink:
+			if !symlinkStaysWithin(localPath, dest, header.Linkname) {
+				return count, errors.CorruptedTarball(fmt.Sprintf("%s contains the symlink %q to %q, which points outside %s", filepath.Base(tarPath), header.Name, header.Linkname, localPath))
+			}
+			if err := extractTarSymlink(header, dest); err != nil {
+				return count, err
+			}
+			created[dest] = true
+		default:
+			console.Debug("Skipping %s in %s, because it is not a file, directory, or symlink", header.Name, filepath.Base(tarPath))
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func hasParentComponent(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return true
 		}
-		newPath := path.Join(localPath, relativePath)
+	}
+	return false
+}
 
-		dir := filepath.Dir(newPath)
+// stripFirstComponent removes the directory that Keepsake prefixes the paths
+// in tarballs with
+func stripFirstComponent(name string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path.Clean(name), "/"), "/", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
 
-		exists, err := files.FileExists(dir)
-		if err != nil {
-			return err
+func extractTarFile(tr *tar.Reader, header *tar.Header, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("Failed to create directory %q: %w", filepath.Dir(dest), err)
+	}
+	// Don't write through a symlink left by a previous checkout
+	if err := removeSymlink(dest); err != nil {
+		return err
+	}
+	mode := header.FileInfo().Mode().Perm()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("Failed to create %s: %w", dest, err)
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, tr); err != nil {
+		return fmt.Errorf("Failed to write %s: %w", dest, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("Failed to write %s: %w", dest, err)
+	}
+	// OpenFile's mode is subject to umask and doesn't change existing files
+	if err := os.Chmod(dest, mode); err != nil {
+		return fmt.Errorf("Failed to set the mode of %s: %w", dest, err)
+	}
+	if err := os.Chtimes(dest, header.ModTime, header.ModTime); err != nil {
+		return fmt.Errorf("Failed to set the modification time of %s: %w", dest, err)
+	}
+	return nil
+}
+
+func extractTarSymlink(header *tar.Header, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("Failed to create directory %q: %w", filepath.Dir(dest), err)
+	}
+	if _, err := os.Lstat(dest); err == nil {
+		if err := os.Remove(dest); err != nil {
+			return fmt.Errorf("Failed to overwrite %s: %w", dest, err)
 		}
+	}
+	if err := os.Symlink(filepath.FromSlash(header.Linkname), dest); err != nil {
+		return fmt.Errorf("Failed to create symlink %s: %w", dest, err)
+	}
+	return nil
+}
 
-		if !exists {
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return fmt.Errorf("Failed to create directory %q: %w", dir, err)
-			}
+func removeSymlink(p string) error {
+	info, err := os.Lstat(p)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	if err := os.Remove(p); err != nil {
+		return fmt.Errorf("Failed to overwrite %s: %w", p, err)
+	}
+	return nil
+}
+
+// checkSymlinksInPath returns an error if any of the directories between root
+// and dest are symlinks that could be used to write files outside root.
+//
+// Symlinks created by this extraction are never followed. Symlinks that were
+// already there, such as a data directory the user has linked to elsewhere
+// in the directory, or one kept from a previous checkout, are followed if
+// they resolve to somewhere inside root. Otherwise, an UnsafeSymlink error is
+// returned.
+func checkSymlinksInPath(root, dest string, created map[string]bool) error {
+	rel, err := filepath.Rel(root, filepath.Dir(dest))
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return nil
+	}
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
 		}
-		err = os.Rename(currentPath, newPath)
 		if err != nil {
 			return err
 		}
-		return nil
-	})
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		if created[current] {
+			return fmt.Errorf("%s is a symlink", current)
+		}
+		if !resolvesWithin(root, current) {
+			return errors.UnsafeSymlink(fmt.Sprintf("%s is a symlink to somewhere outside %s", current, root))
+		}
+	}
+	return nil
+}
 
+// resolvesWithin returns true if p, after following all symlinks, is inside
+// root
+func resolvesWithin(root, p string) bool {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
 	if err != nil {
-		return err
+		return false
 	}
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return false
+	}
+	return within(resolvedRoot, resolved)
+}
 
-	return nil
+// symlinkStaysWithin returns true if a symlink at linkPath to target would
+// point inside root. Absolute targets are never allowed, and neither are
+// targets that go up a directory after passing through another symlink,
+// because where they end up can't be known from the path alone.
+func symlinkStaysWithin(root, linkPath, target string) bool {
+	if target == "" || path.IsAbs(target) || filepath.IsAbs(target) {
+		return false
+	}
+	current := filepath.Dir(linkPath)
+	passedSymlink := false
+	for _, part := range strings.Split(filepath.ToSlash(target), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if passedSymlink {
+				return false
+			}
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, part)
+			if info, err := os.Lstat(current); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				passedSymlink = true
+			}
+		}
+		if !within(root, current) {
+			return false
+		}
+	}
+	return true
 }
 
-// NeedsCaching returns true if the repository URL is slow and needs caching
+// NeedsCaching returns true if the repository URL is slow and needs caching,
+// which is the case for anything other than a disk
+// repository, or the cache option in the URL says so
 func NeedsCaching(repositoryURL string) (bool, error) {
 	scheme, _, _, err := SplitURL(repositoryURL)
-	return scheme != "file", err
+	if err != nil {
+		return false, err
+	}
+	opts, err := ParseURLOptions(repositoryURL)
+	if err != nil {
+		return false, err
+	}
+	switch opts.Cache {
+	case config.CacheAlways:
+		return true, nil
+	case config.CacheNever:
+		return false, nil
+	}
+	return scheme != SchemeDisk, nil
 }
 
 func unknownRepositoryScheme(scheme string) error {
@@ -380,7 +823,10 @@ func isVirtualenvDir(path string) (bool, error) {
 	return files.FileExists(filepath.Join(path, "pyvenv.cfg"))
 }
 
-func CopyToTempDir(localPath string, includePath string) (tempDir string, err error) {
+// CopyToTempDir copies `includePath` in `localPath` to a temporary directory,
+// keeping file modes and modification times. Symlinks are preserved,
+// followed, or skipped depending on `symlinks`.
+func CopyToTempDir(localPath string, includePath string, symlinks config.SymlinkMode) (tempDir string, err error) {
 	// normalize path
 	includePath = filepath.Join(includePath)
 
@@ -392,25 +838,12 @@ func CopyToTempDir(localPath string, includePath string) (tempDir string, err er
 		return "", err
 	}
 
-	// we first scan the whole repository to get the list of eligable files,
-	// then copy the ones that match the includePath.
-	// TODO(andreas): only scan files in the includePath
-	filesToCopy, err := getListOfFilesToPut(localPath, tempDir)
+	filesToCopy, err := getListOfFilesToPut(localPath, tempDir, includePath, symlinks)
 	if err != nil {
 		return "", err
 	}
 	count := 0
 	for _, file := range filesToCopy {
-
-		// only include files in includePath
-		relPath, err := filepath.Rel(localPath, file.Source)
-		if err != nil {
-			return "", err
-		}
-		if !(includePath == "." || relPath == includePath || strings.HasPrefix(relPath, includePath+"/")) {
-			continue
-		}
-
 		dir := path.Dir(file.Dest)
 		dirExists, err := files.FileExists(dir)
 		if err != nil {
@@ -421,9 +854,22 @@ func CopyToTempDir(localPath string, includePath string) (tempDir string, err er
 				return "", fmt.Errorf("Failed to create directory %s: %v", dir, err)
 			}
 		}
+		if file.LinkTarget != "" {
+			if err := os.Symlink(file.LinkTarget, file.Dest); err != nil {
+				return "", fmt.Errorf("Failed to create symlink %s: %v", file.Dest, err)
+			}
+			count += 1
+			continue
+		}
 		if err := files.CopyFile(file.Source, file.Dest); err != nil {
 			return "", fmt.Errorf("Failed to copy %s to %s: %v", file.Source, file.Dest, err)
 		}
+		if err := os.Chmod(file.Dest, file.Info.Mode().Perm()); err != nil {
+			return "", fmt.Errorf("Failed to set the mode of %s: %v", file.Dest, err)
+		}
+		if err := os.Chtimes(file.Dest, file.Info.ModTime(), file.Info.ModTime()); err != nil {
+			return "", fmt.Errorf("Failed to set the modification time of %s: %v", file.Dest, err)
+		}
 		count += 1
 	}
 
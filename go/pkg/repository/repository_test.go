@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -8,9 +10,11 @@ import (
 	"path/filepath"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/replicate/keepsake/go/pkg/config"
 	"github.com/replicate/keepsake/go/pkg/errors"
 	"github.com/replicate/keepsake/go/pkg/files"
 )
@@ -63,7 +67,7 @@ func TestListOfFilesToPut(t *testing.T) {
 	// test that .keepsakeignore is used
 	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "ignoreme/qux.txt"), []byte("qux"), 0644))
 
-	filesToPut, err := getListOfFilesToPut(tmpDir, "")
+	filesToPut, err := getListOfFilesToPut(tmpDir, "", ".", config.SymlinksPreserve)
 	require.NoError(t, err)
 
 	// erase .Info
@@ -124,7 +128,7 @@ func TestExtractTarItem(t *testing.T) {
 	require.NoError(t, err)
 	defer tarFile.Close()
 
-	err = putPathTar(fileDir, tarFile, "temp.tar.gz", "")
+	_, err = putPathTar(fileDir, tarFile, "temp.tar.gz", "")
 	require.NoError(t, err)
 
 	// Create a temporary directory
@@ -132,7 +136,7 @@ func TestExtractTarItem(t *testing.T) {
 	require.NoError(t, err)
 
 	// Extract just one of the two files from the repo dir.
-	err = extractTarItem(path.Join(dir, "temp.tar.gz"), "a.txt", tmpDir)
+	err = extractTarItem(path.Join(dir, "temp.tar.gz"), "a.txt", tmpDir, nil)
 	require.NoError(t, err)
 
 	content, err := ioutil.ReadFile(path.Join(tmpDir, "a.txt"))
@@ -140,7 +144,7 @@ func TestExtractTarItem(t *testing.T) {
 	require.Equal(t, []byte("file a"), content)
 
 	// Extract an entire directory
-	err = extractTarItem(path.Join(dir, "temp.tar.gz"), "c", tmpDir)
+	err = extractTarItem(path.Join(dir, "temp.tar.gz"), "c", tmpDir, nil)
 	require.NoError(t, err)
 
 	content, err = ioutil.ReadFile(path.Join(tmpDir, "c/d.txt"))
@@ -148,7 +152,7 @@ func TestExtractTarItem(t *testing.T) {
 	require.Equal(t, []byte("file d"), content)
 
 	// Extract a file that does not exist
-	err = extractTarItem(path.Join(dir, "temp.tar.gz"), "does-not-exist.txt", tmpDir)
+	err = extractTarItem(path.Join(dir, "temp.tar.gz"), "does-not-exist.txt", tmpDir, nil)
 	require.True(t, errors.IsDoesNotExist(err))
 }
 
@@ -165,7 +169,7 @@ func TestCopyToTempDir(t *testing.T) {
 	require.NoError(t, err)
 
 	// without includePath
-	tempDir, err := CopyToTempDir(dir, ".")
+	tempDir, err := CopyToTempDir(dir, ".", config.SymlinksPreserve)
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 
@@ -178,7 +182,7 @@ func TestCopyToTempDir(t *testing.T) {
 	require.Equal(t, "bar", string(contents))
 
 	// with directory includePath
-	tempDir, err = CopyToTempDir(dir, "my")
+	tempDir, err = CopyToTempDir(dir, "my", config.SymlinksPreserve)
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 
@@ -191,7 +195,7 @@ func TestCopyToTempDir(t *testing.T) {
 	require.Equal(t, "bar", string(contents))
 
 	// with file includePath
-	tempDir, err = CopyToTempDir(dir, "my/folder/bar")
+	tempDir, err = CopyToTempDir(dir, "my/folder/bar", config.SymlinksPreserve)
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 
@@ -204,6 +208,259 @@ func TestCopyToTempDir(t *testing.T) {
 	require.Equal(t, "bar", string(contents))
 
 	// with missing file
-	_, err = CopyToTempDir(dir, "not-existing")
+	_, err = CopyToTempDir(dir, "not-existing", config.SymlinksPreserve)
 	require.Error(t, err)
 }
+
+// createSymlinkTestDir creates a directory with an executable script, a
+// symlink to a file inside it, a symlink to a directory inside it, and a
+// symlink to a directory outside it
+func createSymlinkTestDir(t *testing.T) (dir string, outsideDir string) {
+	dir, err := files.TempDir("test-symlinks")
+	require.NoError(t, err)
+	outsideDir, err = files.TempDir("test-symlinks-outside")
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "train.sh"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(dir, "data"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "data/weights"), []byte("weights"), 0644))
+	require.NoError(t, os.Symlink("data/weights", path.Join(dir, "latest")))
+	require.NoError(t, os.Symlink("data", path.Join(dir, "data-link")))
+	require.NoError(t, ioutil.WriteFile(path.Join(outsideDir, "shared.txt"), []byte("shared"), 0644))
+	require.NoError(t, os.Symlink(outsideDir, path.Join(dir, "shared")))
+
+	mtime := time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path.Join(dir, "train.sh"), mtime, mtime))
+	return dir, outsideDir
+}
+
+func TestListOfFilesToPutSymlinks(t *testing.T) {
+	dir, outsideDir := createSymlinkTestDir(t)
+	defer os.RemoveAll(dir)
+	defer os.RemoveAll(outsideDir)
+
+	list := func(mode config.SymlinkMode) map[string]string {
+		filesToPut, err := getListOfFilesToPut(dir, "", ".", mode)
+		require.NoError(t, err)
+		ret := map[string]string{}
+		for _, f := range filesToPut {
+			ret[f.Dest] = f.LinkTarget
+		}
+		return ret
+	}
+
+	// symlinks inside the directory are kept, and ones outside it are followed
+	require.Equal(t, map[string]string{
+		"train.sh":          "",
+		"data/weights":      "",
+		"latest":            "data/weights",
+		"data-link":         "data",
+		"shared/shared.txt": "",
+	}, list(config.SymlinksPreserve))
+
+	require.Equal(t, map[string]string{
+		"train.sh":          "",
+		"data/weights":      "",
+		"latest":            "",
+		"data-link/weights": "",
+		"shared/shared.txt": "",
+	}, list(config.SymlinksFollow))
+
+	require.Equal(t, map[string]string{
+		"train.sh":     "",
+		"data/weights": "",
+	}, list(config.SymlinksSkip))
+
+	// symlink loops are only followed once
+	require.NoError(t, os.Symlink("..", path.Join(dir, "data/parent")))
+	filesToPut, err := getListOfFilesToPut(dir, "", "data", config.SymlinksFollow)
+	require.NoError(t, err)
+	require.NotEmpty(t, filesToPut)
+}
+
+func TestPutPathTarKeepsSymlinksModesAndTimes(t *testing.T) {
+	dir, outsideDir := createSymlinkTestDir(t)
+	defer os.RemoveAll(dir)
+	defer os.RemoveAll(outsideDir)
+
+	tempDir, err := CopyToTempDir(dir, ".", config.SymlinksPreserve)
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	tarDir, err := files.TempDir("test-symlinks-tar")
+	require.NoError(t, err)
+	defer os.RemoveAll(tarDir)
+	tarPath := path.Join(tarDir, "abc123.tar.gz")
+	tarFile, err := os.Create(tarPath)
+	require.NoError(t, err)
+	_, err = putPathTar(tempDir, tarFile, "abc123.tar.gz", "")
+	require.NoError(t, err)
+	require.NoError(t, tarFile.Close())
+
+	outDir, err := files.TempDir("test-symlinks-out")
+	require.NoError(t, err)
+	defer os.RemoveAll(outDir)
+	require.NoError(t, extractTar(tarPath, outDir, nil))
+
+	info, err := os.Stat(path.Join(outDir, "train.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	require.True(t, info.ModTime().Equal(time.Date(2020, 12, 7, 1, 13, 29, 0, time.UTC)), info.ModTime())
+
+	target, err := os.Readlink(path.Join(outDir, "latest"))
+	require.NoError(t, err)
+	require.Equal(t, "data/weights", target)
+	contents, err := ioutil.ReadFile(path.Join(outDir, "data-link/weights"))
+	require.NoError(t, err)
+	require.Equal(t, "weights", string(contents))
+
+	// the symlink outside the directory was followed
+	info, err = os.Lstat(path.Join(outDir, "shared"))
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+	contents, err = ioutil.ReadFile(path.Join(outDir, "shared/shared.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "shared", string(contents))
+
+	// extracting again overwrites symlinks rather than writing through them
+	require.NoError(t, extractTar(tarPath, outDir, nil))
+	target, err = os.Readlink(path.Join(outDir, "latest"))
+	require.NoError(t, err)
+	require.Equal(t, "data/weights", target)
+}
+
+// writeTestTarball writes a tarball with the given headers, and the contents
+// "evil" for regular files
+func writeTestTarball(t *testing.T, tarPath string, headers []*tar.Header) {
+	f, err := os.Create(tarPath)
+	require.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, header := range headers {
+		if header.Typeflag == tar.TypeReg {
+			header.Size = 4
+		}
+		header.Mode = 0644
+		require.NoError(t, tw.WriteHeader(header))
+		if header.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte("evil"))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func TestExtractTarRefusesEscapingPaths(t *testing.T) {
+	dir, err := files.TempDir("test-malicious-tar")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{"path traversal", []*tar.Header{
+			{Name: "abc123/../../evil.txt", Typeflag: tar.TypeReg},
+		}},
+		{"absolute path", []*tar.Header{
+			{Name: "/tmp/evil.txt", Typeflag: tar.TypeReg},
+		}},
+		{"absolute symlink", []*tar.Header{
+			{Name: "abc123/link", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+		}},
+		{"escaping symlink", []*tar.Header{
+			{Name: "abc123/link", Typeflag: tar.TypeSymlink, Linkname: "../.."},
+		}},
+		{"escaping through another symlink", []*tar.Header{
+			{Name: "abc123/here", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "abc123/link", Typeflag: tar.TypeSymlink, Linkname: "here/.."},
+		}},
+		{"writing through a symlink", []*tar.Header{
+			{Name: "abc123/sub", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "abc123/sub/evil.txt", Typeflag: tar.TypeReg},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tarPath := path.Join(dir, "abc123.tar.gz")
+			writeTestTarball(t, tarPath, tc.headers)
+			outDir := path.Join(dir, "out", "checkout")
+			require.NoError(t, os.MkdirAll(outDir, 0755))
+			defer os.RemoveAll(path.Join(dir, "out"))
+
+			err := extractTar(tarPath, outDir, nil)
+			require.True(t, errors.IsCorruptedTarball(err), err)
+			require.NoFileExists(t, path.Join(dir, "evil.txt"))
+			require.NoFileExists(t, path.Join(dir, "out", "evil.txt"))
+		})
+	}
+
+	// symlinks that stay inside the directory are fine
+	tarPath := path.Join(dir, "abc123.tar.gz")
+	writeTestTarball(t, tarPath, []*tar.Header{
+		{Name: "abc123/data/weights", Typeflag: tar.TypeReg},
+		{Name: "abc123/data/link", Typeflag: tar.TypeSymlink, Linkname: "../data/weights"},
+	})
+	outDir := path.Join(dir, "ok")
+	require.NoError(t, extractTar(tarPath, outDir, nil))
+	contents, err := ioutil.ReadFile(path.Join(outDir, "data/link"))
+	require.NoError(t, err)
+	require.Equal(t, "evil", string(contents))
+}
+
+func TestExtractTarThroughExistingSymlinks(t *testing.T) {
+	dir, err := files.TempDir("test-existing-symlinks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tarPath := path.Join(dir, "abc123.tar.gz")
+	writeTestTarball(t, tarPath, []*tar.Header{
+		{Name: "abc123/data/weights", Typeflag: tar.TypeReg},
+	})
+
+	// a directory the user has symlinked to somewhere else in the checkout
+	outDir := path.Join(dir, "inside")
+	require.NoError(t, os.MkdirAll(path.Join(outDir, "mnt/data"), 0755))
+	require.NoError(t, os.Symlink("mnt/data", path.Join(outDir, "data")))
+	require.NoError(t, extractTar(tarPath, outDir, nil))
+	contents, err := ioutil.ReadFile(path.Join(outDir, "mnt/data/weights"))
+	require.NoError(t, err)
+	require.Equal(t, "evil", string(contents))
+
+	// a directory symlink kept from a previous checkout
+	tarPath = path.Join(dir, "def456.tar.gz")
+	writeTestTarball(t, tarPath, []*tar.Header{
+		{Name: "def456/real/weights", Typeflag: tar.TypeReg},
+		{Name: "def456/data", Typeflag: tar.TypeSymlink, Linkname: "real"},
+	})
+	outDir = path.Join(dir, "previous")
+	require.NoError(t, extractTar(tarPath, outDir, nil))
+	writeTestTarball(t, tarPath, []*tar.Header{
+		{Name: "def456/data/weights", Typeflag: tar.TypeReg},
+	})
+	require.NoError(t, extractTar(tarPath, outDir, nil))
+	contents, err = ioutil.ReadFile(path.Join(outDir, "real/weights"))
+	require.NoError(t, err)
+	require.Equal(t, "evil", string(contents))
+
+	// a symlink that points outside the checkout
+	outside := path.Join(dir, "outside")
+	require.NoError(t, os.MkdirAll(outside, 0755))
+	outDir = path.Join(dir, "escaping")
+	require.NoError(t, os.MkdirAll(outDir, 0755))
+	require.NoError(t, os.Symlink(outside, path.Join(outDir, "data")))
+	err = extractTar(path.Join(dir, "abc123.tar.gz"), outDir, nil)
+	require.True(t, errors.IsUnsafeSymlink(err), err)
+	require.NoFileExists(t, path.Join(outside, "weights"))
+}
+
+func TestCopyPartRanges(t *testing.T) {
+	require.Equal(t, []string{"bytes=0-9", "bytes=10-19", "bytes=20-24"}, copyPartRanges(25, 10))
+	require.Equal(t, []string{"bytes=0-9", "bytes=10-19"}, copyPartRanges(20, 10))
+
+	// the largest object S3 allows is copied in no more parts than S3 allows
+	size := int64(5 * 1024 * 1024 * 1024 * 1024)
+	require.Len(t, copyPartRanges(size, copyPartSize(size)), s3MaxParts)
+	require.Len(t, copyPartRanges(6*1024*1024*1024, copyPartSize(6*1024*1024*1024)), 12)
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -20,6 +22,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/replicate/keepsake/go/pkg/concurrency"
+	"github.com/replicate/keepsake/go/pkg/config"
 	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/errors"
 	"github.com/replicate/keepsake/go/pkg/files"
@@ -33,20 +36,37 @@ type S3Repository struct {
 	svc        *s3.S3
 }
 
+// S3Options are the options for connecting to S3
+type S3Options struct {
+	// Profile is the AWS profile to get credentials from. If it is empty,
+	// the default credential chain is used.
+	Profile string
+	// Endpoint is the URL of an S3-compatible service to use instead of S3.
+	// The bucket must already exist.
+	Endpoint string
+}
+
 func NewS3Repository(bucket, root string) (*S3Repository, error) {
-	region, err := getBucketRegionOrCreateBucket(bucket)
-	if err != nil {
-		return nil, err
+	return NewS3RepositoryWithOptions(bucket, root, S3Options{})
+}
+
+func NewS3RepositoryWithOptions(bucket, root string, opts S3Options) (*S3Repository, error) {
+	region := global.S3Region
+	// S3-compatible services don't have regions to discover
+	if opts.Endpoint == "" {
+		var err error
+		region, err = getBucketRegionOrCreateBucket(bucket, opts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	s := &S3Repository{
 		bucketName: bucket,
 		root:       root,
 	}
-	s.sess, err = session.NewSession(&aws.Config{
-		Region:                        aws.String(region),
-		CredentialsChainVerboseErrors: aws.Bool(true),
-	})
+	var err error
+	s.sess, err = newS3Session(region, opts)
 	if err != nil {
 		return nil, errors.RepositoryConfigurationError(fmt.Sprintf("Failed to connect to S3: %s", err))
 	}
@@ -55,6 +75,23 @@ func NewS3Repository(bucket, root string) (*S3Repository, error) {
 	return s, nil
 }
 
+func newS3Session(region string, opts S3Options) (*session.Session, error) {
+	conf := aws.Config{
+		Region:                        aws.String(region),
+		CredentialsChainVerboseErrors: aws.Bool(true),
+	}
+	if opts.Endpoint != "" {
+		conf.Endpoint = aws.String(opts.Endpoint)
+		conf.S3ForcePathStyle = aws.Bool(true)
+	}
+	sessOpts := session.Options{Config: conf}
+	if opts.Profile != "" {
+		sessOpts.Profile = opts.Profile
+		sessOpts.SharedConfigState = session.SharedConfigEnable
+	}
+	return session.NewSessionWithOptions(sessOpts)
+}
+
 func (s *S3Repository) RootURL() string {
 	ret := "s3://" + s.bucketName
 	if s.root != "" {
@@ -85,6 +122,46 @@ func (s *S3Repository) Get(path string) ([]byte, error) {
 	return body, nil
 }
 
+// GetVersioned gets data at path, and its version, which is its ETag
+func (s *S3Repository) GetVersioned(path string) ([]byte, string, error) {
+	key := filepath.Join(s.root, path)
+	obj, err := s.svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() == s3.ErrCodeNoSuchKey {
+				return nil, "", errors.DoesNotExist(fmt.Sprintf("Get: path does not exist: %v", path))
+			}
+		}
+		return nil, "", errors.ReadError(fmt.Sprintf("Failed to read %s/%s: %s", s.RootURL(), path, err))
+	}
+	defer obj.Body.Close()
+	body, err := ioutil.ReadAll(obj.Body)
+	if err != nil {
+		return nil, "", errors.ReadError(fmt.Sprintf("Failed to read body from %s/%s: %s", s.RootURL(), path, err))
+	}
+	return body, aws.StringValue(obj.ETag), nil
+}
+
+// Exists returns true if there is an object at path, with a HEAD request
+func (s *S3Repository) Exists(path string) (bool, error) {
+	key := filepath.Join(s.root, path)
+	_, err := s.svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		// HEAD responses don't have a body, so there is only the status code
+		if rerr, ok := err.(awserr.RequestFailure); ok && rerr.StatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, errors.ReadError(fmt.Sprintf("Failed to read %s/%s: %s", s.RootURL(), path, err))
+	}
+	return true, nil
+}
+
 func (s *S3Repository) Delete(path string) error {
 	console.Debug("Deleting %s/%s...", s.RootURL(), path)
 	key := filepath.Join(s.root, path)
@@ -98,6 +175,142 @@ func (s *S3Repository) Delete(path string) error {
 	return nil
 }
 
+// s3MaxCopySize is the size of the largest object that can be copied with a
+// single CopyObject request
+const s3MaxCopySize = 5 * 1024 * 1024 * 1024
+
+// s3CopyPartSize is the size of the parts that larger objects are copied in,
+// unless they are so large they would have more than s3MaxParts parts
+const s3CopyPartSize = 512 * 1024 * 1024
+
+// s3MaxParts is the most parts a multipart upload can have
+const s3MaxParts = 10000
+
+// Move copies the object at src to dest, then deletes src. The copy is done
+// by S3, so the data isn't downloaded. Objects larger than S3 can copy in one
+// request are copied in parts.
+func (s *S3Repository) Move(src, dest string) error {
+	srcKey := filepath.Join(s.root, src)
+	destKey := filepath.Join(s.root, dest)
+	head, err := s.svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		if rerr, ok := err.(awserr.RequestFailure); ok && rerr.StatusCode() == http.StatusNotFound {
+			return errors.DoesNotExist(fmt.Sprintf("Move: path does not exist: %v", src))
+		}
+		return errors.ReadError(fmt.Sprintf("Failed to read %s/%s: %v", s.RootURL(), src, err))
+	}
+	copySource := aws.String(url.PathEscape(s.bucketName + "/" + srcKey))
+	if size := aws.Int64Value(head.ContentLength); size > s3MaxCopySize {
+		err = s.copyInParts(copySource, destKey, size)
+	} else {
+		_, err = s.svc.CopyObject(&s3.CopyObjectInput{
+			Bucket:     aws.String(s.bucketName),
+			Key:        aws.String(destKey),
+			CopySource: copySource,
+		})
+	}
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return errors.DoesNotExist(fmt.Sprintf("Move: path does not exist: %v", src))
+		}
+		return errors.WriteError(fmt.Sprintf("Failed to copy %s/%s to %s: %v", s.RootURL(), src, dest, err))
+	}
+	_, err = s.svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return errors.WriteError(fmt.Sprintf("Failed to delete %s/%s: %v", s.RootURL(), src, err))
+	}
+	return nil
+}
+
+// copyInParts copies an object of the given size to destKey with a
+// multipart upload, copying each part from copySource. If it fails, the
+// upload is aborted, so nothing is left at destKey.
+func (s *S3Repository) copyInParts(copySource *string, destKey string, size int64) error {
+	upload, err := s.svc.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(destKey),
+	})
+	if err != nil {
+		return err
+	}
+	ranges := copyPartRanges(size, copyPartSize(size))
+	parts := make([]*s3.CompletedPart, len(ranges))
+	queue := concurrency.NewWorkerQueue(context.Background(), 16)
+	for i, byteRange := range ranges {
+		// Variables used in closure
+		partNumber := aws.Int64(int64(i + 1))
+		i, byteRange := i, byteRange
+		if err = queue.Go(func() error {
+			out, err := s.svc.UploadPartCopy(&s3.UploadPartCopyInput{
+				Bucket:          aws.String(s.bucketName),
+				Key:             aws.String(destKey),
+				UploadId:        upload.UploadId,
+				PartNumber:      partNumber,
+				CopySource:      copySource,
+				CopySourceRange: aws.String(byteRange),
+			})
+			if err != nil {
+				return err
+			}
+			parts[i] = &s3.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: partNumber}
+			return nil
+		}); err != nil {
+			break
+		}
+	}
+	if waitErr := queue.Wait(); waitErr != nil {
+		err = waitErr
+	}
+	if err == nil {
+		_, err = s.svc.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.bucketName),
+			Key:             aws.String(destKey),
+			UploadId:        upload.UploadId,
+			MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		if _, abortErr := s.svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucketName),
+			Key:      aws.String(destKey),
+			UploadId: upload.UploadId,
+		}); abortErr != nil {
+			console.Warn("Failed to abort copy to s3://%s/%s: %v", s.bucketName, destKey, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// copyPartSize returns the size of the parts that an object of the given size
+// is copied in
+func copyPartSize(size int64) int64 {
+	if size > s3CopyPartSize*s3MaxParts {
+		return (size + s3MaxParts - 1) / s3MaxParts
+	}
+	return s3CopyPartSize
+}
+
+// copyPartRanges returns the byte ranges, in the format of the Range HTTP
+// header, of the parts that an object of the given size is copied in
+func copyPartRanges(size int64, partSize int64) []string {
+	ranges := []string{}
+	for start := int64(0); start < size; start += partSize {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		ranges = append(ranges, fmt.Sprintf("bytes=%d-%d", start, end))
+	}
+	return ranges
+}
+
 // Put data at path
 func (s *S3Repository) Put(path string, data []byte) error {
 	key := filepath.Join(s.root, path)
@@ -113,8 +326,35 @@ func (s *S3Repository) Put(path string, data []byte) error {
 	return nil
 }
 
+// PutIfMatch puts data at path if its ETag is still `version`, using S3's
+// conditional writes
+func (s *S3Repository) PutIfMatch(path string, data []byte, version string) error {
+	key := filepath.Join(s.root, path)
+	req, _ := s.svc.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	// This version of the SDK doesn't have fields for conditional writes
+	if version == "" {
+		req.HTTPRequest.Header.Set("If-None-Match", "*")
+	} else {
+		req.HTTPRequest.Header.Set("If-Match", version)
+	}
+	if err := req.Send(); err != nil {
+		if rerr, ok := err.(awserr.RequestFailure); ok {
+			// 409 is returned if another conditional write is in progress
+			if rerr.StatusCode() == http.StatusPreconditionFailed || rerr.StatusCode() == http.StatusConflict {
+				return errors.Conflict(fmt.Sprintf("%s/%s has been changed since it was read", s.RootURL(), path))
+			}
+		}
+		return errors.WriteError(fmt.Sprintf("Unable to upload to %s/%s: %v", s.RootURL(), path, err))
+	}
+	return nil
+}
+
 func (s *S3Repository) PutPath(localPath string, destPath string) error {
-	files, err := getListOfFilesToPut(localPath, filepath.Join(s.root, destPath))
+	files, err := getListOfFilesToPut(localPath, filepath.Join(s.root, destPath), ".", config.SymlinksFollow)
 	if err != nil {
 		return errors.WriteError(err.Error())
 	}
@@ -148,9 +388,9 @@ func (s *S3Repository) PutPath(localPath string, destPath string) error {
 	return nil
 }
 
-func (s *S3Repository) PutPathTar(localPath, tarPath, includePath string) error {
+func (s *S3Repository) PutPathTar(localPath, tarPath, includePath string) (*Digest, error) {
 	if !strings.HasSuffix(tarPath, ".tar.gz") {
-		return fmt.Errorf("PutPathTar: tarPath must end with .tar.gz")
+		return nil, fmt.Errorf("PutPathTar: tarPath must end with .tar.gz")
 	}
 
 	reader, writer := io.Pipe()
@@ -158,8 +398,11 @@ func (s *S3Repository) PutPathTar(localPath, tarPath, includePath string) error
 	// TODO: This doesn't cancel elegantly on error -- we should use the context returned here and check if it is done.
 	errs, _ := errgroup.WithContext(context.TODO())
 
+	var digest *Digest
 	errs.Go(func() error {
-		if err := putPathTar(localPath, writer, filepath.Base(tarPath), includePath); err != nil {
+		var err error
+		digest, err = putPathTar(localPath, writer, filepath.Base(tarPath), includePath)
+		if err != nil {
 			return err
 		}
 		return writer.Close()
@@ -175,9 +418,9 @@ func (s *S3Repository) PutPathTar(localPath, tarPath, includePath string) error
 		return err
 	})
 	if err := errs.Wait(); err != nil {
-		return errors.WriteError(err.Error())
+		return nil, errors.WriteError(err.Error())
 	}
-	return nil
+	return digest, nil
 }
 
 // GetPath recursively copies repoDir to localDir
@@ -242,7 +485,7 @@ func (s *S3Repository) GetPath(remoteDir string, localDir string) error {
 	return nil
 }
 
-func (s *S3Repository) GetPathTar(tarPath, localPath string) error {
+func (s *S3Repository) GetPathTar(tarPath, localPath string, expected *Digest) error {
 	// archiver doesn't let us use readers, so download to temporary file
 	// TODO: make a better tar implementation
 	tmpdir, err := files.TempDir("tar")
@@ -261,10 +504,10 @@ func (s *S3Repository) GetPathTar(tarPath, localPath string) error {
 	if !exists {
 		return errors.DoesNotExist(fmt.Sprintf("GetPathTar: does not exist: %v", tmptarball))
 	}
-	return extractTar(tmptarball, localPath)
+	return extractTar(tmptarball, localPath, expected)
 }
 
-func (s *S3Repository) GetPathItemTar(tarPath, itemPath, localPath string) error {
+func (s *S3Repository) GetPathItemTar(tarPath, itemPath, localPath string, expected *Digest) error {
 	// archiver doesn't let us use readers, so download to temporary file
 	// TODO: make a better tar implementation
 	tmpdir, err := files.TempDir("tar")
@@ -283,7 +526,7 @@ func (s *S3Repository) GetPathItemTar(tarPath, itemPath, localPath string) error
 	if !exists {
 		return errors.DoesNotExist("Path does not exist: " + tmptarball)
 	}
-	return extractTarItem(tmptarball, itemPath, localPath)
+	return extractTarItem(tmptarball, itemPath, localPath, expected)
 }
 
 func (s *S3Repository) ListRecursive(results chan<- ListResult, dir string) {
@@ -362,10 +605,11 @@ func (s *S3Repository) ListTarFile(tarPath string) ([]string, error) {
 }
 
 func CreateS3Bucket(region, bucket string) (err error) {
-	sess, err := session.NewSession(&aws.Config{
-		Region:                        aws.String(region),
-		CredentialsChainVerboseErrors: aws.Bool(true),
-	})
+	return CreateS3BucketWithOptions(region, bucket, S3Options{})
+}
+
+func CreateS3BucketWithOptions(region, bucket string, opts S3Options) (err error) {
+	sess, err := newS3Session(region, opts)
 	if err != nil {
 		return fmt.Errorf("Failed to connect to S3: %w", err)
 	}
@@ -436,7 +680,7 @@ func (s *S3Repository) listRecursive(results chan<- ListResult, dir string, filt
 				// If S3 gives us an empty/bad etag, then make it blank and cause sync instead of throwing error
 				// Also, the etag includes quotes for some reason
 				md5, _ := hex.DecodeString(strings.Replace(*value.ETag, "\"", "", -1))
-				results <- ListResult{Path: key, MD5: md5}
+				results <- ListResult{Path: key, MD5: md5, Size: aws.Int64Value(value.Size)}
 			}
 		}
 		return true
@@ -447,8 +691,11 @@ func (s *S3Repository) listRecursive(results chan<- ListResult, dir string, filt
 	close(results)
 }
 
-func discoverBucketRegion(bucket string) (string, error) {
-	sess := session.Must(session.NewSession(&aws.Config{}))
+func discoverBucketRegion(bucket string, opts S3Options) (string, error) {
+	sess, err := newS3Session(global.S3Region, opts)
+	if err != nil {
+		return "", err
+	}
 	ctx := context.Background()
 	region, err := s3manager.GetBucketRegion(ctx, sess, bucket, global.S3Region)
 	if err != nil {
@@ -457,15 +704,38 @@ func discoverBucketRegion(bucket string) (string, error) {
 	return region, nil
 }
 
-func getBucketRegionOrCreateBucket(bucket string) (string, error) {
+// S3BucketExists returns true if the bucket exists and the credentials can
+// see it
+func S3BucketExists(bucket string, opts S3Options) (bool, error) {
+	var err error
+	if opts.Endpoint != "" {
+		var sess *session.Session
+		sess, err = newS3Session(global.S3Region, opts)
+		if err != nil {
+			return false, fmt.Errorf("Failed to connect to S3: %w", err)
+		}
+		_, err = s3.New(sess).HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	} else {
+		_, err = discoverBucketRegion(bucket, opts)
+	}
+	if err == nil {
+		return true, nil
+	}
+	if aerr, ok := err.(awserr.Error); ok && strings.Contains(aerr.Error(), "NotFound") {
+		return false, nil
+	}
+	return false, errors.RepositoryConfigurationError(fmt.Sprintf("Failed to determine if bucket s3://%s exists: %v", bucket, err))
+}
+
+func getBucketRegionOrCreateBucket(bucket string, opts S3Options) (string, error) {
 	// TODO (bfirsh): cache this
-	region, err := discoverBucketRegion(bucket)
+	region, err := discoverBucketRegion(bucket, opts)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			// The real check for this is `aerr.Code() == s3.ErrCodeNoSuchBucket` but GetBucketRegion doesnt return right error
 			if strings.Contains(aerr.Error(), "NotFound") {
 				// TODO (bfirsh): report to use that this is being created, in a way that is compatible with shared library
-				if err := CreateS3Bucket(global.S3Region, bucket); err != nil {
+				if err := CreateS3BucketWithOptions(global.S3Region, bucket, opts); err != nil {
 					return "", fmt.Errorf("Error creating bucket: %v", err)
 				}
 				return region, nil
@@ -41,6 +41,13 @@ func TestS3RepositoryGet(t *testing.T) {
 	_, err = repository.Get("does-not-exist")
 	fmt.Println(err)
 	require.True(t, errors.IsDoesNotExist(err))
+
+	exists, err := repository.Exists("some-file")
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = repository.Exists("does-not-exist")
+	require.NoError(t, err)
+	require.False(t, exists)
 }
 
 func TestS3GetPathTar(t *testing.T) {
@@ -52,7 +59,7 @@ func TestS3GetPathTar(t *testing.T) {
 
 	tmpDir, err := files.TempDir("test")
 	require.NoError(t, err)
-	err = repository.GetPathTar("does-not-exist.tar.gz", tmpDir)
+	err = repository.GetPathTar("does-not-exist.tar.gz", tmpDir, nil)
 	require.True(t, errors.IsDoesNotExist(err))
 }
 
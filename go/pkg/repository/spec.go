@@ -7,11 +7,27 @@ import (
 	"github.com/replicate/keepsake/go/pkg/errors"
 )
 
-const Version = 1
+// Version is the current version of the repository format
+//
+// Version 2 doesn't have the legacy fields from when Keepsake was called
+// Replicate in experiment metadata. Repositories are migrated to new versions
+// with `keepsake migrate`.
+const Version = 2
 const SpecPath = "repository.json"
 
 type Spec struct {
 	Version int `json:"version"`
+	// Migration is set while the repository is being migrated to Version,
+	// so the migration can be resumed if it is interrupted
+	Migration *MigrationProgress `json:"migration,omitempty"`
+	// Mode restricts what can be changed in the repository
+	Mode AccessMode `json:"mode,omitempty"`
+}
+
+// MigrationProgress records how far a migration has got
+type MigrationProgress struct {
+	From           int      `json:"from"`
+	CompletedSteps []string `json:"completed_steps"`
 }
 
 // LoadSpec returns the repository spec, or nil if the repository doesn't have a spec file
@@ -28,15 +44,66 @@ func LoadSpec(r Repository) (*Spec, error) {
 	if err := json.Unmarshal(raw, spec); err != nil {
 		return nil, errors.CorruptedRepositorySpec(r.RootURL(), SpecPath, err)
 	}
+	if err := spec.Mode.Validate(); err != nil {
+		return nil, errors.CorruptedRepositorySpec(r.RootURL(), SpecPath, err)
+	}
 
 	return spec, nil
 }
 
-func WriteSpec(r Repository) error {
-	spec := Spec{Version: Version}
-	raw, err := json.Marshal(&spec)
+// WriteSpec writes the repository spec. The spec lock should be held.
+func WriteSpec(r Repository, spec *Spec) error {
+	raw, err := json.Marshal(spec)
 	if err != nil {
 		panic(err) // should never happen
 	}
 	return r.Put(SpecPath, raw)
 }
+
+// EnsureSpec returns the repository spec, writing one if the repository
+// doesn't have one yet. The spec is written while holding the spec lock, so it
+// isn't written over by another process that is creating or changing it at the
+// same time.
+//
+// Repositories without a spec that already have experiments in them were
+// created by versions of Keepsake before specs existed, so they are version 1.
+func EnsureSpec(r Repository) (*Spec, error) {
+	spec, err := LoadSpec(r)
+	if err != nil || spec != nil {
+		return spec, err
+	}
+	unlock, err := LockSpec(r)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	spec, err = LoadSpec(r)
+	if err != nil || spec != nil {
+		return spec, err
+	}
+	spec, err = DefaultSpec(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteSpec(r, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// DefaultSpec returns the spec of a repository that doesn't have a spec file
+func DefaultSpec(r Repository) (*Spec, error) {
+	paths, err := r.List("metadata/experiments/")
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) > 0 {
+		return &Spec{Version: 1}, nil
+	}
+	return &Spec{Version: Version}, nil
+}
+
+// LockSpec takes the lock that must be held while writing the repository spec
+func LockSpec(r Repository) (unlock func(), err error) {
+	return Lock(r, SpecPath)
+}
@@ -3,10 +3,13 @@ package repository
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/replicate/keepsake/go/pkg/concurrency"
+	"github.com/replicate/keepsake/go/pkg/errors"
 )
 
 // Sync destRepository/destPath to match sourceRepository/sourcePath
@@ -15,37 +18,192 @@ import (
 // - If file exists in both but different content, it will copy from source to dest
 // - If file exists in dest but not in source, it will delete in dest
 func Sync(sourceRepository Repository, sourcePath string, destRepository Repository, destPath string) error {
+	return NewSyncer(sourceRepository, sourcePath, destRepository, destPath).Sync()
+}
+
+// Syncer syncs destRepository/destPath to match sourceRepository/sourcePath
+// repeatedly, in the same way as Sync.
+//
+// It remembers what is in dest after each sync, so later syncs only need to
+// list source. Anything else that writes to dest must tell the syncer with
+// DestChanged or Reset, otherwise it won't be put right by the next sync.
+type Syncer struct {
+	sourceRepository Repository
+	sourcePath       string
+	destRepository   Repository
+	destPath         string
+
+	mu sync.Mutex
+	// relative path -> MD5 of what was last synced to dest, or nil if dest
+	// needs listing
+	destFiles map[string][]byte
+	// relative paths of files in dest that have been written or deleted
+	// since they were last synced
+	changed map[string]bool
+}
+
+func NewSyncer(sourceRepository Repository, sourcePath string, destRepository Repository, destPath string) *Syncer {
+	return &Syncer{
+		sourceRepository: sourceRepository,
+		sourcePath:       sourcePath,
+		destRepository:   destRepository,
+		destPath:         destPath,
+	}
+}
+
+// DestChanged records that the file p in dest has been written, or the file
+// or directory p deleted, by something other than the syncer, so the next sync doesn't assume it still
+// has what was synced to it.
+func (s *Syncer) DestChanged(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.changed == nil {
+		s.changed = make(map[string]bool)
+	}
+	s.changed[strings.TrimPrefix(p, s.destPath)] = true
+}
+
+// Reset makes the next sync list dest again, for when more has changed in
+// dest than DestChanged can describe, like a directory being written.
+func (s *Syncer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.destFiles = nil
+	s.changed = nil
+}
+
+// Sync syncs everything in sourcePath. It lists all of sourcePath, which
+// costs a request per 1,000 files on S3 and GCS.
+func (s *Syncer) Sync() error {
+	return s.sync("")
+}
+
+// SyncDir syncs only the directory dir, relative to sourcePath and
+// destPath, so only dir is listed. It relies on what the syncer remembers
+// about dest, so it syncs everything if nothing has been synced yet.
+func (s *Syncer) SyncDir(dir string) error {
+	return s.sync(dir)
+}
+
+// SyncFile syncs only the file p, relative to sourcePath and destPath. It
+// reads p instead of listing anything, so it costs one request. Like
+// SyncDir, it syncs everything if nothing has been synced yet.
+func (s *Syncer) SyncFile(p string) error {
+	sourceFile := path.Join(s.sourcePath, p)
+	destFile := path.Join(s.destPath, p)
+	relativePath := strings.TrimPrefix(sourceFile, s.sourcePath)
+
+	s.mu.Lock()
+	if s.destFiles == nil {
+		s.mu.Unlock()
+		return s.Sync()
+	}
+	destMD5, found := s.destFiles[relativePath]
+	s.mu.Unlock()
+
+	data, err := s.sourceRepository.Get(sourceFile)
+	if errors.IsDoesNotExist(err) {
+		if found {
+			if err := s.destRepository.Delete(destFile); err != nil {
+				return err
+			}
+		}
+		s.mu.Lock()
+		delete(s.destFiles, relativePath)
+		s.mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sourceMD5 := md5.Sum(data)
+	if !found || !bytes.Equal(sourceMD5[:], destMD5) {
+		if err := s.destRepository.Put(destFile, data); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	if s.destFiles != nil {
+		s.destFiles[relativePath] = sourceMD5[:]
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// sync syncs dir, relative to sourcePath and destPath, or everything if dir
+// is empty
+func (s *Syncer) sync(dir string) error {
+	sourceRepository, sourcePath := s.sourceRepository, s.sourcePath
+	destRepository, destPath := s.destRepository, s.destPath
+
 	// A queue to use for the various storage operations we have to run
 	queue := concurrency.NewWorkerQueue(context.Background(), maxWorkers)
 
-	// 1: Fetch destFiles synchronously off disk
+	// 1: Fetch destFiles synchronously off disk, unless we know what's there from the last sync
 	// TODO: This could be optimized by doing this while source list request is in flight
-	results := make(chan ListResult)
 	// path -> MD5 hash map used to efficiently check if files should be synced
-	destFiles := make(map[string][]byte)
-
-	go destRepository.ListRecursive(results, destPath)
-	for result := range results {
-		if result.Error != nil {
-			return result.Error
+	s.mu.Lock()
+	destFiles, changed := s.destFiles, s.changed
+	s.destFiles, s.changed = nil, nil
+	s.mu.Unlock()
+	if destFiles != nil {
+		// We don't know what changed files now contain, so copy them
+		// again if they're in source, and delete them if they're not
+		for changedPath := range changed {
+			isDir := false
+			for relativePath := range destFiles {
+				if strings.HasPrefix(relativePath, changedPath+"/") {
+					// a deleted directory, so the files in it were too
+					destFiles[relativePath] = nil
+					isDir = true
+				}
+			}
+			if !isDir {
+				destFiles[changedPath] = nil
+			}
 		}
-		destFiles[strings.TrimPrefix(result.Path, destPath)] = result.MD5
+	} else {
+		// dest has to be listed, so we might as well sync all of it
+		dir = ""
+		destFiles = make(map[string][]byte)
+		results := make(chan ListResult)
+		go destRepository.ListRecursive(results, destPath)
+		for result := range results {
+			if result.Error != nil {
+				return result.Error
+			}
+			destFiles[strings.TrimPrefix(result.Path, destPath)] = result.MD5
+		}
+	}
+
+	// Only files in dir are synced
+	listPath := sourcePath
+	relativeDir := ""
+	if dir != "" {
+		listPath = path.Join(sourcePath, dir)
+		relativeDir = strings.TrimPrefix(listPath, sourcePath) + "/"
+	}
+	inDir := func(relativePath string) bool {
+		return strings.HasPrefix(relativePath, relativeDir)
 	}
 
 	// 2: Copy files from dest to source which don't exist or have changed
 	sourceFiles := make(chan ListResult)
-	// path map used for step (3)
-	sourceFileMap := make(map[string]struct{})
-	go sourceRepository.ListRecursive(sourceFiles, sourcePath)
+	// path -> MD5 map used for step (3), and to remember what's in dest for the next sync
+	sourceFileMap := make(map[string][]byte)
+	go sourceRepository.ListRecursive(sourceFiles, listPath)
 	for sourceFile := range sourceFiles {
 		if sourceFile.Error != nil {
 			return sourceFile.Error
 		}
 		relativePath := strings.TrimPrefix(sourceFile.Path, sourcePath)
-		sourceFileMap[relativePath] = struct{}{}
+		if !inDir(relativePath) {
+			continue
+		}
+		sourceFileMap[relativePath] = sourceFile.MD5
 
 		needsCopying := false
-		if destMD5, found := destFiles[relativePath]; found {
+		if destMD5, found := destFiles[relativePath]; found && destMD5 != nil {
 			if !bytes.Equal(sourceFile.MD5, destMD5) {
 				needsCopying = true
 			}
@@ -73,6 +231,9 @@ func Sync(sourceRepository Repository, sourcePath string, destRepository Reposit
 
 	// 3: Delete files from dest that don't exist in source
 	for relativePath := range destFiles {
+		if !inDir(relativePath) {
+			continue
+		}
 		if _, found := sourceFileMap[relativePath]; !found {
 			// Variables used in closure
 			destPath := destPath
@@ -86,5 +247,22 @@ func Sync(sourceRepository Repository, sourcePath string, destRepository Reposit
 		}
 	}
 
-	return queue.Wait()
+	if err := queue.Wait(); err != nil {
+		return err
+	}
+	// Files outside dir are left as they were, including changed files,
+	// which the next sync copies again
+	for relativePath := range destFiles {
+		if inDir(relativePath) {
+			delete(destFiles, relativePath)
+		}
+	}
+	for relativePath, sourceMD5 := range sourceFileMap {
+		destFiles[relativePath] = sourceMD5
+	}
+	// Anything that changed while syncing is left in s.changed for the next sync
+	s.mu.Lock()
+	s.destFiles = destFiles
+	s.mu.Unlock()
+	return nil
 }
@@ -63,3 +63,168 @@ func TestSync(t *testing.T) {
 	info, _ = os.Stat(filepath.Join(destRepository.rootDir, "dest-path/same-content"))
 	require.Equal(t, info.ModTime(), sameContentMTime)
 }
+
+func TestSyncerRepeated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sourceRepository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	dir, err = ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	destRepository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	syncer := NewSyncer(sourceRepository, "src-path", destRepository, "dest-path")
+
+	require.NoError(t, sourceRepository.Put("src-path/unchanged", []byte("hello")))
+	require.NoError(t, sourceRepository.Put("src-path/changed", []byte("hello")))
+	require.NoError(t, sourceRepository.Put("src-path/deleted", []byte("hello")))
+	require.NoError(t, syncer.Sync())
+	info, _ := os.Stat(filepath.Join(destRepository.rootDir, "dest-path/unchanged"))
+	unchangedMTime := info.ModTime()
+
+	require.NoError(t, sourceRepository.Put("src-path/changed", []byte("what is up")))
+	require.NoError(t, sourceRepository.Delete("src-path/deleted"))
+	require.NoError(t, sourceRepository.Put("src-path/added", []byte("hi")))
+	require.NoError(t, syncer.Sync())
+
+	data, err := destRepository.Get("dest-path/changed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("what is up"), data)
+	data, err = destRepository.Get("dest-path/added")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hi"), data)
+	_, err = destRepository.Get("dest-path/deleted")
+	require.True(t, errors.IsDoesNotExist(err))
+
+	info, _ = os.Stat(filepath.Join(destRepository.rootDir, "dest-path/unchanged"))
+	require.Equal(t, unchangedMTime, info.ModTime())
+
+	// Syncing again with nothing changed is a no-op
+	require.NoError(t, syncer.Sync())
+	data, err = destRepository.Get("dest-path/changed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("what is up"), data)
+}
+
+func TestSyncerDestChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sourceRepository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	dir, err = ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	destRepository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	syncer := NewSyncer(sourceRepository, "src-path", destRepository, "dest-path")
+
+	require.NoError(t, sourceRepository.Put("src-path/overwritten", []byte("hello")))
+	require.NoError(t, sourceRepository.Put("src-path/deleted", []byte("hello")))
+	require.NoError(t, sourceRepository.Put("src-path/dir/deleted", []byte("hello")))
+	require.NoError(t, syncer.Sync())
+
+	// Writes to dest that didn't make it to source, like a cache write
+	// followed by a failed write to the underlying repository
+	require.NoError(t, destRepository.Put("dest-path/overwritten", []byte("what is up")))
+	syncer.DestChanged("dest-path/overwritten")
+	require.NoError(t, destRepository.Put("dest-path/added", []byte("hi")))
+	syncer.DestChanged("dest-path/added")
+	require.NoError(t, destRepository.Delete("dest-path/deleted"))
+	syncer.DestChanged("dest-path/deleted")
+	require.NoError(t, destRepository.Delete("dest-path/dir"))
+	syncer.DestChanged("dest-path/dir")
+
+	require.NoError(t, syncer.Sync())
+	data, err := destRepository.Get("dest-path/overwritten")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+	_, err = destRepository.Get("dest-path/added")
+	require.True(t, errors.IsDoesNotExist(err))
+	data, err = destRepository.Get("dest-path/deleted")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+	data, err = destRepository.Get("dest-path/dir/deleted")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+}
+
+func TestSyncerDirAndFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sourceRepository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	dir, err = ioutil.TempDir("", "keepsake-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	destRepository, err := NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	syncer := NewSyncer(sourceRepository, "src-path", destRepository, "dest-path")
+
+	// Nothing has been synced, so everything is
+	require.NoError(t, sourceRepository.Put("src-path/dir/changed", []byte("hello")))
+	require.NoError(t, sourceRepository.Put("src-path/dir/deleted", []byte("hello")))
+	require.NoError(t, sourceRepository.Put("src-path/other/changed", []byte("hello")))
+	require.NoError(t, sourceRepository.Put("src-path/file", []byte("hello")))
+	require.NoError(t, syncer.SyncDir("dir"))
+	data, err := destRepository.Get("dest-path/other/changed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+
+	require.NoError(t, sourceRepository.Put("src-path/dir/changed", []byte("what is up")))
+	require.NoError(t, sourceRepository.Delete("src-path/dir/deleted"))
+	require.NoError(t, sourceRepository.Put("src-path/dir/added", []byte("hi")))
+	require.NoError(t, sourceRepository.Put("src-path/other/changed", []byte("what is up")))
+	require.NoError(t, sourceRepository.Put("src-path/file", []byte("what is up")))
+
+	// Only dir is synced
+	require.NoError(t, syncer.SyncDir("dir"))
+	data, err = destRepository.Get("dest-path/dir/changed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("what is up"), data)
+	data, err = destRepository.Get("dest-path/dir/added")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hi"), data)
+	_, err = destRepository.Get("dest-path/dir/deleted")
+	require.True(t, errors.IsDoesNotExist(err))
+	data, err = destRepository.Get("dest-path/other/changed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+	data, err = destRepository.Get("dest-path/file")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+
+	// Only file is synced
+	require.NoError(t, syncer.SyncFile("file"))
+	data, err = destRepository.Get("dest-path/file")
+	require.NoError(t, err)
+	require.Equal(t, []byte("what is up"), data)
+	data, err = destRepository.Get("dest-path/other/changed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+
+	require.NoError(t, sourceRepository.Delete("src-path/file"))
+	require.NoError(t, syncer.SyncFile("file"))
+	_, err = destRepository.Get("dest-path/file")
+	require.True(t, errors.IsDoesNotExist(err))
+
+	// What the partial syncs did is remembered by the next full sync
+	require.NoError(t, syncer.Sync())
+	data, err = destRepository.Get("dest-path/other/changed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("what is up"), data)
+	data, err = destRepository.Get("dest-path/dir/added")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hi"), data)
+	_, err = destRepository.Get("dest-path/file")
+	require.True(t, errors.IsDoesNotExist(err))
+}
@@ -27,6 +27,8 @@ type DaemonClient interface {
 	DeleteExperiment(ctx context.Context, in *DeleteExperimentRequest, opts ...grpc.CallOption) (*DeleteExperimentReply, error)
 	CheckoutCheckpoint(ctx context.Context, in *CheckoutCheckpointRequest, opts ...grpc.CallOption) (*CheckoutCheckpointReply, error)
 	GetExperimentStatus(ctx context.Context, in *GetExperimentStatusRequest, opts ...grpc.CallOption) (*GetExperimentStatusReply, error)
+	GetDaemonStatus(ctx context.Context, in *GetDaemonStatusRequest, opts ...grpc.CallOption) (*GetDaemonStatusReply, error)
+	WatchExperiments(ctx context.Context, in *WatchExperimentsRequest, opts ...grpc.CallOption) (Daemon_WatchExperimentsClient, error)
 }
 
 type daemonClient struct {
@@ -118,6 +120,47 @@ func (c *daemonClient) GetExperimentStatus(ctx context.Context, in *GetExperimen
 	return out, nil
 }
 
+func (c *daemonClient) GetDaemonStatus(ctx context.Context, in *GetDaemonStatusRequest, opts ...grpc.CallOption) (*GetDaemonStatusReply, error) {
+	out := new(GetDaemonStatusReply)
+	err := c.cc.Invoke(ctx, "/service.Daemon/GetDaemonStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) WatchExperiments(ctx context.Context, in *WatchExperimentsRequest, opts ...grpc.CallOption) (Daemon_WatchExperimentsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Daemon_serviceDesc.Streams[0], "/service.Daemon/WatchExperiments", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonWatchExperimentsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_WatchExperimentsClient interface {
+	Recv() (*WatchExperimentsReply, error)
+	grpc.ClientStream
+}
+
+type daemonWatchExperimentsClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonWatchExperimentsClient) Recv() (*WatchExperimentsReply, error) {
+	m := new(WatchExperimentsReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
@@ -131,6 +174,8 @@ type DaemonServer interface {
 	DeleteExperiment(context.Context, *DeleteExperimentRequest) (*DeleteExperimentReply, error)
 	CheckoutCheckpoint(context.Context, *CheckoutCheckpointRequest) (*CheckoutCheckpointReply, error)
 	GetExperimentStatus(context.Context, *GetExperimentStatusRequest) (*GetExperimentStatusReply, error)
+	GetDaemonStatus(context.Context, *GetDaemonStatusRequest) (*GetDaemonStatusReply, error)
+	WatchExperiments(*WatchExperimentsRequest, Daemon_WatchExperimentsServer) error
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -165,6 +210,12 @@ func (UnimplementedDaemonServer) CheckoutCheckpoint(context.Context, *CheckoutCh
 func (UnimplementedDaemonServer) GetExperimentStatus(context.Context, *GetExperimentStatusRequest) (*GetExperimentStatusReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetExperimentStatus not implemented")
 }
+func (UnimplementedDaemonServer) GetDaemonStatus(context.Context, *GetDaemonStatusRequest) (*GetDaemonStatusReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDaemonStatus not implemented")
+}
+func (UnimplementedDaemonServer) WatchExperiments(*WatchExperimentsRequest, Daemon_WatchExperimentsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchExperiments not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -340,6 +391,45 @@ func _Daemon_GetExperimentStatus_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_GetDaemonStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDaemonStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetDaemonStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/service.Daemon/GetDaemonStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetDaemonStatus(ctx, req.(*GetDaemonStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_WatchExperiments_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchExperimentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).WatchExperiments(m, &daemonWatchExperimentsServer{stream})
+}
+
+type Daemon_WatchExperimentsServer interface {
+	Send(*WatchExperimentsReply) error
+	grpc.ServerStream
+}
+
+type daemonWatchExperimentsServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonWatchExperimentsServer) Send(m *WatchExperimentsReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Daemon_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "service.Daemon",
 	HandlerType: (*DaemonServer)(nil),
@@ -380,7 +470,17 @@ var _Daemon_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetExperimentStatus",
 			Handler:    _Daemon_GetExperimentStatus_Handler,
 		},
+		{
+			MethodName: "GetDaemonStatus",
+			Handler:    _Daemon_GetDaemonStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchExperiments",
+			Handler:       _Daemon_WatchExperiments_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "keepsake.proto",
 }
@@ -0,0 +1,382 @@
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/servicepb"
+)
+
+// GatewayPathPrefix is the start of the path of each method in the HTTP/JSON
+// gateway, which is followed by the method's name, like /v1/CreateExperiment
+const GatewayPathPrefix = "/v1/"
+
+var (
+	jsonMarshalOptions   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	jsonUnmarshalOptions = protojson.UnmarshalOptions{}
+)
+
+// GatewayTokenSuffix is added to the path of the daemon's socket to get the
+// path of the file that the gateway's token is written to, if the token isn't
+// set with $KEEPSAKE_HTTP_TOKEN
+const GatewayTokenSuffix = ".http-token"
+
+// gateway serves the daemon's gRPC methods as HTTP/JSON, for languages that
+// can't easily use gRPC. Each method is a POST to /v1/<method>, with the
+// request message as JSON in the body, and the reply message as JSON in the
+// response. Errors are the gRPC status as JSON, with an HTTP status code
+// that matches the gRPC one.
+//
+// Methods that stream their replies respond with a line of JSON for each
+// reply, which is {"result": <reply>}, or {"error": <status>} if the method
+// fails after it has started replying.
+//
+// Anything that can connect to the gateway can delete experiments and write
+// files, so requests must have the token in an "Authorization: Bearer"
+// header, and a JSON content type. Requests from web browsers, which have an
+// Origin header, are refused, so web pages can't make requests to it.
+type gateway struct {
+	server  servicepb.DaemonServer
+	token   string
+	methods map[string]protoreflect.MethodDescriptor
+}
+
+func newGateway(server servicepb.DaemonServer, token string) *gateway {
+	g := &gateway{
+		server:  server,
+		token:   token,
+		methods: map[string]protoreflect.MethodDescriptor{},
+	}
+	methods := daemonServiceDescriptor().Methods()
+	for i := 0; i < methods.Len(); i++ {
+		g.methods[string(methods.Get(i).Name())] = methods.Get(i)
+	}
+	return g
+}
+
+func daemonServiceDescriptor() protoreflect.ServiceDescriptor {
+	return servicepb.File_keepsake_proto.Services().ByName("Daemon")
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Origin") != "" {
+		writeGatewayError(w, status.Error(codes.PermissionDenied, "Requests from web browsers aren't allowed"))
+		return
+	}
+	if r.URL.Path == "/openapi.json" {
+		g.serveOpenAPI(w, r)
+		return
+	}
+	if !g.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeGatewayError(w, status.Errorf(codes.Unauthenticated, "Missing or incorrect token. Pass it in an 'Authorization: Bearer <token>' header."))
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, GatewayPathPrefix) {
+		writeGatewayError(w, status.Errorf(codes.NotFound, "Not found: %s. Methods are at %s<method>.", r.URL.Path, GatewayPathPrefix))
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, GatewayPathPrefix)
+	method, ok := g.methods[name]
+	if !ok {
+		writeGatewayError(w, status.Errorf(codes.NotFound, "Unknown method: %s", name))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeGatewayStatus(w, http.StatusMethodNotAllowed, status.Newf(codes.Unimplemented, "%s must be called with POST, not %s", name, r.Method))
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeGatewayStatus(w, http.StatusUnsupportedMediaType, status.Newf(codes.InvalidArgument, "The request must have the content type application/json, not %q", r.Header.Get("Content-Type")))
+		return
+	}
+	if method.IsStreamingServer() {
+		g.stream(w, method, r)
+		return
+	}
+	reply, err := g.call(r.Context(), method, r)
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+	data, err := jsonMarshalOptions.Marshal(reply)
+	if err != nil {
+		writeGatewayError(w, status.Errorf(codes.Internal, "Failed to encode reply: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		console.Debug("Failed to write HTTP response: %v", err)
+	}
+}
+
+// authorized returns whether r has the gateway's token
+func (g *gateway) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) == 1
+}
+
+// call decodes the request for method from the body of r, and calls the
+// method on the server, the same way as the gRPC server does
+func (g *gateway) call(ctx context.Context, method protoreflect.MethodDescriptor, r *http.Request) (proto.Message, error) {
+	fn := reflect.ValueOf(g.server).MethodByName(string(method.Name()))
+	if !fn.IsValid() {
+		return nil, status.Errorf(codes.Unimplemented, "Unknown method: %s", method.Name())
+	}
+	req, err := readGatewayRequest(method, fn.Type().In(1), r)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     g.server,
+		FullMethod: fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name()),
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		results := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+		err, _ := results[1].Interface().(error)
+		return results[0].Interface(), err
+	}
+	reply, err := metricsInterceptor(ctx, req, info, handler)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(proto.Message), nil
+}
+
+// stream calls a method that streams its replies, writing each reply as a
+// line of JSON as soon as it is sent
+func (g *gateway) stream(w http.ResponseWriter, method protoreflect.MethodDescriptor, r *http.Request) {
+	fn := reflect.ValueOf(g.server).MethodByName(string(method.Name()))
+	newStream, ok := gatewayStreams[string(method.Name())]
+	if !fn.IsValid() || !ok {
+		writeGatewayError(w, status.Errorf(codes.Unimplemented, "Unknown method: %s", method.Name()))
+		return
+	}
+	req, err := readGatewayRequest(method, fn.Type().In(0), r)
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+	stream := &gatewayStream{ctx: r.Context(), w: w}
+	results := fn.Call([]reflect.Value{reflect.ValueOf(req), reflect.ValueOf(newStream(stream))})
+	err, _ = results[0].Interface().(error)
+	if err == nil {
+		return
+	}
+	if !stream.started {
+		writeGatewayError(w, err)
+		return
+	}
+	data, marshalErr := jsonMarshalOptions.Marshal(status.Convert(err).Proto())
+	if marshalErr != nil {
+		data = []byte(fmt.Sprintf(`{"code": %d, "message": %q, "details": []}`, codes.Internal, marshalErr.Error()))
+	}
+	if err := stream.writeLine("error", data); err != nil {
+		console.Debug("Failed to write HTTP response: %v", err)
+	}
+}
+
+// readGatewayRequest decodes the JSON in the body of r as a request for
+// method, which has the type reqType
+func readGatewayRequest(method protoreflect.MethodDescriptor, reqType reflect.Type, r *http.Request) (proto.Message, error) {
+	req := reflect.New(reqType.Elem()).Interface().(proto.Message)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Failed to read request: %v", err)
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := jsonUnmarshalOptions.Unmarshal(body, req); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Failed to parse request as %s: %v", method.Input().Name(), err)
+		}
+	}
+	return req, nil
+}
+
+// gatewayStream is a grpc.ServerStream that writes the messages sent on it
+// to an HTTP response
+type gatewayStream struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	started bool
+}
+
+func (s *gatewayStream) SetHeader(metadata.MD) error  { return nil }
+func (s *gatewayStream) SendHeader(metadata.MD) error { return nil }
+func (s *gatewayStream) SetTrailer(metadata.MD)       {}
+func (s *gatewayStream) Context() context.Context     { return s.ctx }
+
+func (s *gatewayStream) SendMsg(m interface{}) error {
+	data, err := jsonMarshalOptions.Marshal(m.(proto.Message))
+	if err != nil {
+		return status.Errorf(codes.Internal, "Failed to encode reply: %v", err)
+	}
+	return s.writeLine("result", data)
+}
+
+func (s *gatewayStream) RecvMsg(m interface{}) error {
+	return status.Error(codes.Unimplemented, "The gateway doesn't support streaming requests")
+}
+
+// writeLine writes {"<key>": <data>} as a line of the response, and sends it
+// to the client straight away
+func (s *gatewayStream) writeLine(key string, data []byte) error {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.started = true
+	}
+	line := append([]byte(fmt.Sprintf(`{"%s":`, key)), data...)
+	line = append(line, "}\n"...)
+	if _, err := s.w.Write(line); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// gatewayStreams makes the streams that are passed to methods that stream
+// their replies, which have a Send method for the type of reply
+var gatewayStreams = map[string]func(*gatewayStream) interface{}{
+	"WatchExperiments": func(s *gatewayStream) interface{} { return watchExperimentsGatewayStream{s} },
+}
+
+var _ servicepb.Daemon_WatchExperimentsServer = watchExperimentsGatewayStream{}
+
+type watchExperimentsGatewayStream struct {
+	*gatewayStream
+}
+
+func (s watchExperimentsGatewayStream) Send(reply *servicepb.WatchExperimentsReply) error {
+	return s.SendMsg(reply)
+}
+
+func (g *gateway) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	data, err := OpenAPI()
+	if err != nil {
+		writeGatewayError(w, status.Errorf(codes.Internal, "Failed to generate OpenAPI document: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		console.Debug("Failed to write HTTP response: %v", err)
+	}
+}
+
+// writeGatewayError writes a gRPC error as a JSON google.rpc.Status, which
+// has the code, message, and the details added by handleError
+func writeGatewayError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeGatewayStatus(w, httpStatusFromCode(st.Code()), st)
+}
+
+func writeGatewayStatus(w http.ResponseWriter, httpStatus int, st *status.Status) {
+	data, marshalErr := jsonMarshalOptions.Marshal(st.Proto())
+	if marshalErr != nil {
+		data = []byte(fmt.Sprintf(`{"code": %d, "message": %q, "details": []}`, codes.Internal, marshalErr.Error()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	if _, err := w.Write(data); err != nil {
+		console.Debug("Failed to write HTTP response: %v", err)
+	}
+}
+
+// httpStatusFromCode returns the HTTP status code that is closest to a gRPC
+// code, the same way as Google's APIs do
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// serveGateway serves the HTTP/JSON gateway at http://<address>/v1/ in the
+// background. The address must be a loopback address, because the gateway
+// can delete experiments and write files. If token is empty, a random token
+// is made and written to tokenPath, which is returned so it can be removed
+// when the daemon exits.
+func serveGateway(address string, token string, tokenPath string, server servicepb.DaemonServer) (string, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", fmt.Errorf("Failed to listen for HTTP requests on %s: %w", address, err)
+	}
+	if addr, ok := listener.Addr().(*net.TCPAddr); !ok || !addr.IP.IsLoopback() {
+		listener.Close()
+		return "", fmt.Errorf("The HTTP/JSON API can only be served on a loopback address, like localhost:8080, not %s, because anything that can connect to it can delete experiments and write files", address)
+	}
+	writtenTokenPath := ""
+	if token == "" {
+		token, err = newGatewayToken()
+		if err != nil {
+			listener.Close()
+			return "", err
+		}
+		if err := ioutil.WriteFile(tokenPath, []byte(token), 0600); err != nil {
+			listener.Close()
+			return "", fmt.Errorf("Failed to write HTTP/JSON API token to %s: %w", tokenPath, err)
+		}
+		writtenTokenPath = tokenPath
+		console.Debug("Wrote HTTP/JSON API token to %s", tokenPath)
+	}
+	console.Debug("Serving HTTP/JSON API at http://%s%s", listener.Addr(), GatewayPathPrefix)
+	go func() {
+		if err := http.Serve(listener, newGateway(server, token)); err != nil {
+			console.Warn("Stopped serving HTTP/JSON API: %v", err)
+		}
+	}()
+	return writtenTokenPath, nil
+}
+
+func newGatewayToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("Failed to make HTTP/JSON API token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
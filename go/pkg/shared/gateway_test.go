@@ -0,0 +1,315 @@
+package shared
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+const testGatewayToken = "test-token"
+
+func createGatewayTestServer(t *testing.T) (*httptest.Server, func()) {
+	ts, _, cleanup := createGatewayTestServerInDir(t)
+	return ts, cleanup
+}
+
+func createGatewayTestServerInDir(t *testing.T) (*httptest.Server, string, func()) {
+	dir, err := files.TempDir("test-gateway")
+	require.NoError(t, err)
+	repo, err := repository.NewDiskRepository(dir)
+	require.NoError(t, err)
+	s := &server{projectGetter: func() (*project.Project, error) {
+		return project.NewProject(repo, dir), nil
+	}}
+	ts := httptest.NewServer(newGateway(s, testGatewayToken))
+	return ts, dir, func() {
+		ts.Close()
+		if s.session != nil {
+			s.session.Close()
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func newGatewayRequest(t *testing.T, ctx context.Context, httpMethod string, url string, body string) *http.Request {
+	req, err := http.NewRequestWithContext(ctx, httpMethod, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testGatewayToken)
+	return req
+}
+
+func post(t *testing.T, ts *httptest.Server, method string, body string) (int, map[string]interface{}) {
+	return do(t, newGatewayRequest(t, context.Background(), http.MethodPost, ts.URL+"/v1/"+method, body))
+}
+
+func do(t *testing.T, req *http.Request) (int, map[string]interface{}) {
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	ret := map[string]interface{}{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ret))
+	return resp.StatusCode, ret
+}
+
+func TestGateway(t *testing.T) {
+	ts, cleanup := createGatewayTestServer(t)
+	defer cleanup()
+
+	code, reply := post(t, ts, "CreateExperiment", `{
+		"experiment": {"params": {"learning_rate": {"floatValue": 0.01}}, "command": "train.R"},
+		"disableHeartbeat": true,
+		"quiet": true
+	}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	exp := reply["experiment"].(map[string]interface{})
+	experimentID := exp["id"].(string)
+	require.Len(t, experimentID, project.IDLength)
+	require.Equal(t, "train.R", exp["command"])
+	// fields that aren't set are included, so clients don't have to handle them being missing
+	require.Equal(t, "", exp["path"])
+
+	code, reply = post(t, ts, "CreateCheckpoint", `{"checkpoint": {"metrics": {"loss": {"floatValue": 0.1}}, "step": 3}, "quiet": true}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	chk := reply["checkpoint"].(map[string]interface{})
+	// 64-bit integers are strings in protobuf's JSON encoding
+	require.Equal(t, "3", chk["step"])
+
+	exp["checkpoints"] = []interface{}{chk}
+	body, err := json.Marshal(map[string]interface{}{"experiment": exp, "quiet": true})
+	require.NoError(t, err)
+	code, reply = post(t, ts, "SaveExperiment", string(body))
+	require.Equal(t, http.StatusOK, code, reply)
+
+	code, reply = post(t, ts, "GetExperiment", `{"experimentIDPrefix": "`+experimentID[:7]+`"}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	exp = reply["experiment"].(map[string]interface{})
+	require.Equal(t, experimentID, exp["id"])
+	require.Equal(t, map[string]interface{}{"floatValue": 0.01}, exp["params"].(map[string]interface{})["learning_rate"])
+	require.Len(t, exp["checkpoints"], 1)
+
+	// requests without any fields can have an empty body
+	code, reply = post(t, ts, "ListExperiments", "")
+	require.Equal(t, http.StatusOK, code, reply)
+	require.Len(t, reply["experiments"], 1)
+
+	code, reply = post(t, ts, "StopExperiment", `{"experimentID": "`+experimentID+`"}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	code, reply = post(t, ts, "GetExperimentStatus", `{"experimentID": "`+experimentID+`"}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	require.Equal(t, "STOPPED", reply["status"])
+
+	code, reply = post(t, ts, "GetDaemonStatus", "{}")
+	require.Equal(t, http.StatusOK, code, reply)
+	require.NotEmpty(t, reply["metrics"])
+}
+
+func TestGatewayDeleteExperiment(t *testing.T) {
+	ts, dir, cleanup := createGatewayTestServerInDir(t)
+	defer cleanup()
+
+	ids := []string{}
+	for i := 0; i < 2; i++ {
+		code, reply := post(t, ts, "CreateExperiment", `{"experiment": {}, "disableHeartbeat": true, "quiet": true}`)
+		require.Equal(t, http.StatusOK, code, reply)
+		ids = append(ids, reply["experiment"].(map[string]interface{})["id"].(string))
+	}
+
+	// experiments are moved to the trash, like "keepsake rm"
+	code, reply := post(t, ts, "DeleteExperiment", `{"experimentID": "`+ids[0]+`"}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	require.FileExists(t, filepath.Join(dir, "trash", ids[0], "metadata/experiments", ids[0]+".json"))
+
+	code, reply = post(t, ts, "DeleteExperiment", `{"experimentID": "`+ids[1]+`", "permanent": true}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	require.NoDirExists(t, filepath.Join(dir, "trash", ids[1]))
+	require.NoFileExists(t, filepath.Join(dir, "metadata/experiments", ids[1]+".json"))
+
+	code, reply = post(t, ts, "ListExperiments", "{}")
+	require.Equal(t, http.StatusOK, code, reply)
+	require.Empty(t, reply["experiments"])
+}
+
+func TestGatewayErrors(t *testing.T) {
+	ts, cleanup := createGatewayTestServer(t)
+	defer cleanup()
+
+	// errors from Keepsake have the same code and reason as they do over gRPC
+	code, reply := post(t, ts, "GetExperiment", `{"experimentIDPrefix": "doesnotexist"}`)
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Equal(t, float64(13), reply["code"])
+	require.Contains(t, reply["message"], "doesnotexist")
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"@type":    "type.googleapis.com/google.rpc.ErrorInfo",
+		"reason":   "DOES_NOT_EXIST",
+		"domain":   "",
+		"metadata": map[string]interface{}{},
+	}}, reply["details"])
+
+	code, reply = post(t, ts, "GetExperiment", `{"experimentIDPrefix": `)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, float64(3), reply["code"])
+
+	code, reply = post(t, ts, "GetExperiment", `{"notAField": "foo"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Contains(t, reply["message"], "notAField")
+
+	code, reply = post(t, ts, "RemoveEverything", "{}")
+	require.Equal(t, http.StatusNotFound, code)
+	require.Equal(t, float64(5), reply["code"])
+
+	resp, err := http.DefaultClient.Do(newGatewayRequest(t, context.Background(), http.MethodGet, ts.URL+"/v1/ListExperiments", ""))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	require.Equal(t, "POST", resp.Header.Get("Allow"))
+}
+
+func TestGatewayRefusesUnsafeRequests(t *testing.T) {
+	ts, cleanup := createGatewayTestServer(t)
+	defer cleanup()
+	url := ts.URL + "/v1/DeleteExperiment"
+	body := `{"experimentIDPrefix": "1eeeeee"}`
+
+	req := newGatewayRequest(t, context.Background(), http.MethodPost, url, body)
+	req.Header.Del("Authorization")
+	code, reply := do(t, req)
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, float64(16), reply["code"])
+
+	req = newGatewayRequest(t, context.Background(), http.MethodPost, url, body)
+	req.Header.Set("Authorization", "Bearer not-the-token")
+	code, _ = do(t, req)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	// web pages can make POST requests to any address, but browsers always
+	// add an Origin header to them
+	req = newGatewayRequest(t, context.Background(), http.MethodPost, url, body)
+	req.Header.Set("Origin", "https://example.com")
+	code, reply = do(t, req)
+	require.Equal(t, http.StatusForbidden, code)
+	require.Equal(t, float64(7), reply["code"])
+
+	// only JSON is accepted, because forms and plain text can be posted by
+	// web pages without asking the server first
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		req = newGatewayRequest(t, context.Background(), http.MethodPost, url, body)
+		req.Header.Set("Content-Type", contentType)
+		code, _ = do(t, req)
+		require.Equal(t, http.StatusUnsupportedMediaType, code, contentType)
+	}
+	req = newGatewayRequest(t, context.Background(), http.MethodPost, ts.URL+"/v1/ListExperiments", "{}")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	code, reply = do(t, req)
+	require.Equal(t, http.StatusOK, code, reply)
+}
+
+func TestServeGateway(t *testing.T) {
+	dir, err := files.TempDir("test-serve-gateway")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenPath := filepath.Join(dir, "keepsake.sock"+GatewayTokenSuffix)
+
+	_, err = serveGateway("0.0.0.0:0", "", tokenPath, &server{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "loopback")
+	require.NoFileExists(t, tokenPath)
+
+	// a random token is written next to the socket if one isn't set
+	written, err := serveGateway("127.0.0.1:0", "", tokenPath, &server{})
+	require.NoError(t, err)
+	require.Equal(t, tokenPath, written)
+	token, err := ioutil.ReadFile(tokenPath)
+	require.NoError(t, err)
+	require.Len(t, token, 64)
+	info, err := os.Stat(tokenPath)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	written, err = serveGateway("127.0.0.1:0", "my-token", filepath.Join(dir, "other.sock"+GatewayTokenSuffix), &server{})
+	require.NoError(t, err)
+	require.Equal(t, "", written)
+	require.NoFileExists(t, filepath.Join(dir, "other.sock"+GatewayTokenSuffix))
+}
+
+func TestGatewayStream(t *testing.T) {
+	ts, cleanup := createGatewayTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp, err := http.DefaultClient.Do(newGatewayRequest(t, ctx, http.MethodPost, ts.URL+"/v1/WatchExperiments", "{}"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	// each reply is a line, which is sent as soon as it happens
+	lines := bufio.NewScanner(resp.Body)
+	next := func() map[string]interface{} {
+		require.True(t, lines.Scan(), lines.Err())
+		line := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(lines.Bytes(), &line))
+		return line["result"].(map[string]interface{})
+	}
+	require.Equal(t, "RESET", next()["type"])
+
+	code, reply := post(t, ts, "CreateExperiment", `{"experiment": {}, "disableHeartbeat": true, "quiet": true}`)
+	require.Equal(t, http.StatusOK, code, reply)
+	event := next()
+	require.Equal(t, "CREATED", event["type"])
+	require.Equal(t, reply["experiment"].(map[string]interface{})["id"], event["experimentID"])
+	require.NotEmpty(t, event["cursor"])
+
+	code, reply = post(t, ts, "WatchExperiments", `{"cursor": "not a cursor"}`)
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Contains(t, reply["message"], "Invalid cursor")
+}
+
+func TestOpenAPI(t *testing.T) {
+	data, err := OpenAPI()
+	require.NoError(t, err)
+	doc := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	paths := doc["paths"].(map[string]interface{})
+	methods := daemonServiceDescriptor().Methods()
+	require.Len(t, paths, methods.Len())
+	for i := 0; i < methods.Len(); i++ {
+		require.Contains(t, paths, "/v1/"+string(methods.Get(i).Name()))
+	}
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"},
+		schemas["Experiment"].(map[string]interface{})["properties"].(map[string]interface{})["created"])
+
+	// the gateway serves the same document
+	ts, cleanup := createGatewayTestServer(t)
+	defer cleanup()
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	served, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(served))
+
+	// and it has been published
+	published, err := ioutil.ReadFile("../../../web/public/openapi.json")
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(published), "web/public/openapi.json is out of date. Run 'go generate ./pkg/shared' to update it.")
+}
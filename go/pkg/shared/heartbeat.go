@@ -8,19 +8,30 @@ import (
 )
 
 type HeartbeatProcess struct {
-	project      *project.Project
-	experimentID string
-	ticker       *time.Ticker
-	done         chan struct{}
+	project   *project.Project
+	heartbeat *project.Heartbeat
+	ticker    *time.Ticker
+	done      chan struct{}
+
+	// lastRefreshed is when the heartbeat was last written, for metrics
+	lastRefreshed time.Time
 }
 
-func StartHeartbeat(proj *project.Project, experimentID string) *HeartbeatProcess {
+// StartHeartbeat writes heartbeats for an experiment in the background, at
+// the interval in the project's heartbeat config. pid is the process the
+// experiment runs in, which is recorded in the heartbeats so experiments
+// whose process has exited can be told apart from running ones.
+func StartHeartbeat(proj *project.Project, experimentID string, pid int) *HeartbeatProcess {
+	conf := proj.HeartbeatConfig()
 	h := &HeartbeatProcess{
-		project:      proj,
-		experimentID: experimentID,
-		ticker:       time.NewTicker(5 * time.Second),
-		done:         make(chan struct{}),
+		project:   proj,
+		heartbeat: project.NewHeartbeat(experimentID, pid, conf),
+		ticker:    time.NewTicker(conf.IntervalDuration()),
+		done:      make(chan struct{}),
+		// the lag of the first heartbeat is from when heartbeats started
+		lastRefreshed: time.Now(),
 	}
+	heartbeatsRunning.Inc()
 	go func() {
 		for {
 			select {
@@ -35,12 +46,18 @@ func StartHeartbeat(proj *project.Project, experimentID string) *HeartbeatProces
 }
 
 func (h *HeartbeatProcess) Refresh() {
-	if err := h.project.RefreshHeartbeat(h.experimentID); err != nil {
-		console.Error("Failed to refresh heartbeat: %v", err)
+	if err := h.project.RefreshHeartbeat(h.heartbeat); err != nil {
+		heartbeatsTotal.WithLabelValues("error").Inc()
+		console.WithField("experiment_id", h.heartbeat.ExperimentID).Error("Failed to refresh heartbeat: %v", err)
+		return
 	}
+	heartbeatsTotal.WithLabelValues("success").Inc()
+	heartbeatLag.Observe(time.Since(h.lastRefreshed).Seconds())
+	h.lastRefreshed = time.Now()
 }
 
 func (h *HeartbeatProcess) Kill() {
 	h.ticker.Stop()
 	h.done <- struct{}{}
+	heartbeatsRunning.Dec()
 }
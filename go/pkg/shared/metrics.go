@@ -0,0 +1,94 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/metrics"
+)
+
+var (
+	requestsTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "keepsake_daemon_requests_total",
+		Help: "Number of requests to the daemon, by method and gRPC status code.",
+	}, []string{"method", "code"})
+	requestDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "keepsake_daemon_request_duration_seconds",
+		Help:    "How long the daemon takes to respond to requests, by method.",
+		Buckets: metrics.DefaultBuckets,
+	}, []string{"method"})
+
+	// workQueue holds the *Session whose queue workQueueLength measures
+	workQueue       atomic.Value
+	workQueueLength = promauto.With(metrics.Registry).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "keepsake_daemon_work_queue_length",
+		Help: "Number of uploads and other work waiting in the daemon's queue, including the work being done now.",
+	}, func() float64 {
+		if session, ok := workQueue.Load().(*Session); ok {
+			return float64(session.queueLength())
+		}
+		return 0
+	})
+	workTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "keepsake_daemon_work_total",
+		Help: "Number of uploads and other work done from the daemon's queue, by whether it succeeded or failed.",
+	}, []string{"result"})
+	workDuration = promauto.With(metrics.Registry).NewHistogram(prometheus.HistogramOpts{
+		Name:    "keepsake_daemon_work_duration_seconds",
+		Help:    "How long each upload or other work in the daemon's queue takes.",
+		Buckets: metrics.DefaultBuckets,
+	})
+
+	heartbeatsRunning = promauto.With(metrics.Registry).NewGauge(prometheus.GaugeOpts{
+		Name: "keepsake_daemon_heartbeats_running",
+		Help: "Number of experiments the daemon is writing heartbeats for.",
+	})
+	heartbeatsTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "keepsake_daemon_heartbeats_total",
+		Help: "Number of heartbeats written, by whether they succeeded or failed.",
+	}, []string{"result"})
+	heartbeatLag = promauto.With(metrics.Registry).NewHistogram(prometheus.HistogramOpts{
+		Name:    "keepsake_daemon_heartbeat_lag_seconds",
+		Help:    "Time since the previous heartbeat of the same experiment was written, when a heartbeat is written. It is the heartbeat interval unless heartbeats are slow or failing.",
+		Buckets: []float64{1, 2.5, 5, 7.5, 10, 15, 30, 60, 120, 300},
+	})
+)
+
+// metricsInterceptor records the number and duration of requests
+func metricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	method := path.Base(info.FullMethod)
+	requestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// serveMetrics serves the metrics at http://<address>/metrics in the
+// background
+func serveMetrics(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("Failed to listen for metrics requests on %s: %w", address, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	console.Debug("Serving metrics at http://%s/metrics", listener.Addr())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			console.Warn("Stopped serving metrics: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,207 @@
+package shared
+
+//go:generate go run ../../cmd/keepsake-openapi ../../../web/public/openapi.json
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schema is a JSON schema in an OpenAPI document
+type schema map[string]interface{}
+
+// OpenAPI returns an OpenAPI 3 document that describes the HTTP/JSON gateway.
+// It is generated from the daemon's protobuf service, with the same field
+// names and types that the gateway uses to encode messages as JSON.
+func OpenAPI() ([]byte, error) {
+	service := daemonServiceDescriptor()
+	paths := map[string]interface{}{}
+	schemas := map[string]interface{}{
+		"Status": schema{
+			"type":        "object",
+			"description": "An error. code is a gRPC status code, and for errors from Keepsake, details has a google.rpc.ErrorInfo with a reason like DOES_NOT_EXIST.",
+			"properties": schema{
+				"code":    schema{"type": "integer", "format": "int32"},
+				"message": schema{"type": "string"},
+				"details": schema{
+					"type": "array",
+					"items": schema{
+						"type":                 "object",
+						"properties":           schema{"@type": schema{"type": "string"}},
+						"additionalProperties": true,
+					},
+				},
+			},
+		},
+	}
+
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		addMessageSchemas(schemas, method.Input())
+		addMessageSchemas(schemas, method.Output())
+		success := map[string]interface{}{
+			"description": "Success",
+			"content":     jsonContent(method.Output()),
+		}
+		if method.IsStreamingServer() {
+			success = map[string]interface{}{
+				"description": "Success. Each line of the response is a reply, or an error if the method fails after it has started replying.",
+				"content": map[string]interface{}{
+					"application/x-ndjson": map[string]interface{}{"schema": schema{
+						"type": "object",
+						"properties": schema{
+							"result": messageSchemaRef(method.Output()),
+							"error":  schemaRef("Status"),
+						},
+					}},
+				},
+			}
+		}
+		paths[GatewayPathPrefix+string(method.Name())] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": string(method.Name()),
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content":  jsonContent(method.Input()),
+				},
+				"responses": map[string]interface{}{
+					"200": success,
+					"default": map[string]interface{}{
+						"description": "Error",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": schemaRef("Status")},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Keepsake daemon",
+			"description": fmt.Sprintf("The HTTP/JSON version of the %s gRPC service, which is what the Keepsake libraries use to save experiments. Start the daemon with --http-address to serve it. Requests must have the token from $KEEPSAKE_HTTP_TOKEN, or the file <socket-path>.http-token, as a bearer token.", service.FullName()),
+			"version":     "1",
+		},
+		"paths":    paths,
+		"security": []interface{}{map[string]interface{}{"token": []interface{}{}}},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"token": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func jsonContent(message protoreflect.MessageDescriptor) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": messageSchemaRef(message)},
+	}
+}
+
+func schemaRef(name string) schema {
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+func messageSchemaName(message protoreflect.MessageDescriptor) string {
+	return string(message.Name())
+}
+
+func messageSchemaRef(message protoreflect.MessageDescriptor) schema {
+	return schemaRef(messageSchemaName(message))
+}
+
+// addMessageSchemas adds the schema of message, and the messages it refers
+// to, to schemas
+func addMessageSchemas(schemas map[string]interface{}, message protoreflect.MessageDescriptor) {
+	name := messageSchemaName(message)
+	if _, ok := schemas[name]; ok {
+		return
+	}
+	properties := schema{}
+	s := schema{"type": "object", "properties": properties}
+	schemas[name] = s
+
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		properties[string(field.Name())] = fieldSchema(schemas, field)
+	}
+
+	oneofs := message.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneof := oneofs.Get(i)
+		names := []string{}
+		for j := 0; j < oneof.Fields().Len(); j++ {
+			names = append(names, string(oneof.Fields().Get(j).Name()))
+		}
+		s["description"] = fmt.Sprintf("Only one of %s is set.", strings.Join(names, ", "))
+	}
+}
+
+func fieldSchema(schemas map[string]interface{}, field protoreflect.FieldDescriptor) schema {
+	if field.IsMap() {
+		return schema{
+			"type":                 "object",
+			"additionalProperties": singularFieldSchema(schemas, field.MapValue()),
+		}
+	}
+	if field.IsList() {
+		return schema{
+			"type":  "array",
+			"items": singularFieldSchema(schemas, field),
+		}
+	}
+	return singularFieldSchema(schemas, field)
+}
+
+// singularFieldSchema returns the schema of one of field's values. The types
+// are how protobuf encodes them as JSON, so for example 64-bit integers are
+// strings.
+func singularFieldSchema(schemas map[string]interface{}, field protoreflect.FieldDescriptor) schema {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return schema{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return schema{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return schema{"type": "integer", "format": "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return schema{"type": "string", "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return schema{"type": "string", "format": "uint64"}
+	case protoreflect.FloatKind:
+		return schema{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return schema{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		return schema{"type": "string"}
+	case protoreflect.BytesKind:
+		return schema{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		values := []string{}
+		for i := 0; i < field.Enum().Values().Len(); i++ {
+			values = append(values, string(field.Enum().Values().Get(i).Name()))
+		}
+		return schema{"type": "string", "enum": values}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		message := field.Message()
+		if message.FullName() == "google.protobuf.Timestamp" {
+			return schema{"type": "string", "format": "date-time"}
+		}
+		addMessageSchemas(schemas, message)
+		return messageSchemaRef(message)
+	}
+	panic(fmt.Sprintf("Unknown protobuf kind: %s", field.Kind()))
+}
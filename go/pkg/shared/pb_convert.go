@@ -2,7 +2,10 @@ package shared
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
+	dto "github.com/prometheus/client_model/go"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/replicate/keepsake/go/pkg/config"
@@ -32,6 +35,11 @@ func checkpointFromPb(chkPb *servicepb.Checkpoint) *project.Checkpoint {
 		Step:          chkPb.Step,
 		Path:          chkPb.Path,
 		PrimaryMetric: primaryMetricFromPb(chkPb.PrimaryMetric),
+		Tags:          chkPb.Tags,
+		Pruned:        chkPb.Pruned,
+		Trashed:       chkPb.Trashed,
+		Size:          chkPb.Size,
+		SHA256:        chkPb.Sha256,
 	}
 }
 
@@ -49,9 +57,31 @@ func experimentFromPb(expPb *servicepb.Experiment) *project.Experiment {
 		PythonVersion:   expPb.PythonVersion,
 		Checkpoints:     checkpointsFromPb(expPb.Checkpoints),
 		KeepsakeVersion: expPb.KeepsakeVersion,
+		Parents:         parentsFromPb(expPb.Parents),
+		Size:            expPb.Size,
+		SHA256:          expPb.Sha256,
 	}
 }
 
+func parentsFromPb(parentsPb []*servicepb.ParentRef) []*project.ParentRef {
+	if len(parentsPb) == 0 {
+		return nil
+	}
+	ret := make([]*project.ParentRef, len(parentsPb))
+	for i, parentPb := range parentsPb {
+		kind := project.ParentKindParent
+		if parentPb.Kind == servicepb.ParentRef_RERUN {
+			kind = project.ParentKindRerun
+		}
+		ret[i] = &project.ParentRef{
+			ExperimentID: parentPb.ExperimentID,
+			CheckpointID: parentPb.CheckpointID,
+			Kind:         kind,
+		}
+	}
+	return ret
+}
+
 func configFromPb(confPb *servicepb.Config) *config.Config {
 	var conf *config.Config
 	if confPb != nil {
@@ -129,9 +159,31 @@ func experimentToPb(exp *project.Experiment) *servicepb.Experiment {
 		PythonVersion:   exp.PythonVersion,
 		KeepsakeVersion: exp.KeepsakeVersion,
 		Checkpoints:     checkpointsToPb(exp.Checkpoints),
+		Parents:         parentsToPb(exp.Parents),
+		Size:            exp.Size,
+		Sha256:          exp.SHA256,
 	}
 }
 
+func parentsToPb(parents []*project.ParentRef) []*servicepb.ParentRef {
+	if parents == nil {
+		return nil
+	}
+	ret := make([]*servicepb.ParentRef, len(parents))
+	for i, parent := range parents {
+		kind := servicepb.ParentRef_PARENT
+		if parent.Kind == project.ParentKindRerun {
+			kind = servicepb.ParentRef_RERUN
+		}
+		ret[i] = &servicepb.ParentRef{
+			ExperimentID: parent.ExperimentID,
+			CheckpointID: parent.CheckpointID,
+			Kind:         kind,
+		}
+	}
+	return ret
+}
+
 func configToPb(conf *config.Config) *servicepb.Config {
 	if conf == nil {
 		return nil
@@ -164,6 +216,11 @@ func checkpointToPb(chk *project.Checkpoint) *servicepb.Checkpoint {
 		Metrics:       valueMapToPb(chk.Metrics),
 		Path:          chk.Path,
 		PrimaryMetric: primaryMetricToPb(chk.PrimaryMetric),
+		Tags:          chk.Tags,
+		Pruned:        chk.Pruned,
+		Trashed:       chk.Trashed,
+		Size:          chk.Size,
+		Sha256:        chk.SHA256,
 	}
 }
 
@@ -215,3 +272,78 @@ func valueToPb(v param.Value) *servicepb.ParamType {
 	}
 	panic("Uninitiazlied param.Value") // should never happen
 }
+
+// metricsToPb converts metric families gathered from a Prometheus registry.
+// Like the Prometheus text format, histograms have a sample for each bucket
+// with the suffix _bucket, then samples with the suffixes _sum and _count.
+func metricsToPb(families []*dto.MetricFamily) []*servicepb.Metric {
+	pbMetrics := make([]*servicepb.Metric, len(families))
+	for i, family := range families {
+		samples := []*servicepb.MetricSample{}
+		for _, metric := range family.Metric {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = append(samples, metricSampleToPb(family.GetName(), metric.Label, metric.GetCounter().GetValue()))
+			case dto.MetricType_GAUGE:
+				samples = append(samples, metricSampleToPb(family.GetName(), metric.Label, metric.GetGauge().GetValue()))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				for _, bucket := range histogram.Bucket {
+					le := strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64)
+					sample := metricSampleToPb(family.GetName()+"_bucket", metric.Label, float64(bucket.GetCumulativeCount()))
+					sample.Labels["le"] = le
+					samples = append(samples, sample)
+				}
+				sample := metricSampleToPb(family.GetName()+"_bucket", metric.Label, float64(histogram.GetSampleCount()))
+				sample.Labels["le"] = "+Inf"
+				samples = append(samples,
+					sample,
+					metricSampleToPb(family.GetName()+"_sum", metric.Label, histogram.GetSampleSum()),
+					metricSampleToPb(family.GetName()+"_count", metric.Label, float64(histogram.GetSampleCount())))
+			}
+		}
+		pbMetrics[i] = &servicepb.Metric{
+			Name:    family.GetName(),
+			Help:    family.GetHelp(),
+			Type:    strings.ToLower(family.GetType().String()),
+			Samples: samples,
+		}
+	}
+	return pbMetrics
+}
+
+func metricSampleToPb(name string, labelPairs []*dto.LabelPair, value float64) *servicepb.MetricSample {
+	labels := map[string]string{}
+	for _, label := range labelPairs {
+		labels[label.GetName()] = label.GetValue()
+	}
+	return &servicepb.MetricSample{Name: name, Labels: labels, Value: value}
+}
+
+func watchEventToPb(event *project.WatchEvent) *servicepb.WatchExperimentsReply {
+	var eventType servicepb.WatchExperimentsReply_Type
+	switch event.Type {
+	case project.WatchEventReset:
+		eventType = servicepb.WatchExperimentsReply_RESET
+	case project.WatchEventCreated:
+		eventType = servicepb.WatchExperimentsReply_CREATED
+	case project.WatchEventCheckpointAdded:
+		eventType = servicepb.WatchExperimentsReply_CHECKPOINT_ADDED
+	case project.WatchEventStatusChanged:
+		eventType = servicepb.WatchExperimentsReply_STATUS_CHANGED
+	case project.WatchEventDeleted:
+		eventType = servicepb.WatchExperimentsReply_DELETED
+	}
+	reply := &servicepb.WatchExperimentsReply{
+		Type:         eventType,
+		Cursor:       event.Cursor,
+		Time:         timestamppb.New(event.Time),
+		ExperimentID: event.ExperimentID,
+		Checkpoint:   checkpointToPb(event.Checkpoint),
+		Running:      event.Running,
+	}
+	if event.Experiment != nil {
+		reply.Experiment = experimentToPb(event.Experiment)
+	}
+	return reply
+}
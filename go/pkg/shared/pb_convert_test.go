@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -32,6 +34,11 @@ func fullCheckpointPb() *servicepb.Checkpoint {
 			Name: "myfloat",
 			Goal: servicepb.PrimaryMetric_MAXIMIZE,
 		},
+		Tags:    []string{"best", "release"},
+		Pruned:  true,
+		Trashed: true,
+		Size:    1024,
+		Sha256:  "abc123",
 	}
 }
 
@@ -51,6 +58,11 @@ func fullCheckpoint() *project.Checkpoint {
 			"mymap":    param.Object(map[string]interface{}{"bar": "baz"}),
 		},
 		PrimaryMetric: &project.PrimaryMetric{Name: "myfloat", Goal: "maximize"},
+		Tags:          []string{"best", "release"},
+		Pruned:        true,
+		Trashed:       true,
+		Size:          1024,
+		SHA256:        "abc123",
 	}
 }
 
@@ -103,6 +115,13 @@ func fullExperimentPb() *servicepb.Experiment {
 				Step:    2,
 			},
 		},
+		Parents: []*servicepb.ParentRef{
+			{ExperimentID: "baz"},
+			{ExperimentID: "qux", CheckpointID: "c4"},
+			{ExperimentID: "bar", CheckpointID: "c3", Kind: servicepb.ParentRef_RERUN},
+		},
+		Size:   2048,
+		Sha256: "def456",
 	}
 }
 
@@ -131,6 +150,13 @@ func fullExperiment() *project.Experiment {
 			{ID: "c1", Created: t.Add(time.Minute * 1), Step: 1},
 			{ID: "c2", Created: t.Add(time.Minute * 2), Step: 2},
 		},
+		Parents: []*project.ParentRef{
+			{ExperimentID: "baz"},
+			{ExperimentID: "qux", CheckpointID: "c4"},
+			{ExperimentID: "bar", CheckpointID: "c3", Kind: project.ParentKindRerun},
+		},
+		Size:   2048,
+		SHA256: "def456",
 	}
 }
 
@@ -195,3 +221,29 @@ func TestConvertEmptyExperimentToPb(t *testing.T) {
 	expected := emptyExperimentPb()
 	require.Equal(t, expected, experimentToPb(exp))
 }
+
+func TestConvertMetricsToPb(t *testing.T) {
+	// use a registry of its own, so it isn't affected by the daemon's metrics
+	registry := prometheus.NewRegistry()
+	promauto.With(registry).NewCounterVec(prometheus.CounterOpts{Name: "test_work_total", Help: "Work done."}, []string{"result"}).WithLabelValues("success").Add(3)
+	promauto.With(registry).NewHistogram(prometheus.HistogramOpts{Name: "test_work_duration_seconds", Help: "How long work takes.", Buckets: []float64{1}}).Observe(0.5)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	require.Equal(t, []*servicepb.Metric{{
+		Name: "test_work_duration_seconds",
+		Help: "How long work takes.",
+		Type: "histogram",
+		Samples: []*servicepb.MetricSample{
+			{Name: "test_work_duration_seconds_bucket", Labels: map[string]string{"le": "1"}, Value: 1},
+			{Name: "test_work_duration_seconds_bucket", Labels: map[string]string{"le": "+Inf"}, Value: 1},
+			{Name: "test_work_duration_seconds_sum", Labels: map[string]string{}, Value: 0.5},
+			{Name: "test_work_duration_seconds_count", Labels: map[string]string{}, Value: 1},
+		},
+	}, {
+		Name:    "test_work_total",
+		Help:    "Work done.",
+		Type:    "counter",
+		Samples: []*servicepb.MetricSample{{Name: "test_work_total", Labels: map[string]string{"result": "success"}, Value: 3}},
+	}}, metricsToPb(families))
+}
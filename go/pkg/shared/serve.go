@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 
 	"github.com/replicate/keepsake/go/pkg/console"
 	"github.com/replicate/keepsake/go/pkg/errors"
+	"github.com/replicate/keepsake/go/pkg/metrics"
 	"github.com/replicate/keepsake/go/pkg/project"
 	"github.com/replicate/keepsake/go/pkg/servicepb"
 )
@@ -27,10 +29,13 @@ type projectGetter func() (proj *project.Project, err error)
 type server struct {
 	servicepb.UnimplementedDaemonServer
 
-	workChan                 chan func() error
-	projectGetter            projectGetter
-	project                  *project.Project
-	heartbeatsByExperimentID map[string]*HeartbeatProcess
+	projectGetter projectGetter
+
+	// projectLock protects project and session, which are created by the
+	// first request
+	projectLock sync.Mutex
+	project     *project.Project
+	session     *Session
 }
 
 func (s *server) CreateExperiment(ctx context.Context, req *servicepb.CreateExperimentRequest) (*servicepb.CreateExperimentReply, error) {
@@ -41,18 +46,16 @@ func (s *server) CreateExperiment(ctx context.Context, req *servicepb.CreateExpe
 		Params:         valueMapFromPb(pbReqExp.GetParams()),
 		PythonPackages: pbReqExp.GetPythonPackages(),
 		PythonVersion:  pbReqExp.GetPythonVersion(),
+		Parents:        parentsFromPb(pbReqExp.GetParents()),
 	}
-	proj, err := s.getProject()
+	session, err := s.getSession()
 	if err != nil {
 		return nil, handleError(err)
 	}
-	exp, err := proj.CreateExperiment(args, true, s.workChan, req.Quiet)
+	exp, err := session.CreateExperiment(args, int(req.Pid), req.DisableHeartbeat, req.Quiet)
 	if err != nil {
 		return nil, handleError(err)
 	}
-	if !req.DisableHeartbeat {
-		s.heartbeatsByExperimentID[exp.ID] = StartHeartbeat(s.project, exp.ID)
-	}
 
 	pbRetExp := experimentToPb(exp)
 	return &servicepb.CreateExperimentReply{Experiment: pbRetExp}, nil
@@ -65,12 +68,13 @@ func (s *server) CreateCheckpoint(ctx context.Context, req *servicepb.CreateChec
 		Metrics:       valueMapFromPb(pbReqChk.GetMetrics()),
 		PrimaryMetric: primaryMetricFromPb(pbReqChk.PrimaryMetric),
 		Step:          pbReqChk.GetStep(),
+		Tags:          pbReqChk.GetTags(),
 	}
-	proj, err := s.getProject()
+	session, err := s.getSession()
 	if err != nil {
 		return nil, handleError(err)
 	}
-	chk, err := proj.CreateCheckpoint(args, true, s.workChan, req.Quiet)
+	chk, err := session.CreateCheckpoint(args, req.Quiet)
 	if err != nil {
 		return nil, handleError(err)
 	}
@@ -82,11 +86,11 @@ func (s *server) CreateCheckpoint(ctx context.Context, req *servicepb.CreateChec
 func (s *server) SaveExperiment(ctx context.Context, req *servicepb.SaveExperimentRequest) (*servicepb.SaveExperimentReply, error) {
 	expPb := req.GetExperiment()
 	exp := experimentFromPb(expPb)
-	proj, err := s.getProject()
+	session, err := s.getSession()
 	if err != nil {
 		return nil, handleError(err)
 	}
-	exp, err = proj.SaveExperiment(exp, req.Quiet)
+	exp, err = session.SaveExperiment(exp, req.Quiet)
 	if err != nil {
 		return nil, handleError(err)
 	}
@@ -94,15 +98,11 @@ func (s *server) SaveExperiment(ctx context.Context, req *servicepb.SaveExperime
 }
 
 func (s *server) StopExperiment(ctx context.Context, req *servicepb.StopExperimentRequest) (*servicepb.StopExperimentReply, error) {
-	if _, ok := s.heartbeatsByExperimentID[req.ExperimentID]; ok {
-		s.heartbeatsByExperimentID[req.ExperimentID].Kill()
-		delete(s.heartbeatsByExperimentID, req.ExperimentID)
-	}
-	proj, err := s.getProject()
+	session, err := s.getSession()
 	if err != nil {
 		return nil, handleError(err)
 	}
-	if err := proj.StopExperiment(req.ExperimentID); err != nil {
+	if err := session.StopExperiment(req.ExperimentID, req.Error); err != nil {
 		return nil, handleError(err)
 	}
 	return &servicepb.StopExperimentReply{}, nil
@@ -143,9 +143,17 @@ func (s *server) DeleteExperiment(ctx context.Context, req *servicepb.DeleteExpe
 	if err != nil {
 		return nil, handleError(err)
 	}
+	if !req.Permanent {
+		if err := s.project.TrashExperiment(exp); err != nil {
+			return nil, handleError(err)
+		}
+		s.session.Watcher().ExperimentDeleted(exp.ID)
+		return &servicepb.DeleteExperimentReply{}, nil
+	}
 	if err := s.project.DeleteExperiment(exp); err != nil {
 		return nil, handleError(err)
 	}
+	s.session.Watcher().ExperimentDeleted(exp.ID)
 	// This is slow, see https://github.com/replicate/keepsake/issues/333
 	for _, checkpoint := range exp.Checkpoints {
 		if err := s.project.DeleteCheckpoint(checkpoint); err != nil {
@@ -173,6 +181,14 @@ func (s *server) CheckoutCheckpoint(ctx context.Context, req *servicepb.Checkout
 	return &servicepb.CheckoutCheckpointReply{}, nil
 }
 
+func (s *server) GetDaemonStatus(ctx context.Context, req *servicepb.GetDaemonStatusRequest) (*servicepb.GetDaemonStatusReply, error) {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		return nil, handleError(err)
+	}
+	return &servicepb.GetDaemonStatusReply{Metrics: metricsToPb(families)}, nil
+}
+
 func (s *server) GetExperimentStatus(ctx context.Context, req *servicepb.GetExperimentStatusRequest) (*servicepb.GetExperimentStatusReply, error) {
 	proj, err := s.getProject()
 	if err != nil {
@@ -191,9 +207,36 @@ func (s *server) GetExperimentStatus(ctx context.Context, req *servicepb.GetExpe
 	return &servicepb.GetExperimentStatusReply{Status: status}, nil
 }
 
+func (s *server) WatchExperiments(req *servicepb.WatchExperimentsRequest, stream servicepb.Daemon_WatchExperimentsServer) error {
+	watcher, err := s.getWatcher()
+	if err != nil {
+		return handleError(err)
+	}
+	sub, err := watcher.Subscribe(req.Cursor)
+	if err != nil {
+		return handleError(err)
+	}
+	defer sub.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return status.Error(codes.ResourceExhausted, sub.Err().Error())
+			}
+			if err := stream.Send(watchEventToPb(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (s *server) getProject() (*project.Project, error) {
 	// we get the project lazily so that we can return a protobuf exception to the client
 	// as part of a request flow
+	s.projectLock.Lock()
+	defer s.projectLock.Unlock()
 
 	if s.project != nil {
 		return s.project, nil
@@ -204,31 +247,70 @@ func (s *server) getProject() (*project.Project, error) {
 		return nil, err
 	}
 	s.project = proj
+	s.session = NewSession(proj)
+	workQueue.Store(s.session)
 	return proj, nil
 }
 
-func Serve(projGetter projectGetter, socketPath string) error {
+func (s *server) getSession() (*Session, error) {
+	if _, err := s.getProject(); err != nil {
+		return nil, err
+	}
+	return s.session, nil
+}
+
+// getWatcher returns the watcher that WatchExperiments subscribes to,
+// creating it if needed
+func (s *server) getWatcher() (*project.Watcher, error) {
+	session, err := s.getSession()
+	if err != nil {
+		return nil, err
+	}
+	s.projectLock.Lock()
+	defer s.projectLock.Unlock()
+	if watcher := session.Watcher(); watcher != nil {
+		return watcher, nil
+	}
+	// The watcher reads the repository with a project of its own, because
+	// s.project is used by requests at the same time
+	proj, err := s.projectGetter()
+	if err != nil {
+		return nil, err
+	}
+	watcher := project.NewWatcher(proj, project.DefaultWatchInterval)
+	session.SetWatcher(watcher)
+	return watcher, nil
+}
+
+// Serve runs the daemon on a UNIX socket. If metricsAddress isn't empty, the
+// daemon's metrics are served at http://<metricsAddress>/metrics. If
+// httpAddress isn't empty, the same methods as on the socket are served as
+// HTTP/JSON at http://<httpAddress>/v1/, to requests with httpToken. If
+// httpToken is empty, a random one is written to <socketPath>.http-token.
+func Serve(projGetter projectGetter, socketPath string, metricsAddress string, httpAddress string, httpToken string) error {
 	console.Debug("Starting daemon")
 
+	if metricsAddress != "" {
+		if err := serveMetrics(metricsAddress); err != nil {
+			return err
+		}
+	}
+
 	listener, err := net.Listen("unix", socketPath)
 	if err != nil {
 		return fmt.Errorf("Failed to open UNIX socket on %s: %w", socketPath, err)
 	}
 
-	grpcServer := grpc.NewServer()
-	s := &server{
-		// block if there already are two items on the queue, in case uploading is a bottleneck
-		// TODO(andreas): warn the user if the queue is full, so they know that they should
-		// upload at a lesser interval
-		workChan:                 make(chan func() error, 2),
-		projectGetter:            projGetter,
-		heartbeatsByExperimentID: make(map[string]*HeartbeatProcess),
-	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(metricsInterceptor))
+	s := &server{projectGetter: projGetter}
 	servicepb.RegisterDaemonServer(grpcServer, s)
-
-	// when the process exits, make sure any pending
-	// uploads are completed
-	completedChan := make(chan struct{})
+	tokenPath := ""
+	if httpAddress != "" {
+		tokenPath, err = serveGateway(httpAddress, httpToken, socketPath+GatewayTokenSuffix, s)
+		if err != nil {
+			return err
+		}
+	}
 
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc,
@@ -237,52 +319,25 @@ func Serve(projGetter projectGetter, socketPath string) error {
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	// when the process exits, make sure any pending
+	// uploads are completed
 	go func() {
 		<-sigc
 		console.Debug("Exiting...")
-		s.workChan <- nil // nil is an exit sentinel
-
-		// Wait a short sec so completedChan gets filled if workChan is empty. (Surely there's a more elegant way to do this.)
-		time.Sleep(1 * time.Millisecond)
 
-		select {
-		case <-completedChan:
-			console.Debug("No work left to do, exiting immediately")
-		// Wait a sec so the log messages displays after KeyboardInterrupt traceback from Python.
-		// If tasks complete within this time, then previous case will be selected and message will never be displayed.
-		// Anything slower than this and Python seems to beat it. This isn't a perfect solution,
-		// but it's just to make a log message prettier so it doesn't need to be perfect.
-		case <-time.After(250 * time.Millisecond):
-			console.Info("Your program has ended, but Keepsake is still saving data. It will exit when it has finished. Hold on...")
-			select {
-			case <-completedChan:
-				console.Debug("Work completed")
-			case <-time.After(5 * time.Second):
-				console.Info("Keepsake is still saving. If you force quit, you might lose data.")
-				<-completedChan
-			}
+		s.projectLock.Lock()
+		session := s.session
+		s.projectLock.Unlock()
+		if session != nil {
+			waitForWork(session.finish())
+			session.Close()
 		}
-
-		for _, hb := range s.heartbeatsByExperimentID {
-			hb.Kill()
+		if tokenPath != "" {
+			os.Remove(tokenPath)
 		}
 		grpcServer.Stop()
 	}()
 
-	go func() {
-		for {
-			work := <-s.workChan
-			if work == nil {
-				completedChan <- struct{}{}
-				return
-			}
-			if err := work(); err != nil {
-				console.Error("%v", err)
-				// TODO(andreas): poll status endpoint, put errors in chan of messages to return. also include progress in these messages
-			}
-		}
-	}()
-
 	if err := grpcServer.Serve(listener); err != nil {
 		return fmt.Errorf("Failed to start server: %w", err)
 	}
@@ -290,6 +345,28 @@ func Serve(projGetter projectGetter, socketPath string) error {
 	return nil
 }
 
+// waitForWork waits until done is closed, telling the user what is happening
+// if it takes a while
+func waitForWork(done <-chan struct{}) {
+	select {
+	case <-done:
+		console.Debug("No work left to do, exiting immediately")
+	// Wait a sec so the log messages displays after KeyboardInterrupt traceback from Python.
+	// If tasks complete within this time, then previous case will be selected and message will never be displayed.
+	// Anything slower than this and Python seems to beat it. This isn't a perfect solution,
+	// but it's just to make a log message prettier so it doesn't need to be perfect.
+	case <-time.After(250 * time.Millisecond):
+		console.Info("Your program has ended, but Keepsake is still saving data. It will exit when it has finished. Hold on...")
+		select {
+		case <-done:
+			console.Debug("Work completed")
+		case <-time.After(5 * time.Second):
+			console.Info("Keepsake is still saving. If you force quit, you might lose data.")
+			<-done
+		}
+	}
+}
+
 func handleError(err error) error {
 	reason := errors.Code(err)
 	if reason != "" {
@@ -0,0 +1,407 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/replicate/keepsake/go/pkg/config"
+	"github.com/replicate/keepsake/go/pkg/console"
+	"github.com/replicate/keepsake/go/pkg/hooks"
+	"github.com/replicate/keepsake/go/pkg/project"
+)
+
+// Session saves the experiments of a training process to a project. Files are
+// uploaded in the background, heartbeats are written while experiments run,
+// the retention policy is applied as checkpoints are saved, and hooks are run
+// when things happen to experiments.
+//
+// The daemon that the Python library talks to has a session, and so does
+// each project opened with the Go library.
+type Session struct {
+	project *project.Project
+
+	workChan chan func() error
+	// workDone is closed when the work loop has exited
+	workDone   chan struct{}
+	finishOnce sync.Once
+	// queueLock is held for reading while work is queued, and for writing
+	// while the session is closed, so nothing is queued after the work loop
+	// has been told to exit
+	queueLock sync.RWMutex
+
+	// working is 1 while work from workChan is being done, for metrics
+	working int32
+
+	// warnRetentionSkipped warns once that the retention policy isn't applied
+	// to repositories that files can't be deleted from
+	warnRetentionSkipped sync.Once
+
+	// hooks runs the hooks in keepsake.yaml. It is nil if there aren't any.
+	hooks *hooks.Runner
+
+	// lock protects the state below
+	lock                     sync.Mutex
+	closed                   bool
+	heartbeatsByExperimentID map[string]*HeartbeatProcess
+	// pendingCheckpointIDs are checkpoints that have been created, but whose
+	// experiment hasn't been saved with them yet
+	pendingCheckpointIDs map[string]bool
+	// experimentsByID is what hook events are made from
+	experimentsByID map[string]*project.Experiment
+	// workErr is the first error from the work done since Flush was last
+	// called
+	workErr error
+	// watcher is told about the changes the session makes, if it is set
+	watcher *project.Watcher
+}
+
+// NewSession creates a session for proj, and starts doing its work in the
+// background
+func NewSession(proj *project.Project) *Session {
+	s := &Session{
+		project: proj,
+		// block if there already are two items on the queue, in case uploading is a bottleneck
+		// TODO(andreas): warn the user if the queue is full, so they know that they should
+		// upload at a lesser interval
+		workChan:                 make(chan func() error, 2),
+		workDone:                 make(chan struct{}),
+		heartbeatsByExperimentID: make(map[string]*HeartbeatProcess),
+		pendingCheckpointIDs:     make(map[string]bool),
+		experimentsByID:          make(map[string]*project.Experiment),
+	}
+	if proj.Hooks() != nil {
+		s.hooks = hooks.NewRunner(proj.Hooks(), proj.Directory())
+	}
+	go s.work()
+	return s
+}
+
+// Project returns the project the session saves to
+func (s *Session) Project() *project.Project {
+	return s.project
+}
+
+// SetWatcher makes the session tell w about the changes it makes to
+// experiments, so subscribers find out about them straight away rather than
+// when w next reads the repository
+func (s *Session) SetWatcher(w *project.Watcher) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.watcher = w
+}
+
+// Watcher returns the watcher set with SetWatcher, or nil
+func (s *Session) Watcher() *project.Watcher {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.watcher
+}
+
+func (s *Session) work() {
+	for {
+		work := <-s.workChan
+		if work == nil { // nil is an exit sentinel
+			close(s.workDone)
+			return
+		}
+		atomic.StoreInt32(&s.working, 1)
+		start := time.Now()
+		err := work()
+		workDuration.Observe(time.Since(start).Seconds())
+		atomic.StoreInt32(&s.working, 0)
+		if err != nil {
+			workTotal.WithLabelValues("error").Inc()
+			console.Error("%v", err)
+			// TODO(andreas): poll status endpoint, put errors in chan of messages to return. also include progress in these messages
+			s.lock.Lock()
+			if s.workErr == nil {
+				s.workErr = err
+			}
+			s.lock.Unlock()
+		} else {
+			workTotal.WithLabelValues("success").Inc()
+		}
+	}
+}
+
+// queueLength is the number of pieces of work waiting in the queue,
+// including the one being done now
+func (s *Session) queueLength() int {
+	return len(s.workChan) + int(atomic.LoadInt32(&s.working))
+}
+
+func (s *Session) checkNotClosed() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return fmt.Errorf("Keepsake has been closed, so nothing more can be saved")
+	}
+	return nil
+}
+
+// withQueue calls fn with the work queue, if the session hasn't been closed.
+// Nothing takes work from the queue after the session has been closed, so it
+// returns an error then instead of letting fn block forever.
+func (s *Session) withQueue(fn func(workChan chan func() error) error) error {
+	s.queueLock.RLock()
+	defer s.queueLock.RUnlock()
+	if err := s.checkNotClosed(); err != nil {
+		return err
+	}
+	return fn(s.workChan)
+}
+
+// queueWork queues work to be done in the background
+func (s *Session) queueWork(work func() error) error {
+	return s.withQueue(func(workChan chan func() error) error {
+		workChan <- work
+		return nil
+	})
+}
+
+// CreateExperiment creates an experiment, uploading its files in the
+// background, and writes heartbeats for it until it is stopped unless
+// disableHeartbeat is true. pid is the process that runs the experiment, or
+// 0 if it is this one.
+func (s *Session) CreateExperiment(args project.CreateExperimentArgs, pid int, disableHeartbeat bool, quiet bool) (*project.Experiment, error) {
+	var exp *project.Experiment
+	err := s.withQueue(func(workChan chan func() error) (err error) {
+		exp, err = s.project.CreateExperiment(args, true, workChan, quiet)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	if !disableHeartbeat {
+		if pid == 0 {
+			pid = os.Getpid()
+		}
+		s.heartbeatsByExperimentID[exp.ID] = StartHeartbeat(s.project, exp.ID, pid)
+	}
+	s.experimentsByID[exp.ID] = exp
+	s.lock.Unlock()
+	s.Watcher().ExperimentCreated(exp, !disableHeartbeat)
+	s.recordTarballDigests(exp.ID)
+	s.fireHooks(hooks.NewEvent(config.EventExperimentStart, exp, nil))
+	return exp, nil
+}
+
+// CreateCheckpoint creates a checkpoint, uploading its files in the
+// background. It is only part of its experiment once the experiment has been
+// saved with it by SaveExperiment.
+func (s *Session) CreateCheckpoint(args project.CreateCheckpointArgs, quiet bool) (*project.Checkpoint, error) {
+	var chk *project.Checkpoint
+	err := s.withQueue(func(workChan chan func() error) (err error) {
+		chk, err = s.project.CreateCheckpoint(args, true, workChan, quiet)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The checkpoint's hooks are run when the experiment is saved with it,
+	// because that is when the checkpoint's metadata is saved
+	s.lock.Lock()
+	s.pendingCheckpointIDs[chk.ID] = true
+	s.lock.Unlock()
+	return chk, nil
+}
+
+// SaveExperiment merges exp with the copy that has been saved, saves it, and
+// applies the retention policy to its checkpoints
+func (s *Session) SaveExperiment(exp *project.Experiment, quiet bool) (*project.Experiment, error) {
+	if err := s.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	proj := s.project
+	if err := proj.MergeSavedExperiment(exp); err != nil {
+		return nil, err
+	}
+
+	// The experiment is saved after each checkpoint is created, which is
+	// when the retention policy is applied. The files are deleted after the
+	// metadata is saved, and after any pending uploads, so a checkpoint is
+	// never uploaded after it has been pruned.
+	pruned := []*project.Checkpoint{}
+	policy := proj.RetentionPolicy()
+	if policy != nil {
+		if err := proj.CheckCanDelete(); err != nil {
+			s.warnRetentionSkipped.Do(func() {
+				console.Warn("The retention policy in keepsake.yaml isn't being applied: %v", err)
+			})
+			policy = nil
+		}
+	}
+	if policy != nil {
+		pruned = exp.CheckpointsToPrune(policy)
+		for _, chk := range pruned {
+			chk.Pruned = true
+		}
+	}
+	exp, err := proj.SaveExperiment(exp, quiet)
+	if err != nil {
+		return nil, err
+	}
+	for _, chk := range pruned {
+		chk := chk
+		console.WithFields(console.Fields{"experiment_id": exp.ID, "checkpoint_id": chk.ID}).Debug("Deleting files of checkpoint %s, because of the retention policy", chk.ShortID())
+		if err := s.queueWork(func() error {
+			return proj.DeleteCheckpoint(chk)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	s.Watcher().ExperimentSaved(exp)
+	s.recordTarballDigests(exp.ID)
+	s.fireCheckpointHooks(exp)
+	return exp, nil
+}
+
+// StopExperiment stops writing heartbeats for an experiment and marks it as
+// stopped. errorMessage is the error it stopped because of, or empty if it
+// finished.
+func (s *Session) StopExperiment(experimentID string, errorMessage string) error {
+	if err := s.checkNotClosed(); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	if hb, ok := s.heartbeatsByExperimentID[experimentID]; ok {
+		hb.Kill()
+		delete(s.heartbeatsByExperimentID, experimentID)
+	}
+	s.lock.Unlock()
+	if err := s.project.StopExperiment(experimentID); err != nil {
+		return err
+	}
+	s.Watcher().ExperimentStopped(experimentID)
+	s.fireStopHooks(experimentID, errorMessage)
+	return nil
+}
+
+// Flush waits for the work that has been queued so far, and returns the
+// first error from the work done since Flush was last called
+func (s *Session) Flush() error {
+	if err := s.checkNotClosed(); err != nil {
+		return err
+	}
+	flushed := make(chan struct{})
+	if err := s.queueWork(func() error {
+		close(flushed)
+		return nil
+	}); err != nil {
+		return err
+	}
+	<-flushed
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	err := s.workErr
+	s.workErr = nil
+	return err
+}
+
+// finish stops the session taking any more work, and returns a channel that
+// is closed when the work that has been queued is done
+func (s *Session) finish() <-chan struct{} {
+	s.finishOnce.Do(func() {
+		s.queueLock.Lock()
+		s.lock.Lock()
+		s.closed = true
+		s.lock.Unlock()
+		s.queueLock.Unlock()
+		s.workChan <- nil
+	})
+	return s.workDone
+}
+
+// Close waits for the work that has been queued, then stops the heartbeats
+// of experiments that are still running, and waits for their hooks
+func (s *Session) Close() {
+	<-s.finish()
+
+	s.lock.Lock()
+	heartbeats := s.heartbeatsByExperimentID
+	s.heartbeatsByExperimentID = make(map[string]*HeartbeatProcess)
+	s.lock.Unlock()
+	for experimentID, hb := range heartbeats {
+		hb.Kill()
+		s.Watcher().ExperimentStopped(experimentID)
+		// The experiment wasn't stopped explicitly, but it has stopped
+		// now that the training script has exited
+		s.lock.Lock()
+		exp, ok := s.experimentsByID[experimentID]
+		s.lock.Unlock()
+		if ok {
+			s.hooks.Fire(hooks.NewEvent(config.EventStop, exp, nil))
+		}
+	}
+	s.hooks.Wait()
+}
+
+// recordTarballDigests saves the digests of an experiment's tarballs in its
+// metadata, after the uploads that are queued have finished
+func (s *Session) recordTarballDigests(experimentID string) {
+	if err := s.queueWork(func() error {
+		return s.project.RecordTarballDigests(experimentID)
+	}); err != nil {
+		console.WithField("experiment_id", experimentID).Warn("Tarball digests weren't recorded: %v", err)
+	}
+}
+
+// fireHooks runs the hooks for an event, after the uploads that are queued
+// have finished
+func (s *Session) fireHooks(event *hooks.Event) {
+	if s.hooks == nil || len(s.project.Hooks().ForEvent(event.Event)) == 0 {
+		return
+	}
+	if err := s.queueWork(func() error {
+		s.hooks.Fire(event)
+		return nil
+	}); err != nil {
+		console.WithField("experiment_id", event.ExperimentID).Warn("The %s hooks weren't run: %v", event.Event, err)
+	}
+}
+
+// fireCheckpointHooks runs the hooks for the checkpoints that exp has been
+// saved with for the first time
+func (s *Session) fireCheckpointHooks(exp *project.Experiment) {
+	s.lock.Lock()
+	s.experimentsByID[exp.ID] = exp
+	created := []*project.Checkpoint{}
+	for _, chk := range exp.Checkpoints {
+		if s.pendingCheckpointIDs[chk.ID] {
+			delete(s.pendingCheckpointIDs, chk.ID)
+			created = append(created, chk)
+		}
+	}
+	s.lock.Unlock()
+
+	best := exp.BestCheckpoint()
+	for _, chk := range created {
+		s.fireHooks(hooks.NewEvent(config.EventCheckpoint, exp, chk))
+		if chk == best && chk.PrimaryMetric != nil {
+			s.fireHooks(hooks.NewEvent(config.EventBestCheckpoint, exp, chk))
+		}
+	}
+}
+
+// fireStopHooks runs the on_crash hooks if the experiment stopped because of
+// an error, and the on_stop hooks otherwise
+func (s *Session) fireStopHooks(experimentID string, errorMessage string) {
+	s.lock.Lock()
+	exp, ok := s.experimentsByID[experimentID]
+	delete(s.experimentsByID, experimentID)
+	s.lock.Unlock()
+	if !ok {
+		exp = &project.Experiment{ID: experimentID}
+	}
+	if errorMessage == "" {
+		s.fireHooks(hooks.NewEvent(config.EventStop, exp, nil))
+		return
+	}
+	event := hooks.NewEvent(config.EventCrash, exp, nil)
+	event.Error = errorMessage
+	s.fireHooks(event)
+}
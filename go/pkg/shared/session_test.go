@@ -0,0 +1,34 @@
+package shared
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/replicate/keepsake/go/pkg/files"
+	"github.com/replicate/keepsake/go/pkg/project"
+	"github.com/replicate/keepsake/go/pkg/repository"
+)
+
+func TestSessionUsedAfterClose(t *testing.T) {
+	dir, err := files.TempDir("test-session")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	repo, err := repository.NewDiskRepository(dir)
+	require.NoError(t, err)
+
+	s := NewSession(project.NewProject(repo, dir))
+	exp, err := s.CreateExperiment(project.CreateExperimentArgs{Path: ""}, 0, true, true)
+	require.NoError(t, err)
+	s.Close()
+
+	// these used to block forever, because nothing takes work from the queue
+	// after the session has been closed
+	require.EqualError(t, s.StopExperiment(exp.ID, ""), "Keepsake has been closed, so nothing more can be saved")
+	require.Error(t, s.Flush())
+	_, err = s.CreateCheckpoint(project.CreateCheckpointArgs{Path: ""}, true)
+	require.Error(t, err)
+	_, err = s.CreateExperiment(project.CreateExperimentArgs{Path: ""}, 0, true, true)
+	require.Error(t, err)
+}
@@ -0,0 +1,512 @@
+// The web interface for `keepsake serve`. It is a single page app that
+// renders everything from the JSON API, using the URL hash for navigation.
+(function () {
+  "use strict";
+
+  var app = document.getElementById("app");
+  var refreshInterval = 10000;
+  var refreshTimer = null;
+  // IDs of experiments selected in the list, kept across refreshes
+  var selected = new Set();
+
+  function esc(s) {
+    return String(s)
+      .replace(/&/g, "&amp;")
+      .replace(/</g, "&lt;")
+      .replace(/>/g, "&gt;")
+      .replace(/"/g, "&quot;");
+  }
+
+  function shortID(id) {
+    return id.slice(0, 7);
+  }
+
+  function formatValue(v) {
+    if (v === null || v === undefined) {
+      return "";
+    }
+    if (typeof v === "object") {
+      return JSON.stringify(v);
+    }
+    if (typeof v === "number" && !Number.isInteger(v)) {
+      return String(Number(v.toPrecision(6)));
+    }
+    return String(v);
+  }
+
+  function formatTime(s) {
+    return new Date(s).toLocaleString();
+  }
+
+  function queryString(params) {
+    var search = new URLSearchParams();
+    Object.keys(params).forEach(function (key) {
+      var values = Array.isArray(params[key]) ? params[key] : [params[key]];
+      values.forEach(function (v) {
+        if (v !== "" && v !== undefined && v !== null && v !== false) {
+          search.append(key, v);
+        }
+      });
+    });
+    return search.toString();
+  }
+
+  async function api(path) {
+    var response = await fetch(path);
+    var body = await response.json();
+    if (!response.ok) {
+      throw new Error(body.error || response.statusText);
+    }
+    return body;
+  }
+
+  // chart fetches an SVG chart into element, or displays why it couldn't
+  async function chart(element, params) {
+    try {
+      var response = await fetch("api/plot.svg?" + queryString(params));
+      if (!response.ok) {
+        var body = await response.json();
+        element.innerHTML = '<p class="muted">' + esc(body.error) + "</p>";
+        return;
+      }
+      element.innerHTML = await response.text();
+    } catch (err) {
+      element.innerHTML = '<p class="muted">' + esc(err.message) + "</p>";
+    }
+  }
+
+  function showError(err) {
+    app.innerHTML = '<div class="error">' + esc(err.message) + "</div>";
+  }
+
+  function setRefreshed() {
+    document.getElementById("refreshed").textContent =
+      "Updated " + new Date().toLocaleTimeString();
+  }
+
+  // Experiment list
+
+  function parseFilters(s) {
+    return s
+      .split(";")
+      .map(function (f) {
+        return f.trim();
+      })
+      .filter(Boolean);
+  }
+
+  // changedKeys returns the keys that don't have the same value in every object
+  function changedKeys(objects) {
+    var values = {};
+    objects.forEach(function (obj) {
+      Object.keys(obj || {}).forEach(function (key) {
+        values[key] = values[key] || new Set();
+        values[key].add(JSON.stringify(obj[key]));
+      });
+    });
+    return Object.keys(values)
+      .filter(function (key) {
+        return values[key].size > 1;
+      })
+      .sort();
+  }
+
+  function checkpointSummary(chk, metrics) {
+    if (!chk) {
+      return "";
+    }
+    var lines = [
+      '<a class="id" href="#/checkpoints/' + esc(chk.id) + '">' + esc(shortID(chk.id)) + "</a> (step " + esc(chk.step) + ")",
+    ];
+    metrics.forEach(function (key) {
+      if (chk.metrics && key in chk.metrics) {
+        lines.push(esc(key) + "=" + esc(formatValue(chk.metrics[key])));
+      }
+    });
+    return lines.join("<br>");
+  }
+
+  async function listView(query) {
+    var filter = query.get("filter") || "";
+    var sort = query.get("sort") || "created-desc";
+
+    app.innerHTML =
+      '<div class="toolbar">' +
+      '<input id="filter" type="text" placeholder="Filters separated by semicolons, e.g. status = running; loss &lt; 0.5" value="' +
+      esc(filter) +
+      '">' +
+      '<button id="apply">Filter</button>' +
+      '<button id="compare" disabled>Compare selected</button>' +
+      "</div>" +
+      '<div id="experiments"></div>' +
+      "<h2>Chart</h2>" +
+      '<div class="toolbar">' +
+      '<input id="metric" type="text" placeholder="Metric (default: primary metric)">' +
+      '<select id="x-axis"><option value="step">Step</option><option value="time">Time</option></select>' +
+      '<label><input id="log" type="checkbox"> Log scale</label>' +
+      '<label>Smoothing <input id="smoothing" type="range" min="0" max="0.99" step="0.01" value="0"></label>' +
+      "</div>" +
+      '<p class="muted">Showing selected experiments, or every experiment in the list if none are selected.</p>' +
+      '<div id="chart" class="charts"></div>';
+
+    function navigate(newFilter, newSort) {
+      location.hash = "#/?" + queryString({ filter: newFilter, sort: newSort });
+    }
+    document.getElementById("apply").onclick = function () {
+      navigate(document.getElementById("filter").value, sort);
+    };
+    document.getElementById("filter").onkeydown = function (e) {
+      if (e.key === "Enter") {
+        navigate(e.target.value, sort);
+      }
+    };
+    document.getElementById("compare").onclick = function () {
+      location.hash = "#/diff?" + queryString({ id: Array.from(selected) });
+    };
+
+    var experiments = [];
+
+    function updateChart() {
+      var params = {
+        filter: parseFilters(filter),
+        metric: document.getElementById("metric").value.trim(),
+        x_axis: document.getElementById("x-axis").value,
+        log: document.getElementById("log").checked ? "true" : "",
+        smoothing: document.getElementById("smoothing").value,
+      };
+      var ids = experiments
+        .map(function (exp) {
+          return exp.id;
+        })
+        .filter(function (id) {
+          return selected.has(id);
+        });
+      if (ids.length > 0) {
+        params.id = ids;
+      }
+      chart(document.getElementById("chart"), params);
+    }
+    ["metric", "x-axis", "log", "smoothing"].forEach(function (id) {
+      document.getElementById(id).onchange = updateChart;
+    });
+
+    function renderTable() {
+      var container = document.getElementById("experiments");
+      if (experiments.length === 0) {
+        container.innerHTML = '<p class="muted">No experiments found</p>';
+        return;
+      }
+      var params = changedKeys(
+        experiments.map(function (exp) {
+          return exp.params;
+        })
+      );
+      var metrics = new Set();
+      experiments.forEach(function (exp) {
+        if (exp.best_checkpoint && exp.best_checkpoint.primary_metric) {
+          metrics.add(exp.best_checkpoint.primary_metric.name);
+        }
+      });
+      metrics = Array.from(metrics);
+      var hasBest = experiments.some(function (exp) {
+        return exp.best_checkpoint;
+      });
+
+      var columns = [
+        ["", null],
+        ["Experiment", null],
+        ["Started", "created"],
+        ["Status", "status"],
+        ["User", "user"],
+        ["Host", "host"],
+        ["Params", null],
+      ];
+      if (hasBest) {
+        columns.push(["Best checkpoint", null]);
+      }
+      columns.push(["Latest checkpoint", "step"]);
+
+      var html = "<table><thead><tr>";
+      columns.forEach(function (col) {
+        var cls = "";
+        if (col[1]) {
+          cls = "sortable";
+          if (sort === col[1]) {
+            cls += " sorted";
+          } else if (sort === col[1] + "-desc") {
+            cls += " sorted-desc";
+          }
+        }
+        html += '<th class="' + cls + '" data-sort="' + esc(col[1] || "") + '">' + esc(col[0]) + "</th>";
+      });
+      html += "</tr></thead><tbody>";
+      experiments.forEach(function (exp) {
+        html +=
+          "<tr>" +
+          '<td><input type="checkbox" data-id="' + esc(exp.id) + '"' + (selected.has(exp.id) ? " checked" : "") + "></td>" +
+          '<td><a class="id" href="#/experiments/' + esc(exp.id) + '">' + esc(shortID(exp.id)) + "</a></td>" +
+          "<td>" + esc(formatTime(exp.created)) + "</td>" +
+          '<td class="' + (exp.running ? "running" : "") + '">' + (exp.running ? "running" : "stopped") + "</td>" +
+          "<td>" + esc(exp.user) + "</td>" +
+          "<td>" + esc(exp.host) + "</td>" +
+          "<td>" +
+          params
+            .filter(function (key) {
+              return exp.params && key in exp.params;
+            })
+            .map(function (key) {
+              return esc(key) + "=" + esc(formatValue(exp.params[key]));
+            })
+            .join("<br>") +
+          "</td>" +
+          (hasBest ? "<td>" + checkpointSummary(exp.best_checkpoint, metrics) + "</td>" : "") +
+          "<td>" + checkpointSummary(exp.latest_checkpoint, metrics) + "</td>" +
+          "</tr>";
+      });
+      html += "</tbody></table>";
+      container.innerHTML = html;
+
+      container.querySelectorAll("th.sortable").forEach(function (th) {
+        th.onclick = function () {
+          var key = th.getAttribute("data-sort");
+          navigate(filter, sort === key ? key + "-desc" : key);
+        };
+      });
+      container.querySelectorAll("input[type=checkbox]").forEach(function (input) {
+        input.onchange = function () {
+          var id = input.getAttribute("data-id");
+          if (input.checked) {
+            selected.add(id);
+          } else {
+            selected.delete(id);
+          }
+          updateCompareButton();
+          updateChart();
+        };
+      });
+      updateCompareButton();
+    }
+
+    function updateCompareButton() {
+      document.getElementById("compare").disabled = selected.size < 2;
+    }
+
+    async function load() {
+      try {
+        experiments = await api("api/experiments?" + queryString({ filter: parseFilters(filter), sort: sort }));
+      } catch (err) {
+        document.getElementById("experiments").innerHTML = '<div class="error">' + esc(err.message) + "</div>";
+        return;
+      }
+      renderTable();
+      updateChart();
+      setRefreshed();
+    }
+
+    await load();
+    refreshTimer = setInterval(load, refreshInterval);
+  }
+
+  // Experiment and checkpoint details
+
+  function summaryTable(rows) {
+    var html = "<table>";
+    rows.forEach(function (row) {
+      if (row[1] !== "" && row[1] !== undefined && row[1] !== null) {
+        html += "<tr><th>" + esc(row[0]) + "</th><td>" + row[1] + "</td></tr>";
+      }
+    });
+    return html + "</table>";
+  }
+
+  function valueTable(values) {
+    var keys = Object.keys(values || {}).sort();
+    if (keys.length === 0) {
+      return '<p class="muted">None</p>';
+    }
+    return summaryTable(
+      keys.map(function (key) {
+        return [key, esc(formatValue(values[key]))];
+      })
+    );
+  }
+
+  async function filesList(element, basePath) {
+    try {
+      var paths = await api(basePath + "/files");
+      paths = paths.filter(function (p) {
+        return p !== "" && !p.endsWith("/");
+      });
+      if (paths.length === 0) {
+        element.innerHTML = '<p class="muted">No files were saved</p>';
+        return;
+      }
+      element.innerHTML =
+        '<ul class="files">' +
+        paths
+          .sort()
+          .map(function (p) {
+            var url = basePath + "/files/" + p.split("/").map(encodeURIComponent).join("/");
+            return '<li><a href="' + esc(url) + '">' + esc(p) + "</a></li>";
+          })
+          .join("") +
+        "</ul>";
+    } catch (err) {
+      element.innerHTML = '<div class="error">' + esc(err.message) + "</div>";
+    }
+  }
+
+  async function experimentView(id) {
+    var exp = await api("api/experiments/" + encodeURIComponent(id));
+    var checkpoints = (exp.checkpoints || []).slice().sort(function (a, b) {
+      return a.step - b.step || new Date(a.created) - new Date(b.created);
+    });
+
+    var metrics = new Set();
+    checkpoints.forEach(function (chk) {
+      Object.keys(chk.metrics || {}).forEach(function (key) {
+        if (typeof chk.metrics[key] === "number") {
+          metrics.add(key);
+        }
+      });
+    });
+
+    var html =
+      "<h1>Experiment <code>" + esc(shortID(exp.id)) + "</code></h1>" +
+      summaryTable([
+        ["ID", '<span class="id">' + esc(exp.id) + "</span>"],
+        ["Created", esc(formatTime(exp.created))],
+        ["Status", exp.running ? '<span class="running">running</span>' : "stopped"],
+        ["User", esc(exp.user)],
+        ["Host", esc(exp.host)],
+        ["Command", exp.command ? "<code>" + esc(exp.command) + "</code>" : ""],
+        ["Python version", esc(exp.python_version || "")],
+        ["Keepsake version", esc(exp.keepsake_version || "")],
+        ["Path", esc(exp.path || "")],
+      ]) +
+      "<h2>Params</h2>" +
+      valueTable(exp.params) +
+      "<h2>Metrics</h2>" +
+      '<div id="charts" class="charts"></div>' +
+      "<h2>Checkpoints</h2>";
+
+    if (checkpoints.length === 0) {
+      html += '<p class="muted">No checkpoints</p>';
+    } else {
+      html += "<table><thead><tr><th>Checkpoint</th><th>Created</th><th>Step</th><th>Metrics</th></tr></thead><tbody>";
+      checkpoints.forEach(function (chk) {
+        html +=
+          "<tr>" +
+          '<td><a class="id" href="#/checkpoints/' + esc(chk.id) + '">' + esc(shortID(chk.id)) + "</a></td>" +
+          "<td>" + esc(formatTime(chk.created)) + "</td>" +
+          "<td>" + esc(chk.step) + "</td>" +
+          "<td>" +
+          Object.keys(chk.metrics || {})
+            .sort()
+            .map(function (key) {
+              return esc(key) + "=" + esc(formatValue(chk.metrics[key]));
+            })
+            .join("<br>") +
+          "</td>" +
+          "</tr>";
+      });
+      html += "</tbody></table>";
+    }
+    html += "<h2>Files</h2>" + '<div id="files"></div>';
+    app.innerHTML = html;
+
+    var charts = document.getElementById("charts");
+    if (metrics.size === 0) {
+      charts.innerHTML = '<p class="muted">No metrics</p>';
+    }
+    Array.from(metrics)
+      .sort()
+      .forEach(function (metric) {
+        var element = document.createElement("div");
+        charts.appendChild(element);
+        chart(element, { id: exp.id, metric: metric, width: 480, height: 320 });
+      });
+    filesList(document.getElementById("files"), "api/experiments/" + encodeURIComponent(exp.id));
+    setRefreshed();
+  }
+
+  async function checkpointView(id) {
+    var chk = await api("api/checkpoints/" + encodeURIComponent(id));
+    var primaryMetric = chk.primary_metric ? esc(chk.primary_metric.name) + " (" + esc(chk.primary_metric.goal) + ")" : "";
+    app.innerHTML =
+      "<h1>Checkpoint <code>" + esc(shortID(chk.id)) + "</code></h1>" +
+      summaryTable([
+        ["ID", '<span class="id">' + esc(chk.id) + "</span>"],
+        ["Experiment", '<a class="id" href="#/experiments/' + esc(chk.experiment_id) + '">' + esc(shortID(chk.experiment_id)) + "</a>"],
+        ["Created", esc(formatTime(chk.created))],
+        ["Step", esc(chk.step)],
+        ["Path", esc(chk.path || "")],
+        ["Primary metric", primaryMetric],
+      ]) +
+      "<h2>Metrics</h2>" +
+      valueTable(chk.metrics) +
+      "<h2>Files</h2>" +
+      '<div id="files"></div>';
+    filesList(document.getElementById("files"), "api/checkpoints/" + encodeURIComponent(chk.id));
+    setRefreshed();
+  }
+
+  // Comparison
+
+  async function diffView(query) {
+    var diff = await api("api/diff?" + queryString({ id: query.getAll("id") }));
+    var html = "<h1>Compare " + diff.columns.length + " checkpoints</h1>";
+    diff.sections.forEach(function (section) {
+      html += "<h2>" + esc(section.name) + "</h2>";
+      html += "<table><thead><tr><th></th>";
+      section.ids.forEach(function (id) {
+        html += '<th class="id">' + esc(id) + "</th>";
+      });
+      html += "</tr></thead><tbody>";
+      if (section.rows.length === 0) {
+        html += '<tr><td class="muted" colspan="' + (section.ids.length + 1) + '">No difference</td></tr>';
+      }
+      section.rows.forEach(function (row) {
+        html += "<tr><th>" + esc(row.key) + "</th>";
+        row.values.forEach(function (v, i) {
+          if (v === null) {
+            html += '<td class="muted">(not set)</td>';
+          } else {
+            html += "<td" + (row.best === i ? ' class="best"' : "") + ">" + esc(v) + "</td>";
+          }
+        });
+        html += "</tr>";
+      });
+      html += "</tbody></table>";
+    });
+    app.innerHTML = html;
+    setRefreshed();
+  }
+
+  // Routing
+
+  async function route() {
+    clearInterval(refreshTimer);
+    var hash = location.hash.replace(/^#/, "") || "/";
+    var parts = hash.split("?");
+    var path = parts[0];
+    var query = new URLSearchParams(parts[1] || "");
+    var match;
+    try {
+      if ((match = path.match(/^\/experiments\/([^/]+)$/))) {
+        await experimentView(decodeURIComponent(match[1]));
+      } else if ((match = path.match(/^\/checkpoints\/([^/]+)$/))) {
+        await checkpointView(decodeURIComponent(match[1]));
+      } else if (path === "/diff") {
+        await diffView(query);
+      } else {
+        await listView(query);
+      }
+    } catch (err) {
+      showError(err);
+    }
+  }
+
+  window.addEventListener("hashchange", route);
+  route();
+})();
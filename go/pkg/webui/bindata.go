@@ -0,0 +1,290 @@
+// Code generated for package webui by go-bindata DO NOT EDIT. (@generated)
+// sources:
+// assets/app.js
+// assets/index.html
+// assets/style.css
+package webui
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes []byte
+	info  os.FileInfo
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// Name return file name
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+
+// Size return file size
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+
+// Mode return file mode
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+
+// Mode return file modify time
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+// IsDir return file whether a directory
+func (fi bindataFileInfo) IsDir() bool {
+	return fi.mode&os.ModeDir != 0
+}
+
+// Sys return file is sys mode
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var _appJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x5b\x6d\x73\xe3\x36\x92\xfe\xee\x5f\xd1\xc1\x5e\xc5\x64\x8d\x42\x7a\x72\xaf\x6b\x4b\x9a\x4a\x26\x73\xb7\x73\x3b\x9b\x4d\xc5\xc9\xde\x07\xaf\x93\x85\x49\x48\xc4\x98\x22\xb8\x00\x24\x8f\x2e\xe3\xff\x7e\xd5\x78\x23\x40\x51\xb2\x67\x6a\xaf\x92\x1a\x93\x04\xd0\x68\x74\x37\xba\x9f\x6e\x40\x65\x09\x3f\x35\x0c\x1e\xd8\x1d\xf0\x4e\x33\xb9\xa2\x15\x83\x95\x90\xf0\xb7\x7b\xc6\x7a\x45\xef\x19\x28\x26\x77\xec\x6f\x05\xbc\xd5\xc0\x15\x50\x50\xbc\x5b\xb7\x0c\x7a\xba\x66\x40\xfb\x1e\x74\x43\xf5\x59\x59\x82\x64\x5d\xcd\xa4\x02\xb6\x63\x72\xaf\x1b\xde\xad\x61\x25\xc5\x06\x74\xc3\xe0\xbf\xaf\xff\xfc\x3d\x7c\xf3\xc3\xdb\x19\x6c\x71\xb8\xf9\xf6\xf3\x8f\xef\xa0\xa1\xaa\x31\xd3\x75\x74\xc7\xd7\x54\x73\xd1\x15\x67\xd9\x6a\xdb\x55\xf8\x08\x59\x0e\xbf\x9d\x01\x90\xad\x62\xa0\xb4\xe4\x95\x26\x57\x67\x67\x00\x3b\x2a\xcd\xd4\x0b\xa8\x45\xb5\xdd\xb0\x4e\x17\x6b\xa6\xdf\xb4\x0c\x1f\xbf\xdd\xbf\xad\x33\x42\xfb\x9e\xe4\x57\xae\xaf\x64\x2b\xc9\x54\xf3\x16\x57\xb8\xa3\x2d\x2c\xe0\xe5\xc5\xc5\xc5\xc5\xa8\xf9\x27\xbe\x61\x12\x16\xd0\x6d\xdb\x16\x9b\xca\x12\xde\x7e\xa7\x40\xac\x80\x7d\xe8\x99\xe4\x48\x5c\x81\x62\x2d\xab\x34\xab\x81\x77\x66\x19\x2d\x57\x7a\x06\xf7\xac\xd7\x40\x2b\x29\x94\xf2\xe4\x98\x72\xe4\xc3\x88\x05\x74\xec\x01\xae\x99\xce\x72\xb3\x8c\xb0\x4e\xa6\xaa\x4c\xd9\xb5\x02\x48\xa6\xb7\xb2\x83\x6b\x2d\x79\xb7\xce\x54\x6e\x3e\x02\x14\x92\xf5\x2d\xad\x58\x56\x7e\x59\xae\x67\x40\xbe\xa4\x9b\xfe\x8a\x1c\xb6\xce\x6d\x6b\xab\xa7\x1a\x97\xb6\x71\x3d\xd9\x48\x6c\xe3\xdf\xb7\x02\x9b\x51\x02\x8f\x09\x97\xaa\x11\x52\xbf\xfd\x2e\xe3\xf5\x88\x55\x5e\x17\xaa\xe5\x15\xcb\x2e\x66\xf0\xef\x13\x03\x57\x42\x6e\xa8\xfe\x0b\x6d\xb7\x2c\xdb\xf9\xb1\x7c\x05\xd9\x0e\x16\x0b\x2b\x6f\xf8\xf8\x11\xec\xdb\xb6\xab\xd9\x8a\x77\x2c\x4c\x12\xa6\x21\x04\x49\x23\x57\x7e\xbc\xde\xf7\x4c\xac\xdc\x40\x22\xee\xde\xb3\x4a\x93\x83\x71\x68\x7c\x05\x9a\x4f\xb7\xe6\xab\x7d\xb6\xcb\x4f\x93\xe9\xb6\x9b\x3b\x26\x09\x7c\xf9\x25\x7c\xf1\xbd\x79\x2e\xb8\x42\xd3\x59\x33\x99\xed\xf2\x03\xf2\x4e\x51\xb6\x6b\xb6\x2b\xb4\xf8\x41\xb2\x8a\x2b\x2e\xba\xec\xdf\xf2\x3c\x99\x2d\x1d\xb2\x3b\x2a\x2b\x34\xc5\x03\x8b\x40\xe3\xf9\x8e\x6a\x96\xa9\xbc\xd0\xe2\x9d\xa8\x68\xcb\x1c\xa5\x09\x42\x7f\xdf\x32\xb9\x77\xcd\x3d\x95\x74\x13\xc8\xa1\xc9\x2b\x46\x65\xd5\xa0\xb1\xb3\x07\xf8\xf9\xc7\x77\xd7\xe6\xfd\x07\xd3\x2f\x73\x2c\xff\xd9\xc8\xb3\xb8\x67\x7b\xe5\x29\x14\x2b\x21\xdf\xd0\xaa\x89\xb6\xe8\x3d\xdb\x7b\xc2\xd6\xdc\x77\xa8\x67\x05\x0b\xf8\x46\x4a\xba\x2f\xb8\x32\x7f\x1d\x85\x9b\x7b\xb6\xbf\xcd\xe1\x15\x44\xaf\x70\x09\x37\xd1\xeb\xed\x55\x20\x86\x84\x26\xa6\x0c\x36\xe4\x15\xb8\x83\x2f\xd0\x00\x8c\xce\xec\x73\xb0\xa2\xe1\x93\x31\xb3\xf0\xb6\xa2\xad\x62\x31\x1d\x70\x32\x29\x68\xdf\xb3\xae\xce\xee\xd9\x7e\x06\xde\x56\x06\x0d\x02\x3c\xba\x6f\xfe\xaf\xd3\x8e\x1b\xad\xc5\x81\x4a\xa8\xda\x77\xd5\xa0\x18\xda\xf3\xac\xa7\xba\xf1\x93\xa3\xcc\x24\x53\xbd\xe8\x14\x83\x05\xd0\x07\xca\x35\xac\x98\xae\x1a\xdb\xed\x2a\xf4\xba\x13\xf5\x3e\xf4\xf0\x43\x8a\xf7\x4a\x74\x5e\x65\x28\x8c\x2f\x42\x8b\xb8\x1f\x16\xa8\x1b\x29\x1e\x8c\xb6\xdf\x48\x29\x64\x86\xb4\x0a\x86\x8f\xb8\xf5\xc2\x10\xa5\xa9\xde\xaa\x9f\xd8\x07\x3d\x65\xb7\x38\x28\x2c\xab\x2c\xa1\x6a\xa8\x74\xbc\x32\x05\xb4\x83\xeb\xbf\xfc\x97\xfb\xc8\x3b\x2d\x80\x59\x8f\x3c\x03\x21\xa1\xe6\xaa\x6f\xe9\x5e\xc1\x43\xb3\x07\xae\xa1\x12\xdb\xb6\xee\xce\xf5\xa1\x80\x0c\x81\x2c\x8c\x4d\x8d\x57\xcb\x7d\x58\xd3\x09\xc9\x11\xda\xf3\xb2\x6f\x85\x2e\xd4\x6e\xfd\x8a\xc0\x8b\xa9\xfd\xe0\x96\x78\x4a\x6c\xcf\x15\x3c\xfe\xe7\x38\x2e\x78\xd7\x31\xf9\x87\x9f\xfe\xf4\x0e\x16\x70\x3e\xef\xa1\x6a\xa9\x52\x0b\xb2\xd9\x6a\x56\x93\xe5\x39\xbc\x30\xfe\x7e\x50\x40\x0e\x2f\x80\xcc\xcb\x7e\xe9\xfc\xdb\x20\xf0\xab\xb3\xd4\xf4\xa6\x66\x18\x31\xa5\xd9\x07\xed\x99\x7a\x84\x8a\xea\xaa\x81\x8c\x49\x39\x2c\xe9\xd3\xd8\x64\x52\x16\x1b\xa6\x14\x5d\xb3\x31\x9f\x8f\xde\x12\x82\xe6\x54\x23\x1e\xac\x79\x45\x33\xd2\xbe\x1f\xcd\x55\xf3\x9d\x9f\xcd\x58\xe0\xa9\xd9\x6a\xbe\x5b\x92\x43\xef\xa6\x98\xfe\xd1\x85\xd9\xda\xa1\x04\x38\x0e\x06\x7c\x44\xae\x49\x6e\x04\xf4\x5a\x74\x9a\x75\x1a\x16\x4e\x24\xe4\xe7\xbe\xa6\x18\xd3\xd1\x50\x82\x9b\x1d\xbc\x2c\xba\xe3\x83\x6d\x5d\x96\xf0\x26\xe0\x02\x03\x03\x12\x0e\x7b\x2a\x15\xfb\x4f\xde\x6a\x26\xd5\x81\x2b\x57\x6e\xe2\x42\xf5\x2d\xd7\x19\x89\xc2\xf1\x86\xf6\x91\xab\x5b\xf9\x81\xd1\xe0\x55\xa1\x25\xdf\x0c\x96\xf7\x18\xc6\xae\xcc\x74\xd9\xb7\x42\xb4\x8c\x76\x09\xab\x55\x43\xbb\x35\xab\xff\xc8\xf6\xca\xd1\x51\x06\xbe\xa0\x77\x37\x10\x0e\x6a\xd1\x9d\x6b\x68\xe8\x8e\x99\x06\x45\x37\xcc\xba\x60\x44\x3a\x06\xd4\x81\x8d\xb0\xf1\x2a\x23\xb2\x99\x6d\x0d\x4b\x4d\x62\xc1\x6f\x8f\xc8\x0c\x38\x0a\x53\x4e\x5d\xdc\xbd\x1f\xd6\x1a\x87\x1e\x71\xf7\x1e\x5d\xd4\x6f\x8f\x4f\x46\x1f\x1f\x32\x4c\x18\x81\x45\xf2\xf6\xf1\x63\x0c\xbe\x26\xfa\x17\xb4\xae\xb3\x11\x54\x10\x77\xef\x4d\x9b\x0f\xe2\x47\x9d\x7f\xcc\xaf\xa5\x39\xd6\xc9\x51\x96\x1d\x85\x98\x13\xc5\xff\x97\xc1\x12\x5e\x0e\x93\xba\x87\x42\x09\xe9\xf8\x4f\xb7\x43\xd5\xb0\xea\xbe\x17\xbc\xd3\xd7\xdb\xcd\x86\xca\x7d\x56\x35\xf7\x33\xd8\x30\x04\xcd\x41\x23\xc6\xcd\x55\x4d\xe4\xde\x26\x91\x15\x2a\xae\xe5\x9d\xd1\xdb\x8d\xeb\x78\x3e\xa7\x7e\xcb\xf2\x9a\x40\x23\xd9\x6a\x41\x7e\x57\x0e\xf3\xaa\xd2\xef\xe1\xaa\xb9\x2f\x10\x22\xbe\x80\xf3\x61\x63\x7b\xec\xe8\x1a\xdd\xe6\xa6\x4b\xc8\x94\x66\x3d\x90\x68\x2c\x7e\x30\xed\x39\x99\x99\xd9\x1d\x20\x70\x8b\x79\xca\x08\x70\x91\x48\xc6\x75\xc7\xf8\x7f\xcf\xf6\x68\xc3\xd1\xd7\xa1\x3b\xd8\xa5\x16\xfd\x56\x35\x19\xb2\x60\xd4\xf3\x02\xc8\xc2\xf3\x14\xa3\xd7\x88\x84\x33\x8c\x3c\xf5\xd1\x23\xbb\xb0\xb4\xdf\x0b\xde\x65\x64\x7e\x27\x97\xe4\x28\x2a\x40\xff\xf1\x17\xce\x1e\x32\x13\xa7\x3c\x7f\xa8\x0a\xbb\xa9\x61\x61\x23\x18\x26\x3a\x19\xb1\xdf\x48\x8e\x1b\xc3\x2b\x0f\xfb\xa2\x7d\xa4\x3d\xf1\x8b\xeb\x57\x49\x86\x4e\xee\xab\x9a\xa9\xca\xe6\x51\x07\xce\xd9\xad\x25\x71\xd1\x5a\x88\xf6\x8e\x4a\xa3\xcb\xd0\xce\xbb\x7e\xab\x81\xd7\x0b\xcf\x0a\x20\x16\x5f\x10\xf4\xae\x04\x4c\x42\xd1\x88\xb6\x66\x72\x41\x9c\x0f\x04\xc5\x30\xec\xa2\x97\xbd\xdb\x83\x62\x1b\x5e\x89\x56\x74\x6a\x06\xac\x58\x17\x60\x81\x07\x2c\x40\x6e\xbb\x8e\x77\xeb\x2b\x68\x31\x97\xfa\xb2\xd5\x57\x70\x51\xfc\x2b\xb1\x9b\x79\x41\x06\x26\x8c\x6e\x0c\xed\x7c\x60\x2c\xe5\xf2\x6e\xab\xb5\xe8\x0c\x9b\xb4\xef\xdb\x3d\x59\x5a\x66\xe6\xa5\x6d\x39\xd6\xb9\x12\x9b\x9e\x4a\x46\x10\xb4\xd0\xbb\x96\xd5\xcb\xd7\xf6\x4b\xc8\xfe\x26\x28\xf8\x60\x15\x91\x44\x29\x22\xbd\x28\x7f\x24\x4b\xdb\x2d\x1e\xd7\x7c\xbd\x7c\x8d\x98\x67\x5e\x36\x5f\x1f\x8c\x7f\x9e\x16\xac\x51\x9e\xd0\xc2\x9f\x4c\x07\xc8\x6a\xb6\xa2\xdb\x56\x5f\x42\x2f\x39\x3a\x0a\xe7\x23\xf2\x94\xb0\x5d\xa6\x11\xdc\x87\xaf\xe8\x07\xae\xc8\x72\x2e\x7a\xe3\xbf\x9c\x1e\x70\x97\x92\xe5\xb5\x66\xfd\xbc\xb4\x2d\xe3\x1e\x9a\x6f\x18\x59\x62\xe8\x1c\x7a\x94\x96\x70\x32\x57\x4b\xef\x58\xbb\x8c\xd6\xd2\x8a\xb5\x5f\x88\xf1\x2f\x77\xe2\x03\x59\xc2\x3b\xb1\x06\x85\xc1\x78\x5e\xda\x11\x87\x34\xae\x37\x42\xd8\xc2\x43\x44\x4d\xf9\x8f\x9e\xa6\xc4\x88\x45\x60\xc3\xbb\x05\xb9\x20\xb0\xa1\x1f\x16\xe4\xa2\xf8\xfd\xef\x09\xe0\x9a\xf0\xf9\xe2\x65\x30\xb7\x0b\xb2\x3c\x9c\x6f\x42\xd3\x63\xf8\x74\xdd\x88\x07\x64\x24\xe4\xfe\x91\x09\x18\x38\x6c\x03\xea\xf0\x35\x2e\x27\x20\x1e\xed\x44\xc7\x20\xb6\xb8\x02\x11\xe2\xf9\x84\x71\x55\x68\x3a\xc4\xcf\x6f\xde\x06\x2b\x73\xdb\x3c\xb8\x19\x57\x67\x61\x59\xc7\x1e\xec\x5e\x98\x61\x5c\xbc\x16\x52\x0f\x3e\xb1\x15\x95\x2d\xc5\x98\xf2\xcc\x02\xc8\xef\xca\x03\x08\xfd\x9b\xf3\x4b\x97\x10\x51\x42\x67\x73\xe9\xe9\x0d\xb1\xf2\x34\x38\xb3\x3b\x33\x2f\x44\x57\xb5\xbc\xba\x87\x05\x8c\xeb\x3f\x38\x3c\x30\x7e\x94\x8e\x73\x44\x79\x61\x54\x67\x99\xf1\x1c\x5c\x9d\x66\x21\x0c\x15\xdd\x3d\xdb\xd7\xe2\xa1\x4b\xb8\x88\xf2\x44\x8c\x2e\x0c\xe3\xbc\x2d\x39\xbc\xc1\x8a\x52\x54\x71\x88\xf8\x64\x85\xa6\x72\xcd\xf4\x04\x3b\x21\x58\x3c\xc1\x96\x77\x43\x4f\xc9\x66\x42\x5f\x35\x5f\xad\x26\x74\xc6\xeb\x4b\x97\x96\x63\x69\x2e\xf3\xb6\x95\x0f\xba\x72\x06\x83\xc1\x24\x32\x59\x44\x02\xb7\x63\x5b\xda\x1a\xd0\x6c\x3c\x57\xc4\x0c\x8e\x44\x47\xbf\x41\xf8\xe0\x3f\x42\xb0\x96\x04\x16\xdb\x8f\xf9\x2c\xf4\xb2\xce\xe8\xf2\xb8\x48\x9c\x9f\x73\x4a\x76\x40\x78\x18\xff\xe1\x57\x74\x56\x27\xc6\x3b\x6f\xe6\x8d\x24\x0c\x6c\xc5\xfa\xc4\x28\xf4\x48\x79\x61\x9c\x11\xab\xe1\x15\x10\x2d\xb7\x8c\xc0\x25\x10\x07\x51\xf0\xff\xe0\x68\x4e\x10\x1a\x9c\xd1\x88\x03\x67\xa1\x36\x8a\xf3\x1a\x65\x17\x89\xdf\xb5\x1d\x64\x07\xec\x43\x3f\x08\x3e\x82\x1d\xec\x43\x5f\xf0\xda\x93\x8c\x00\xe4\x14\x1e\xe5\xf5\x24\x8d\xe0\x77\x1a\xaa\xb0\xe4\x17\x53\xf3\xcf\xb8\x1b\x78\xad\x8a\x96\x75\x6b\xdd\xc0\x12\x2e\x62\x52\xd6\x0a\x0a\x5e\xc3\x02\x78\xad\xc6\xc9\xac\xf1\x54\xd9\x51\x51\x99\x66\x92\x87\xfc\xdf\xd9\xa7\xf9\xf7\xc6\xdb\xc1\x0c\xbc\x46\x67\x60\xb4\x34\x83\x48\xc6\xb7\x13\x58\x31\x5e\xed\xb1\xb9\x79\x6d\x76\x9c\x49\x6d\x60\x11\xdb\xb9\x63\x22\x1f\x6f\x05\x5b\xfb\xfe\x09\xb1\xc2\x68\x2b\x54\xa2\xd3\x94\x77\x06\xc0\x1d\x5d\x6b\xa4\x6a\x92\x08\x37\x6a\xf0\x42\x46\xbf\x93\x88\x39\xcc\x30\xca\xb2\xc7\x21\xe9\x7b\x91\xec\xe8\x95\xd8\x76\xb5\x89\x29\x57\x67\xa9\xea\xc7\x8a\x4a\x76\x74\x9c\xf0\xb9\x76\x88\xe9\x7e\x82\x85\x5a\x9a\x13\x56\x1a\x44\x80\x33\x3b\xb8\x0d\x8b\xc3\xfc\x2d\x9e\xf6\x50\xd3\xa3\xa9\x9d\x38\x8b\x3b\xa6\xf4\xaf\x43\xde\x82\xf9\xc1\xc4\xe7\xc2\xc1\xa3\x5f\x1d\x3c\x4a\xd6\xe0\x58\x32\x19\xe3\xd3\x63\x8b\x8e\x6e\x58\xe0\x79\x10\xeb\xb0\x8d\x86\x25\x46\xbe\xd9\x7d\x0c\x9d\x50\x16\x0d\x55\xdf\x32\xa5\x53\xe7\x50\x28\xb1\x61\xc7\xd7\x1d\x09\x7c\xc4\xa7\xa7\x1c\xcc\xd9\x5b\x6c\xbb\xdd\x74\x71\xf2\x07\x70\x43\xc8\xcc\xd4\xe9\x6f\x07\x7f\x77\x43\x86\xf2\xc7\x54\xeb\xb5\xa6\x12\x2b\x5e\xb3\x90\x79\x90\x71\xbb\xde\x2a\xb3\x63\xed\x53\xd2\xfa\xb3\x62\x12\xdb\xb6\xf8\x37\x69\xf9\x83\x50\x1a\x5b\x1a\xfc\x9b\xb4\xd8\xf2\xf5\x98\x97\x50\x4f\x46\x0b\x70\x12\x8c\x05\xe4\xd6\x6b\x33\xc0\x1b\x62\x04\x3c\x08\xc9\x53\xcb\xc7\xbb\x62\x34\xec\x1d\xd5\x07\x03\x09\x02\x4a\x72\x9b\x8a\xb7\xd1\x1b\x3c\x80\x22\x73\x8d\xde\x62\x39\xd7\x0d\xa3\xf5\x72\xae\xe5\x50\xfe\xf3\xa4\x0f\x6d\xba\x12\x6d\xcc\x3a\xd2\xab\x5a\x54\x95\x4f\x02\xfd\x3a\x2b\xd1\xde\xbc\xbc\x8d\xfb\x82\xef\x89\x40\x04\xa7\x8e\x46\xd8\x31\xd8\x60\x60\xcd\xd4\x60\x3b\xfc\xc5\x02\x88\x01\x56\xac\x4e\x86\x3f\x02\x6b\x15\x9b\xa2\x82\x89\xbc\xcd\x38\x9f\xa0\xe7\xd3\x52\x80\xf1\x46\x89\x9f\x8c\xf4\x5e\x60\xc1\x52\x37\xde\xbf\x61\x8d\xc1\x10\x83\x73\x02\x35\xd5\xf4\x2b\xe4\xd0\x7e\xc7\x24\xd1\x31\x62\x52\xe5\x51\x59\x02\x9b\x2e\x6e\x5d\x35\x42\x37\x83\x0a\x86\xcd\xe9\x67\xc4\x0e\x72\x39\x2f\xbd\xbe\xb0\x7e\xbb\x24\x9f\xe5\x8a\x1c\xc9\xf0\x8e\x19\x85\x96\x51\x3e\x81\xff\x9f\xcf\x75\xed\xb3\xa2\x51\x32\x64\x17\xc9\xeb\x61\x89\xe8\x85\x7c\xcd\x05\xbf\x05\x64\x87\x90\x30\xb4\xbe\x02\x02\x0e\xc4\x58\xf0\x82\x03\x30\x55\xd0\xf5\xe4\xe4\x93\x25\x9f\x68\xa5\xe5\xd4\xf4\x07\x25\x1f\xd7\x18\x4a\x3e\x13\xd3\x11\x9c\x2e\xad\xb7\x60\xde\x68\xe8\x3a\xdf\x91\x7b\x25\x4d\xb1\x1a\x5b\x82\x19\xe4\x8a\x08\x08\xd5\xdc\xe3\xb0\x60\xc7\xe2\xa9\x7e\x4a\x8b\xbe\x67\x35\x39\x36\x67\xc2\x2f\xd2\x41\x2f\xf5\xec\xce\xe8\xb8\x9e\xea\x1c\x7d\xb2\x61\x32\xfa\xf0\x8c\xa2\xe2\x91\x58\x1b\x15\xc3\xa6\x02\xf0\x08\x2a\x4e\x00\xce\x93\xd3\x3c\x55\x3c\x1b\xa6\x1c\x15\x55\xa7\x67\x8e\xab\x66\x23\x19\x1d\xca\xcd\x3b\x76\x78\x15\x44\x38\x51\x14\x9d\x08\x82\x51\x91\x74\xd0\x88\x33\x95\x29\x25\x4e\x13\x6d\xa9\x7e\x06\xd9\xf1\x22\xe4\x53\xfe\xc6\xf8\x98\x79\x69\x23\x45\xe8\x3b\x0d\xf8\xd0\xa9\x5c\x9d\x1d\x74\x31\x45\xc3\x6b\xe3\x0d\x84\xfc\xa6\x6d\x33\xa2\x9b\x22\x84\x80\xa9\x92\xfa\x70\x32\x89\xff\xe9\xe6\x89\xe4\xd3\xc7\x21\xb4\xab\x05\xe8\x06\xc1\xed\x37\x5a\x4b\x7e\xb7\xd5\x2c\x23\xc1\x1f\x0f\xc0\x36\x49\x93\x57\x51\xe9\xc0\x04\x1f\xa4\xf3\xca\xfc\x1b\x22\x07\x5c\xe2\x7b\x34\xfe\xf1\x50\x6c\xa7\x56\x6c\x9c\xe8\x8d\x71\xa2\xde\x87\xde\x4e\x2e\xdd\x74\x8c\x97\x66\x3e\xc4\xb9\xc0\x29\x09\xd8\x44\xc7\x8c\x98\x90\x01\xaf\x53\x09\x60\xac\xb4\xe4\x9d\x4b\x4e\x09\xc2\x50\xf8\x41\x98\x99\x24\x60\x21\xde\x1e\x19\x50\xb3\x96\x69\x76\x30\x26\x7a\x76\x29\x8d\xad\x2f\x7c\x6b\x0a\x9f\xf1\xb1\x48\xe8\x80\x39\x4f\x76\x5a\xf0\x27\x48\x3d\x4e\x17\x0c\xd2\xae\x4f\x66\x63\xa1\x1a\x9b\x17\xbe\x1c\x0b\x8b\x61\xb1\xe6\xb0\x64\x0e\x5f\x27\x53\x8e\x8b\xeb\x82\x0e\xa7\x84\xe9\x19\x72\x12\xba\xc3\x71\x2a\x9e\xd1\x9b\x23\xe4\xa8\xf1\x44\x09\x6c\xb2\xa8\xe1\xaa\x61\xf8\x2f\x3c\x46\x27\x48\x93\x87\xb2\x27\x96\x1f\xb1\x40\xf2\x51\x8e\xf7\x59\x27\xa9\xb1\xd7\x0e\x5c\xb9\xbf\x49\x22\x3b\x52\x71\x6a\x0c\xe9\x09\x6c\x2a\x7d\x23\x43\x2b\x74\x7f\x1c\x92\xdc\xb0\x52\x4c\xfb\xbb\x58\x19\x76\x9b\x8d\x6f\x68\x1d\x3b\x65\xa5\x5d\x1d\x79\x60\xa8\x99\xa6\xbc\x55\xc9\x51\x98\xb2\xbe\xde\x2e\x41\x8a\x87\xe4\x3c\x72\x04\xbe\x9d\x38\xb0\xd7\x84\x37\x90\xe2\x61\x50\x10\x6e\x57\x29\x1e\x6e\x5e\xde\x46\x77\x4d\xa2\x0f\xc9\x85\x93\xe8\x3b\x26\x4d\x13\xd8\x0f\x59\x90\x08\xfe\x03\x32\xc0\x31\x31\x14\xf5\x01\xc7\xd1\xf2\x51\x64\x14\x36\xa6\x0e\x9d\xec\x14\x40\x92\xc0\x91\x9e\x17\x9a\xda\x93\x15\x91\x3b\xae\x74\x2c\x3a\x4f\x8e\x29\xc5\xe1\x99\xa6\x3f\x86\x1d\x4e\x21\xad\x5c\x70\xc0\x91\xf2\x84\x63\x69\xaa\x1a\xd1\xb1\xa8\xfa\x90\x5c\x33\x49\x54\xe8\x08\x99\x39\x4e\x82\x11\x37\x18\xb1\xc5\xec\x00\x7b\x44\xc7\xab\x79\x1e\x32\x42\x07\x39\x8e\x1e\xca\xad\x78\xcb\xd4\x3b\xae\xa2\xdb\x28\x77\x54\xb1\x1f\xa2\xdb\x3b\xe3\xfb\x28\x78\x65\x27\xf5\x23\x7e\x04\xaa\xa4\x34\x14\x87\x40\xe0\x7b\x9b\xbf\x87\xa8\x6e\x2a\x91\xef\x23\xfb\xfb\xa2\x2f\x58\x57\xab\xff\xe1\xba\xc9\x48\x49\xf2\x43\xef\x8c\xea\xb1\xc4\xa7\xf5\xf3\xdc\xeb\x20\xdf\x0b\x74\x76\x4c\xc1\x03\xc3\x33\x09\xba\x63\xcf\xab\x1d\x4d\x50\x77\x2d\x88\xdc\xb7\xad\x9f\xc6\x10\x8f\xcf\xa0\x9c\x6c\xc2\x5b\x38\xfc\x3e\x3b\x0a\x4e\x13\x69\x79\x7d\x6c\x25\x66\xdb\x87\x3a\x28\x71\x6f\xf5\xfe\xfe\x45\x49\x72\x83\x74\x59\x57\x89\x9a\xfd\xfc\xe3\x5b\x8c\x67\xa2\x63\x9d\xce\x1d\x0e\x8d\xa4\x9b\xa8\xe3\x7c\xde\xf2\xe5\x9c\xba\xf4\xc8\x7b\xdf\xad\x6c\x47\xd9\x50\xef\x76\x36\xc5\xf3\x24\x1e\x3b\xe2\x11\xf2\x75\xf3\x25\xf8\x93\xcc\xcb\x6d\xbb\x24\x9f\x7e\xab\xe7\x33\xe3\xc3\xe3\x91\xfd\x30\x84\x22\x73\x54\x3d\xd4\x52\xdd\x71\xc1\xc9\xf8\x69\x24\x7e\x28\x60\x84\x29\x4e\xb4\x48\x65\xf0\xee\xb8\x2f\x4c\xbe\x14\x7f\xfa\xf8\x11\x6e\x6e\x73\x77\xdd\xd4\x79\xa2\xc1\x02\xe8\x0c\xee\x06\x91\x38\x0d\xd1\x02\xcb\x30\xf0\x15\xdc\xd9\x07\x77\x13\xc4\x5c\xf1\xa1\x21\xbd\x84\xaf\x86\xaf\x77\xe1\xab\x13\x87\xaf\xe0\x9c\xac\x47\x46\x6c\x4e\x04\x93\xe4\xda\x45\xec\x5a\xa3\x2b\x05\xcf\xbe\xe6\x12\x5d\x5a\x1d\xdf\x48\x48\xee\xb0\x1e\x2f\x5b\x8e\xf0\xf4\xc8\x6f\x84\x8a\xe0\x10\x33\x5d\x0f\x32\x6f\x5e\x2e\xa3\x80\x3c\x47\x75\x2e\xc9\xc9\x94\xdf\x74\x99\x97\xcd\xcb\x28\x05\x4a\x5c\x7c\x5c\x67\x7c\xfb\x1d\x99\xc1\xf9\x5c\xf5\xb4\xf3\x96\xcb\xe3\xeb\x68\xa1\xd2\x40\xe6\x25\x76\x5a\xa6\x25\xc0\xd7\xae\xd2\x38\x3b\x59\x4a\x98\xae\x43\xa6\x05\x81\x94\x07\x5f\x1d\x58\xba\x07\x37\xf9\x79\x5c\x2d\x98\xaa\x5f\x7a\x9e\x4d\x81\x60\xaa\x8c\xe9\x3b\x98\xa2\x40\xd2\xe1\xb5\xd8\x6c\x68\x57\x3b\xc6\x2a\xfb\x66\xf2\xdb\x44\xe6\x51\x63\x2c\x6f\x9b\xc4\x26\x04\x7f\xd8\xeb\x06\x8f\xe7\x99\xc4\xbb\xc9\xd1\xdc\xbd\x69\xf8\xd5\x35\xa0\x15\x12\x92\xca\xe8\x8f\xfe\xc7\x00\x87\x83\xfd\xef\x04\x4e\x0d\x47\xd7\x1b\xcf\x47\x75\x33\xee\x76\x3b\xf8\x3b\x73\x21\xc2\xd6\x72\x47\x37\x22\x22\xd8\x32\x14\x13\x46\x03\xed\x45\x87\xf1\xc8\xd1\x71\xb9\x3a\x76\x5e\x9e\xd2\x7a\x3d\x6c\x69\x4b\xcf\x6d\x0b\xdc\x7f\xf1\x76\x9f\x8e\xad\x1e\xea\x4d\x46\xd3\x68\x78\x0c\x83\xd2\xbc\x2e\x02\x8b\xe3\x62\x31\x42\xc7\x81\x3d\x87\x18\x9b\xa5\xb3\xff\xf0\x6e\x2f\x68\xb8\x97\x20\x1a\x7c\x3f\x55\xcd\xfc\x04\x57\x16\x98\x7c\x4e\x35\xf3\xff\xe7\x0e\xd9\x64\x75\xc5\xa1\xe7\x91\x17\xc0\xa1\xc1\x0b\x1c\xad\xcc\x44\x63\x93\x0b\x69\x27\x3a\x47\x9f\x4e\x3b\xf7\xa8\xe3\x14\xa4\xf9\xc7\x56\xdc\xc6\xc1\x61\xc8\x42\xbd\xbf\x4f\x5e\x3f\xad\xe4\xf6\xd9\x15\xac\xc7\xb3\xb4\x57\xf3\x35\x5e\xcb\x62\x61\xcb\x46\x9b\xd5\xc1\xc2\x70\x99\x65\xea\x3e\x71\x54\xf8\xc2\x70\x65\xf7\xf7\xa9\xd3\x56\xb7\xe5\x1d\xbf\xb8\x97\x9d\x8c\x6c\x2d\x61\xb4\x8b\x6d\xef\x11\xae\x9a\xd8\xd0\x8e\x46\xbc\x99\xcd\xbf\x13\x47\x7a\x67\x53\xda\x9f\xd8\x68\x87\x67\x8f\xb8\x40\x87\xf4\xe2\x15\x5a\x93\x76\x8b\xcc\x48\xcd\x77\x31\x5a\x75\x2b\xb0\xbf\x6b\x78\xdd\xf0\xb6\xf6\xe9\xcc\xb8\xd3\x90\xe6\xd8\xab\x22\xe8\x64\x79\xed\x6b\x99\x97\xee\xef\x0c\x1e\x78\xad\x9b\x4b\xf8\x97\xff\xb8\x98\x41\xc3\xf8\xba\xd1\x97\xf0\xcf\x5f\x5f\x44\x36\xe0\x9f\x86\x0c\xea\xa8\x3a\x5c\x4a\x34\x83\xe7\x02\x46\x8f\x2e\xae\xce\xa6\xeb\x10\x53\xc8\x75\x70\x32\x13\xc8\xb5\x6a\xee\x0f\x91\xeb\x30\xe2\x99\xc8\xd5\x9d\xfa\xba\x8b\x76\x0b\xc0\xcd\x97\x9e\x04\xc3\xab\xe0\x53\xd2\x06\x7b\x44\x8c\xfe\x08\xb2\xd8\xf3\x8c\x7a\xad\x05\x35\x69\x05\xc9\x6d\x74\x9f\xdc\x0f\x4e\x01\x06\xa8\x0d\x01\xe2\x08\x50\x4b\x5d\xe9\x3f\x12\xa8\x39\xca\x47\x81\xda\x80\x21\x0d\xde\x7b\xf6\x51\x13\xd2\x1d\xbe\xff\x7a\x32\x48\xa4\xfd\xdc\x22\xe9\x73\x11\x63\x12\x2b\x92\x21\x18\x54\xc9\x2c\x8d\x0f\xc7\xf0\x0e\x52\x99\xc2\x3b\x06\x16\x25\x57\x30\xc9\xcc\xdf\xc9\xb4\x26\x74\x14\x1a\x4d\x23\x9c\x08\x1b\x45\x6e\x7f\x34\x34\xf1\xb3\x07\xd0\x68\xca\xdb\x7e\xf2\x06\x7e\xc6\xbe\xf1\x56\x77\x72\x03\x97\x25\xe0\x96\xa7\x92\x2b\xd1\x4d\xec\x68\xbc\xe1\x36\x7d\x61\x1a\x5b\x0e\xf7\xf3\x89\x1b\x71\xe1\xba\xb4\x39\x1f\xc1\x42\xfd\x50\xb0\x4d\xaa\x86\xcd\xcb\x70\x11\x18\x97\x86\x24\x0b\x7f\x58\xef\x30\xe0\x0b\x20\x29\xb6\xc3\xb4\xc7\xdd\xf3\xc3\xee\x8a\x19\x87\x34\x85\xab\x5c\x93\x5f\xca\x28\x44\x86\xad\x6b\x7b\x0d\x2e\xc3\x23\xd3\xf1\x98\x83\xfb\x05\x08\x19\xd3\x53\x6e\x37\x63\xc1\xeb\x29\x7e\x06\x27\x79\xec\xe4\x3d\xde\xf0\x61\xb3\xc7\x13\x3c\xe6\x87\x7c\x9d\x02\x9e\x7c\x15\xc4\x50\x98\xaa\xec\x34\xb2\x4e\xb8\x41\x2c\x5c\x7b\x8e\xec\x8f\xac\xa0\x12\x2d\xfa\x1c\x77\x22\x1c\x2f\x33\xa8\xe9\xa5\x73\x1c\xdf\x0b\xa3\x47\x26\x59\x57\xb1\xa1\xc4\xea\xcc\xdf\x47\xf1\x41\x56\xcf\x29\x16\xc7\xcb\x75\x82\xf7\xea\x93\xe2\xa1\xf0\x98\x2d\x95\x15\x00\xb6\x1d\xff\xd1\xe3\x0c\x78\x3c\xc3\xf8\x17\xb4\x69\x5b\x22\xa1\x91\x74\x96\x59\x27\x34\x28\xa6\x73\xb3\xdc\xb0\xd2\x89\x04\xe4\x60\x31\x35\xae\x03\x17\x6b\xee\x51\x19\xbd\x70\x78\x05\xe7\x7e\x06\xfc\x4a\xce\xc3\x59\x3b\x09\x11\x67\xe7\x57\x5c\xc7\x2b\x1e\xc4\x1b\xdb\x4a\x3c\xe3\xe7\x62\xcc\x7c\x32\x38\x3a\xb0\x78\xda\xed\xfc\x28\xb6\x9a\x77\xeb\x09\x9f\x23\x05\x9e\x69\x7a\x49\x57\x2d\xa3\x32\x9c\x63\xc4\x47\x1c\x6e\x72\x77\x65\x0b\xaf\xe4\x26\x57\x74\x87\x9f\x5c\xff\xf2\xbb\x72\x66\xce\x9a\x31\x1b\x2e\x1d\xef\xee\xbe\x9d\x01\xb1\x38\xdc\x17\x29\x5f\x79\x50\xe7\xeb\xcc\xa6\x70\x2c\xb5\xba\xb9\x70\x15\x6d\x6c\x30\xce\xec\xc8\x0f\x7b\x6d\xef\x70\x17\x66\x18\xb4\xc1\x23\xa9\xab\x83\x82\x36\x5a\x58\x66\xda\x5c\x8d\xba\x30\x2f\x59\xf9\xcb\x5f\xe3\xe0\xfc\xd7\x32\xbb\xf9\xa5\xbc\x7d\x91\xff\x53\x99\x47\xbf\x8f\xf6\x07\x41\x43\x4f\xe3\xaf\x6b\x76\x10\x10\x0c\x55\xbc\xaf\xe4\x16\x18\xec\xf0\x14\x03\x91\x97\x7d\x82\x81\xa1\xe7\x67\x31\x60\x45\x8d\x65\xb5\x12\x3d\x45\x72\x91\xc9\xae\x70\x14\x8b\x46\x34\xc6\xbd\x47\x3f\xf5\x09\xbd\x4f\x54\x77\xd3\x1f\x56\x0e\xe9\x85\x31\xd9\x07\xde\xd5\xe2\x01\x6f\x23\xbe\xd9\xb1\x4e\xe3\x79\x05\xeb\x98\xcc\x08\x1a\x8f\xbd\xaf\x49\x66\xd6\x76\x8d\x7c\x9d\x15\x5f\x9d\x3d\xe6\x59\x7e\x75\xf6\x7f\x03\x00\x8e\x87\x1b\x29\x03\x42\x00\x00")
+
+func appJsBytes() ([]byte, error) {
+	return bindataRead(
+		_appJs,
+		"app.js",
+	)
+}
+
+func appJs() (*asset, error) {
+	bytes, err := appJsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "app.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _indexHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\x8e\xbd\x52\xc3\x30\x0c\xc7\xf7\x3e\x85\x10\x2b\xad\x8f\x8d\x41\xce\x02\x4c\x0c\x30\xb0\x30\x0a\x47\xad\x4d\x1d\x27\x67\x89\xf6\xfa\xf6\x9c\x9b\x00\x99\xec\xff\x87\x7e\x12\xdd\x3c\xbd\x3e\xbe\x7f\xbc\x3d\x43\xb4\x21\x77\x1b\x6a\x0f\x64\x2e\x07\x8f\x52\xb0\xdb\x00\x50\x14\xee\xdb\x07\x80\x06\x31\x86\x10\xb9\xaa\x98\xc7\x6f\xdb\x6f\x1f\x10\xdc\x3a\x2c\x3c\x88\xc7\x53\x92\xf3\x34\x56\x43\x08\x63\x31\x29\xe6\xf1\x9c\x7a\x8b\xbe\x97\x53\x0a\xb2\xbd\x8a\x3b\x48\x25\x59\xe2\xbc\xd5\xc0\x59\xfc\xfd\x3f\xca\x92\x65\xe9\x5e\x44\x26\xe5\xa3\x90\x9b\xf5\x9c\xe5\x54\x8e\x50\x25\x7b\x54\xbb\x64\xd1\x28\x62\x08\xb1\xca\x7e\x71\x76\x41\x75\x41\x91\xfb\x3d\x9e\x3e\xc7\xfe\xb2\x10\x9a\x27\x75\x16\x00\xc4\xcb\xf0\xad\x43\x08\x99\x55\x3d\xe6\xf1\x30\xe2\x6a\x3f\xff\x95\x75\xe2\x02\xa9\xf7\x58\x65\x5f\xdb\xf2\x1e\x3b\x72\xcd\x5d\xe0\x6e\x4d\xa7\x81\xd3\x5c\xe7\x69\x6a\xc5\xa6\x97\x48\x43\x4d\x93\x81\xd6\x70\x4d\x77\x5f\xda\x0a\xb3\xdb\x2a\xe4\xe6\x93\xc9\x45\x1b\x72\xb7\xf9\x19\x00\x0c\x05\x21\xa8\xab\x01\x00\x00")
+
+func indexHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_indexHtml,
+		"index.html",
+	)
+}
+
+func indexHtml() (*asset, error) {
+	bytes, err := indexHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "index.html", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _styleCss = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x54\x4d\x6e\xf3\x36\x10\xdd\xeb\x14\x03\x1b\xdd\x49\x82\xad\xa4\x8e\xc1\xa0\x8b\xb6\x40\xd0\x2e\xb2\x69\xd0\x55\xd1\x05\x25\x8e\x24\x36\x14\x87\x20\x47\xb1\x5d\x23\xd7\xe8\x41\xba\xee\x69\x7a\x92\x82\xb4\x1c\xff\xa4\x6e\xf1\xc1\x80\x21\x92\xf3\x38\x6f\xde\xbc\x61\x4d\x6a\x07\xfb\x0c\x60\x90\xbe\xd3\x56\xc0\xe2\x31\x03\x68\xc9\x72\xd1\xca\x41\x9b\x9d\x80\x42\x3a\x67\xb0\x08\xbb\xc0\x38\xe4\xf0\x9d\xd1\xf6\xf5\x59\x36\x2f\x69\xfd\x44\x96\x73\x98\xbd\x60\x47\x08\x3f\xff\x38\xcb\xe1\x07\x34\x6f\xc8\xba\x91\x39\x7c\xeb\xb5\x34\x39\x04\x69\x43\x11\xd0\xeb\xf6\xe3\xee\xa0\x7f\x47\x01\xcb\x7b\xb7\x8d\x5b\x0d\x19\xf2\x02\xe6\x55\x55\xc5\x65\x2d\x9b\xd7\xce\xd3\x68\x95\x80\x79\xdb\xb6\x8f\xd9\x7b\x96\xf5\x28\x15\xfa\x44\x55\xe9\xe0\x8c\xdc\x09\x68\x0d\x26\xfc\x6f\x63\x60\xdd\xee\x8a\x86\x2c\xa3\x65\x01\xc1\xc9\x06\x8b\x1a\x79\x83\x68\x63\x84\x34\xba\xb3\x85\x66\x1c\x82\x80\x06\x2d\xa3\x8f\xdb\x4e\x2a\xa5\x6d\x27\x60\x59\xb9\x2d\x54\x13\x9d\x9a\xbc\x42\x5f\xd4\xc4\x4c\x83\x80\xa5\xdb\x42\x20\xa3\x15\xcc\xf1\xeb\xf8\x3b\xe7\x53\x1a\xea\x28\xb1\x4a\x75\x6d\x50\x77\x3d\x0b\xa8\xc9\xa8\xeb\x62\xd7\xff\x5a\x2c\xe3\x96\x0b\x85\x0d\x79\xc9\x9a\xac\x00\x4b\x16\x53\x86\xb9\xc7\xd6\x63\xe8\x51\xc1\xfe\x0c\xb7\x5e\xaf\xd3\xf1\x20\xb5\x85\xfd\x45\x11\xab\xa9\x08\xb8\x4f\xb9\xde\xb3\x4c\x5e\x40\x97\xed\xc3\x43\x7d\x9f\xd0\xfd\xf2\x44\xfa\xd0\x8c\x6a\x31\x61\xfa\xea\xfa\x68\xb9\x8a\x47\x47\x8f\x14\x4c\x4e\xc0\x5d\x35\x85\x37\xa4\x30\xcf\x4a\xad\x60\x7f\xed\x9c\x97\xa7\x67\xb2\x54\xfc\x84\xdd\x68\xa4\xcf\xe1\x19\xad\xa1\x1c\xbe\x27\x1b\xc8\xc8\x90\xc3\x40\x96\x52\xaf\xae\xb5\xba\x9b\x2e\x67\x59\x1b\x84\xfd\xa9\x29\x0d\x19\x23\x5d\x40\x01\xc7\xaf\x13\x31\x01\x6b\xb7\x8d\x06\x8e\xc0\x3e\xcf\xf8\x40\x29\x29\x9c\x0c\x20\xc0\x60\xcb\x11\xf0\x86\x3e\x5a\xd4\x1c\xf7\x99\xdc\x85\x1f\xa2\x92\xc9\x13\xf1\x63\xf1\x3f\xa6\x40\x9c\x52\x7e\xd2\xad\x3a\xe8\x96\x18\xb0\x97\x36\xb4\xe4\x07\x01\xa3\x73\xe8\x1b\x19\xf0\xdc\x0f\xab\xd5\x2a\x2e\x37\xbd\x66\x2c\x92\x28\xd1\x0b\x1b\x2f\xdd\x74\x7b\x19\xc8\x9f\xf4\x68\x46\x1f\x62\x53\x1d\xe9\x83\x9b\x4f\x31\xa8\x84\x90\x2d\x4f\xd3\xf2\x31\x14\x33\xf8\xfb\x8f\x3f\x67\x97\x81\x85\xc2\xd0\xdc\x8c\xfe\x6b\x8a\x56\x65\x8d\x81\x6f\xbb\xfc\x58\x42\xd5\xc8\x45\xd5\x24\x4c\x39\x8c\x7c\xc3\xb8\xa5\x1f\xad\xd5\xb6\x83\xfd\x0d\x28\x7a\x4f\xfe\xca\xda\x93\x92\x87\xd1\xbc\x90\x5f\xad\xaa\x87\x6a\x7d\x4e\xe3\xb4\xf3\xd9\x17\x25\x13\x99\x5a\xde\x78\x48\xe2\x83\x52\x44\xc9\x05\xc4\xff\x78\x45\x27\xdd\x29\xfd\x8d\x67\xe4\x3f\xd2\x68\xeb\x46\xfe\x85\x77\x0e\xbf\x99\x45\x1b\xcc\x7e\x4d\x99\x07\x6d\x8b\x8d\x56\xdc\xc7\x41\x4a\x83\x77\x56\x6c\x9c\xe0\xd5\x34\x00\x65\xd3\x4b\xcf\xe1\x0b\xe9\x5e\xa3\xc3\x5b\x37\x3d\xf2\xdb\x63\xda\xe5\x62\xf1\x55\xbc\xa3\x9f\x7a\x29\x47\xa6\x1b\x0a\x1f\x0d\x3e\x9a\xb2\xd5\x06\xc3\x79\x6b\x8a\x38\x51\x02\xaa\x85\xdb\x3e\x66\xef\xd9\x3f\x03\x00\x41\xcd\xbf\x9e\x4f\x06\x00\x00")
+
+func styleCssBytes() ([]byte, error) {
+	return bindataRead(
+		_styleCss,
+		"style.css",
+	)
+}
+
+func styleCss() (*asset, error) {
+	bytes, err := styleCssBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "style.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"app.js":     appJs,
+	"index.html": indexHtml,
+	"style.css":  styleCss,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//     data/
+//       foo.txt
+//       img/
+//         a.png
+//         b.png
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"app.js":     &bintree{appJs, map[string]*bintree{}},
+	"index.html": &bintree{indexHtml, map[string]*bintree{}},
+	"style.css":  &bintree{styleCss, map[string]*bintree{}},
+}}
+
+// RestoreAsset restores an asset under the given directory
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreAssets restores an asset under the given directory recursively
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
+}
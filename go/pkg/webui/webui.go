@@ -0,0 +1,7 @@
+// Package webui contains the web interface served by `keepsake serve`.
+//
+// The files in assets/ are compiled into bindata.go, so the keepsake binary
+// doesn't need them at runtime. Run `go generate` after changing them.
+package webui
+
+//go:generate go run github.com/go-bindata/go-bindata/go-bindata -pkg webui -o bindata.go -prefix assets/ -nometadata assets/...
@@ -43,6 +43,11 @@ class Checkpoint(object):
     step: Optional[int] = None
     metrics: Optional[Dict[str, Any]] = None
     primary_metric: Optional[PrimaryMetric] = None
+    tags: Optional[List[str]] = None
+    pruned: bool = False
+    trashed: bool = False
+    size: Optional[int] = None
+    sha256: Optional[str] = None
 
     def __post_init__(self):
         self._experiment: Optional["Experiment"] = None
@@ -64,6 +69,11 @@ class Checkpoint(object):
             "metrics": self.metrics,
             "primary_metric": self.primary_metric,
             "step": self.step,
+            "tags": self.tags,
+            "pruned": self.pruned,
+            "trashed": self.trashed,
+            "size": self.size,
+            "sha256": self.sha256,
         }
 
     def validate(self) -> List[str]:
@@ -75,6 +85,12 @@ class Checkpoint(object):
         if self.step is not None and not isinstance(self.step, int):
             errors.append("step must be an integer")
 
+        if self.tags is not None and (
+            not isinstance(self.tags, list)
+            or not all(isinstance(tag, str) for tag in self.tags)
+        ):
+            errors.append("tags must be a list of strings")
+
         if self.metrics is not None:
             if isinstance(self.metrics, dict):
                 for key, value in self.metrics.items():
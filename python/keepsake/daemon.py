@@ -16,7 +16,7 @@ from google.rpc import status_pb2, error_details_pb2  # type: ignore
 from .servicepb.keepsake_pb2_grpc import DaemonStub
 from .servicepb import keepsake_pb2 as pb
 from . import pb_convert
-from .experiment import Experiment
+from .experiment import Experiment, ParentRef
 from .checkpoint import Checkpoint, PrimaryMetric
 from . import exceptions
 from . import console
@@ -57,6 +57,14 @@ def handle_exception(code, details):
         return exceptions.CorruptedRepositorySpec(details)
     if code == "CONFIG_NOT_FOUND":
         return exceptions.ConfigNotFound(details)
+    if code == "CORRUPTED_TARBALL":
+        return exceptions.CorruptedTarball(details)
+    if code == "CONFLICT":
+        return exceptions.Conflict(details)
+    if code == "REPOSITORY_READ_ONLY":
+        return exceptions.RepositoryReadOnly(details)
+    if code == "UNSAFE_SYMLINK":
+        return exceptions.UnsafeSymlink(details)
 
 
 def get_status_code(e, details):
@@ -145,6 +153,7 @@ class Daemon:
         python_version: str,
         quiet: bool,
         disable_hearbeat: bool,
+        parents: Optional[List[ParentRef]] = None,
     ) -> Experiment:
         pb_experiment = pb.Experiment(
             params=pb_convert.value_map_to_pb(params),
@@ -152,12 +161,14 @@ class Daemon:
             command=command,
             pythonPackages=python_packages,
             pythonVersion=python_version,
+            parents=pb_convert.parents_to_pb(parents),
         )
         ret = self.stub.CreateExperiment(
             pb.CreateExperimentRequest(
                 experiment=pb_experiment,
                 disableHeartbeat=disable_hearbeat,
                 quiet=quiet,
+                pid=os.getpid(),
             ),
         )
         return pb_convert.experiment_from_pb(self.project, ret.experiment)
@@ -171,6 +182,7 @@ class Daemon:
         metrics: Optional[Dict[str, Any]],
         primary_metric: Optional[PrimaryMetric],
         quiet: bool,
+        tags: Optional[List[str]] = None,
     ) -> Checkpoint:
         pb_primary_metric = pb_convert.primary_metric_to_pb(primary_metric)
         pb_checkpoint = pb.Checkpoint(
@@ -178,6 +190,7 @@ class Daemon:
             path=path,
             primaryMetric=pb_primary_metric,
             step=step,
+            tags=tags,
         )
         ret = self.stub.CreateCheckpoint(
             pb.CreateCheckpointRequest(checkpoint=pb_checkpoint, quiet=quiet)
@@ -194,8 +207,10 @@ class Daemon:
         )
 
     @handle_error
-    def stop_experiment(self, experiment_id: str):
-        self.stub.StopExperiment(pb.StopExperimentRequest(experimentID=experiment_id))
+    def stop_experiment(self, experiment_id: str, error: Optional[str] = None):
+        self.stub.StopExperiment(
+            pb.StopExperimentRequest(experimentID=experiment_id, error=error or "")
+        )
 
     @handle_error
     def get_experiment(self, experiment_id_prefix: str) -> Experiment:
@@ -210,9 +225,9 @@ class Daemon:
         return pb_convert.experiments_from_pb(self.project, ret.experiments)
 
     @handle_error
-    def delete_experiment(self, experiment_id: str):
+    def delete_experiment(self, experiment_id: str, permanent: bool = False):
         self.stub.DeleteExperiment(
-            pb.DeleteExperimentRequest(experimentID=experiment_id)
+            pb.DeleteExperimentRequest(experimentID=experiment_id, permanent=permanent)
         )
 
     @handle_error
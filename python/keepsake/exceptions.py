@@ -27,3 +27,19 @@ class CorruptedRepositorySpec(Exception):
 
 class ConfigNotFound(Exception):
     pass
+
+
+class CorruptedTarball(Exception):
+    pass
+
+
+class Conflict(Exception):
+    pass
+
+
+class RepositoryReadOnly(Exception):
+    pass
+
+
+class UnsafeSymlink(Exception):
+    pass
@@ -14,6 +14,7 @@ import math
 import os
 import shlex
 import sys
+import traceback
 from typing import (
     TYPE_CHECKING,
     Any,
@@ -25,6 +26,11 @@ from typing import (
     Tuple,
 )
 
+if sys.version_info >= (3, 8):
+    from typing import TypedDict
+else:
+    from ._vendor.typing_extensions import TypedDict
+
 from . import console
 from .checkpoint import Checkpoint, CheckpointList, PrimaryMetric
 from .metadata import parse_rfc3339, rfc3339_datetime
@@ -37,6 +43,14 @@ if TYPE_CHECKING:
     from .project import Project
 
 
+class ParentRef(TypedDict):
+    experiment_id: str
+    checkpoint_id: str
+    # "rerun" if the experiment was run again from the parent by `keepsake
+    # rerun`, otherwise ""
+    kind: str
+
+
 @dataclass
 class Experiment:
     """
@@ -56,6 +70,9 @@ class Experiment:
     python_version: Optional[str] = None
     python_packages: Optional[Dict[str, str]] = None
     keepsake_version: Optional[str] = None
+    parents: Optional[List[ParentRef]] = None
+    size: Optional[int] = None
+    sha256: Optional[str] = None
     checkpoints: CheckpointList = field(default_factory=CheckpointList)
 
     def __post_init__(self, project: "Project"):
@@ -95,11 +112,14 @@ class Experiment:
         metrics: Optional[Dict[str, Any]] = None,
         primary_metric: Optional[Tuple[str, str]] = None,
         quiet: bool = False,
+        tags: Optional[List[str]] = None,
     ) -> Optional[Checkpoint]:
         """
         Create a checkpoint within this experiment.
 
         This saves the metrics at this point, and makes a copy of the file or directory passed to `path`, which could be weights or any other artifact.
+
+        Checkpoints with `tags` can be kept by a retention policy that deletes the files of other checkpoints.
         """
         # protobuf 3 doesn't have optionals, so path=None becomes ""
         # and we have no way of differentiating between empty strings
@@ -135,6 +155,7 @@ class Experiment:
             metrics=metrics,
             primary_metric=primary_metric_dict,
             quiet=quiet,
+            tags=tags,
         )
         self.checkpoints.append(checkpoint)
         self._save(quiet=quiet)
@@ -174,6 +195,9 @@ class Experiment:
             "python_packages": self.python_packages,
             "checkpoints": [c.to_json() for c in self.checkpoints],
             "keepsake_version": version,
+            "parents": self.parents,
+            "size": self.size,
+            "sha256": self.sha256,
         }
 
     def stop(self):
@@ -183,15 +207,22 @@ class Experiment:
         Experiments running in a script will eventually timeout, but when running in a notebook,
         you are required to call this method to mark an experiment as stopped.
         """
+        global _running_experiment
+        if _running_experiment is self:
+            _running_experiment = None
         self._project._daemon().stop_experiment(self.id)
 
-    def delete(self):
+    def delete(self, permanent: bool = False):
         """
         Delete this experiment and all associated checkpoints.
+
+        Like `keepsake rm`, they are moved to the trash, where they can be
+        restored with `keepsake restore`. Pass `permanent=True` to delete them
+        straight away.
         """
         # We should consolidate delete logic, see https://github.com/replicate/keepsake/issues/332
         # It's also slow https://github.com/replicate/keepsake/issues/333
-        self._project._daemon().delete_experiment(self.id)
+        self._project._daemon().delete_experiment(self.id, permanent=permanent)
 
     def latest(self) -> Optional[Checkpoint]:
         """
@@ -350,6 +381,82 @@ class Experiment:
         return out
 
 
+def parent_refs(parents) -> Optional[List[ParentRef]]:
+    """
+    Convert the experiments, checkpoints, or ID prefixes passed as parents
+    to references. The daemon expands prefixes to full IDs.
+    """
+    if parents is None:
+        return None
+    if isinstance(parents, (str, Experiment, Checkpoint)):
+        parents = [parents]
+    refs = []
+    for parent in parents:
+        if isinstance(parent, Checkpoint):
+            refs.append(
+                ParentRef(
+                    experiment_id=parent._experiment.id if parent._experiment else "",
+                    checkpoint_id=parent.id,
+                    kind="",
+                )
+            )
+        elif isinstance(parent, Experiment):
+            refs.append(ParentRef(experiment_id=parent.id, checkpoint_id="", kind=""))
+        elif isinstance(parent, str):
+            refs.append(ParentRef(experiment_id=parent, checkpoint_id="", kind=""))
+        else:
+            raise ValueError(
+                "parents must be experiments, checkpoints, or experiment or checkpoint IDs, not {}".format(
+                    type(parent).__name__
+                )
+            )
+    return refs
+
+
+def take_rerun_parent() -> Optional[ParentRef]:
+    """
+    Return the experiment and checkpoint that `keepsake rerun` set in the
+    environment as a parent, and remove them from it, so only the first
+    experiment this process creates is recorded as a rerun.
+    """
+    experiment_id = os.environ.pop("KEEPSAKE_RERUN_EXPERIMENT_ID", "")
+    checkpoint_id = os.environ.pop("KEEPSAKE_RERUN_CHECKPOINT_ID", "")
+    if not experiment_id:
+        return None
+    return ParentRef(
+        experiment_id=experiment_id, checkpoint_id=checkpoint_id, kind="rerun"
+    )
+
+
+# The experiment that is stopped with the exception if the script crashes
+_running_experiment: Optional[Experiment] = None
+
+
+def report_crashes(experiment: Experiment):
+    """
+    Stop the experiment with the exception if the script exits with one, so
+    the on_crash hooks in keepsake.yaml are run.
+    """
+    global _running_experiment
+    if _running_experiment is None:
+        previous_hook = sys.excepthook
+
+        def excepthook(exc_type, exc_value, exc_traceback):
+            exp = _running_experiment
+            if exp is not None and not issubclass(exc_type, KeyboardInterrupt):
+                try:
+                    error = "".join(
+                        traceback.format_exception(exc_type, exc_value, exc_traceback)
+                    )
+                    exp._project._daemon().stop_experiment(exp.id, error=error)
+                except Exception as e:  # pylint: disable=broad-except
+                    console.warn("Failed to report crash to Keepsake: {}".format(e))
+            previous_hook(exc_type, exc_value, exc_traceback)
+
+        sys.excepthook = excepthook
+    _running_experiment = experiment
+
+
 @dataclass
 class ExperimentCollection:
     """
@@ -361,10 +468,19 @@ class ExperimentCollection:
     project: "Project"
 
     def create(
-        self, path=None, params=None, quiet=False, disable_heartbeat=False
+        self,
+        path=None,
+        params=None,
+        quiet=False,
+        disable_heartbeat=False,
+        parents=None,
     ) -> Experiment:
         command = " ".join(map(shlex.quote, sys.argv))
-        return self.project._daemon().create_experiment(
+        refs = parent_refs(parents)
+        rerun = take_rerun_parent()
+        if rerun is not None:
+            refs = (refs or []) + [rerun]
+        experiment = self.project._daemon().create_experiment(
             path=path,
             params=params,
             command=command,
@@ -372,7 +488,10 @@ class ExperimentCollection:
             python_packages=get_imported_packages(),
             quiet=quiet,
             disable_hearbeat=disable_heartbeat,
+            parents=refs,
         )
+        report_crashes(experiment)
+        return experiment
 
     def get(self, experiment_id_prefix) -> Experiment:
         """
@@ -508,12 +627,14 @@ class ExperimentList(list, MutableSequence[Experiment]):
         if logy:
             plt.yscale("log")
 
-    def delete(self):
+    def delete(self, permanent: bool = False):
         """
         Delete all experiments in this list of experiments.
+
+        They are moved to the trash, unless `permanent=True` is passed.
         """
         for exp in self:
-            exp.delete()
+            exp.delete(permanent=permanent)
 
     def _repr_html_(self):
         show_user = False
@@ -5,7 +5,7 @@ from typing import List, Dict, Any, Optional, MutableMapping
 from google.protobuf import timestamp_pb2
 
 from .servicepb import keepsake_pb2 as pb
-from .experiment import Experiment
+from .experiment import Experiment, ParentRef
 from .checkpoint import Checkpoint, PrimaryMetric, CheckpointList
 
 # We load numpy but not torch or tensorflow because numpy loads very fast and
@@ -54,6 +54,11 @@ def checkpoint_from_pb(experiment: Experiment, chk_pb: pb.Checkpoint) -> Checkpo
         step=chk_pb.step,
         metrics=value_map_from_pb(chk_pb.metrics),
         primary_metric=primary_metric_from_pb(chk_pb.primaryMetric),
+        tags=list(chk_pb.tags) or None,
+        pruned=chk_pb.pruned,
+        trashed=chk_pb.trashed,
+        size=noneable(chk_pb.size),
+        sha256=noneable(chk_pb.sha256),
     )
     chk._experiment = experiment
     return chk
@@ -82,11 +87,29 @@ def experiment_from_pb(project, exp_pb: pb.Experiment) -> Experiment:
         python_packages=noneable(exp_pb.pythonPackages),
         python_version=noneable(exp_pb.pythonVersion),
         keepsake_version=noneable(exp_pb.keepsakeVersion),
+        parents=parents_from_pb(exp_pb.parents),
+        size=noneable(exp_pb.size),
+        sha256=noneable(exp_pb.sha256),
     )
     exp.checkpoints = checkpoints_from_pb(exp, exp_pb.checkpoints)
     return exp
 
 
+def parents_from_pb(
+    parents_pb: List[pb.ParentRef],
+) -> Optional[List[ParentRef]]:
+    if not parents_pb:
+        return None
+    return [
+        ParentRef(
+            experiment_id=p.experimentID,
+            checkpoint_id=p.checkpointID,
+            kind="rerun" if p.kind == pb.ParentRef.Kind.RERUN else "",
+        )
+        for p in parents_pb
+    ]
+
+
 def config_from_pb(conf_pb: Optional[pb.Config]) -> Optional[Dict[str, Any]]:
     if not conf_pb:
         return None
@@ -148,9 +171,29 @@ def experiment_to_pb(exp: Experiment) -> pb.Experiment:
         pythonVersion=exp.python_version,
         keepsakeVersion=exp.keepsake_version,
         checkpoints=checkpoints_to_pb(exp.checkpoints),
+        parents=parents_to_pb(exp.parents),
+        size=exp.size,
+        sha256=exp.sha256,
     )
 
 
+def parents_to_pb(
+    parents: Optional[List[ParentRef]],
+) -> Optional[List[pb.ParentRef]]:
+    if parents is None:
+        return None
+    return [
+        pb.ParentRef(
+            experimentID=p["experiment_id"],
+            checkpointID=p["checkpoint_id"],
+            kind=pb.ParentRef.Kind.RERUN
+            if p.get("kind") == "rerun"
+            else pb.ParentRef.Kind.PARENT,
+        )
+        for p in parents
+    ]
+
+
 def config_to_pb(conf: Optional[Dict[str, Any]]) -> Optional[pb.Config]:
     if conf is None:
         return None
@@ -173,6 +216,11 @@ def checkpoint_to_pb(chk: Checkpoint) -> pb.Checkpoint:
         step=chk.step,
         metrics=value_map_to_pb(chk.metrics),
         primaryMetric=primary_metric_to_pb(chk.primary_metric),
+        tags=chk.tags,
+        pruned=chk.pruned,
+        trashed=chk.trashed,
+        size=chk.size,
+        sha256=chk.sha256,
     )
 
 
@@ -4,11 +4,12 @@ try:
 except ImportError:
     from ._vendor.dataclasses import dataclass
 import os
-from typing import Dict, Any, Optional
+from typing import Dict, Any, Optional, Sequence, Union
 import json
 
 from . import console
 from .daemon import Daemon
+from .checkpoint import Checkpoint
 from .experiment import ExperimentCollection, Experiment
 
 
@@ -63,11 +64,19 @@ def init(
     params: Optional[Dict[str, Any]] = None,
     disable_heartbeat: bool = False,
     debug: bool = False,
+    parents: Optional[Sequence[Union[str, Experiment, Checkpoint]]] = None,
 ) -> Experiment:
     """
     Create a new experiment.
+
+    If the experiment resumes or fine-tunes other runs, pass them to `parents`
+    as experiments, checkpoints, or experiment or checkpoint IDs, so its lineage
+    is recorded.
     """
     project = Project(debug=debug)
     return project.experiments.create(
-        path=path, params=params, disable_heartbeat=disable_heartbeat
+        path=path,
+        params=params,
+        disable_heartbeat=disable_heartbeat,
+        parents=parents,
     )
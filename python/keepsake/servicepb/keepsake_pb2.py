@@ -20,7 +20,7 @@ DESCRIPTOR = _descriptor.FileDescriptor(
   syntax='proto3',
   serialized_options=b'Z.github.com/replicate/keepsake/go/pkg/servicepb',
   create_key=_descriptor._internal_create_key,
-  serialized_pb=b'\n\x0ekeepsake.proto\x12\x07service\x1a\x1fgoogle/protobuf/timestamp.proto\"k\n\x17\x43reateExperimentRequest\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\x12\x18\n\x10\x64isableHeartbeat\x18\x02 \x01(\x08\x12\r\n\x05quiet\x18\x03 \x01(\x08\"@\n\x15\x43reateExperimentReply\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\"Q\n\x17\x43reateCheckpointRequest\x12\'\n\ncheckpoint\x18\x01 \x01(\x0b\x32\x13.service.Checkpoint\x12\r\n\x05quiet\x18\x02 \x01(\x08\"@\n\x15\x43reateCheckpointReply\x12\'\n\ncheckpoint\x18\x01 \x01(\x0b\x32\x13.service.Checkpoint\"O\n\x15SaveExperimentRequest\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\x12\r\n\x05quiet\x18\x02 \x01(\x08\">\n\x13SaveExperimentReply\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\"-\n\x15StopExperimentRequest\x12\x14\n\x0c\x65xperimentID\x18\x01 \x01(\t\"\x15\n\x13StopExperimentReply\"2\n\x14GetExperimentRequest\x12\x1a\n\x12\x65xperimentIDPrefix\x18\x01 \x01(\t\"=\n\x12GetExperimentReply\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\"\x18\n\x16ListExperimentsRequest\"@\n\x14ListExperimentsReply\x12(\n\x0b\x65xperiments\x18\x01 \x03(\x0b\x32\x13.service.Experiment\"/\n\x17\x44\x65leteExperimentRequest\x12\x14\n\x0c\x65xperimentID\x18\x01 \x01(\t\"\x17\n\x15\x44\x65leteExperimentReply\"_\n\x19\x43heckoutCheckpointRequest\x12\x1a\n\x12\x63heckpointIDPrefix\x18\x01 \x01(\t\x12\x17\n\x0foutputDirectory\x18\x02 \x01(\t\x12\r\n\x05quiet\x18\x03 \x01(\x08\"\x19\n\x17\x43heckoutCheckpointReply\"2\n\x1aGetExperimentStatusRequest\x12\x14\n\x0c\x65xperimentID\x18\x01 \x01(\t\"x\n\x18GetExperimentStatusReply\x12\x38\n\x06status\x18\x01 \x01(\x0e\x32(.service.GetExperimentStatusReply.Status\"\"\n\x06Status\x12\x0b\n\x07RUNNING\x10\x00\x12\x0b\n\x07STOPPED\x10\x01\"\xe7\x03\n\nExperiment\x12\n\n\x02id\x18\x01 \x01(\t\x12+\n\x07\x63reated\x18\x02 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12/\n\x06params\x18\x03 \x03(\x0b\x32\x1f.service.Experiment.ParamsEntry\x12\x0c\n\x04host\x18\x04 \x01(\t\x12\x0c\n\x04user\x18\x05 \x01(\t\x12\x1f\n\x06\x63onfig\x18\x06 \x01(\x0b\x32\x0f.service.Config\x12\x0f\n\x07\x63ommand\x18\x07 \x01(\t\x12\x0c\n\x04path\x18\x08 \x01(\t\x12?\n\x0epythonPackages\x18\t \x03(\x0b\x32\'.service.Experiment.PythonPackagesEntry\x12\x15\n\rpythonVersion\x18\n \x01(\t\x12(\n\x0b\x63heckpoints\x18\x0b \x03(\x0b\x32\x13.service.Checkpoint\x12\x17\n\x0fkeepsakeVersion\x18\x0c \x01(\t\x1a\x41\n\x0bParamsEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12!\n\x05value\x18\x02 \x01(\x0b\x32\x12.service.ParamType:\x02\x38\x01\x1a\x35\n\x13PythonPackagesEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t:\x02\x38\x01\"-\n\x06\x43onfig\x12\x12\n\nrepository\x18\x01 \x01(\t\x12\x0f\n\x07storage\x18\x02 \x01(\t\"\x87\x02\n\nCheckpoint\x12\n\n\x02id\x18\x01 \x01(\t\x12+\n\x07\x63reated\x18\x02 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x31\n\x07metrics\x18\x03 \x03(\x0b\x32 .service.Checkpoint.MetricsEntry\x12\x0c\n\x04step\x18\x04 \x01(\x03\x12\x0c\n\x04path\x18\x05 \x01(\t\x12-\n\rprimaryMetric\x18\x06 \x01(\x0b\x32\x16.service.PrimaryMetric\x1a\x42\n\x0cMetricsEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12!\n\x05value\x18\x02 \x01(\x0b\x32\x12.service.ParamType:\x02\x38\x01\"l\n\rPrimaryMetric\x12\x0c\n\x04name\x18\x01 \x01(\t\x12)\n\x04goal\x18\x02 \x01(\x0e\x32\x1b.service.PrimaryMetric.Goal\"\"\n\x04Goal\x12\x0c\n\x08MAXIMIZE\x10\x00\x12\x0c\n\x08MINIMIZE\x10\x01\"\x85\x01\n\tParamType\x12\x13\n\tboolValue\x18\x01 \x01(\x08H\x00\x12\x12\n\x08intValue\x18\x02 \x01(\x03H\x00\x12\x14\n\nfloatValue\x18\x03 \x01(\x01H\x00\x12\x15\n\x0bstringValue\x18\x04 \x01(\tH\x00\x12\x19\n\x0fobjectValueJson\x18\x05 \x01(\tH\x00\x42\x07\n\x05value2\x97\x06\n\x06\x44\x61\x65mon\x12V\n\x10\x43reateExperiment\x12 .service.CreateExperimentRequest\x1a\x1e.service.CreateExperimentReply\"\x00\x12V\n\x10\x43reateCheckpoint\x12 .service.CreateCheckpointRequest\x1a\x1e.service.CreateCheckpointReply\"\x00\x12P\n\x0eSaveExperiment\x12\x1e.service.SaveExperimentRequest\x1a\x1c.service.SaveExperimentReply\"\x00\x12P\n\x0eStopExperiment\x12\x1e.service.StopExperimentRequest\x1a\x1c.service.StopExperimentReply\"\x00\x12M\n\rGetExperiment\x12\x1d.service.GetExperimentRequest\x1a\x1b.service.GetExperimentReply\"\x00\x12S\n\x0fListExperiments\x12\x1f.service.ListExperimentsRequest\x1a\x1d.service.ListExperimentsReply\"\x00\x12V\n\x10\x44\x65leteExperiment\x12 .service.DeleteExperimentRequest\x1a\x1e.service.DeleteExperimentReply\"\x00\x12\\\n\x12\x43heckoutCheckpoint\x12\".service.CheckoutCheckpointRequest\x1a .service.CheckoutCheckpointReply\"\x00\x12_\n\x13GetExperimentStatus\x12#.service.GetExperimentStatusRequest\x1a!.service.GetExperimentStatusReply\"\x00\x42\x30Z.github.com/replicate/keepsake/go/pkg/servicepbb\x06proto3'
+  serialized_pb=b'\n\x0ekeepsake.proto\x12\x07service\x1a\x1fgoogle/protobuf/timestamp.proto\"x\n\x17\x43reateExperimentRequest\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\x12\x18\n\x10\x64isableHeartbeat\x18\x02 \x01(\x08\x12\r\n\x05quiet\x18\x03 \x01(\x08\x12\x0b\n\x03pid\x18\x04 \x01(\x05\"@\n\x15\x43reateExperimentReply\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\"Q\n\x17\x43reateCheckpointRequest\x12\'\n\ncheckpoint\x18\x01 \x01(\x0b\x32\x13.service.Checkpoint\x12\r\n\x05quiet\x18\x02 \x01(\x08\"@\n\x15\x43reateCheckpointReply\x12\'\n\ncheckpoint\x18\x01 \x01(\x0b\x32\x13.service.Checkpoint\"O\n\x15SaveExperimentRequest\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\x12\r\n\x05quiet\x18\x02 \x01(\x08\">\n\x13SaveExperimentReply\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\"<\n\x15StopExperimentRequest\x12\x14\n\x0c\x65xperimentID\x18\x01 \x01(\t\x12\r\n\x05\x65rror\x18\x02 \x01(\t\"\x15\n\x13StopExperimentReply\"2\n\x14GetExperimentRequest\x12\x1a\n\x12\x65xperimentIDPrefix\x18\x01 \x01(\t\"=\n\x12GetExperimentReply\x12\'\n\nexperiment\x18\x01 \x01(\x0b\x32\x13.service.Experiment\"\x18\n\x16ListExperimentsRequest\"@\n\x14ListExperimentsReply\x12(\n\x0b\x65xperiments\x18\x01 \x03(\x0b\x32\x13.service.Experiment\"B\n\x17\x44\x65leteExperimentRequest\x12\x14\n\x0c\x65xperimentID\x18\x01 \x01(\t\x12\x11\n\tpermanent\x18\x02 \x01(\x08\"\x17\n\x15\x44\x65leteExperimentReply\"_\n\x19\x43heckoutCheckpointRequest\x12\x1a\n\x12\x63heckpointIDPrefix\x18\x01 \x01(\t\x12\x17\n\x0foutputDirectory\x18\x02 \x01(\t\x12\r\n\x05quiet\x18\x03 \x01(\x08\"\x19\n\x17\x43heckoutCheckpointReply\"2\n\x1aGetExperimentStatusRequest\x12\x14\n\x0c\x65xperimentID\x18\x01 \x01(\t\"x\n\x18GetExperimentStatusReply\x12\x38\n\x06status\x18\x01 \x01(\x0e\x32(.service.GetExperimentStatusReply.Status\"\"\n\x06Status\x12\x0b\n\x07RUNNING\x10\x00\x12\x0b\n\x07STOPPED\x10\x01\"\x18\n\x16GetDaemonStatusRequest\"8\n\x14GetDaemonStatusReply\x12 \n\x07metrics\x18\x01 \x03(\x0b\x32\x0f.service.Metric\")\n\x17WatchExperimentsRequest\x12\x0e\n\x06\x63ursor\x18\x01 \x01(\t\"\xd4\x02\n\x15WatchExperimentsReply\x12\x31\n\x04type\x18\x01 \x01(\x0e\x32#.service.WatchExperimentsReply.Type\x12\x0e\n\x06\x63ursor\x18\x02 \x01(\t\x12(\n\x04time\x18\x03 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x14\n\x0c\x65xperimentID\x18\x04 \x01(\t\x12\'\n\nexperiment\x18\x05 \x01(\x0b\x32\x13.service.Experiment\x12\'\n\ncheckpoint\x18\x06 \x01(\x0b\x32\x13.service.Checkpoint\x12\x0f\n\x07running\x18\x07 \x01(\x08\"U\n\x04Type\x12\t\n\x05RESET\x10\x00\x12\x0b\n\x07\x43REATED\x10\x01\x12\x14\n\x10\x43HECKPOINT_ADDED\x10\x02\x12\x12\n\x0eSTATUS_CHANGED\x10\x03\x12\x0b\n\x07\x44\x45LETED\x10\x04\"Z\n\x06Metric\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x0c\n\x04help\x18\x02 \x01(\t\x12\x0c\n\x04type\x18\x03 \x01(\t\x12&\n\x07samples\x18\x04 \x03(\x0b\x32\x15.service.MetricSample\"\x8d\x01\n\x0cMetricSample\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x31\n\x06labels\x18\x02 \x03(\x0b\x32!.service.MetricSample.LabelsEntry\x12\r\n\x05value\x18\x03 \x01(\x01\x1a-\n\x0bLabelsEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t:\x02\x38\x01\"\xb9\x04\n\nExperiment\x12\n\n\x02id\x18\x01 \x01(\t\x12+\n\x07\x63reated\x18\x02 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12/\n\x06params\x18\x03 \x03(\x0b\x32\x1f.service.Experiment.ParamsEntry\x12\x0c\n\x04host\x18\x04 \x01(\t\x12\x0c\n\x04user\x18\x05 \x01(\t\x12\x1f\n\x06\x63onfig\x18\x06 \x01(\x0b\x32\x0f.service.Config\x12\x0f\n\x07\x63ommand\x18\x07 \x01(\t\x12\x0c\n\x04path\x18\x08 \x01(\t\x12?\n\x0epythonPackages\x18\t \x03(\x0b\x32\'.service.Experiment.PythonPackagesEntry\x12\x15\n\rpythonVersion\x18\n \x01(\t\x12(\n\x0b\x63heckpoints\x18\x0b \x03(\x0b\x32\x13.service.Checkpoint\x12\x17\n\x0fkeepsakeVersion\x18\x0c \x01(\t\x12#\n\x07parents\x18\x0e \x03(\x0b\x32\x12.service.ParentRef\x12\x0c\n\x04size\x18\x0f \x01(\x03\x12\x0e\n\x06sha256\x18\x10 \x01(\t\x1a\x41\n\x0bParamsEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12!\n\x05value\x18\x02 \x01(\x0b\x32\x12.service.ParamType:\x02\x38\x01\x1a\x35\n\x13PythonPackagesEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t:\x02\x38\x01J\x04\x08\r\x10\x0eR\x07rerunOf\"}\n\tParentRef\x12\x14\n\x0c\x65xperimentID\x18\x01 \x01(\t\x12\x14\n\x0c\x63heckpointID\x18\x02 \x01(\t\x12%\n\x04kind\x18\x03 \x01(\x0e\x32\x17.service.ParentRef.Kind\"\x1d\n\x04Kind\x12\n\n\x06PARENT\x10\x00\x12\t\n\x05RERUN\x10\x01\"-\n\x06\x43onfig\x12\x12\n\nrepository\x18\x01 \x01(\t\x12\x0f\n\x07storage\x18\x02 \x01(\t\"\xd4\x02\n\nCheckpoint\x12\n\n\x02id\x18\x01 \x01(\t\x12+\n\x07\x63reated\x18\x02 \x01(\x0b\x32\x1a.google.protobuf.Timestamp\x12\x31\n\x07metrics\x18\x03 \x03(\x0b\x32 .service.Checkpoint.MetricsEntry\x12\x0c\n\x04step\x18\x04 \x01(\x03\x12\x0c\n\x04path\x18\x05 \x01(\t\x12-\n\rprimaryMetric\x18\x06 \x01(\x0b\x32\x16.service.PrimaryMetric\x12\x0c\n\x04tags\x18\x07 \x03(\t\x12\x0e\n\x06pruned\x18\x08 \x01(\x08\x12\x0c\n\x04size\x18\t \x01(\x03\x12\x0e\n\x06sha256\x18\n \x01(\t\x12\x0f\n\x07trashed\x18\x0b \x01(\x08\x1a\x42\n\x0cMetricsEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12!\n\x05value\x18\x02 \x01(\x0b\x32\x12.service.ParamType:\x02\x38\x01\"l\n\rPrimaryMetric\x12\x0c\n\x04name\x18\x01 \x01(\t\x12)\n\x04goal\x18\x02 \x01(\x0e\x32\x1b.service.PrimaryMetric.Goal\"\"\n\x04Goal\x12\x0c\n\x08MAXIMIZE\x10\x00\x12\x0c\n\x08MINIMIZE\x10\x01\"\x85\x01\n\tParamType\x12\x13\n\tboolValue\x18\x01 \x01(\x08H\x00\x12\x12\n\x08intValue\x18\x02 \x01(\x03H\x00\x12\x14\n\nfloatValue\x18\x03 \x01(\x01H\x00\x12\x15\n\x0bstringValue\x18\x04 \x01(\tH\x00\x12\x19\n\x0fobjectValueJson\x18\x05 \x01(\tH\x00\x42\x07\n\x05value2\xc6\x07\n\x06\x44\x61\x65mon\x12V\n\x10\x43reateExperiment\x12 .service.CreateExperimentRequest\x1a\x1e.service.CreateExperimentReply\"\x00\x12V\n\x10\x43reateCheckpoint\x12 .service.CreateCheckpointRequest\x1a\x1e.service.CreateCheckpointReply\"\x00\x12P\n\x0eSaveExperiment\x12\x1e.service.SaveExperimentRequest\x1a\x1c.service.SaveExperimentReply\"\x00\x12P\n\x0eStopExperiment\x12\x1e.service.StopExperimentRequest\x1a\x1c.service.StopExperimentReply\"\x00\x12M\n\rGetExperiment\x12\x1d.service.GetExperimentRequest\x1a\x1b.service.GetExperimentReply\"\x00\x12S\n\x0fListExperiments\x12\x1f.service.ListExperimentsRequest\x1a\x1d.service.ListExperimentsReply\"\x00\x12V\n\x10\x44\x65leteExperiment\x12 .service.DeleteExperimentRequest\x1a\x1e.service.DeleteExperimentReply\"\x00\x12\\\n\x12\x43heckoutCheckpoint\x12\".service.CheckoutCheckpointRequest\x1a .service.CheckoutCheckpointReply\"\x00\x12_\n\x13GetExperimentStatus\x12#.service.GetExperimentStatusRequest\x1a!.service.GetExperimentStatusReply\"\x00\x12S\n\x0fGetDaemonStatus\x12\x1f.service.GetDaemonStatusRequest\x1a\x1d.service.GetDaemonStatusReply\"\x00\x12X\n\x10WatchExperiments\x12 .service.WatchExperimentsRequest\x1a\x1e.service.WatchExperimentsReply\"\x00\x30\x01\x42\x30Z.github.com/replicate/keepsake/go/pkg/servicepbb\x06proto3'
   ,
   dependencies=[google_dot_protobuf_dot_timestamp__pb2.DESCRIPTOR,])
 
@@ -46,11 +46,76 @@ _GETEXPERIMENTSTATUSREPLY_STATUS = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=1142,
-  serialized_end=1176,
+  serialized_start=1189,
+  serialized_end=1223,
 )
 _sym_db.RegisterEnumDescriptor(_GETEXPERIMENTSTATUSREPLY_STATUS)
 
+_WATCHEXPERIMENTSREPLY_TYPE = _descriptor.EnumDescriptor(
+  name='Type',
+  full_name='service.WatchExperimentsReply.Type',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='RESET', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='CREATED', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='CHECKPOINT_ADDED', index=2, number=2,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='STATUS_CHANGED', index=3, number=3,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='DELETED', index=4, number=4,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=1608,
+  serialized_end=1693,
+)
+_sym_db.RegisterEnumDescriptor(_WATCHEXPERIMENTSREPLY_TYPE)
+
+_PARENTREF_KIND = _descriptor.EnumDescriptor(
+  name='Kind',
+  full_name='service.ParentRef.Kind',
+  filename=None,
+  file=DESCRIPTOR,
+  create_key=_descriptor._internal_create_key,
+  values=[
+    _descriptor.EnumValueDescriptor(
+      name='PARENT', index=0, number=0,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+    _descriptor.EnumValueDescriptor(
+      name='RERUN', index=1, number=1,
+      serialized_options=None,
+      type=None,
+      create_key=_descriptor._internal_create_key),
+  ],
+  containing_type=None,
+  serialized_options=None,
+  serialized_start=2599,
+  serialized_end=2628,
+)
+_sym_db.RegisterEnumDescriptor(_PARENTREF_KIND)
+
 _PRIMARYMETRIC_GOAL = _descriptor.EnumDescriptor(
   name='Goal',
   full_name='service.PrimaryMetric.Goal',
@@ -71,8 +136,8 @@ _PRIMARYMETRIC_GOAL = _descriptor.EnumDescriptor(
   ],
   containing_type=None,
   serialized_options=None,
-  serialized_start=2055,
-  serialized_end=2089,
+  serialized_start=3094,
+  serialized_end=3128,
 )
 _sym_db.RegisterEnumDescriptor(_PRIMARYMETRIC_GOAL)
 
@@ -106,6 +171,13 @@ _CREATEEXPERIMENTREQUEST = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='pid', full_name='service.CreateExperimentRequest.pid', index=3,
+      number=4, type=5, cpp_type=1, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -119,7 +191,7 @@ _CREATEEXPERIMENTREQUEST = _descriptor.Descriptor(
   oneofs=[
   ],
   serialized_start=60,
-  serialized_end=167,
+  serialized_end=180,
 )
 
 
@@ -150,8 +222,8 @@ _CREATEEXPERIMENTREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=169,
-  serialized_end=233,
+  serialized_start=182,
+  serialized_end=246,
 )
 
 
@@ -189,8 +261,8 @@ _CREATECHECKPOINTREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=235,
-  serialized_end=316,
+  serialized_start=248,
+  serialized_end=329,
 )
 
 
@@ -221,8 +293,8 @@ _CREATECHECKPOINTREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=318,
-  serialized_end=382,
+  serialized_start=331,
+  serialized_end=395,
 )
 
 
@@ -260,8 +332,8 @@ _SAVEEXPERIMENTREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=384,
-  serialized_end=463,
+  serialized_start=397,
+  serialized_end=476,
 )
 
 
@@ -292,8 +364,8 @@ _SAVEEXPERIMENTREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=465,
-  serialized_end=527,
+  serialized_start=478,
+  serialized_end=540,
 )
 
 
@@ -312,6 +384,13 @@ _STOPEXPERIMENTREQUEST = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='error', full_name='service.StopExperimentRequest.error', index=1,
+      number=2, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -324,8 +403,8 @@ _STOPEXPERIMENTREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=529,
-  serialized_end=574,
+  serialized_start=542,
+  serialized_end=602,
 )
 
 
@@ -349,8 +428,8 @@ _STOPEXPERIMENTREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=576,
-  serialized_end=597,
+  serialized_start=604,
+  serialized_end=625,
 )
 
 
@@ -381,8 +460,8 @@ _GETEXPERIMENTREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=599,
-  serialized_end=649,
+  serialized_start=627,
+  serialized_end=677,
 )
 
 
@@ -413,8 +492,8 @@ _GETEXPERIMENTREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=651,
-  serialized_end=712,
+  serialized_start=679,
+  serialized_end=740,
 )
 
 
@@ -438,8 +517,8 @@ _LISTEXPERIMENTSREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=714,
-  serialized_end=738,
+  serialized_start=742,
+  serialized_end=766,
 )
 
 
@@ -470,8 +549,8 @@ _LISTEXPERIMENTSREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=740,
-  serialized_end=804,
+  serialized_start=768,
+  serialized_end=832,
 )
 
 
@@ -490,6 +569,13 @@ _DELETEEXPERIMENTREQUEST = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='permanent', full_name='service.DeleteExperimentRequest.permanent', index=1,
+      number=2, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -502,8 +588,8 @@ _DELETEEXPERIMENTREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=806,
-  serialized_end=853,
+  serialized_start=834,
+  serialized_end=900,
 )
 
 
@@ -527,8 +613,8 @@ _DELETEEXPERIMENTREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=855,
-  serialized_end=878,
+  serialized_start=902,
+  serialized_end=925,
 )
 
 
@@ -573,8 +659,8 @@ _CHECKOUTCHECKPOINTREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=880,
-  serialized_end=975,
+  serialized_start=927,
+  serialized_end=1022,
 )
 
 
@@ -598,8 +684,8 @@ _CHECKOUTCHECKPOINTREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=977,
-  serialized_end=1002,
+  serialized_start=1024,
+  serialized_end=1049,
 )
 
 
@@ -630,8 +716,8 @@ _GETEXPERIMENTSTATUSREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1004,
-  serialized_end=1054,
+  serialized_start=1051,
+  serialized_end=1101,
 )
 
 
@@ -663,8 +749,309 @@ _GETEXPERIMENTSTATUSREPLY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1056,
-  serialized_end=1176,
+  serialized_start=1103,
+  serialized_end=1223,
+)
+
+
+_GETDAEMONSTATUSREQUEST = _descriptor.Descriptor(
+  name='GetDaemonStatusRequest',
+  full_name='service.GetDaemonStatusRequest',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1225,
+  serialized_end=1249,
+)
+
+
+_GETDAEMONSTATUSREPLY = _descriptor.Descriptor(
+  name='GetDaemonStatusReply',
+  full_name='service.GetDaemonStatusReply',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='metrics', full_name='service.GetDaemonStatusReply.metrics', index=0,
+      number=1, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1251,
+  serialized_end=1307,
+)
+
+
+_WATCHEXPERIMENTSREQUEST = _descriptor.Descriptor(
+  name='WatchExperimentsRequest',
+  full_name='service.WatchExperimentsRequest',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='cursor', full_name='service.WatchExperimentsRequest.cursor', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1309,
+  serialized_end=1350,
+)
+
+
+_WATCHEXPERIMENTSREPLY = _descriptor.Descriptor(
+  name='WatchExperimentsReply',
+  full_name='service.WatchExperimentsReply',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='type', full_name='service.WatchExperimentsReply.type', index=0,
+      number=1, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='cursor', full_name='service.WatchExperimentsReply.cursor', index=1,
+      number=2, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='time', full_name='service.WatchExperimentsReply.time', index=2,
+      number=3, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='experimentID', full_name='service.WatchExperimentsReply.experimentID', index=3,
+      number=4, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='experiment', full_name='service.WatchExperimentsReply.experiment', index=4,
+      number=5, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='checkpoint', full_name='service.WatchExperimentsReply.checkpoint', index=5,
+      number=6, type=11, cpp_type=10, label=1,
+      has_default_value=False, default_value=None,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='running', full_name='service.WatchExperimentsReply.running', index=6,
+      number=7, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+    _WATCHEXPERIMENTSREPLY_TYPE,
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1353,
+  serialized_end=1693,
+)
+
+
+_METRIC = _descriptor.Descriptor(
+  name='Metric',
+  full_name='service.Metric',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='name', full_name='service.Metric.name', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='help', full_name='service.Metric.help', index=1,
+      number=2, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='type', full_name='service.Metric.type', index=2,
+      number=3, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='samples', full_name='service.Metric.samples', index=3,
+      number=4, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1695,
+  serialized_end=1785,
+)
+
+
+_METRICSAMPLE_LABELSENTRY = _descriptor.Descriptor(
+  name='LabelsEntry',
+  full_name='service.MetricSample.LabelsEntry',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='key', full_name='service.MetricSample.LabelsEntry.key', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='value', full_name='service.MetricSample.LabelsEntry.value', index=1,
+      number=2, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=b'8\001',
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1884,
+  serialized_end=1929,
+)
+
+_METRICSAMPLE = _descriptor.Descriptor(
+  name='MetricSample',
+  full_name='service.MetricSample',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='name', full_name='service.MetricSample.name', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='labels', full_name='service.MetricSample.labels', index=1,
+      number=2, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='value', full_name='service.MetricSample.value', index=2,
+      number=3, type=1, cpp_type=5, label=1,
+      has_default_value=False, default_value=float(0),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[_METRICSAMPLE_LABELSENTRY, ],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=1788,
+  serialized_end=1929,
 )
 
 
@@ -702,8 +1089,8 @@ _EXPERIMENT_PARAMSENTRY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1546,
-  serialized_end=1611,
+  serialized_start=2366,
+  serialized_end=2431,
 )
 
 _EXPERIMENT_PYTHONPACKAGESENTRY = _descriptor.Descriptor(
@@ -740,8 +1127,8 @@ _EXPERIMENT_PYTHONPACKAGESENTRY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1613,
-  serialized_end=1666,
+  serialized_start=2433,
+  serialized_end=2486,
 )
 
 _EXPERIMENT = _descriptor.Descriptor(
@@ -836,6 +1223,27 @@ _EXPERIMENT = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='parents', full_name='service.Experiment.parents', index=12,
+      number=14, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='size', full_name='service.Experiment.size', index=13,
+      number=15, type=3, cpp_type=2, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='sha256', full_name='service.Experiment.sha256', index=14,
+      number=16, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -848,8 +1256,55 @@ _EXPERIMENT = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1179,
-  serialized_end=1666,
+  serialized_start=1932,
+  serialized_end=2501,
+)
+
+
+_PARENTREF = _descriptor.Descriptor(
+  name='ParentRef',
+  full_name='service.ParentRef',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='experimentID', full_name='service.ParentRef.experimentID', index=0,
+      number=1, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='checkpointID', full_name='service.ParentRef.checkpointID', index=1,
+      number=2, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='kind', full_name='service.ParentRef.kind', index=2,
+      number=3, type=14, cpp_type=8, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+    _PARENTREF_KIND,
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=2503,
+  serialized_end=2628,
 )
 
 
@@ -887,8 +1342,8 @@ _CONFIG = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1668,
-  serialized_end=1713,
+  serialized_start=2630,
+  serialized_end=2675,
 )
 
 
@@ -926,8 +1381,8 @@ _CHECKPOINT_METRICSENTRY = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1913,
-  serialized_end=1979,
+  serialized_start=2952,
+  serialized_end=3018,
 )
 
 _CHECKPOINT = _descriptor.Descriptor(
@@ -980,6 +1435,41 @@ _CHECKPOINT = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='tags', full_name='service.Checkpoint.tags', index=6,
+      number=7, type=9, cpp_type=9, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='pruned', full_name='service.Checkpoint.pruned', index=7,
+      number=8, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='size', full_name='service.Checkpoint.size', index=8,
+      number=9, type=3, cpp_type=2, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='sha256', full_name='service.Checkpoint.sha256', index=9,
+      number=10, type=9, cpp_type=9, label=1,
+      has_default_value=False, default_value=b"".decode('utf-8'),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='trashed', full_name='service.Checkpoint.trashed', index=10,
+      number=11, type=8, cpp_type=7, label=1,
+      has_default_value=False, default_value=False,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -992,8 +1482,8 @@ _CHECKPOINT = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1716,
-  serialized_end=1979,
+  serialized_start=2678,
+  serialized_end=3018,
 )
 
 
@@ -1032,8 +1522,8 @@ _PRIMARYMETRIC = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=1981,
-  serialized_end=2089,
+  serialized_start=3020,
+  serialized_end=3128,
 )
 
 
@@ -1097,8 +1587,8 @@ _PARAMTYPE = _descriptor.Descriptor(
       create_key=_descriptor._internal_create_key,
     fields=[]),
   ],
-  serialized_start=2092,
-  serialized_end=2225,
+  serialized_start=3131,
+  serialized_end=3264,
 )
 
 _CREATEEXPERIMENTREQUEST.fields_by_name['experiment'].message_type = _EXPERIMENT
@@ -1111,6 +1601,15 @@ _GETEXPERIMENTREPLY.fields_by_name['experiment'].message_type = _EXPERIMENT
 _LISTEXPERIMENTSREPLY.fields_by_name['experiments'].message_type = _EXPERIMENT
 _GETEXPERIMENTSTATUSREPLY.fields_by_name['status'].enum_type = _GETEXPERIMENTSTATUSREPLY_STATUS
 _GETEXPERIMENTSTATUSREPLY_STATUS.containing_type = _GETEXPERIMENTSTATUSREPLY
+_GETDAEMONSTATUSREPLY.fields_by_name['metrics'].message_type = _METRIC
+_WATCHEXPERIMENTSREPLY.fields_by_name['type'].enum_type = _WATCHEXPERIMENTSREPLY_TYPE
+_WATCHEXPERIMENTSREPLY.fields_by_name['time'].message_type = google_dot_protobuf_dot_timestamp__pb2._TIMESTAMP
+_WATCHEXPERIMENTSREPLY.fields_by_name['experiment'].message_type = _EXPERIMENT
+_WATCHEXPERIMENTSREPLY.fields_by_name['checkpoint'].message_type = _CHECKPOINT
+_WATCHEXPERIMENTSREPLY_TYPE.containing_type = _WATCHEXPERIMENTSREPLY
+_METRIC.fields_by_name['samples'].message_type = _METRICSAMPLE
+_METRICSAMPLE_LABELSENTRY.containing_type = _METRICSAMPLE
+_METRICSAMPLE.fields_by_name['labels'].message_type = _METRICSAMPLE_LABELSENTRY
 _EXPERIMENT_PARAMSENTRY.fields_by_name['value'].message_type = _PARAMTYPE
 _EXPERIMENT_PARAMSENTRY.containing_type = _EXPERIMENT
 _EXPERIMENT_PYTHONPACKAGESENTRY.containing_type = _EXPERIMENT
@@ -1119,6 +1618,9 @@ _EXPERIMENT.fields_by_name['params'].message_type = _EXPERIMENT_PARAMSENTRY
 _EXPERIMENT.fields_by_name['config'].message_type = _CONFIG
 _EXPERIMENT.fields_by_name['pythonPackages'].message_type = _EXPERIMENT_PYTHONPACKAGESENTRY
 _EXPERIMENT.fields_by_name['checkpoints'].message_type = _CHECKPOINT
+_EXPERIMENT.fields_by_name['parents'].message_type = _PARENTREF
+_PARENTREF.fields_by_name['kind'].enum_type = _PARENTREF_KIND
+_PARENTREF_KIND.containing_type = _PARENTREF
 _CHECKPOINT_METRICSENTRY.fields_by_name['value'].message_type = _PARAMTYPE
 _CHECKPOINT_METRICSENTRY.containing_type = _CHECKPOINT
 _CHECKPOINT.fields_by_name['created'].message_type = google_dot_protobuf_dot_timestamp__pb2._TIMESTAMP
@@ -1159,7 +1661,14 @@ DESCRIPTOR.message_types_by_name['CheckoutCheckpointRequest'] = _CHECKOUTCHECKPO
 DESCRIPTOR.message_types_by_name['CheckoutCheckpointReply'] = _CHECKOUTCHECKPOINTREPLY
 DESCRIPTOR.message_types_by_name['GetExperimentStatusRequest'] = _GETEXPERIMENTSTATUSREQUEST
 DESCRIPTOR.message_types_by_name['GetExperimentStatusReply'] = _GETEXPERIMENTSTATUSREPLY
+DESCRIPTOR.message_types_by_name['GetDaemonStatusRequest'] = _GETDAEMONSTATUSREQUEST
+DESCRIPTOR.message_types_by_name['GetDaemonStatusReply'] = _GETDAEMONSTATUSREPLY
+DESCRIPTOR.message_types_by_name['WatchExperimentsRequest'] = _WATCHEXPERIMENTSREQUEST
+DESCRIPTOR.message_types_by_name['WatchExperimentsReply'] = _WATCHEXPERIMENTSREPLY
+DESCRIPTOR.message_types_by_name['Metric'] = _METRIC
+DESCRIPTOR.message_types_by_name['MetricSample'] = _METRICSAMPLE
 DESCRIPTOR.message_types_by_name['Experiment'] = _EXPERIMENT
+DESCRIPTOR.message_types_by_name['ParentRef'] = _PARENTREF
 DESCRIPTOR.message_types_by_name['Config'] = _CONFIG
 DESCRIPTOR.message_types_by_name['Checkpoint'] = _CHECKPOINT
 DESCRIPTOR.message_types_by_name['PrimaryMetric'] = _PRIMARYMETRIC
@@ -1292,6 +1801,56 @@ GetExperimentStatusReply = _reflection.GeneratedProtocolMessageType('GetExperime
   })
 _sym_db.RegisterMessage(GetExperimentStatusReply)
 
+GetDaemonStatusRequest = _reflection.GeneratedProtocolMessageType('GetDaemonStatusRequest', (_message.Message,), {
+  'DESCRIPTOR' : _GETDAEMONSTATUSREQUEST,
+  '__module__' : 'keepsake_pb2'
+  # @@protoc_insertion_point(class_scope:service.GetDaemonStatusRequest)
+  })
+_sym_db.RegisterMessage(GetDaemonStatusRequest)
+
+GetDaemonStatusReply = _reflection.GeneratedProtocolMessageType('GetDaemonStatusReply', (_message.Message,), {
+  'DESCRIPTOR' : _GETDAEMONSTATUSREPLY,
+  '__module__' : 'keepsake_pb2'
+  # @@protoc_insertion_point(class_scope:service.GetDaemonStatusReply)
+  })
+_sym_db.RegisterMessage(GetDaemonStatusReply)
+
+WatchExperimentsRequest = _reflection.GeneratedProtocolMessageType('WatchExperimentsRequest', (_message.Message,), {
+  'DESCRIPTOR' : _WATCHEXPERIMENTSREQUEST,
+  '__module__' : 'keepsake_pb2'
+  # @@protoc_insertion_point(class_scope:service.WatchExperimentsRequest)
+  })
+_sym_db.RegisterMessage(WatchExperimentsRequest)
+
+WatchExperimentsReply = _reflection.GeneratedProtocolMessageType('WatchExperimentsReply', (_message.Message,), {
+  'DESCRIPTOR' : _WATCHEXPERIMENTSREPLY,
+  '__module__' : 'keepsake_pb2'
+  # @@protoc_insertion_point(class_scope:service.WatchExperimentsReply)
+  })
+_sym_db.RegisterMessage(WatchExperimentsReply)
+
+Metric = _reflection.GeneratedProtocolMessageType('Metric', (_message.Message,), {
+  'DESCRIPTOR' : _METRIC,
+  '__module__' : 'keepsake_pb2'
+  # @@protoc_insertion_point(class_scope:service.Metric)
+  })
+_sym_db.RegisterMessage(Metric)
+
+MetricSample = _reflection.GeneratedProtocolMessageType('MetricSample', (_message.Message,), {
+
+  'LabelsEntry' : _reflection.GeneratedProtocolMessageType('LabelsEntry', (_message.Message,), {
+    'DESCRIPTOR' : _METRICSAMPLE_LABELSENTRY,
+    '__module__' : 'keepsake_pb2'
+    # @@protoc_insertion_point(class_scope:service.MetricSample.LabelsEntry)
+    })
+  ,
+  'DESCRIPTOR' : _METRICSAMPLE,
+  '__module__' : 'keepsake_pb2'
+  # @@protoc_insertion_point(class_scope:service.MetricSample)
+  })
+_sym_db.RegisterMessage(MetricSample)
+_sym_db.RegisterMessage(MetricSample.LabelsEntry)
+
 Experiment = _reflection.GeneratedProtocolMessageType('Experiment', (_message.Message,), {
 
   'ParamsEntry' : _reflection.GeneratedProtocolMessageType('ParamsEntry', (_message.Message,), {
@@ -1315,6 +1874,13 @@ _sym_db.RegisterMessage(Experiment)
 _sym_db.RegisterMessage(Experiment.ParamsEntry)
 _sym_db.RegisterMessage(Experiment.PythonPackagesEntry)
 
+ParentRef = _reflection.GeneratedProtocolMessageType('ParentRef', (_message.Message,), {
+  'DESCRIPTOR' : _PARENTREF,
+  '__module__' : 'keepsake_pb2'
+  # @@protoc_insertion_point(class_scope:service.ParentRef)
+  })
+_sym_db.RegisterMessage(ParentRef)
+
 Config = _reflection.GeneratedProtocolMessageType('Config', (_message.Message,), {
   'DESCRIPTOR' : _CONFIG,
   '__module__' : 'keepsake_pb2'
@@ -1353,6 +1919,7 @@ _sym_db.RegisterMessage(ParamType)
 
 
 DESCRIPTOR._options = None
+_METRICSAMPLE_LABELSENTRY._options = None
 _EXPERIMENT_PARAMSENTRY._options = None
 _EXPERIMENT_PYTHONPACKAGESENTRY._options = None
 _CHECKPOINT_METRICSENTRY._options = None
@@ -1364,8 +1931,8 @@ _DAEMON = _descriptor.ServiceDescriptor(
   index=0,
   serialized_options=None,
   create_key=_descriptor._internal_create_key,
-  serialized_start=2228,
-  serialized_end=3019,
+  serialized_start=3267,
+  serialized_end=4233,
   methods=[
   _descriptor.MethodDescriptor(
     name='CreateExperiment',
@@ -1457,6 +2024,26 @@ _DAEMON = _descriptor.ServiceDescriptor(
     serialized_options=None,
     create_key=_descriptor._internal_create_key,
   ),
+  _descriptor.MethodDescriptor(
+    name='GetDaemonStatus',
+    full_name='service.Daemon.GetDaemonStatus',
+    index=9,
+    containing_service=None,
+    input_type=_GETDAEMONSTATUSREQUEST,
+    output_type=_GETDAEMONSTATUSREPLY,
+    serialized_options=None,
+    create_key=_descriptor._internal_create_key,
+  ),
+  _descriptor.MethodDescriptor(
+    name='WatchExperiments',
+    full_name='service.Daemon.WatchExperiments',
+    index=10,
+    containing_service=None,
+    input_type=_WATCHEXPERIMENTSREQUEST,
+    output_type=_WATCHEXPERIMENTSREPLY,
+    serialized_options=None,
+    create_key=_descriptor._internal_create_key,
+  ),
 ])
 _sym_db.RegisterServiceDescriptor(_DAEMON)
 
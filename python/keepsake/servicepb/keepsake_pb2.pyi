@@ -8,6 +8,7 @@ from google.protobuf.descriptor import (
 
 from google.protobuf.internal.containers import (
     RepeatedCompositeFieldContainer as google___protobuf___internal___containers___RepeatedCompositeFieldContainer,
+    RepeatedScalarFieldContainer as google___protobuf___internal___containers___RepeatedScalarFieldContainer,
 )
 
 from google.protobuf.internal.enum_type_wrapper import (
@@ -49,6 +50,7 @@ class CreateExperimentRequest(google___protobuf___message___Message):
     DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
     disableHeartbeat: builtin___bool = ...
     quiet: builtin___bool = ...
+    pid: builtin___int = ...
 
     @property
     def experiment(self) -> type___Experiment: ...
@@ -58,9 +60,10 @@ class CreateExperimentRequest(google___protobuf___message___Message):
         experiment : typing___Optional[type___Experiment] = None,
         disableHeartbeat : typing___Optional[builtin___bool] = None,
         quiet : typing___Optional[builtin___bool] = None,
+        pid : typing___Optional[builtin___int] = None,
         ) -> None: ...
     def HasField(self, field_name: typing_extensions___Literal[u"experiment",b"experiment"]) -> builtin___bool: ...
-    def ClearField(self, field_name: typing_extensions___Literal[u"disableHeartbeat",b"disableHeartbeat",u"experiment",b"experiment",u"quiet",b"quiet"]) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"disableHeartbeat",b"disableHeartbeat",u"experiment",b"experiment",u"pid",b"pid",u"quiet",b"quiet"]) -> None: ...
 type___CreateExperimentRequest = CreateExperimentRequest
 
 class CreateExperimentReply(google___protobuf___message___Message):
@@ -140,12 +143,14 @@ type___SaveExperimentReply = SaveExperimentReply
 class StopExperimentRequest(google___protobuf___message___Message):
     DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
     experimentID: typing___Text = ...
+    error: typing___Text = ...
 
     def __init__(self,
         *,
         experimentID : typing___Optional[typing___Text] = None,
+        error : typing___Optional[typing___Text] = None,
         ) -> None: ...
-    def ClearField(self, field_name: typing_extensions___Literal[u"experimentID",b"experimentID"]) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"error",b"error",u"experimentID",b"experimentID"]) -> None: ...
 type___StopExperimentRequest = StopExperimentRequest
 
 class StopExperimentReply(google___protobuf___message___Message):
@@ -203,12 +208,14 @@ type___ListExperimentsReply = ListExperimentsReply
 class DeleteExperimentRequest(google___protobuf___message___Message):
     DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
     experimentID: typing___Text = ...
+    permanent: builtin___bool = ...
 
     def __init__(self,
         *,
         experimentID : typing___Optional[typing___Text] = None,
+        permanent : typing___Optional[builtin___bool] = None,
         ) -> None: ...
-    def ClearField(self, field_name: typing_extensions___Literal[u"experimentID",b"experimentID"]) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"experimentID",b"experimentID",u"permanent",b"permanent"]) -> None: ...
 type___DeleteExperimentRequest = DeleteExperimentRequest
 
 class DeleteExperimentReply(google___protobuf___message___Message):
@@ -273,6 +280,133 @@ class GetExperimentStatusReply(google___protobuf___message___Message):
     def ClearField(self, field_name: typing_extensions___Literal[u"status",b"status"]) -> None: ...
 type___GetExperimentStatusReply = GetExperimentStatusReply
 
+class GetDaemonStatusRequest(google___protobuf___message___Message):
+    DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+
+    def __init__(self,
+        ) -> None: ...
+type___GetDaemonStatusRequest = GetDaemonStatusRequest
+
+class GetDaemonStatusReply(google___protobuf___message___Message):
+    DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+
+    @property
+    def metrics(self) -> google___protobuf___internal___containers___RepeatedCompositeFieldContainer[type___Metric]: ...
+
+    def __init__(self,
+        *,
+        metrics : typing___Optional[typing___Iterable[type___Metric]] = None,
+        ) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"metrics",b"metrics"]) -> None: ...
+type___GetDaemonStatusReply = GetDaemonStatusReply
+
+class WatchExperimentsRequest(google___protobuf___message___Message):
+    DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+    cursor: typing___Text = ...
+
+    def __init__(self,
+        *,
+        cursor : typing___Optional[typing___Text] = None,
+        ) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"cursor",b"cursor"]) -> None: ...
+type___WatchExperimentsRequest = WatchExperimentsRequest
+
+class WatchExperimentsReply(google___protobuf___message___Message):
+    DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+    TypeValue = typing___NewType('TypeValue', builtin___int)
+    type___TypeValue = TypeValue
+    Type: _Type
+    class _Type(google___protobuf___internal___enum_type_wrapper____EnumTypeWrapper[WatchExperimentsReply.TypeValue]):
+        DESCRIPTOR: google___protobuf___descriptor___EnumDescriptor = ...
+        RESET = typing___cast(WatchExperimentsReply.TypeValue, 0)
+        CREATED = typing___cast(WatchExperimentsReply.TypeValue, 1)
+        CHECKPOINT_ADDED = typing___cast(WatchExperimentsReply.TypeValue, 2)
+        STATUS_CHANGED = typing___cast(WatchExperimentsReply.TypeValue, 3)
+        DELETED = typing___cast(WatchExperimentsReply.TypeValue, 4)
+    RESET = typing___cast(WatchExperimentsReply.TypeValue, 0)
+    CREATED = typing___cast(WatchExperimentsReply.TypeValue, 1)
+    CHECKPOINT_ADDED = typing___cast(WatchExperimentsReply.TypeValue, 2)
+    STATUS_CHANGED = typing___cast(WatchExperimentsReply.TypeValue, 3)
+    DELETED = typing___cast(WatchExperimentsReply.TypeValue, 4)
+    type___Type = Type
+
+    type: type___WatchExperimentsReply.TypeValue = ...
+    cursor: typing___Text = ...
+    experimentID: typing___Text = ...
+    running: builtin___bool = ...
+
+    @property
+    def time(self) -> google___protobuf___timestamp_pb2___Timestamp: ...
+
+    @property
+    def experiment(self) -> type___Experiment: ...
+
+    @property
+    def checkpoint(self) -> type___Checkpoint: ...
+
+    def __init__(self,
+        *,
+        type : typing___Optional[type___WatchExperimentsReply.TypeValue] = None,
+        cursor : typing___Optional[typing___Text] = None,
+        time : typing___Optional[google___protobuf___timestamp_pb2___Timestamp] = None,
+        experimentID : typing___Optional[typing___Text] = None,
+        experiment : typing___Optional[type___Experiment] = None,
+        checkpoint : typing___Optional[type___Checkpoint] = None,
+        running : typing___Optional[builtin___bool] = None,
+        ) -> None: ...
+    def HasField(self, field_name: typing_extensions___Literal[u"checkpoint",b"checkpoint",u"experiment",b"experiment",u"time",b"time"]) -> builtin___bool: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"checkpoint",b"checkpoint",u"cursor",b"cursor",u"experiment",b"experiment",u"experimentID",b"experimentID",u"running",b"running",u"time",b"time",u"type",b"type"]) -> None: ...
+type___WatchExperimentsReply = WatchExperimentsReply
+
+class Metric(google___protobuf___message___Message):
+    DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+    name: typing___Text = ...
+    help: typing___Text = ...
+    type: typing___Text = ...
+
+    @property
+    def samples(self) -> google___protobuf___internal___containers___RepeatedCompositeFieldContainer[type___MetricSample]: ...
+
+    def __init__(self,
+        *,
+        name : typing___Optional[typing___Text] = None,
+        help : typing___Optional[typing___Text] = None,
+        type : typing___Optional[typing___Text] = None,
+        samples : typing___Optional[typing___Iterable[type___MetricSample]] = None,
+        ) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"help",b"help",u"name",b"name",u"samples",b"samples",u"type",b"type"]) -> None: ...
+type___Metric = Metric
+
+class MetricSample(google___protobuf___message___Message):
+    DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+    class LabelsEntry(google___protobuf___message___Message):
+        DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+        key: typing___Text = ...
+        value: typing___Text = ...
+
+        def __init__(self,
+            *,
+            key : typing___Optional[typing___Text] = None,
+            value : typing___Optional[typing___Text] = None,
+            ) -> None: ...
+        def ClearField(self, field_name: typing_extensions___Literal[u"key",b"key",u"value",b"value"]) -> None: ...
+    type___LabelsEntry = LabelsEntry
+
+    name: typing___Text = ...
+    value: builtin___float = ...
+
+    @property
+    def labels(self) -> typing___MutableMapping[typing___Text, typing___Text]: ...
+
+    def __init__(self,
+        *,
+        name : typing___Optional[typing___Text] = None,
+        labels : typing___Optional[typing___Mapping[typing___Text, typing___Text]] = None,
+        value : typing___Optional[builtin___float] = None,
+        ) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"labels",b"labels",u"name",b"name",u"value",b"value"]) -> None: ...
+type___MetricSample = MetricSample
+
 class Experiment(google___protobuf___message___Message):
     DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
     class ParamsEntry(google___protobuf___message___Message):
@@ -311,6 +445,8 @@ class Experiment(google___protobuf___message___Message):
     path: typing___Text = ...
     pythonVersion: typing___Text = ...
     keepsakeVersion: typing___Text = ...
+    size: builtin___int = ...
+    sha256: typing___Text = ...
 
     @property
     def created(self) -> google___protobuf___timestamp_pb2___Timestamp: ...
@@ -327,6 +463,9 @@ class Experiment(google___protobuf___message___Message):
     @property
     def checkpoints(self) -> google___protobuf___internal___containers___RepeatedCompositeFieldContainer[type___Checkpoint]: ...
 
+    @property
+    def parents(self) -> google___protobuf___internal___containers___RepeatedCompositeFieldContainer[type___ParentRef]: ...
+
     def __init__(self,
         *,
         id : typing___Optional[typing___Text] = None,
@@ -341,11 +480,40 @@ class Experiment(google___protobuf___message___Message):
         pythonVersion : typing___Optional[typing___Text] = None,
         checkpoints : typing___Optional[typing___Iterable[type___Checkpoint]] = None,
         keepsakeVersion : typing___Optional[typing___Text] = None,
+        parents : typing___Optional[typing___Iterable[type___ParentRef]] = None,
+        size : typing___Optional[builtin___int] = None,
+        sha256 : typing___Optional[typing___Text] = None,
         ) -> None: ...
     def HasField(self, field_name: typing_extensions___Literal[u"config",b"config",u"created",b"created"]) -> builtin___bool: ...
-    def ClearField(self, field_name: typing_extensions___Literal[u"checkpoints",b"checkpoints",u"command",b"command",u"config",b"config",u"created",b"created",u"host",b"host",u"id",b"id",u"keepsakeVersion",b"keepsakeVersion",u"params",b"params",u"path",b"path",u"pythonPackages",b"pythonPackages",u"pythonVersion",b"pythonVersion",u"user",b"user"]) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"checkpoints",b"checkpoints",u"command",b"command",u"config",b"config",u"created",b"created",u"host",b"host",u"id",b"id",u"keepsakeVersion",b"keepsakeVersion",u"params",b"params",u"parents",b"parents",u"path",b"path",u"pythonPackages",b"pythonPackages",u"pythonVersion",b"pythonVersion",u"sha256",b"sha256",u"size",b"size",u"user",b"user"]) -> None: ...
 type___Experiment = Experiment
 
+class ParentRef(google___protobuf___message___Message):
+    DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
+    KindValue = typing___NewType('KindValue', builtin___int)
+    type___KindValue = KindValue
+    Kind: _Kind
+    class _Kind(google___protobuf___internal___enum_type_wrapper____EnumTypeWrapper[ParentRef.KindValue]):
+        DESCRIPTOR: google___protobuf___descriptor___EnumDescriptor = ...
+        PARENT = typing___cast(ParentRef.KindValue, 0)
+        RERUN = typing___cast(ParentRef.KindValue, 1)
+    PARENT = typing___cast(ParentRef.KindValue, 0)
+    RERUN = typing___cast(ParentRef.KindValue, 1)
+    type___Kind = Kind
+
+    experimentID: typing___Text = ...
+    checkpointID: typing___Text = ...
+    kind: type___ParentRef.KindValue = ...
+
+    def __init__(self,
+        *,
+        experimentID : typing___Optional[typing___Text] = None,
+        checkpointID : typing___Optional[typing___Text] = None,
+        kind : typing___Optional[type___ParentRef.KindValue] = None,
+        ) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"checkpointID",b"checkpointID",u"experimentID",b"experimentID",u"kind",b"kind"]) -> None: ...
+type___ParentRef = ParentRef
+
 class Config(google___protobuf___message___Message):
     DESCRIPTOR: google___protobuf___descriptor___Descriptor = ...
     repository: typing___Text = ...
@@ -380,6 +548,10 @@ class Checkpoint(google___protobuf___message___Message):
     id: typing___Text = ...
     step: builtin___int = ...
     path: typing___Text = ...
+    pruned: builtin___bool = ...
+    size: builtin___int = ...
+    sha256: typing___Text = ...
+    trashed: builtin___bool = ...
 
     @property
     def created(self) -> google___protobuf___timestamp_pb2___Timestamp: ...
@@ -390,6 +562,9 @@ class Checkpoint(google___protobuf___message___Message):
     @property
     def primaryMetric(self) -> type___PrimaryMetric: ...
 
+    @property
+    def tags(self) -> google___protobuf___internal___containers___RepeatedScalarFieldContainer[typing___Text]: ...
+
     def __init__(self,
         *,
         id : typing___Optional[typing___Text] = None,
@@ -398,9 +573,14 @@ class Checkpoint(google___protobuf___message___Message):
         step : typing___Optional[builtin___int] = None,
         path : typing___Optional[typing___Text] = None,
         primaryMetric : typing___Optional[type___PrimaryMetric] = None,
+        tags : typing___Optional[typing___Iterable[typing___Text]] = None,
+        pruned : typing___Optional[builtin___bool] = None,
+        size : typing___Optional[builtin___int] = None,
+        sha256 : typing___Optional[typing___Text] = None,
+        trashed : typing___Optional[builtin___bool] = None,
         ) -> None: ...
     def HasField(self, field_name: typing_extensions___Literal[u"created",b"created",u"primaryMetric",b"primaryMetric"]) -> builtin___bool: ...
-    def ClearField(self, field_name: typing_extensions___Literal[u"created",b"created",u"id",b"id",u"metrics",b"metrics",u"path",b"path",u"primaryMetric",b"primaryMetric",u"step",b"step"]) -> None: ...
+    def ClearField(self, field_name: typing_extensions___Literal[u"created",b"created",u"id",b"id",u"metrics",b"metrics",u"path",b"path",u"primaryMetric",b"primaryMetric",u"pruned",b"pruned",u"sha256",b"sha256",u"size",b"size",u"step",b"step",u"tags",b"tags",u"trashed",b"trashed"]) -> None: ...
 type___Checkpoint = Checkpoint
 
 class PrimaryMetric(google___protobuf___message___Message):
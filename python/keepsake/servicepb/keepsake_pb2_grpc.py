@@ -59,6 +59,16 @@ class DaemonStub(object):
                 request_serializer=keepsake__pb2.GetExperimentStatusRequest.SerializeToString,
                 response_deserializer=keepsake__pb2.GetExperimentStatusReply.FromString,
                 )
+        self.GetDaemonStatus = channel.unary_unary(
+                '/service.Daemon/GetDaemonStatus',
+                request_serializer=keepsake__pb2.GetDaemonStatusRequest.SerializeToString,
+                response_deserializer=keepsake__pb2.GetDaemonStatusReply.FromString,
+                )
+        self.WatchExperiments = channel.unary_stream(
+                '/service.Daemon/WatchExperiments',
+                request_serializer=keepsake__pb2.WatchExperimentsRequest.SerializeToString,
+                response_deserializer=keepsake__pb2.WatchExperimentsReply.FromString,
+                )
 
 
 class DaemonServicer(object):
@@ -118,6 +128,18 @@ class DaemonServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def GetDaemonStatus(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def WatchExperiments(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_DaemonServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -166,6 +188,16 @@ def add_DaemonServicer_to_server(servicer, server):
                     request_deserializer=keepsake__pb2.GetExperimentStatusRequest.FromString,
                     response_serializer=keepsake__pb2.GetExperimentStatusReply.SerializeToString,
             ),
+            'GetDaemonStatus': grpc.unary_unary_rpc_method_handler(
+                    servicer.GetDaemonStatus,
+                    request_deserializer=keepsake__pb2.GetDaemonStatusRequest.FromString,
+                    response_serializer=keepsake__pb2.GetDaemonStatusReply.SerializeToString,
+            ),
+            'WatchExperiments': grpc.unary_stream_rpc_method_handler(
+                    servicer.WatchExperiments,
+                    request_deserializer=keepsake__pb2.WatchExperimentsRequest.FromString,
+                    response_serializer=keepsake__pb2.WatchExperimentsReply.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'service.Daemon', rpc_method_handlers)
@@ -328,3 +360,37 @@ class Daemon(object):
             keepsake__pb2.GetExperimentStatusReply.FromString,
             options, channel_credentials,
             insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
+
+    @staticmethod
+    def GetDaemonStatus(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(request, target, '/service.Daemon/GetDaemonStatus',
+            keepsake__pb2.GetDaemonStatusRequest.SerializeToString,
+            keepsake__pb2.GetDaemonStatusReply.FromString,
+            options, channel_credentials,
+            insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
+
+    @staticmethod
+    def WatchExperiments(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_stream(request, target, '/service.Daemon/WatchExperiments',
+            keepsake__pb2.WatchExperimentsRequest.SerializeToString,
+            keepsake__pb2.WatchExperimentsReply.FromString,
+            options, channel_credentials,
+            insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
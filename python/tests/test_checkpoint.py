@@ -92,6 +92,11 @@ class TestCheckpoint:
             "metrics": {"loss": 0.9042219519615173, "accuracy": 0.8666666746139526},
             "primary_metric": {"name": "loss", "goal": "minimize"},
             "step": 7,
+            "tags": None,
+            "pruned": False,
+            "trashed": False,
+            "size": None,
+            "sha256": None,
         }
 
     def test_checkout(self, temp_workdir, tmpdir_factory):
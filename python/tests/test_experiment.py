@@ -192,6 +192,50 @@ def test_init_with_config_file(temp_workdir):
     experiment.stop()
 
 
+def test_init_with_parents(temp_workdir):
+    with open("keepsake.yaml", "w") as f:
+        f.write("repository: file://.keepsake/")
+    parent = keepsake.init(disable_heartbeat=True)
+    checkpoint = parent.checkpoint(step=1)
+
+    experiment = keepsake.init(
+        parents=[parent, checkpoint.id[:7]], disable_heartbeat=True
+    )
+    assert experiment.parents == [
+        {"experiment_id": parent.id, "checkpoint_id": "", "kind": ""},
+        {"experiment_id": parent.id, "checkpoint_id": checkpoint.id, "kind": ""},
+    ]
+    with open(".keepsake/metadata/experiments/{}.json".format(experiment.id)) as fh:
+        metadata = json.load(fh)
+    assert metadata["parents"] == [
+        {"experiment_id": parent.id},
+        {"experiment_id": parent.id, "checkpoint_id": checkpoint.id},
+    ]
+
+    with pytest.raises(DoesNotExist):
+        keepsake.init(parents=["doesnotexist"], disable_heartbeat=True)
+
+
+def test_init_rerun(temp_workdir, monkeypatch):
+    with open("keepsake.yaml", "w") as f:
+        f.write("repository: file://.keepsake/")
+    source = keepsake.init(disable_heartbeat=True)
+    checkpoint = source.checkpoint(step=1)
+    monkeypatch.setenv("KEEPSAKE_RERUN_EXPERIMENT_ID", source.id)
+    monkeypatch.setenv("KEEPSAKE_RERUN_CHECKPOINT_ID", checkpoint.id)
+
+    experiment = keepsake.init(parents=[source], disable_heartbeat=True)
+    assert experiment.parents == [
+        {"experiment_id": source.id, "checkpoint_id": "", "kind": ""},
+        {"experiment_id": source.id, "checkpoint_id": checkpoint.id, "kind": "rerun"},
+    ]
+    # only the first experiment is a rerun, and processes the script starts
+    # aren't reruns either
+    assert "KEEPSAKE_RERUN_EXPERIMENT_ID" not in os.environ
+    experiment = keepsake.init(disable_heartbeat=True)
+    assert experiment.parents is None
+
+
 def test_init_without_config_file(temp_workdir):
     with pytest.raises(ConfigNotFound):
         keepsake.init()
@@ -202,7 +246,7 @@ def test_project_repository_version(temp_workdir):
         f.write("repository: file://.keepsake")
     experiment = keepsake.init()
 
-    expected = """{"version":1}"""
+    expected = """{"version":2}"""
     with open(".keepsake/repository.json") as f:
         assert f.read() == expected
 
@@ -213,7 +257,7 @@ def test_project_repository_version(temp_workdir):
         assert f.read() == expected
 
     with open(".keepsake/repository.json", "w") as f:
-        f.write("""{"version":2}""")
+        f.write("""{"version":3}""")
     with pytest.raises(IncompatibleRepositoryVersion):
         keepsake.init()
 
@@ -328,8 +372,11 @@ class TestExperiment:
         chk = experiment.checkpoint(path="model.txt", metrics={"accuracy": "awesome"})
 
         def get_paths():
+            # locks and temporary files are kept in .keepsake-internal
             return set(
-                str(p).replace(".keepsake/", "") for p in Path(".keepsake").rglob("*")
+                str(p).replace(".keepsake/", "")
+                for p in Path(".keepsake").rglob("*")
+                if not str(p).startswith(".keepsake/.keepsake-internal")
             )
 
         chk_tar_path = os.path.join(".keepsake/checkpoints", chk.id + ".tar.gz")
@@ -352,9 +399,11 @@ class TestExperiment:
         )
         assert paths == expected
 
+        # experiments are moved to the trash
         experiment.delete()
 
         paths = get_paths()
+        trash = "trash/{}".format(experiment.id)
         expected = set(
             [
                 "repository.json",  # we're not deleting the project spec
@@ -362,9 +411,42 @@ class TestExperiment:
                 "metadata",
                 "metadata/experiments",
                 "checkpoints",
+                "trash",
+                trash,
+                trash + "/trash.json",
+                trash + "/metadata",
+                trash + "/metadata/experiments",
+                trash + "/metadata/experiments/{}.json".format(experiment.id),
+                trash + "/experiments",
+                trash + "/experiments/{}.tar.gz".format(experiment.id),
+                trash + "/checkpoints",
+                trash + "/checkpoints/{}.tar.gz".format(chk.id),
             ]
         )
         assert paths == expected
+        assert len(project.experiments.list()) == 0
+
+    def test_delete_permanently(self, temp_workdir):
+        project = Project()
+
+        with open("keepsake.yaml", "w") as f:
+            f.write("repository: file://.keepsake/")
+
+        experiment = project.experiments.create(
+            path=None, params={"foo": "bar"}, disable_heartbeat=True
+        )
+        chk = experiment.checkpoint(metrics={"accuracy": "awesome"})
+
+        experiment.delete(permanent=True)
+
+        paths = set(
+            str(p).replace(".keepsake/", "")
+            for p in Path(".keepsake").rglob("*")
+            if not str(p).startswith(".keepsake/.keepsake-internal")
+        )
+        assert not any(p.startswith("trash") for p in paths)
+        assert "metadata/experiments/{}.json".format(experiment.id) not in paths
+        assert "checkpoints/{}.tar.gz".format(chk.id) not in paths
 
     def test_refresh(self, temp_workdir):
         project = Project()
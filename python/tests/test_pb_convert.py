@@ -27,6 +27,11 @@ def full_checkpoint_pb():
         primaryMetric=pb.PrimaryMetric(
             name="myfloat", goal=pb.PrimaryMetric.Goal.MAXIMIZE
         ),
+        tags=["best", "release"],
+        pruned=True,
+        trashed=True,
+        size=1024,
+        sha256="abc123",
     )
 
 
@@ -46,6 +51,11 @@ def full_checkpoint():
             "mymap": {"bar": "baz"},
         },
         primary_metric=PrimaryMetric(name="myfloat", goal="maximize"),
+        tags=["best", "release"],
+        pruned=True,
+        trashed=True,
+        size=1024,
+        sha256="abc123",
     )
 
 
@@ -98,6 +108,15 @@ def full_experiment_pb():
                 step=2,
             ),
         ],
+        parents=[
+            pb.ParentRef(experimentID="baz"),
+            pb.ParentRef(experimentID="qux", checkpointID="c4"),
+            pb.ParentRef(
+                experimentID="bar", checkpointID="c3", kind=pb.ParentRef.Kind.RERUN
+            ),
+        ],
+        size=2048,
+        sha256="def456",
     )
 
 
@@ -129,6 +148,13 @@ def full_experiment(project):
                 Checkpoint(id="c2", created=t + datetime.timedelta(minutes=2), step=2,),
             ]
         ),
+        parents=[
+            {"experiment_id": "baz", "checkpoint_id": "", "kind": ""},
+            {"experiment_id": "qux", "checkpoint_id": "c4", "kind": ""},
+            {"experiment_id": "bar", "checkpoint_id": "c3", "kind": "rerun"},
+        ],
+        size=2048,
+        sha256="def456",
     )
 
 
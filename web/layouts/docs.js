@@ -107,6 +107,11 @@ function DocsLayout({ title, children, ...props }) {
                     <a>Python library</a>
                   </Link>
                 </li>
+                <li>
+                  <Link href="/docs/reference/go">
+                    <a>Go library</a>
+                  </Link>
+                </li>
                 <li>
                   <Link href="/docs/reference/yaml">
                     <a>keepsake.yaml</a>